@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"microvibe-go/internal/config"
 	"microvibe-go/internal/database"
 	"microvibe-go/internal/repository"
@@ -9,6 +11,11 @@ import (
 	"microvibe-go/pkg/cache"
 	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -89,13 +96,29 @@ func main() {
 	}
 
 	// 初始化路由
-	r := router.Setup(db, redisClient, cfg)
+	r, cleanup := router.Setup(db, redisClient, cfg)
+	defer cleanup()
 
 	// 启动服务器
 	addr := cfg.Server.Host + ":" + cfg.Server.Port
 	logger.Info("服务器启动", zap.String("address", addr))
 
-	if err := r.Run(addr); err != nil {
-		logger.Fatal("启动服务器失败", zap.Error(err))
+	srv := &http.Server{Addr: addr, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("启动服务器失败", zap.Error(err))
+		}
+	}()
+
+	// 等待退出信号后优雅关闭，随后按 defer 顺序停止后台组件、事件总线和连接
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logger.Info("正在关闭服务器")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("关闭服务器失败", zap.Error(err))
 	}
 }
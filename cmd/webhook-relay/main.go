@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/middleware"
+	"microvibe-go/pkg/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBodyBytes 转发的回调请求体大小上限（与 API 服务一致）
+const maxBodyBytes = 64 << 10
+
+// webhook-relay 与 nginx-rtmp / SRS 部署在同一网络内，接收其回调后
+// 以当前时间戳对请求体签名，再转发给 API 服务的 /api/v1/live/webhooks 接口
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8090", "监听地址（供流媒体服务器回调）")
+	target := flag.String("target", "http://127.0.0.1:8080", "API 服务地址")
+	flag.Parse()
+
+	log.Println("=== 流媒体回调签名转发 ===")
+
+	// 加载配置
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	secret := cfg.Streaming.WebhookSecret
+	if secret == "" {
+		log.Fatalf("未配置 streaming.webhook_secret（环境变量 STREAMING_WEBHOOK_SECRET），拒绝启动")
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		// 回调响应中的重定向（如 on_publish 改写推流地址）原样交给流媒体服务器处理
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	base := strings.TrimRight(*target, "/")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+		if err != nil || len(body) > maxBodyBytes {
+			http.Error(w, "回调请求体无效", http.StatusBadRequest)
+			return
+		}
+
+		req, err := http.NewRequestWithContext(r.Context(), r.Method, base+r.URL.RequestURI(), bytes.NewReader(body))
+		if err != nil {
+			http.Error(w, "创建转发请求失败", http.StatusBadGateway)
+			return
+		}
+		ts := time.Now().Unix()
+		req.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		req.Header.Set(middleware.WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(middleware.WebhookSignatureHeader, utils.SignWebhook(ts, body, secret))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Printf("转发回调失败: %s %v", r.URL.Path, err)
+			http.Error(w, "转发回调失败", http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		if location := resp.Header.Get("Location"); location != "" {
+			w.Header().Set("Location", location)
+		}
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	})
+
+	log.Printf("监听 %s，转发至 %s", *listen, base)
+	if err := http.ListenAndServe(*listen, handler); err != nil {
+		log.Fatalf("服务异常退出: %v", err)
+	}
+}
@@ -4,26 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"microvibe-go/internal/config"
 	"microvibe-go/internal/model"
-	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/logger"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // Engineer 特征工程
 type Engineer struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db     *gorm.DB
+	redis  *redis.Client
+	config config.InterestConfig
 }
 
 // NewEngineer 创建特征工程实例
-func NewEngineer(db *gorm.DB, redis *redis.Client) *Engineer {
-	return &Engineer{
+// cfg 为用户兴趣的衰减和落库配置，为空时使用默认值
+func NewEngineer(db *gorm.DB, redis *redis.Client, cfg *config.InterestConfig) *Engineer {
+	e := &Engineer{
 		db:    db,
 		redis: redis,
 	}
+	if cfg != nil {
+		e.config = *cfg
+	}
+	return e
 }
 
 // UserFeature 用户特征
@@ -325,14 +333,18 @@ func (e *Engineer) calculateUserFeature(ctx context.Context, userID uint) (*User
 		}
 	}
 
-	// 获取用户兴趣标签
-	var interests []model.UserInterest
-	if err := e.db.WithContext(ctx).Where("user_id = ?", userID).
-		Order("score DESC").
-		Limit(10).
-		Find(&interests).Error; err == nil {
-		for _, interest := range interests {
-			feature.InterestTags[interest.CategoryID] = interest.Score
+	// 获取用户兴趣标签（优先使用 Redis 中实时更新的分类兴趣，没有时读取落库的兴趣）
+	if scores, ok := e.readInterest(ctx, userID, interestKindCategory); ok {
+		feature.InterestTags = topInterests(scores, 10)
+	} else {
+		var interests []model.UserInterest
+		if err := e.db.WithContext(ctx).Where("user_id = ? AND tag_id IS NULL AND score > 0", userID).
+			Order("score DESC").
+			Limit(10).
+			Find(&interests).Error; err == nil {
+			for _, interest := range interests {
+				feature.InterestTags[interest.CategoryID] = interest.Score
+			}
 		}
 	}
 
@@ -414,6 +426,7 @@ func (e *Engineer) calculateVideoFeature(ctx context.Context, videoID uint) (*Vi
 }
 
 // UpdateUserProfile 更新用户画像
+// 观看、点赞、评论、分享对兴趣的影响由事件驱动（见 Subscribe），这里记录行为并处理没有对应事件的收藏
 func (e *Engineer) UpdateUserProfile(ctx context.Context, userID uint, behavior *model.UserBehavior) error {
 	// 获取视频信息，检查是否是自己的视频
 	var video model.Video
@@ -433,8 +446,14 @@ func (e *Engineer) UpdateUserProfile(ctx context.Context, userID uint, behavior
 		}
 	}
 
-	// 异步更新用户兴趣标签
-	go e.updateUserInterest(context.Background(), userID, behavior, &video)
+	if behavior.Action == 5 { // 收藏
+		interest, err := e.loadInterestVideo(ctx, userID, video.ID)
+		if err == nil && interest != nil {
+			if err := e.applyInterest(ctx, userID, interest, signalFavor, ""); err != nil {
+				logger.Warn("更新用户兴趣失败", zap.Uint("user_id", userID), zap.Error(err))
+			}
+		}
+	}
 
 	// 清除用户特征缓存
 	cacheKey := fmt.Sprintf("user:feature:%d", userID)
@@ -443,65 +462,6 @@ func (e *Engineer) UpdateUserProfile(ctx context.Context, userID uint, behavior
 	return nil
 }
 
-// updateUserInterest 更新用户兴趣
-func (e *Engineer) updateUserInterest(ctx context.Context, userID uint, behavior *model.UserBehavior, video *model.Video) {
-	if video == nil {
-		return
-	}
-
-	// 计算兴趣分数增量
-	var scoreIncrement float64
-	switch behavior.Action {
-	case 1: // 浏览
-		scoreIncrement = 0.1 * (float64(behavior.Progress) / 100.0)
-	case 2: // 点赞
-		scoreIncrement = 0.3
-	case 3: // 评论
-		scoreIncrement = 0.4
-	case 4: // 分享
-		scoreIncrement = 0.5
-	case 5: // 收藏
-		scoreIncrement = 0.6
-	case 6: // 完播
-		scoreIncrement = 0.8
-	}
-
-	// 更新用户兴趣表
-	if video.CategoryID == nil {
-		return
-	}
-
-	var interest model.UserInterest
-	err := e.db.WithContext(ctx).Where("user_id = ? AND category_id = ?", userID, *video.CategoryID).
-		First(&interest).Error
-
-	if pkgerrors.IsNotFound(err) {
-		// 创建新的兴趣记录
-		interest = model.UserInterest{
-			UserID:     userID,
-			CategoryID: *video.CategoryID,
-			Score:      scoreIncrement,
-			Weight:     1.0,
-			ViewCount:  1,
-		}
-		if behavior.Action == 2 {
-			interest.LikeCount = 1
-		}
-		e.db.WithContext(ctx).Create(&interest)
-	} else if err == nil {
-		// 更新现有兴趣记录
-		interest.Score = interest.Score*0.9 + scoreIncrement // 指数衰减
-		if interest.Score > 1.0 {
-			interest.Score = 1.0
-		}
-		interest.ViewCount++
-		if behavior.Action == 2 {
-			interest.LikeCount++
-		}
-		e.db.WithContext(ctx).Save(&interest)
-	}
-}
-
 // UpdateVideoFeature 更新视频特征
 func (e *Engineer) UpdateVideoFeature(ctx context.Context, videoID uint) error {
 	// 清除视频特征缓存
@@ -0,0 +1,501 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"microvibe-go/internal/model"
+	"microvibe-go/pkg/event"
+	"microvibe-go/pkg/logger"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 用户兴趣向量
+// 观看、点赞、评论、分享事件实时更新 Redis 中按时间衰减的分类兴趣和话题兴趣，
+// 快速划走（观看时长不足 SkipSeconds）记为负向兴趣。有变化的用户记入待落库集合，
+// 由定时任务批量写回 user_interests 表（分类兴趣 tag_id 为空，话题兴趣 category_id 为 0）。
+//
+// Redis 键：
+//   - user:interest:<uid>:category  分类兴趣（HASH：分类ID -> 分数，_ts 为上次衰减时间）
+//   - user:interest:<uid>:hashtag   话题兴趣（HASH：话题ID -> 分数，_ts 为上次衰减时间）
+//   - user:interest:<uid>:stats     上次落库后新增的浏览、点赞次数（HASH：view:<分类ID>、like:<分类ID>）
+//   - user:interest:dirty           待落库的用户（SET）
+const (
+	interestKindCategory = "category"
+	interestKindHashtag  = "hashtag"
+
+	interestDirtyKey = "user:interest:dirty"
+
+	// interestTTL 兴趣向量长期没有新行为时过期，之后再从 user_interests 表恢复
+	interestTTL = 30 * 24 * time.Hour
+	// interestFlushBatch 每批落库的用户数
+	interestFlushBatch = 100
+)
+
+// 各类行为对兴趣分数的增量
+const (
+	signalView    = 0.1  // 浏览（乘以观看进度）
+	signalLike    = 0.3  // 点赞
+	signalComment = 0.4  // 评论
+	signalShare   = 0.5  // 分享
+	signalFavor   = 0.6  // 收藏
+	signalFinish  = 0.8  // 完播
+	signalSkip    = -0.3 // 快速划走
+)
+
+// applyInterestScript 先按半衰期衰减向量中的所有分数，再叠加增量
+// 分数限制在 [-1, 1]，绝对值过小的分数直接移除
+// KEYS[1] 兴趣向量；ARGV[1] 当前时间（秒）；ARGV[2] 半衰期（秒）；ARGV[3] 过期时间（秒）；ARGV[4...] 字段、增量交替
+var applyInterestScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local halfLife = tonumber(ARGV[2])
+local data = redis.call('HGETALL', KEYS[1])
+local ts = now
+local scores = {}
+for i = 1, #data, 2 do
+	if data[i] == '_ts' then
+		ts = tonumber(data[i + 1])
+	else
+		scores[data[i]] = tonumber(data[i + 1])
+	end
+end
+local factor = 1
+if halfLife > 0 and now > ts then
+	factor = math.pow(0.5, (now - ts) / halfLife)
+end
+for field, score in pairs(scores) do
+	scores[field] = score * factor
+end
+for i = 4, #ARGV, 2 do
+	local field = ARGV[i]
+	scores[field] = (scores[field] or 0) + tonumber(ARGV[i + 1])
+end
+redis.call('DEL', KEYS[1])
+local args = {'_ts', now}
+for field, score in pairs(scores) do
+	if score > 1 then score = 1 end
+	if score < -1 then score = -1 end
+	if math.abs(score) >= 0.01 then
+		table.insert(args, field)
+		table.insert(args, tostring(score))
+	end
+end
+redis.call('HSET', KEYS[1], unpack(args))
+redis.call('EXPIRE', KEYS[1], tonumber(ARGV[3]))
+return 1
+`)
+
+// interestVideo 计算兴趣所需的视频信息
+type interestVideo struct {
+	ID         uint
+	UserID     uint
+	CategoryID *uint
+	Duration   int
+	Hashtags   []uint `gorm:"-"`
+}
+
+func interestKey(userID uint, kind string) string {
+	return fmt.Sprintf("user:interest:%d:%s", userID, kind)
+}
+
+func interestStatsKey(userID uint) string {
+	return fmt.Sprintf("user:interest:%d:stats", userID)
+}
+
+// Subscribe 订阅视频观看、点赞、评论、分享事件，实时更新用户兴趣
+func (e *Engineer) Subscribe(bus event.EventBus) error {
+	listeners := map[string]*event.EventListener{
+		event.EventVideoViewed:    event.NewEventListener("feature_interest_viewed", e.handleVideoViewed, true),
+		event.EventVideoLiked:     event.NewEventListener("feature_interest_liked", e.handleVideoLiked, true),
+		event.EventVideoCommented: event.NewEventListener("feature_interest_commented", e.handleVideoCommented, true),
+		event.EventVideoShared:    event.NewEventListener("feature_interest_shared", e.handleVideoShared, true),
+	}
+	for name, listener := range listeners {
+		if err := bus.Subscribe(name, listener); err != nil {
+			return fmt.Errorf("failed to subscribe %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// handleVideoViewed 观看：按观看进度加分，完播加分更多，快速划走减分
+func (e *Engineer) handleVideoViewed(ctx context.Context, ev event.Event) error {
+	viewed, ok := ev.(*event.VideoViewedEvent)
+	if !ok {
+		return nil
+	}
+	video, err := e.loadInterestVideo(ctx, viewed.UserID, viewed.VideoID)
+	if err != nil || video == nil {
+		return err
+	}
+
+	skipSeconds := e.config.SkipSeconds
+	if skipSeconds <= 0 {
+		skipSeconds = 3
+	}
+
+	var delta float64
+	switch {
+	case viewed.WatchDuration < skipSeconds && video.Duration > skipSeconds:
+		delta = signalSkip
+	case video.Duration > 0 && float64(viewed.WatchDuration)/float64(video.Duration) >= 0.9:
+		delta = signalFinish
+	case video.Duration > 0:
+		delta = signalView * float64(viewed.WatchDuration) / float64(video.Duration)
+	}
+	return e.applyInterest(ctx, viewed.UserID, video, delta, "view")
+}
+
+// handleVideoLiked 点赞
+func (e *Engineer) handleVideoLiked(ctx context.Context, ev event.Event) error {
+	liked, ok := ev.(*event.VideoLikedEvent)
+	if !ok {
+		return nil
+	}
+	video, err := e.loadInterestVideo(ctx, liked.UserID, liked.VideoID)
+	if err != nil || video == nil {
+		return err
+	}
+	return e.applyInterest(ctx, liked.UserID, video, signalLike, "like")
+}
+
+// handleVideoCommented 评论
+func (e *Engineer) handleVideoCommented(ctx context.Context, ev event.Event) error {
+	commented, ok := ev.(*event.VideoCommentedEvent)
+	if !ok {
+		return nil
+	}
+	video, err := e.loadInterestVideo(ctx, commented.UserID, commented.VideoID)
+	if err != nil || video == nil {
+		return err
+	}
+	return e.applyInterest(ctx, commented.UserID, video, signalComment, "")
+}
+
+// handleVideoShared 分享
+func (e *Engineer) handleVideoShared(ctx context.Context, ev event.Event) error {
+	shared, ok := ev.(*event.VideoSharedEvent)
+	if !ok {
+		return nil
+	}
+	video, err := e.loadInterestVideo(ctx, shared.UserID, shared.VideoID)
+	if err != nil || video == nil {
+		return err
+	}
+	return e.applyInterest(ctx, shared.UserID, video, signalShare, "")
+}
+
+// loadInterestVideo 加载视频的分类、时长和话题
+// 匿名用户以及自己对自己视频的行为不计入兴趣（防止冷启动数据偏差和作弊），此时返回 nil
+func (e *Engineer) loadInterestVideo(ctx context.Context, userID, videoID uint) (*interestVideo, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+
+	var video interestVideo
+	if err := e.db.WithContext(ctx).Model(&model.Video{}).
+		Select("id, user_id, category_id, duration").
+		Where("id = ?", videoID).
+		Take(&video).Error; err != nil {
+		return nil, err
+	}
+	if video.UserID == userID {
+		return nil, nil
+	}
+
+	if err := e.db.WithContext(ctx).Model(&model.VideoHashtag{}).
+		Where("video_id = ?", videoID).
+		Pluck("hashtag_id", &video.Hashtags).Error; err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// applyInterest 把行为增量叠加到用户的分类兴趣和话题兴趣上
+// counter 为 view 或 like 时累计该分类的浏览、点赞次数
+func (e *Engineer) applyInterest(ctx context.Context, userID uint, video *interestVideo, delta float64, counter string) error {
+	if e.redis == nil || delta == 0 {
+		return nil
+	}
+	if err := e.seedInterest(ctx, userID); err != nil {
+		return err
+	}
+
+	halfLife := e.config.HalfLife
+	if halfLife <= 0 {
+		halfLife = 72
+	}
+	now := time.Now().Unix()
+	deltaStr := strconv.FormatFloat(delta, 'f', -1, 64)
+	baseArgs := []interface{}{now, halfLife * 3600, int64(interestTTL.Seconds())}
+
+	if video.CategoryID != nil && *video.CategoryID > 0 {
+		args := append(baseArgs[:3:3], strconv.FormatUint(uint64(*video.CategoryID), 10), deltaStr)
+		if err := applyInterestScript.Run(ctx, e.redis, []string{interestKey(userID, interestKindCategory)}, args...).Err(); err != nil {
+			return err
+		}
+	}
+	if len(video.Hashtags) > 0 {
+		args := baseArgs[:3:3]
+		for _, id := range video.Hashtags {
+			args = append(args, strconv.FormatUint(uint64(id), 10), deltaStr)
+		}
+		if err := applyInterestScript.Run(ctx, e.redis, []string{interestKey(userID, interestKindHashtag)}, args...).Err(); err != nil {
+			return err
+		}
+	}
+
+	pipe := e.redis.Pipeline()
+	if counter != "" && video.CategoryID != nil && *video.CategoryID > 0 {
+		pipe.HIncrBy(ctx, interestStatsKey(userID), fmt.Sprintf("%s:%d", counter, *video.CategoryID), 1)
+		pipe.Expire(ctx, interestStatsKey(userID), interestTTL)
+	}
+	pipe.SAdd(ctx, interestDirtyKey, userID)
+	pipe.Del(ctx, fmt.Sprintf("user:feature:%d", userID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// seedInterest 用户在 Redis 中还没有兴趣向量时，从 user_interests 表恢复
+func (e *Engineer) seedInterest(ctx context.Context, userID uint) error {
+	exists, err := e.redis.Exists(ctx, interestKey(userID, interestKindCategory), interestKey(userID, interestKindHashtag)).Result()
+	if err != nil || exists > 0 {
+		return err
+	}
+
+	var interests []model.UserInterest
+	if err := e.db.WithContext(ctx).Where("user_id = ?", userID).Find(&interests).Error; err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	categories := map[string]interface{}{"_ts": now}
+	hashtags := map[string]interface{}{"_ts": now}
+	for _, interest := range interests {
+		if interest.TagID != nil {
+			hashtags[strconv.FormatUint(uint64(*interest.TagID), 10)] = interest.Score
+		} else {
+			categories[strconv.FormatUint(uint64(interest.CategoryID), 10)] = interest.Score
+		}
+	}
+
+	pipe := e.redis.Pipeline()
+	pipe.HSet(ctx, interestKey(userID, interestKindCategory), categories)
+	pipe.Expire(ctx, interestKey(userID, interestKindCategory), interestTTL)
+	pipe.HSet(ctx, interestKey(userID, interestKindHashtag), hashtags)
+	pipe.Expire(ctx, interestKey(userID, interestKindHashtag), interestTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// readInterest 读取衰减到当前时间的兴趣向量，向量不存在时 ok 为 false
+func (e *Engineer) readInterest(ctx context.Context, userID uint, kind string) (map[uint]float64, bool) {
+	data, err := e.redis.HGetAll(ctx, interestKey(userID, kind)).Result()
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	halfLife := e.config.HalfLife
+	if halfLife <= 0 {
+		halfLife = 72
+	}
+	factor := 1.0
+	if ts, err := strconv.ParseInt(data["_ts"], 10, 64); err == nil {
+		if elapsed := time.Since(time.Unix(ts, 0)).Hours(); elapsed > 0 {
+			factor = math.Pow(0.5, elapsed/float64(halfLife))
+		}
+	}
+
+	scores := make(map[uint]float64, len(data))
+	for field, value := range data {
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		scores[uint(id)] = score * factor
+	}
+	return scores, true
+}
+
+// topInterests 分数最高的 n 个正向兴趣
+func topInterests(scores map[uint]float64, n int) map[uint]float64 {
+	ids := make([]uint, 0, len(scores))
+	for id, score := range scores {
+		if score > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return scores[ids[i]] > scores[ids[j]] })
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+
+	top := make(map[uint]float64, len(ids))
+	for _, id := range ids {
+		top[id] = scores[id]
+	}
+	return top
+}
+
+// StartInterestFlush 启动兴趣定时落库，ctx 取消后停止
+func (e *Engineer) StartInterestFlush(ctx context.Context) {
+	if e.redis == nil || e.config.FlushInterval <= 0 {
+		return
+	}
+
+	interval := time.Duration(e.config.FlushInterval) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := e.FlushInterests(ctx); err != nil {
+				logger.Error("用户兴趣落库失败", zap.Error(err))
+			}
+		}
+	}()
+
+	logger.Info("用户兴趣落库任务已启动", zap.Duration("interval", interval))
+}
+
+// FlushInterests 把有变化的用户兴趣写回 user_interests 表
+// 待落库集合用 SPOP 取出，多实例同时运行时不会重复处理同一个用户
+func (e *Engineer) FlushInterests(ctx context.Context) error {
+	var failed []interface{}
+	defer func() {
+		// 写入失败的用户放回集合，下个周期重试
+		if len(failed) > 0 {
+			e.redis.SAdd(ctx, interestDirtyKey, failed...)
+		}
+	}()
+
+	for {
+		members, err := e.redis.SPopN(ctx, interestDirtyKey, interestFlushBatch).Result()
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		for _, member := range members {
+			userID, err := strconv.ParseUint(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			if err := e.flushUserInterest(ctx, uint(userID)); err != nil {
+				logger.Warn("用户兴趣落库失败", zap.Uint64("user_id", userID), zap.Error(err))
+				failed = append(failed, member)
+			}
+		}
+	}
+}
+
+// flushUserInterest 用 Redis 中的兴趣向量覆盖用户在 user_interests 表中的记录
+func (e *Engineer) flushUserInterest(ctx context.Context, userID uint) error {
+	categories, hasCategories := e.readInterest(ctx, userID, interestKindCategory)
+	hashtags, hasHashtags := e.readInterest(ctx, userID, interestKindHashtag)
+	if !hasCategories && !hasHashtags {
+		return nil
+	}
+
+	stats, err := e.redis.HGetAll(ctx, interestStatsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+	count := func(counter string, categoryID uint) int64 {
+		n, _ := strconv.ParseInt(stats[fmt.Sprintf("%s:%d", counter, categoryID)], 10, 64)
+		return n
+	}
+
+	err = e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []model.UserInterest
+		if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		categoryRows := make(map[uint]*model.UserInterest)
+		hashtagRows := make(map[uint]*model.UserInterest)
+		var stale []uint
+		for i := range existing {
+			row := &existing[i]
+			if row.TagID != nil {
+				if _, ok := hashtags[*row.TagID]; ok || !hasHashtags {
+					hashtagRows[*row.TagID] = row
+				} else {
+					stale = append(stale, row.ID)
+				}
+			} else {
+				if _, ok := categories[row.CategoryID]; ok || !hasCategories {
+					categoryRows[row.CategoryID] = row
+				} else {
+					stale = append(stale, row.ID)
+				}
+			}
+		}
+
+		// 已经衰减掉的兴趣
+		if len(stale) > 0 {
+			if err := tx.Delete(&model.UserInterest{}, stale).Error; err != nil {
+				return err
+			}
+		}
+
+		for categoryID, score := range categories {
+			row, ok := categoryRows[categoryID]
+			if !ok {
+				row = &model.UserInterest{UserID: userID, CategoryID: categoryID, Weight: 1.0}
+			}
+			row.Score = score
+			row.ViewCount += count("view", categoryID)
+			row.LikeCount += count("like", categoryID)
+			if err := tx.Save(row).Error; err != nil {
+				return err
+			}
+		}
+
+		for hashtagID, score := range hashtags {
+			row, ok := hashtagRows[hashtagID]
+			if !ok {
+				id := hashtagID
+				row = &model.UserInterest{UserID: userID, TagID: &id, Weight: 1.0}
+			}
+			row.Score = score
+			if err := tx.Save(row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 扣除已落库的次数，落库期间新增的次数留到下次
+	if len(stats) > 0 {
+		pipe := e.redis.Pipeline()
+		for field, value := range stats {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				pipe.HIncrBy(ctx, interestStatsKey(userID), field, -n)
+			}
+		}
+		_, err = pipe.Exec(ctx)
+	}
+	return err
+}
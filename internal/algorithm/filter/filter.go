@@ -29,14 +29,22 @@ type FilterRequest struct {
 	UserID uint
 	Videos []*model.Video
 	Scene  string // 场景标识，"follow"/"friends" 时跳过已阅去重
+	Mutes  *Mutes // 用户的负反馈，为空时按 UserID 加载
 }
 
 // Filter 过滤视频
 // 关注/朋友流：有限集合，仅做质量和拉黑检查，不做去重和分类多样性裁剪
 // 推荐流：完整过滤链路（已观看去重、质量、分类多样性、已推荐去重）
+// 所有场景都过滤用户标记不感兴趣或屏蔽的视频、作者、话题和分类
 func (f *VideoFilter) Filter(ctx context.Context, req *FilterRequest) ([]*model.Video, error) {
 	isSocialFeed := req.Scene == "follow" || req.Scene == "friends"
 
+	mutes := req.Mutes
+	if mutes == nil {
+		mutes = f.LoadMutes(ctx, req.UserID)
+	}
+	mutedByHashtag := f.mutedByHashtag(ctx, mutes, req.Videos)
+
 	// 推荐流：加载已观看和已推荐集合用于去重
 	// 关注/朋友流：不加载，避免有限集合被过度过滤
 	var watchedSet map[uint]bool
@@ -84,6 +92,11 @@ func (f *VideoFilter) Filter(ctx context.Context, req *FilterRequest) ([]*model.
 			continue
 		}
 
+		// 负反馈：不感兴趣、屏蔽的作者、话题和分类
+		if mutes.Excludes(video) || mutedByHashtag[video.ID] {
+			continue
+		}
+
 		if isSocialFeed {
 			// 关注/朋友流：不限制分类多样性，全量保留
 			result = append(result, video)
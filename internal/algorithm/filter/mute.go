@@ -0,0 +1,155 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"microvibe-go/internal/model"
+	"time"
+)
+
+// muteCacheTTL 屏蔽列表缓存时间（屏蔽或取消屏蔽时主动清除）
+const muteCacheTTL = 10 * time.Minute
+
+// Mutes 用户的负反馈
+type Mutes struct {
+	Videos     map[uint]bool // 不感兴趣的视频
+	Authors    map[uint]bool // 屏蔽的作者
+	Hashtags   map[uint]bool // 屏蔽的话题
+	Categories map[uint]bool // 屏蔽的分类
+
+	// 不感兴趣的视频所属的分类和作者（分类/作者ID -> 视频数），用于对相似内容降权
+	DislikedCategories map[uint]int
+	DislikedAuthors    map[uint]int
+}
+
+// Empty 是否没有任何负反馈
+func (m *Mutes) Empty() bool {
+	return m == nil || len(m.Videos)+len(m.Authors)+len(m.Hashtags)+len(m.Categories) == 0
+}
+
+// Excludes 视频是否被屏蔽（不含话题，话题需要查询视频的话题关联，见 VideoFilter.Filter）
+func (m *Mutes) Excludes(video *model.Video) bool {
+	if m == nil {
+		return false
+	}
+	if m.Videos[video.ID] || m.Authors[video.UserID] {
+		return true
+	}
+	return video.CategoryID != nil && m.Categories[*video.CategoryID]
+}
+
+func newMutes(rows []*model.UserMute) *Mutes {
+	m := &Mutes{
+		Videos:             make(map[uint]bool),
+		Authors:            make(map[uint]bool),
+		Hashtags:           make(map[uint]bool),
+		Categories:         make(map[uint]bool),
+		DislikedCategories: make(map[uint]int),
+		DislikedAuthors:    make(map[uint]int),
+	}
+	for _, row := range rows {
+		switch row.Type {
+		case model.MuteTypeVideo:
+			m.Videos[row.TargetID] = true
+			if row.CategoryID > 0 {
+				m.DislikedCategories[row.CategoryID]++
+			}
+			if row.AuthorID > 0 {
+				m.DislikedAuthors[row.AuthorID]++
+			}
+		case model.MuteTypeAuthor:
+			m.Authors[row.TargetID] = true
+		case model.MuteTypeHashtag:
+			m.Hashtags[row.TargetID] = true
+		case model.MuteTypeCategory:
+			m.Categories[row.TargetID] = true
+		}
+	}
+	return m
+}
+
+func muteCacheKey(userID uint) string {
+	return fmt.Sprintf("user:mutes:%d", userID)
+}
+
+// LoadMutes 加载用户的负反馈（带 Redis 缓存），匿名用户返回空集合
+func (f *VideoFilter) LoadMutes(ctx context.Context, userID uint) *Mutes {
+	if userID == 0 {
+		return newMutes(nil)
+	}
+
+	cacheKey := muteCacheKey(userID)
+	if f.redis != nil {
+		if cached, err := f.redis.Get(ctx, cacheKey).Result(); err == nil {
+			var rows []*model.UserMute
+			if json.Unmarshal([]byte(cached), &rows) == nil {
+				return newMutes(rows)
+			}
+		}
+	}
+
+	var rows []*model.UserMute
+	if err := f.db.WithContext(ctx).Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return newMutes(nil)
+	}
+
+	if f.redis != nil {
+		if data, err := json.Marshal(rows); err == nil {
+			f.redis.Set(ctx, cacheKey, data, muteCacheTTL)
+		}
+	}
+	return newMutes(rows)
+}
+
+// InvalidateMutes 清除用户的屏蔽列表缓存
+func (f *VideoFilter) InvalidateMutes(ctx context.Context, userID uint) {
+	if f.redis != nil {
+		f.redis.Del(ctx, muteCacheKey(userID))
+	}
+}
+
+// mutedByHashtag 返回带有屏蔽话题的视频
+func (f *VideoFilter) mutedByHashtag(ctx context.Context, mutes *Mutes, videos []*model.Video) map[uint]bool {
+	muted := make(map[uint]bool)
+	if mutes == nil || len(mutes.Hashtags) == 0 || len(videos) == 0 {
+		return muted
+	}
+
+	videoIDs := make([]uint, 0, len(videos))
+	for _, v := range videos {
+		videoIDs = append(videoIDs, v.ID)
+	}
+	hashtagIDs := make([]uint, 0, len(mutes.Hashtags))
+	for id := range mutes.Hashtags {
+		hashtagIDs = append(hashtagIDs, id)
+	}
+
+	var ids []uint
+	if err := f.db.WithContext(ctx).Model(&model.VideoHashtag{}).
+		Where("video_id IN ? AND hashtag_id IN ?", videoIDs, hashtagIDs).
+		Distinct().
+		Pluck("video_id", &ids).Error; err != nil {
+		return muted
+	}
+	for _, id := range ids {
+		muted[id] = true
+	}
+	return muted
+}
+
+// RemoveMuted 移除被用户屏蔽的视频（用于已生成的推荐会话，屏蔽后立即生效）
+func (f *VideoFilter) RemoveMuted(ctx context.Context, mutes *Mutes, videos []*model.Video) []*model.Video {
+	if mutes.Empty() {
+		return videos
+	}
+	mutedByHashtag := f.mutedByHashtag(ctx, mutes, videos)
+
+	result := make([]*model.Video, 0, len(videos))
+	for _, v := range videos {
+		if !mutes.Excludes(v) && !mutedByHashtag[v.ID] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -4,33 +4,158 @@ import (
 	"context"
 	"math"
 	"microvibe-go/internal/algorithm/feature"
+	"microvibe-go/internal/config"
 	"microvibe-go/internal/model"
+	"microvibe-go/pkg/logger"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// 内置打分因子名称
+const (
+	ScorerCTR        = "ctr"        // 点击率预估
+	ScorerFinish     = "finish"     // 完播率预估
+	ScorerEngagement = "engagement" // 互动率预估（点赞、评论、分享）
+	ScorerHot        = "hot"        // 热度
+	ScorerFreshness  = "freshness"  // 新鲜度
+)
+
+// defaultScene 未单独配置权重的场景使用的场景名
+const defaultScene = "default"
+
+// builtinScorers 内置的多目标融合权重（配置中没有对应场景时使用）
+var builtinScorers = []config.ScorerConfig{
+	{Name: ScorerCTR, Weight: 0.3},
+	{Name: ScorerFinish, Weight: 0.25},
+	{Name: ScorerEngagement, Weight: 0.25},
+	{Name: ScorerHot, Weight: 0.1},
+	{Name: ScorerFreshness, Weight: 0.1},
+}
+
+// Scorer 排序打分因子
+// 每个因子给出 [0, 1] 区间的分数，场景配置决定启用哪些因子以及各因子的融合权重
+type Scorer interface {
+	// Name 因子名称，与配置中的 name 对应
+	Name() string
+	// Score 计算视频在该因子上的分数
+	Score(ctx context.Context, req *RankRequest, video *model.Video) float64
+}
+
+// scorerFunc 以函数实现的打分因子
+type scorerFunc struct {
+	name string
+	fn   func(ctx context.Context, req *RankRequest, video *model.Video) float64
+}
+
+func (s *scorerFunc) Name() string { return s.name }
+
+func (s *scorerFunc) Score(ctx context.Context, req *RankRequest, video *model.Video) float64 {
+	return s.fn(ctx, req, video)
+}
+
+// NewScorer 用函数创建打分因子
+func NewScorer(name string, fn func(ctx context.Context, req *RankRequest, video *model.Video) float64) Scorer {
+	return &scorerFunc{name: name, fn: fn}
+}
+
 // Ranker 排序器
 type Ranker struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db     *gorm.DB
+	redis  *redis.Client
+	scenes map[string]config.RecommendSceneConfig
+
+	mu      sync.RWMutex
+	scorers map[string]Scorer
 }
 
-// NewRanker 创建排序器实例
-func NewRanker(db *gorm.DB, redis *redis.Client) *Ranker {
-	return &Ranker{
-		db:    db,
-		redis: redis,
+// NewRanker 创建排序器实例，并注册内置打分因子
+func NewRanker(db *gorm.DB, redis *redis.Client, cfg *config.RecommendConfig) *Ranker {
+	r := &Ranker{
+		db:      db,
+		redis:   redis,
+		scorers: make(map[string]Scorer),
 	}
+	if cfg != nil {
+		r.scenes = cfg.Scenes
+	}
+
+	r.Register(NewScorer(ScorerCTR, func(_ context.Context, req *RankRequest, video *model.Video) float64 {
+		return r.estimateCTR(req.UserID, video, req.Features)
+	}))
+	r.Register(NewScorer(ScorerFinish, func(_ context.Context, req *RankRequest, video *model.Video) float64 {
+		return r.estimateFinishRate(req.UserID, video, req.Features)
+	}))
+	r.Register(NewScorer(ScorerEngagement, func(_ context.Context, req *RankRequest, video *model.Video) float64 {
+		return r.estimateEngagement(req.UserID, video, req.Features)
+	}))
+	r.Register(NewScorer(ScorerHot, func(_ context.Context, _ *RankRequest, video *model.Video) float64 {
+		return r.normalizeScore(video.HotScore, 0, 1000)
+	}))
+	r.Register(NewScorer(ScorerFreshness, func(_ context.Context, _ *RankRequest, video *model.Video) float64 {
+		return r.calculateFreshnessScore(video)
+	}))
+
+	return r
+}
+
+// Register 注册打分因子（同名因子会被替换）
+func (r *Ranker) Register(scorer Scorer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scorers[scorer.Name()] = scorer
 }
 
 // RankRequest 排序请求
 type RankRequest struct {
 	UserID   uint
+	Scene    string
 	Videos   []*model.Video
 	Features map[string]interface{}
+
+	// Scorers 覆盖场景配置的打分因子及权重（A/B 实验使用，为空时使用场景配置）
+	Scorers []config.ScorerConfig
+
+	// 用户标记不感兴趣的视频所属的分类和作者（ID -> 视频数），同分类、同作者的视频降权
+	DislikedCategories map[uint]int
+	DislikedAuthors    map[uint]int
+}
+
+// weightedScorer 启用的打分因子及其权重
+type weightedScorer struct {
+	scorer Scorer
+	weight float64
+}
+
+// sceneScorers 解析请求启用的打分因子：优先使用请求中的覆盖，其次是场景配置、default 场景，最后是内置权重
+func (r *Ranker) sceneScorers(req *RankRequest) []weightedScorer {
+	scene := req.Scene
+	configs := builtinScorers
+	if len(req.Scorers) > 0 {
+		configs = req.Scorers
+	} else if sc, ok := r.scenes[scene]; ok && len(sc.Scorers) > 0 {
+		configs = sc.Scorers
+	} else if sc, ok := r.scenes[defaultScene]; ok && len(sc.Scorers) > 0 {
+		configs = sc.Scorers
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scorers := make([]weightedScorer, 0, len(configs))
+	for _, c := range configs {
+		scorer, ok := r.scorers[c.Name]
+		if !ok {
+			logger.Warn("未注册的打分因子", zap.String("scene", scene), zap.String("scorer", c.Name))
+			continue
+		}
+		scorers = append(scorers, weightedScorer{scorer: scorer, weight: c.Weight})
+	}
+	return scorers
 }
 
 // VideoScore 视频分数
@@ -42,10 +167,11 @@ type VideoScore struct {
 // Rank 对视频进行排序
 func (r *Ranker) Rank(ctx context.Context, req *RankRequest) ([]*model.Video, error) {
 	var scores []VideoScore
+	scorers := r.sceneScorers(req)
 
 	// 计算每个视频的综合分数
 	for _, video := range req.Videos {
-		score := r.calculateScore(ctx, req.UserID, video, req.Features)
+		score := r.calculateScore(ctx, req, video, scorers)
 		scores = append(scores, VideoScore{
 			Video: video,
 			Score: score,
@@ -67,27 +193,24 @@ func (r *Ranker) Rank(ctx context.Context, req *RankRequest) ([]*model.Video, er
 }
 
 // calculateScore 计算视频的综合分数
-// 采用多目标加权融合的方式
-func (r *Ranker) calculateScore(_ context.Context, userID uint, video *model.Video, features map[string]interface{}) float64 {
-	// 1. 点击率预估（CTR）权重：30%
-	ctrScore := r.estimateCTR(userID, video, features) * 0.3
-
-	// 2. 完播率预估权重：25%
-	finishScore := r.estimateFinishRate(userID, video, features) * 0.25
-
-	// 3. 互动率预估（点赞、评论、分享）权重：25%
-	engagementScore := r.estimateEngagement(userID, video, features) * 0.25
-
-	// 4. 热度分数权重：10%
-	hotScore := r.normalizeScore(video.HotScore, 0, 1000) * 0.1
-
-	// 5. 新鲜度分数权重：10%
-	freshnessScore := r.calculateFreshnessScore(video) * 0.1
+// 采用多目标加权融合的方式：各打分因子的分数乘以场景配置的权重后求和
+func (r *Ranker) calculateScore(ctx context.Context, req *RankRequest, video *model.Video, scorers []weightedScorer) float64 {
+	totalScore := 0.0
+	for _, ws := range scorers {
+		totalScore += ws.scorer.Score(ctx, req, video) * ws.weight
+	}
 
-	// 综合分数
-	totalScore := ctrScore + finishScore + engagementScore + hotScore + freshnessScore
+	return totalScore * dislikePenalty(req, video)
+}
 
-	return totalScore
+// dislikePenalty 相似内容降权系数
+// 每个同分类的不感兴趣视频计 0.5，同作者的计 1，系数为 1/(1+累计值)
+func dislikePenalty(req *RankRequest, video *model.Video) float64 {
+	penalty := float64(req.DislikedAuthors[video.UserID])
+	if video.CategoryID != nil {
+		penalty += 0.5 * float64(req.DislikedCategories[*video.CategoryID])
+	}
+	return 1 / (1 + penalty)
 }
 
 // estimateCTR 预估点击率
@@ -196,15 +319,16 @@ func (r *Ranker) normalizeScore(value, min, max float64) float64 {
 }
 
 // ApplyDiversity 应用多样性策略（避免信息茧房）
+// diversityRatio 越大打散越强：同一分类最多保留总数的 (1 - diversityRatio)，且不少于 3 个
 func (r *Ranker) ApplyDiversity(videos []*model.Video, diversityRatio float64) []*model.Video {
 	if diversityRatio <= 0 || len(videos) == 0 {
 		return videos
 	}
 
-	// 计算需要多样化的数量
-	diversityCount := int(float64(len(videos)) * diversityRatio)
-	if diversityCount == 0 {
-		return videos
+	// 计算单个分类的数量上限
+	maxPerCategory := int(float64(len(videos)) * (1 - math.Min(diversityRatio, 1)))
+	if maxPerCategory < 3 {
+		maxPerCategory = 3
 	}
 
 	// 分类统计
@@ -220,7 +344,7 @@ func (r *Ranker) ApplyDiversity(videos []*model.Video, diversityRatio float64) [
 
 		catID := *video.CategoryID
 		// 如果该分类已经太多，跳过（实现多样性）
-		if categoryCount[catID] >= 3 {
+		if categoryCount[catID] >= maxPerCategory {
 			continue
 		}
 		categoryCount[catID]++
@@ -2,12 +2,14 @@ package recommend
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"microvibe-go/internal/algorithm/feature"
 	"microvibe-go/internal/algorithm/filter"
 	"microvibe-go/internal/algorithm/rank"
+	"microvibe-go/internal/config"
 	"microvibe-go/internal/model"
+	"microvibe-go/pkg/event"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -22,43 +24,81 @@ type Engine struct {
 	featureEng  *feature.Engineer
 	ranker      *rank.Ranker
 	videoFilter *filter.VideoFilter
+	experiments ExperimentProvider
+}
+
+// Override 实验对场景推荐策略的覆盖（为空的字段沿用场景配置）
+type Override struct {
+	Recall         []config.RecallChannelConfig // 召回通道及配额
+	Fill           *bool                        // 召回不足时是否随机补足
+	Scorers        []config.ScorerConfig        // 打分因子及权重
+	DiversityRatio *float64                     // 多样性打散比例
+}
+
+// ExperimentProvider 实验分流
+type ExperimentProvider interface {
+	// Override 返回用户在场景下命中的实验覆盖参数，未命中任何实验时返回 nil
+	Override(ctx context.Context, userID uint, scene string) *Override
+	// RecordImpressions 记录下发给用户的视频数（用于计算各实验变体的点击率）
+	RecordImpressions(ctx context.Context, userID uint, scene string, count int)
 }
 
 // NewEngine 创建推荐引擎实例
-func NewEngine(db *gorm.DB, redis *redis.Client) *Engine {
+// cfg 为各场景的召回和排序策略，为空时使用内置策略
+func NewEngine(db *gorm.DB, redis *redis.Client, cfg *config.RecommendConfig) *Engine {
+	var interestCfg *config.InterestConfig
+	if cfg != nil {
+		interestCfg = &cfg.Interest
+	}
 	return &Engine{
 		db:          db,
 		redis:       redis,
-		recaller:    NewRecaller(db, redis),
-		featureEng:  feature.NewEngineer(db, redis),
-		ranker:      rank.NewRanker(db, redis),
+		recaller:    NewRecaller(db, redis, cfg),
+		featureEng:  feature.NewEngineer(db, redis, interestCfg),
+		ranker:      rank.NewRanker(db, redis, cfg),
 		videoFilter: filter.NewVideoFilter(db, redis),
 	}
 }
 
+// SetExperimentProvider 设置实验分流（登录用户按命中的实验变体覆盖推荐策略）
+func (e *Engine) SetExperimentProvider(provider ExperimentProvider) {
+	e.experiments = provider
+}
+
+// RegisterRecallChannel 注册自定义召回通道，在场景配置中按名称启用
+func (e *Engine) RegisterRecallChannel(channel RecallChannel) {
+	e.recaller.Register(channel)
+}
+
+// RegisterScorer 注册自定义打分因子，在场景配置中按名称启用
+func (e *Engine) RegisterScorer(scorer rank.Scorer) {
+	e.ranker.Register(scorer)
+}
+
 // RecommendRequest 推荐请求
 type RecommendRequest struct {
 	UserID   uint   // 用户ID
-	Page     int    // 页码
+	Page     int    // 页码（未携带游标时使用，兼容按页码翻页的客户端）
 	PageSize int    // 每页数量
-	Scene    string // 场景：feed-推荐流、follow-关注、hot-热门
+	Cursor   string // 翻页游标，为空时开始新的推荐会话
+	Scene    string // 场景：feed-推荐流、follow-关注、friends-朋友、hot-热门
 }
 
 // RecommendResponse 推荐响应
 type RecommendResponse struct {
-	Videos []*model.Video // 推荐视频列表
-	Total  int64          // 总数
+	Videos     []*model.Video // 推荐视频列表
+	Total      int64          // 本次会话的视频总数
+	Page       int            // 本次返回的页码
+	NextCursor string         // 下一页游标
+	HasMore    bool           // 是否还有下一页
 }
 
-// cacheKey 生成推荐结果缓存键
-func cacheKey(scene string, userID uint, page, pageSize int) string {
-	if userID > 0 {
-		return fmt.Sprintf("recommend:%s:%d:%d:%d", scene, userID, page, pageSize)
-	}
-	return fmt.Sprintf("recommend:%s:anon:%d:%d", scene, page, pageSize)
+// cacheKey 匿名用户推荐结果缓存键
+func cacheKey(scene string) string {
+	return fmt.Sprintf("recommend:snapshot:%s:anon", scene)
 }
 
-// cacheTTL 推荐结果缓存过期时间
+// cacheTTL 匿名用户推荐结果缓存过期时间
 func cacheTTL(scene string) time.Duration {
 	switch scene {
 	case "hot":
@@ -70,26 +110,89 @@ func cacheTTL(scene string) time.Duration {
 	}
 }
 
-// Recommend 获取推荐视频（带 Redis 缓存）
-// 核心推荐流程：缓存 -> 召回 -> 特征工程 -> 排序 -> 过滤
+// Recommend 获取推荐视频
+// 首次请求执行完整推荐流程（召回 -> 特征工程 -> 排序 -> 过滤）并保存为会话快照，之后按游标从快照中翻页
 func (e *Engine) Recommend(ctx context.Context, req *RecommendRequest) (*RecommendResponse, error) {
-	// 0. 尝试从缓存获取（匿名和热门场景优先走缓存）
-	if e.redis != nil && (req.UserID == 0 || req.Scene == "hot") {
-		key := cacheKey(req.Scene, req.UserID, req.Page, req.PageSize)
-		cached, err := e.redis.Get(ctx, key).Result()
-		if err == nil && cached != "" {
-			var resp RecommendResponse
-			if json.Unmarshal([]byte(cached), &resp) == nil {
-				return &resp, nil
-			}
-		}
+	// 登录用户按命中的实验变体覆盖推荐策略
+	var override *Override
+	if e.experiments != nil && req.UserID > 0 {
+		override = e.experiments.Override(ctx, req.UserID, req.Scene)
+	}
+
+	var resp *RecommendResponse
+	var err error
+	if e.redis != nil {
+		resp, err = e.recommendSession(ctx, req, override)
+	} else {
+		resp, err = e.recommendPage(ctx, req, override)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录实验曝光
+	if override != nil {
+		e.experiments.RecordImpressions(ctx, req.UserID, req.Scene, len(resp.Videos))
+	}
+	return resp, nil
+}
+
+// recommendPage 没有 Redis 时每次请求重新计算，按页码分页
+func (e *Engine) recommendPage(ctx context.Context, req *RecommendRequest, override *Override) (*RecommendResponse, error) {
+	videos, err := e.pipeline(ctx, req, override)
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有会话时游标即页码
+	page := req.Page
+	if n, err := strconv.Atoi(req.Cursor); err == nil {
+		page = n
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * req.PageSize
+	end := start + req.PageSize
+
+	if start >= len(videos) {
+		return &RecommendResponse{
+			Videos: []*model.Video{},
+			Total:  int64(len(videos)),
+			Page:   page,
+		}, nil
+	}
+	if end > len(videos) {
+		end = len(videos)
 	}
 
+	resp := &RecommendResponse{
+		Videos:  videos[start:end],
+		Total:   int64(len(videos)),
+		Page:    page,
+		HasMore: end < len(videos),
+	}
+	if resp.HasMore {
+		resp.NextCursor = strconv.Itoa(page + 1)
+	}
+	return resp, nil
+}
+
+// pipeline 核心推荐流程：召回 -> 特征工程 -> 排序 -> 过滤
+func (e *Engine) pipeline(ctx context.Context, req *RecommendRequest, override *Override) ([]*model.Video, error) {
+	if override == nil {
+		override = &Override{}
+	}
+	mutes := e.videoFilter.LoadMutes(ctx, req.UserID)
+
 	// 1. 召回阶段：从海量视频中快速召回候选集
 	candidates, err := e.recaller.Recall(ctx, &RecallRequest{
-		UserID: req.UserID,
-		Scene:  req.Scene,
-		Limit:  req.PageSize * 10, // 召回数量是最终需要的10倍
+		UserID:   req.UserID,
+		Scene:    req.Scene,
+		Limit:    req.PageSize * 10, // 召回数量是单页数量的10倍
+		Channels: override.Recall,
+		Fill:     override.Fill,
+		Mutes:    mutes,
 	})
 	if err != nil {
 		return nil, err
@@ -104,51 +207,85 @@ func (e *Engine) Recommend(ctx context.Context, req *RecommendRequest) (*Recomme
 	// 3. 排序阶段：对候选视频进行精准排序
 	rankedVideos, err := e.ranker.Rank(ctx, &rank.RankRequest{
 		UserID:   req.UserID,
+		Scene:    req.Scene,
 		Videos:   candidates,
 		Features: features,
+		Scorers:  override.Scorers,
+
+		DislikedCategories: mutes.DislikedCategories,
+		DislikedAuthors:    mutes.DislikedAuthors,
 	})
 	if err != nil {
 		return nil, err
 	}
+	if override.DiversityRatio != nil {
+		rankedVideos = e.ranker.ApplyDiversity(rankedVideos, *override.DiversityRatio)
+	}
 
 	// 4. 过滤阶段：场景感知过滤（关注/朋友流跳过去重和多样性裁剪）
-	filteredVideos, err := e.videoFilter.Filter(ctx, &filter.FilterRequest{
+	return e.videoFilter.Filter(ctx, &filter.FilterRequest{
 		UserID: req.UserID,
 		Videos: rankedVideos,
 		Scene:  req.Scene,
+		Mutes:  mutes,
+	})
+}
+
+// Related 获取相关视频（"接着看"）
+// 优先使用离线计算的相似视频，没有相似视频时退回到同分类热门视频，结果经过推荐流的过滤规则
+func (e *Engine) Related(ctx context.Context, userID uint, video *model.Video, limit int) ([]*model.Video, error) {
+	// 多取一些候选，给过滤留出余量
+	candidates, err := loadRelated(ctx, e.db, e.redis, video.ID, limit*3)
+	if err != nil || len(candidates) == 0 {
+		candidates, err = e.categoryHot(ctx, video, limit*3)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filtered, err := e.videoFilter.Filter(ctx, &filter.FilterRequest{
+		UserID: userID,
+		Videos: candidates,
+		Scene:  "related",
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// 5. 分页处理
-	start := (req.Page - 1) * req.PageSize
-	end := start + req.PageSize
-
-	if start >= len(filteredVideos) {
-		return &RecommendResponse{
-			Videos: []*model.Video{},
-			Total:  int64(len(filteredVideos)),
-		}, nil
-	}
-	if end > len(filteredVideos) {
-		end = len(filteredVideos)
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
 	}
+	return filtered, nil
+}
 
-	resp := &RecommendResponse{
-		Videos: filteredVideos[start:end],
-		Total:  int64(len(filteredVideos)),
+// categoryHot 同分类热门视频（视频未分类时为全站热门）
+func (e *Engine) categoryHot(ctx context.Context, video *model.Video, limit int) ([]*model.Video, error) {
+	query := e.db.WithContext(ctx).Preload("User").
+		Where("status = ? AND id != ?", 1, video.ID)
+	if video.CategoryID != nil {
+		query = query.Where("category_id = ?", *video.CategoryID)
 	}
 
-	// 异步缓存结果（仅缓存匿名和热门场景）
-	if e.redis != nil && (req.UserID == 0 || req.Scene == "hot") {
-		key := cacheKey(req.Scene, req.UserID, req.Page, req.PageSize)
-		if data, err := json.Marshal(resp); err == nil {
-			e.redis.Set(ctx, key, string(data), cacheTTL(req.Scene))
-		}
+	var videos []*model.Video
+	if err := query.Order("hot_score DESC").Limit(limit).Find(&videos).Error; err != nil {
+		return nil, err
 	}
+	return videos, nil
+}
 
-	return resp, nil
+// SubscribeEvents 订阅事件总线上的视频行为事件，实时更新用户兴趣
+func (e *Engine) SubscribeEvents(bus event.EventBus) error {
+	return e.featureEng.Subscribe(bus)
+}
+
+// StartInterestFlush 启动用户兴趣定时落库
+func (e *Engine) StartInterestFlush(ctx context.Context) {
+	e.featureEng.StartInterestFlush(ctx)
+}
+
+// InvalidateMutes 用户屏蔽或取消屏蔽后清除屏蔽列表缓存
+func (e *Engine) InvalidateMutes(ctx context.Context, userID uint) {
+	e.videoFilter.InvalidateMutes(ctx, userID)
 }
 
 // UpdateUserProfile 更新用户画像
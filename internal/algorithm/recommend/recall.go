@@ -3,26 +3,137 @@ package recommend
 import (
 	"context"
 	"fmt"
+	"microvibe-go/internal/algorithm/filter"
+	"microvibe-go/internal/config"
 	"microvibe-go/internal/model"
+	"microvibe-go/pkg/logger"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
 
+// 内置召回通道名称
+const (
+	ChannelCollaborative = "collaborative" // 协同过滤
+	ChannelCategory      = "category"      // 用户兴趣分类
+	ChannelHot           = "hot"           // 热门
+	ChannelNew           = "new"           // 新视频（冷启动）
+	ChannelFollow        = "follow"        // 关注的人
+	ChannelFriends       = "friends"       // 朋友（双向关注）
+	ChannelHashtag       = "hashtag"       // 感兴趣的话题
+	ChannelLocal         = "local"         // 同城
+)
+
+// defaultScene 未单独配置策略的场景使用的场景名
+const defaultScene = "default"
+
+// builtinRecall 内置的召回策略（配置中没有对应场景时使用）
+var builtinRecall = map[string]struct {
+	Channels []config.RecallChannelConfig
+	Fill     bool
+}{
+	defaultScene: {
+		Channels: []config.RecallChannelConfig{
+			{Name: ChannelCollaborative, Quota: 0.25},
+			{Name: ChannelCategory, Quota: 0.25},
+			{Name: ChannelHot, Quota: 0.25},
+			{Name: ChannelNew, Quota: 0.25},
+		},
+		Fill: true,
+	},
+	// 关注流和朋友流采用纯净召回策略
+	"follow": {
+		Channels: []config.RecallChannelConfig{{Name: ChannelFollow, Quota: 1}},
+	},
+	"friends": {
+		Channels: []config.RecallChannelConfig{{Name: ChannelFriends, Quota: 1}},
+	},
+}
+
+// RecallChannel 召回通道
+// 每个通道负责一路候选视频，场景配置决定启用哪些通道以及各通道的配额
+type RecallChannel interface {
+	// Name 通道名称，与配置中的 name 对应
+	Name() string
+	// Recall 召回至多 limit 个视频
+	Recall(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error)
+}
+
+// recallChannelFunc 以函数实现的召回通道
+type recallChannelFunc struct {
+	name string
+	fn   func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error)
+}
+
+func (c *recallChannelFunc) Name() string { return c.name }
+
+func (c *recallChannelFunc) Recall(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+	return c.fn(ctx, req, limit)
+}
+
+// NewRecallChannel 用函数创建召回通道
+func NewRecallChannel(name string, fn func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error)) RecallChannel {
+	return &recallChannelFunc{name: name, fn: fn}
+}
+
 // Recaller 召回器
 type Recaller struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db     *gorm.DB
+	redis  *redis.Client
+	scenes map[string]config.RecommendSceneConfig
+
+	mu       sync.RWMutex
+	channels map[string]RecallChannel
 }
 
-// NewRecaller 创建召回器实例
-func NewRecaller(db *gorm.DB, redis *redis.Client) *Recaller {
-	return &Recaller{
-		db:    db,
-		redis: redis,
+// NewRecaller 创建召回器实例，并注册内置召回通道
+func NewRecaller(db *gorm.DB, redis *redis.Client, cfg *config.RecommendConfig) *Recaller {
+	r := &Recaller{
+		db:       db,
+		redis:    redis,
+		channels: make(map[string]RecallChannel),
+	}
+	if cfg != nil {
+		r.scenes = cfg.Scenes
 	}
+
+	r.Register(NewRecallChannel(ChannelCollaborative, func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.collaborativeFilteringRecall(ctx, req.UserID, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelCategory, func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.contentBasedRecall(ctx, req.UserID, req.Mutes, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelHot, func(ctx context.Context, _ *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.hotRecall(ctx, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelNew, func(ctx context.Context, _ *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.newVideoRecall(ctx, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelFollow, func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.followRecall(ctx, req.UserID, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelFriends, func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.friendsRecall(ctx, req.UserID, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelHashtag, func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.hashtagRecall(ctx, req.UserID, req.Mutes, limit)
+	}))
+	r.Register(NewRecallChannel(ChannelLocal, func(ctx context.Context, req *RecallRequest, limit int) ([]*model.Video, error) {
+		return r.localRecall(ctx, req.UserID, limit)
+	}))
+
+	return r
+}
+
+// Register 注册召回通道（同名通道会被替换）
+func (r *Recaller) Register(channel RecallChannel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels[channel.Name()] = channel
 }
 
 // RecallRequest 召回请求
@@ -30,68 +141,110 @@ type RecallRequest struct {
 	UserID uint   // 用户ID
 	Scene  string // 场景
 	Limit  int    // 召回数量
+
+	// 覆盖场景配置的召回策略（A/B 实验使用，为空时使用场景配置）
+	Channels []config.RecallChannelConfig
+	Fill     *bool
+
+	// Mutes 用户的负反馈，被屏蔽的视频、作者和分类不进入候选集
+	Mutes *filter.Mutes
+}
+
+// sceneStrategy 解析请求的召回策略
+// 优先使用请求中的覆盖，其次是配置中的场景、内置的同名场景，最后是 default 场景
+func (r *Recaller) sceneStrategy(req *RecallRequest) ([]config.RecallChannelConfig, bool) {
+	channels := req.Channels
+	fill := req.Fill
+	scene := req.Scene
+
+	for _, name := range []string{scene, defaultScene} {
+		if sc, ok := r.scenes[name]; ok {
+			if len(channels) == 0 {
+				channels = sc.Recall
+			}
+			if fill == nil {
+				fill = sc.Fill
+			}
+		}
+		if builtin, ok := builtinRecall[name]; ok {
+			if len(channels) == 0 {
+				channels = builtin.Channels
+			}
+			if fill == nil {
+				fill = &builtin.Fill
+			}
+		}
+		if len(channels) > 0 && fill != nil {
+			break
+		}
+	}
+
+	return channels, fill != nil && *fill
 }
 
 // Recall 多路召回策略
+// 按场景配置并发执行各召回通道，每个通道的召回数量为总量乘以配额，结果按通道顺序合并去重并移除被屏蔽的视频
 func (r *Recaller) Recall(ctx context.Context, req *RecallRequest) ([]*model.Video, error) {
-	// 如果是关注流或朋友流场景，采用纯净召回策略
-	if req.Scene == "follow" {
-		return r.followRecall(ctx, req.UserID, req.Limit)
-	}
-	if req.Scene == "friends" {
-		return r.friendsRecall(ctx, req.UserID, req.Limit)
+	channelConfigs, fill := r.sceneStrategy(req)
+
+	r.mu.RLock()
+	channels := make([]RecallChannel, len(channelConfigs))
+	for i, cc := range channelConfigs {
+		channel, ok := r.channels[cc.Name]
+		if !ok {
+			logger.Warn("未注册的召回通道", zap.String("scene", req.Scene), zap.String("channel", cc.Name))
+			continue
+		}
+		channels[i] = channel
 	}
+	r.mu.RUnlock()
 
-	// 其他场景并发执行 4 路召回
-	type recallResult struct {
-		videos []*model.Video
-	}
-	results := make([]recallResult, 4)
+	results := make([][]*model.Video, len(channels))
 
 	eg, egCtx := errgroup.WithContext(ctx)
-
-	eg.Go(func() error {
-		videos, _ := r.collaborativeFilteringRecall(egCtx, req.UserID, req.Limit/4)
-		results[0] = recallResult{videos: videos}
-		return nil
-	})
-	eg.Go(func() error {
-		videos, _ := r.contentBasedRecall(egCtx, req.UserID, req.Limit/4)
-		results[1] = recallResult{videos: videos}
-		return nil
-	})
-	eg.Go(func() error {
-		videos, _ := r.hotRecall(egCtx, req.Limit/4)
-		results[2] = recallResult{videos: videos}
-		return nil
-	})
-	eg.Go(func() error {
-		videos, _ := r.newVideoRecall(egCtx, req.Limit/4)
-		results[3] = recallResult{videos: videos}
-		return nil
-	})
+	for i, channel := range channels {
+		if channel == nil {
+			continue
+		}
+		limit := int(float64(req.Limit) * channelConfigs[i].Quota)
+		if limit <= 0 {
+			continue
+		}
+		i, channel := i, channel
+		eg.Go(func() error {
+			// 单路召回失败不影响其他通道
+			videos, err := channel.Recall(egCtx, req, limit)
+			if err != nil {
+				logger.Warn("召回通道执行失败", zap.String("channel", channel.Name()), zap.Error(err))
+				return nil
+			}
+			results[i] = videos
+			return nil
+		})
+	}
 
 	_ = eg.Wait()
 
 	// 合并去重
-	videoMap := make(map[uint]*model.Video)
+	seen := make(map[uint]struct{})
+	videos := make([]*model.Video, 0, req.Limit)
 	for _, res := range results {
-		for _, v := range res.videos {
-			videoMap[v.ID] = v
+		for _, v := range res {
+			if _, exists := seen[v.ID]; exists || req.Mutes.Excludes(v) {
+				continue
+			}
+			seen[v.ID] = struct{}{}
+			videos = append(videos, v)
 		}
 	}
 
-	videos := make([]*model.Video, 0, len(videoMap))
-	for _, v := range videoMap {
-		videos = append(videos, v)
-	}
-
 	// 召回不足时兜底随机补充
-	if len(videos) < req.Limit {
+	if fill && len(videos) < req.Limit {
 		randomVideos, err := r.randomRecall(ctx, req.Limit-len(videos))
 		if err == nil {
 			for _, v := range randomVideos {
-				if _, exists := videoMap[v.ID]; !exists {
+				if _, exists := seen[v.ID]; !exists && !req.Mutes.Excludes(v) {
+					seen[v.ID] = struct{}{}
 					videos = append(videos, v)
 				}
 			}
@@ -155,10 +308,11 @@ func (r *Recaller) collaborativeFilteringRecall(ctx context.Context, userID uint
 }
 
 // contentBasedRecall 基于内容的召回
-func (r *Recaller) contentBasedRecall(ctx context.Context, userID uint, limit int) ([]*model.Video, error) {
+// 屏蔽的分类即使仍有兴趣分数也不召回
+func (r *Recaller) contentBasedRecall(ctx context.Context, userID uint, mutes *filter.Mutes, limit int) ([]*model.Video, error) {
 	// 1. 获取用户兴趣标签
 	var interests []model.UserInterest
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND tag_id IS NULL AND score > 0", userID).
 		Order("score DESC").
 		Limit(5).
 		Find(&interests).Error; err != nil {
@@ -172,8 +326,14 @@ func (r *Recaller) contentBasedRecall(ctx context.Context, userID uint, limit in
 	// 2. 根据用户兴趣的分类召回视频
 	var categoryIDs []uint
 	for _, interest := range interests {
+		if mutes != nil && mutes.Categories[interest.CategoryID] {
+			continue
+		}
 		categoryIDs = append(categoryIDs, interest.CategoryID)
 	}
+	if len(categoryIDs) == 0 {
+		return []*model.Video{}, nil
+	}
 
 	var videos []*model.Video
 	if err := r.db.WithContext(ctx).Where("category_id IN ?", categoryIDs).
@@ -326,3 +486,95 @@ func (r *Recaller) friendsRecall(ctx context.Context, userID uint, limit int) ([
 
 	return videos, nil
 }
+
+// hashtagRecall 同话题召回：用户感兴趣的话题（没有话题兴趣时为近期点赞视频的话题）下的热门视频
+// 屏蔽的话题不召回
+func (r *Recaller) hashtagRecall(ctx context.Context, userID uint, mutes *filter.Mutes, limit int) ([]*model.Video, error) {
+	if userID == 0 {
+		return []*model.Video{}, nil
+	}
+
+	recentLikes := r.db.Model(&model.Like{}).
+		Select("video_id").
+		Where("user_id = ? AND type = ?", userID, 1).
+		Order("created_at DESC").
+		Limit(20)
+
+	// 优先使用用户的话题兴趣，还没有话题兴趣时使用最近点赞视频的话题
+	var hashtagIDs []uint
+	if err := r.db.WithContext(ctx).Model(&model.UserInterest{}).
+		Where("user_id = ? AND tag_id IS NOT NULL AND score > 0", userID).
+		Order("score DESC").
+		Limit(10).
+		Pluck("tag_id", &hashtagIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(hashtagIDs) == 0 {
+		if err := r.db.WithContext(ctx).Model(&model.VideoHashtag{}).
+			Distinct("hashtag_id").
+			Where("video_id IN (?)", recentLikes).
+			Limit(10).
+			Pluck("hashtag_id", &hashtagIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if mutes != nil && len(mutes.Hashtags) > 0 {
+		kept := hashtagIDs[:0]
+		for _, id := range hashtagIDs {
+			if !mutes.Hashtags[id] {
+				kept = append(kept, id)
+			}
+		}
+		hashtagIDs = kept
+	}
+	if len(hashtagIDs) == 0 {
+		return []*model.Video{}, nil
+	}
+
+	var videos []*model.Video
+	if err := r.db.WithContext(ctx).
+		Where("id IN (?)", r.db.Model(&model.VideoHashtag{}).Select("video_id").Where("hashtag_id IN ?", hashtagIDs)).
+		Where("id NOT IN (?)", recentLikes).
+		Preload("User").
+		Where("status = ?", 1).
+		Order("hot_score DESC").
+		Limit(limit).
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+
+	return videos, nil
+}
+
+// localRecall 同城召回：与用户同一城市的作者发布的热门视频
+func (r *Recaller) localRecall(ctx context.Context, userID uint, limit int) ([]*model.Video, error) {
+	if userID == 0 {
+		return []*model.Video{}, nil
+	}
+
+	var cities []string
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		Pluck("city", &cities).Error; err != nil {
+		return nil, err
+	}
+
+	if len(cities) == 0 || cities[0] == "" {
+		return []*model.Video{}, nil
+	}
+	city := cities[0]
+
+	var videos []*model.Video
+	if err := r.db.WithContext(ctx).
+		Where("user_id IN (?)", r.db.Model(&model.User{}).Select("id").Where("city = ? AND id != ?", city, userID)).
+		Preload("User").
+		Where("status = ?", 1).
+		Order("hot_score DESC").
+		Limit(limit).
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+
+	return videos, nil
+}
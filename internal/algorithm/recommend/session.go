@@ -0,0 +1,451 @@
+package recommend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microvibe-go/internal/model"
+	"microvibe-go/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 推荐会话
+// 首次请求执行一次完整的推荐流程，把排好序的结果保存为会话快照（待下发队列），之后的请求凭游标
+// 从队列中依次取出下一页，不再重新召回，因此翻页不会重复或遗漏。用户的点赞、完播、划走等反馈
+// 会调整队列中尚未下发的视频的顺序。
+//
+// Redis 键（{sid} 保证同一会话的键落在同一个集群槽位）：
+//   - recommend:session:{sid}          会话信息（HASH：user_id、scene、total、pages）
+//   - recommend:session:{sid}:queue    待下发队列（ZSET：视频ID -> 排序分）
+//   - recommend:session:{sid}:attrs    视频属性（HASH：视频ID -> 分类ID:作者ID），用于按反馈调整顺序
+//   - recommend:session:{sid}:page:<n> 已下发的第 n 页（逗号分隔的视频ID），同一游标重试时原样返回
+//   - recommend:session:user:<uid>     用户最近的会话，反馈作用于该会话
+const (
+	sessionKeyPrefix     = "recommend:session:"
+	sessionUserKeyPrefix = "recommend:session:user:"
+
+	// sessionTTL 会话空闲过期时间，每次翻页时续期
+	sessionTTL = 30 * time.Minute
+)
+
+// ErrSessionExpired 游标对应的会话不存在或已过期
+var ErrSessionExpired = errors.New("推荐会话已过期")
+
+// servePageScript 取出会话的第 n 页
+// 已下发过的页原样返回；下一页从队列头部弹出并记录，弹出、记录页内容和更新已下发页数在同一脚本内完成，
+// 并发请求同一页时只会弹出一次
+// KEYS[1] 会话信息；KEYS[2] 待下发队列；KEYS[3] 第 n 页；ARGV[1] 页码；ARGV[2] 每页数量；ARGV[3] 过期时间（秒）
+// 返回 {逗号分隔的视频ID, 已下发页数}，页不存在或超出下一页时返回 nil
+var servePageScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return nil
+end
+local page = tonumber(ARGV[1])
+local served = tonumber(redis.call('HGET', KEYS[1], 'pages') or '0')
+if page <= served then
+	local raw = redis.call('GET', KEYS[3])
+	if not raw then
+		return nil
+	end
+	return {raw, served}
+end
+if page > served + 1 then
+	return nil
+end
+local popped = redis.call('ZPOPMAX', KEYS[2], tonumber(ARGV[2]))
+local members = {}
+for i = 1, #popped, 2 do
+	members[#members + 1] = popped[i]
+end
+local raw = table.concat(members, ',')
+redis.call('SET', KEYS[3], raw, 'EX', tonumber(ARGV[3]))
+redis.call('HSET', KEYS[1], 'pages', page)
+return {raw, page}
+`)
+
+// FeedbackSignal 用户对推荐视频的反馈
+type FeedbackSignal string
+
+const (
+	FeedbackLike   FeedbackSignal = "like"   // 点赞
+	FeedbackFinish FeedbackSignal = "finish" // 完播
+	FeedbackSkip   FeedbackSignal = "skip"   // 划走
+)
+
+// feedbackBoosts 反馈对队列中同分类、同作者视频排序分的调整
+// 队列中视频的初始排序分在 (0, 1] 之间按名次递减
+var feedbackBoosts = map[FeedbackSignal]struct {
+	Category float64
+	Author   float64
+}{
+	FeedbackLike:   {Category: 0.3, Author: 0.5},
+	FeedbackFinish: {Category: 0.1, Author: 0.2},
+	FeedbackSkip:   {Category: -0.3, Author: -0.5},
+}
+
+// sessionItem 会话快照中的视频
+type sessionItem struct {
+	VideoID    uint `json:"v"`
+	CategoryID uint `json:"c"`
+	AuthorID   uint `json:"a"`
+}
+
+func sessionKey(sid string) string {
+	return sessionKeyPrefix + "{" + sid + "}"
+}
+
+func sessionQueueKey(sid string) string {
+	return sessionKey(sid) + ":queue"
+}
+
+func sessionAttrsKey(sid string) string {
+	return sessionKey(sid) + ":attrs"
+}
+
+func sessionPageKey(sid string, page int) string {
+	return sessionKey(sid) + ":page:" + strconv.Itoa(page)
+}
+
+func sessionUserKey(userID uint) string {
+	return sessionUserKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// encodeCursor 生成不透明游标（会话ID + 下一页序号）
+func encodeCursor(sid string, page int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sid + ":" + strconv.Itoa(page)))
+}
+
+// decodeCursor 解析游标
+func decodeCursor(cursor string) (string, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	sid, pageStr, ok := strings.Cut(string(raw), ":")
+	if !ok || sid == "" {
+		return "", 0, errors.New("游标格式错误")
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		return "", 0, errors.New("游标格式错误")
+	}
+	return sid, page, nil
+}
+
+// newSessionID 生成会话ID
+func newSessionID() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// sessionItems 由排好序的视频生成会话快照
+func sessionItems(videos []*model.Video) []sessionItem {
+	items := make([]sessionItem, 0, len(videos))
+	for _, v := range videos {
+		item := sessionItem{VideoID: v.ID, AuthorID: v.UserID}
+		if v.CategoryID != nil {
+			item.CategoryID = *v.CategoryID
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// snapshot 执行推荐流程生成会话快照
+// 匿名用户的结果与用户无关，按场景短暂缓存后共用
+func (e *Engine) snapshot(ctx context.Context, req *RecommendRequest, override *Override) ([]sessionItem, error) {
+	cacheable := req.UserID == 0 && override == nil
+	if cacheable {
+		if cached, err := e.redis.Get(ctx, cacheKey(req.Scene)).Result(); err == nil {
+			var items []sessionItem
+			if json.Unmarshal([]byte(cached), &items) == nil {
+				return items, nil
+			}
+		}
+	}
+
+	videos, err := e.pipeline(ctx, req, override)
+	if err != nil {
+		return nil, err
+	}
+	items := sessionItems(videos)
+
+	if cacheable {
+		if data, err := json.Marshal(items); err == nil {
+			e.redis.Set(ctx, cacheKey(req.Scene), data, cacheTTL(req.Scene))
+		}
+	}
+	return items, nil
+}
+
+// createSession 生成会话快照并保存
+func (e *Engine) createSession(ctx context.Context, req *RecommendRequest, override *Override) (string, error) {
+	items, err := e.snapshot(ctx, req, override)
+	if err != nil {
+		return "", err
+	}
+
+	sid := newSessionID()
+	pipe := e.redis.TxPipeline()
+	pipe.HSet(ctx, sessionKey(sid), map[string]interface{}{
+		"user_id": req.UserID,
+		"scene":   req.Scene,
+		"total":   len(items),
+		"pages":   0,
+	})
+	if len(items) > 0 {
+		members := make([]redis.Z, 0, len(items))
+		attrs := make(map[string]interface{}, len(items))
+		for i, item := range items {
+			id := strconv.FormatUint(uint64(item.VideoID), 10)
+			members = append(members, redis.Z{Score: 1 - float64(i)/float64(len(items)), Member: id})
+			attrs[id] = fmt.Sprintf("%d:%d", item.CategoryID, item.AuthorID)
+		}
+		pipe.ZAdd(ctx, sessionQueueKey(sid), members...)
+		pipe.HSet(ctx, sessionAttrsKey(sid), attrs)
+		pipe.Expire(ctx, sessionQueueKey(sid), sessionTTL)
+		pipe.Expire(ctx, sessionAttrsKey(sid), sessionTTL)
+	}
+	pipe.Expire(ctx, sessionKey(sid), sessionTTL)
+	if req.UserID > 0 {
+		pipe.Set(ctx, sessionUserKey(req.UserID), sid, sessionTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return sid, nil
+}
+
+// sessionPage 取出会话的第 page 页
+// 已下发过的页直接返回（客户端重试），否则从队列头部取出 pageSize 个视频作为该页
+func (e *Engine) sessionPage(ctx context.Context, sid string, req *RecommendRequest, page int) ([]uint, int64, bool, error) {
+	meta, err := e.redis.HGetAll(ctx, sessionKey(sid)).Result()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(meta) == 0 || meta["scene"] != req.Scene || meta["user_id"] != strconv.FormatUint(uint64(req.UserID), 10) {
+		return nil, 0, false, ErrSessionExpired
+	}
+	total, _ := strconv.ParseInt(meta["total"], 10, 64)
+
+	pageKey := sessionPageKey(sid, page)
+	result, err := servePageScript.Run(ctx, e.redis,
+		[]string{sessionKey(sid), sessionQueueKey(sid), pageKey},
+		page, req.PageSize, int(sessionTTL.Seconds()),
+	).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, false, ErrSessionExpired
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(result) != 2 {
+		return nil, 0, false, ErrSessionExpired
+	}
+	raw, _ := result[0].(string)
+	served, _ := result[1].(int64)
+	ids := parseIDList(raw)
+
+	// 续期
+	remaining, _ := e.redis.ZCard(ctx, sessionQueueKey(sid)).Result()
+	pipe := e.redis.Pipeline()
+	pipe.Expire(ctx, sessionKey(sid), sessionTTL)
+	pipe.Expire(ctx, sessionQueueKey(sid), sessionTTL)
+	pipe.Expire(ctx, sessionAttrsKey(sid), sessionTTL)
+	pipe.Expire(ctx, pageKey, sessionTTL)
+	if req.UserID > 0 {
+		pipe.Set(ctx, sessionUserKey(req.UserID), sid, sessionTTL)
+	}
+	_, _ = pipe.Exec(ctx)
+
+	hasMore := remaining > 0 || int64(page) < served
+	return ids, total, hasMore, nil
+}
+
+// recommendSession 按游标返回会话的下一页，没有游标或会话已过期时创建新会话
+// 未携带游标但请求第 2 页及以后的旧客户端按页码翻页：登录用户沿用其最近的会话，否则按页码重新计算
+func (e *Engine) recommendSession(ctx context.Context, req *RecommendRequest, override *Override) (*RecommendResponse, error) {
+	sid, page := "", 1
+	legacyPage := req.Cursor == "" && req.Page > 1
+	if req.Cursor != "" {
+		if s, p, err := decodeCursor(req.Cursor); err == nil {
+			sid, page = s, p
+		}
+	} else if legacyPage && req.UserID > 0 {
+		if s, err := e.redis.Get(ctx, sessionUserKey(req.UserID)).Result(); err == nil {
+			sid, page = s, req.Page
+		}
+	}
+	if legacyPage && sid == "" {
+		return e.recommendPage(ctx, req, override)
+	}
+
+	var ids []uint
+	var total int64
+	var hasMore bool
+	var err error
+	if sid != "" {
+		ids, total, hasMore, err = e.sessionPage(ctx, sid, req, page)
+	}
+	if legacyPage && errors.Is(err, ErrSessionExpired) {
+		return e.recommendPage(ctx, req, override)
+	}
+	if sid == "" || errors.Is(err, ErrSessionExpired) {
+		// 会话过期后从新会话的第一页开始
+		if sid, err = e.createSession(ctx, req, override); err != nil {
+			return nil, err
+		}
+		page = 1
+		ids, total, hasMore, err = e.sessionPage(ctx, sid, req, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	videos, err := e.loadVideos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	// 会话生成后新屏蔽的内容不再下发
+	videos = e.videoFilter.RemoveMuted(ctx, e.videoFilter.LoadMutes(ctx, req.UserID), videos)
+
+	resp := &RecommendResponse{
+		Videos:  videos,
+		Total:   total,
+		Page:    page,
+		HasMore: hasMore,
+	}
+	if hasMore {
+		resp.NextCursor = encodeCursor(sid, page+1)
+	}
+	return resp, nil
+}
+
+// loadVideos 按顺序加载视频（跳过已下架或删除的视频）
+func (e *Engine) loadVideos(ctx context.Context, ids []uint) ([]*model.Video, error) {
+	if len(ids) == 0 {
+		return []*model.Video{}, nil
+	}
+
+	var videos []*model.Video
+	if err := e.db.WithContext(ctx).Preload("User").
+		Where("id IN ? AND status = ?", ids, 1).
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*model.Video, len(videos))
+	for _, v := range videos {
+		byID[v.ID] = v
+	}
+	ordered := make([]*model.Video, 0, len(videos))
+	for _, id := range ids {
+		if v, ok := byID[id]; ok {
+			ordered = append(ordered, v)
+		}
+	}
+	return ordered, nil
+}
+
+// Feedback 根据用户反馈调整其最近会话中尚未下发的视频顺序
+// 点赞、完播提升同分类和同作者视频，划走降低同分类和同作者视频
+func (e *Engine) Feedback(ctx context.Context, userID, videoID uint, signal FeedbackSignal) {
+	boost, ok := feedbackBoosts[signal]
+	if e.redis == nil || userID == 0 || !ok {
+		return
+	}
+
+	sid, err := e.redis.Get(ctx, sessionUserKey(userID)).Result()
+	if err != nil {
+		return
+	}
+
+	// 反馈的视频通常来自会话本身，不在会话中时查询数据库
+	categoryID, authorID, found := uint(0), uint(0), false
+	if attr, err := e.redis.HGet(ctx, sessionAttrsKey(sid), strconv.FormatUint(uint64(videoID), 10)).Result(); err == nil {
+		categoryID, authorID, found = parseAttr(attr)
+	}
+	if !found {
+		var video model.Video
+		if err := e.db.WithContext(ctx).Select("id, user_id, category_id").First(&video, videoID).Error; err != nil {
+			return
+		}
+		authorID = video.UserID
+		if video.CategoryID != nil {
+			categoryID = *video.CategoryID
+		}
+	}
+
+	tail, err := e.redis.ZRange(ctx, sessionQueueKey(sid), 0, -1).Result()
+	if err != nil || len(tail) == 0 {
+		return
+	}
+	attrs, err := e.redis.HMGet(ctx, sessionAttrsKey(sid), tail...).Result()
+	if err != nil {
+		return
+	}
+
+	pipe := e.redis.Pipeline()
+	adjusted := 0
+	for i, member := range tail {
+		attr, _ := attrs[i].(string)
+		c, a, ok := parseAttr(attr)
+		if !ok {
+			continue
+		}
+		delta := 0.0
+		if categoryID != 0 && c == categoryID {
+			delta += boost.Category
+		}
+		if a == authorID {
+			delta += boost.Author
+		}
+		if delta != 0 {
+			pipe.ZIncrBy(ctx, sessionQueueKey(sid), delta, member)
+			adjusted++
+		}
+	}
+	if adjusted == 0 {
+		return
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("调整推荐会话顺序失败", zap.Error(err), zap.String("session", sid))
+	}
+}
+
+// parseAttr 解析视频属性（分类ID:作者ID）
+func parseAttr(attr string) (uint, uint, bool) {
+	c, a, ok := strings.Cut(attr, ":")
+	if !ok {
+		return 0, 0, false
+	}
+	categoryID, err1 := strconv.ParseUint(c, 10, 64)
+	authorID, err2 := strconv.ParseUint(a, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return uint(categoryID), uint(authorID), true
+}
+
+// parseIDList 解析逗号分隔的视频ID
+func parseIDList(raw string) []uint {
+	var ids []uint
+	for _, s := range strings.Split(raw, ",") {
+		if id, err := strconv.ParseUint(s, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
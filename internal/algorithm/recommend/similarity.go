@@ -0,0 +1,376 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/pkg/logger"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// relatedKeyPrefix 相似视频（ZSET：视频ID -> 相似度），后缀为视频ID
+	relatedKeyPrefix = "recommend:related:"
+	// similarityLockKey 计算锁，保证多实例部署时每个周期只有一个实例执行计算
+	similarityLockKey = "recommend:similarity:lock"
+
+	// similarityBatchSize 批量查询和写入 Redis 的视频数
+	similarityBatchSize = 500
+)
+
+// relatedKey 视频的相似视频缓存键
+func relatedKey(videoID uint) string {
+	return relatedKeyPrefix + strconv.FormatUint(uint64(videoID), 10)
+}
+
+// SimilarityBuilder 视频相似度离线计算
+// 相似度 = 行为共现的余弦相似度（活跃用户按 1/ln(1+n) 降权）+ 同分类加分 + 话题 Jaccard 重合度 × 权重，
+// 每个视频保留 TopK 个最相似的视频写入 Redis
+type SimilarityBuilder struct {
+	db     *gorm.DB
+	redis  *redis.Client
+	config config.SimilarityConfig
+}
+
+// NewSimilarityBuilder 创建视频相似度计算实例
+func NewSimilarityBuilder(db *gorm.DB, redis *redis.Client, cfg *config.SimilarityConfig) *SimilarityBuilder {
+	b := &SimilarityBuilder{db: db, redis: redis}
+	if cfg != nil {
+		b.config = *cfg
+	}
+	return b
+}
+
+// videoPair 共现的视频对（A < B）
+type videoPair struct {
+	A, B uint
+}
+
+// neighbour 相似视频
+type neighbour struct {
+	VideoID uint
+	Score   float64
+}
+
+// Start 启动定时计算，ctx 取消后停止
+func (b *SimilarityBuilder) Start(ctx context.Context) {
+	if b.redis == nil || b.config.Interval <= 0 {
+		return
+	}
+
+	interval := time.Duration(b.config.Interval) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := b.Build(ctx); err != nil {
+				logger.Error("视频相似度计算失败", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Info("视频相似度计算任务已启动", zap.Duration("interval", interval))
+}
+
+// Build 重新计算所有视频的相似视频（其他实例正在计算时跳过）
+func (b *SimilarityBuilder) Build(ctx context.Context) error {
+	if b.redis == nil {
+		return nil
+	}
+
+	// 锁在一个周期后自动过期，不主动释放，保证每个周期只计算一次
+	lockTTL := time.Duration(b.config.Interval) * time.Second
+	if lockTTL <= 0 {
+		lockTTL = time.Hour
+	}
+	acquired, err := b.redis.SetNX(ctx, similarityLockKey, 1, lockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+
+	start := time.Now()
+	cooccurrence, itemUsers, err := b.countCooccurrence(ctx)
+	if err != nil {
+		return err
+	}
+	if len(cooccurrence) == 0 {
+		return nil
+	}
+
+	videoIDs := make([]uint, 0, len(itemUsers))
+	for id := range itemUsers {
+		videoIDs = append(videoIDs, id)
+	}
+	categories, hashtags, err := b.loadContent(ctx, videoIDs)
+	if err != nil {
+		return err
+	}
+
+	neighbours := make(map[uint][]neighbour)
+	for pair, co := range cooccurrence {
+		// 未发布或已删除的视频不参与
+		if _, ok := categories[pair.A]; !ok {
+			continue
+		}
+		if _, ok := categories[pair.B]; !ok {
+			continue
+		}
+
+		score := co / math.Sqrt(itemUsers[pair.A]*itemUsers[pair.B])
+		if categories[pair.A] != 0 && categories[pair.A] == categories[pair.B] {
+			score += b.config.CategoryWeight
+		}
+		score += b.config.HashtagWeight * jaccard(hashtags[pair.A], hashtags[pair.B])
+
+		neighbours[pair.A] = append(neighbours[pair.A], neighbour{VideoID: pair.B, Score: score})
+		neighbours[pair.B] = append(neighbours[pair.B], neighbour{VideoID: pair.A, Score: score})
+	}
+
+	if err := b.save(ctx, neighbours); err != nil {
+		return err
+	}
+
+	logger.Info("视频相似度已更新",
+		zap.Int("videos", len(neighbours)),
+		zap.Int("pairs", len(cooccurrence)),
+		zap.Duration("elapsed", time.Since(start)))
+	return nil
+}
+
+// countCooccurrence 统计时间窗口内视频两两被同一用户观看的次数
+// 返回视频对的加权共现次数，以及每个视频的加权观看用户数
+func (b *SimilarityBuilder) countCooccurrence(ctx context.Context) (map[videoPair]float64, map[uint]float64, error) {
+	window := b.config.Window
+	if window <= 0 {
+		window = 30
+	}
+	maxUserVideos := b.config.MaxUserVideos
+	if maxUserVideos <= 0 {
+		maxUserVideos = 50
+	}
+
+	rows, err := b.db.WithContext(ctx).Model(&model.UserBehavior{}).
+		Select("user_id, video_id, MAX(created_at) AS last_at").
+		Where("created_at > ?", time.Now().AddDate(0, 0, -window)).
+		Group("user_id, video_id").
+		Order("user_id ASC, last_at DESC").
+		Rows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	cooccurrence := make(map[videoPair]float64)
+	itemUsers := make(map[uint]float64)
+
+	var currentUser uint
+	var videos []uint
+	flush := func() {
+		if len(videos) < 2 {
+			videos = videos[:0]
+			return
+		}
+		// 看得越多的用户，单个共现越不能说明两个视频相似
+		weight := 1 / math.Log(1+float64(len(videos)))
+		for i := 0; i < len(videos); i++ {
+			itemUsers[videos[i]] += weight
+			for j := i + 1; j < len(videos); j++ {
+				pair := videoPair{A: videos[i], B: videos[j]}
+				if pair.A > pair.B {
+					pair.A, pair.B = pair.B, pair.A
+				}
+				cooccurrence[pair] += weight
+			}
+		}
+		videos = videos[:0]
+	}
+
+	for rows.Next() {
+		var userID, videoID uint
+		var lastAt time.Time
+		if err := rows.Scan(&userID, &videoID, &lastAt); err != nil {
+			return nil, nil, err
+		}
+		if userID != currentUser {
+			flush()
+			currentUser = userID
+		}
+		if len(videos) < maxUserVideos {
+			videos = append(videos, videoID)
+		}
+	}
+	flush()
+
+	return cooccurrence, itemUsers, rows.Err()
+}
+
+// loadContent 批量加载已发布视频的分类和话题
+func (b *SimilarityBuilder) loadContent(ctx context.Context, videoIDs []uint) (map[uint]uint, map[uint][]uint, error) {
+	categories := make(map[uint]uint, len(videoIDs))
+	hashtags := make(map[uint][]uint)
+
+	for start := 0; start < len(videoIDs); start += similarityBatchSize {
+		end := start + similarityBatchSize
+		if end > len(videoIDs) {
+			end = len(videoIDs)
+		}
+		batch := videoIDs[start:end]
+
+		var videos []struct {
+			ID         uint
+			CategoryID *uint
+		}
+		if err := b.db.WithContext(ctx).Model(&model.Video{}).
+			Select("id, category_id").
+			Where("id IN ? AND status = ?", batch, 1).
+			Scan(&videos).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, v := range videos {
+			categories[v.ID] = 0
+			if v.CategoryID != nil {
+				categories[v.ID] = *v.CategoryID
+			}
+		}
+
+		var links []model.VideoHashtag
+		if err := b.db.WithContext(ctx).
+			Select("video_id, hashtag_id").
+			Where("video_id IN ?", batch).
+			Find(&links).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, link := range links {
+			hashtags[link.VideoID] = append(hashtags[link.VideoID], link.HashtagID)
+		}
+	}
+
+	return categories, hashtags, nil
+}
+
+// save 每个视频保留 TopK 个相似视频，覆盖写入 Redis
+// 过期时间为两个计算周期，计算任务停止后数据自动失效，相关视频退回到同分类热门
+func (b *SimilarityBuilder) save(ctx context.Context, neighbours map[uint][]neighbour) error {
+	topK := b.config.TopK
+	if topK <= 0 {
+		topK = 50
+	}
+	ttl := 2 * time.Duration(b.config.Interval) * time.Second
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	pipe := b.redis.Pipeline()
+	pending := 0
+	for videoID, list := range neighbours {
+		sort.Slice(list, func(i, j int) bool { return list[i].Score > list[j].Score })
+		if len(list) > topK {
+			list = list[:topK]
+		}
+
+		members := make([]redis.Z, 0, len(list))
+		for _, n := range list {
+			members = append(members, redis.Z{Score: n.Score, Member: strconv.FormatUint(uint64(n.VideoID), 10)})
+		}
+
+		key := relatedKey(videoID)
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, ttl)
+
+		pending++
+		if pending >= similarityBatchSize {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+			pipe = b.redis.Pipeline()
+			pending = 0
+		}
+	}
+	if pending > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jaccard 两个话题集合的 Jaccard 相似度
+func jaccard(a, b []uint) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[uint]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	intersection := 0
+	union := len(set)
+	for _, id := range b {
+		if set[id] {
+			intersection++
+			set[id] = false
+		} else if _, seen := set[id]; !seen {
+			union++
+		}
+	}
+	return float64(intersection) / float64(union)
+}
+
+// loadRelated 读取视频的相似视频（按相似度降序，只返回已发布的视频）
+func loadRelated(ctx context.Context, db *gorm.DB, rdb *redis.Client, videoID uint, limit int) ([]*model.Video, error) {
+	if rdb == nil {
+		return nil, nil
+	}
+
+	members, err := rdb.ZRevRange(ctx, relatedKey(videoID), 0, int64(limit-1)).Result()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		var id uint
+		if _, err := fmt.Sscanf(m, "%d", &id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	var videos []*model.Video
+	if err := db.WithContext(ctx).Preload("User").
+		Where("id IN ? AND status = ?", ids, 1).
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+
+	// 按相似度顺序返回
+	byID := make(map[uint]*model.Video, len(videos))
+	for _, v := range videos {
+		byID[v.ID] = v
+	}
+	ordered := make([]*model.Video, 0, len(videos))
+	for _, id := range ids {
+		if v, ok := byID[id]; ok {
+			ordered = append(ordered, v)
+		}
+	}
+	return ordered, nil
+}
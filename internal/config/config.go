@@ -20,6 +20,14 @@ type Config struct {
 	OAuth     OAuthConfig
 	CORS      CORSConfig
 	RateLimit RateLimitConfig
+	Wallet    WalletConfig
+	Live      LiveConfig
+	Commerce  CommerceConfig
+	Cluster   ClusterConfig
+
+	VideoProcessing VideoProcessingConfig `mapstructure:"video_processing"`
+	Trending        TrendingConfig        `mapstructure:"trending"`
+	Recommend       RecommendConfig       `mapstructure:"recommend"`
 }
 
 // ServerConfig 服务器配置
@@ -81,6 +89,12 @@ type StreamingConfig struct {
 	DefaultAudioBitrate int    `mapstructure:"default_audio_bitrate"` // 默认音频码率 (kbps)
 	DefaultFrameRate    int    `mapstructure:"default_frame_rate"`    // 默认帧率
 	DefaultResolution   string `mapstructure:"default_resolution"`    // 默认分辨率
+
+	// 流媒体服务器回调与推流鉴权
+	WebhookSecret     string   `mapstructure:"webhook_secret"`      // 回调与推流签名共享密钥（为空时不注册回调路由并拒绝所有推流）
+	WebhookAllowedIPs []string `mapstructure:"webhook_allowed_ips"` // 允许发起回调的流媒体服务器地址（IP 或 CIDR，为空时不限制）
+	StreamKeyTTL      int      `mapstructure:"stream_key_ttl"`      // 推流地址签名有效期（小时）
+	RecordDir         string   `mapstructure:"record_dir"`          // 流媒体服务器录制目录（录制完成回调只接受该目录下的文件，为空时拒绝所有录制回调）
 }
 
 // SFUConfig SFU 服务器配置
@@ -161,6 +175,123 @@ type RateLimitConfig struct {
 	Burst             int  `mapstructure:"burst"`
 }
 
+// WalletConfig 钱包配置
+type WalletConfig struct {
+	PlatformRate float64 `mapstructure:"platform_rate"` // 平台抽成比例（0-1），礼物收益的剩余部分归主播
+}
+
+// LiveConfig 直播间配置
+type LiveConfig struct {
+	CommentReplaySize int            `mapstructure:"comment_replay_size"` // 新观众进房时回放的最近弹幕条数
+	RankTopN          int            `mapstructure:"rank_top_n"`          // 礼物榜前 N 名发生变化时推送到直播间
+	FansClub          FansClubConfig `mapstructure:"fans_club"`           // 粉丝团经验和等级衰减
+}
+
+// FansClubConfig 粉丝团经验规则配置
+// 观看、弹幕、点赞、送礼按规则为粉丝团成员增加经验，每类经验按自然日封顶（上限为 0 时不限）
+type FansClubConfig struct {
+	WatchExpPerMinute int   `mapstructure:"watch_exp_per_minute"` // 每观看一分钟增加的经验
+	WatchDailyCap     int64 `mapstructure:"watch_daily_cap"`      // 每日观看经验上限
+	CommentExp        int   `mapstructure:"comment_exp"`          // 每条弹幕增加的经验
+	CommentDailyCap   int64 `mapstructure:"comment_daily_cap"`    // 每日弹幕经验上限
+	LikeExp           int   `mapstructure:"like_exp"`             // 每次点赞增加的经验
+	LikeDailyCap      int64 `mapstructure:"like_daily_cap"`       // 每日点赞经验上限
+	GiftExpPerCoin    int   `mapstructure:"gift_exp_per_coin"`    // 礼物每 1 金币价值增加的经验
+	GiftDailyCap      int64 `mapstructure:"gift_daily_cap"`       // 每日送礼经验上限
+
+	DecayInterval     int   `mapstructure:"decay_interval"`      // 等级衰减任务的执行间隔（秒，为 0 时不运行）
+	DecayInactiveDays int   `mapstructure:"decay_inactive_days"` // 连续多少天未获得经验视为不活跃
+	DecayExp          int64 `mapstructure:"decay_exp"`           // 不活跃成员每次衰减扣除的经验（经验不足时降级）
+}
+
+// CommerceConfig 直播带货订单配置
+type CommerceConfig struct {
+	PaymentProvider string `mapstructure:"payment_provider"` // 支付渠道：fake（模拟渠道，仅用于开发和测试）
+	PaymentSecret   string `mapstructure:"payment_secret"`   // 支付回调签名密钥（为空时拒绝所有支付回调）
+	OrderTimeout    int    `mapstructure:"order_timeout"`    // 待支付订单的支付时限（秒），超时后关闭订单并释放库存
+	ExpireInterval  int    `mapstructure:"expire_interval"`  // 扫描超时订单的间隔（秒，为 0 时不运行）
+}
+
+// ClusterConfig 多实例部署配置
+type ClusterConfig struct {
+	Broker string `mapstructure:"broker"` // 实例间消息代理：redis（多实例共享直播间广播、私信推送和在线状态）或 memory（单实例）
+}
+
+// VideoProcessingConfig 视频处理任务队列配置
+type VideoProcessingConfig struct {
+	Workers      int `mapstructure:"workers"`       // 本实例处理任务的 worker 数（为 0 时本实例不处理任务）
+	MaxAttempts  int `mapstructure:"max_attempts"`  // 每个阶段的最大尝试次数
+	PollInterval int `mapstructure:"poll_interval"` // 队列为空时的轮询间隔（秒）
+	LeaseTimeout int `mapstructure:"lease_timeout"` // 任务租约超时（秒），超时未续约的任务视为 worker 已崩溃并由其他 worker 接管
+
+	SegmentDuration int               `mapstructure:"segment_duration"` // HLS 切片时长（秒）
+	FMP4            bool              `mapstructure:"fmp4"`             // 使用 fMP4 切片（默认 MPEG-TS）
+	Renditions      []RenditionConfig `mapstructure:"renditions"`       // 码率阶梯（为空时使用 360p/540p/720p/1080p 默认阶梯）
+}
+
+// RenditionConfig HLS 码率档位配置
+type RenditionConfig struct {
+	Name         string `mapstructure:"name"`          // 档位名称，如 720p
+	Height       int    `mapstructure:"height"`        // 短边像素数（竖屏视频为宽度），超过源视频的档位不输出
+	VideoBitrate int    `mapstructure:"video_bitrate"` // 视频码率（kbps）
+	AudioBitrate int    `mapstructure:"audio_bitrate"` // 音频码率（kbps）
+}
+
+// TrendingConfig 热搜和热门话题的趋势计算配置
+type TrendingConfig struct {
+	Interval     int     `mapstructure:"interval"`      // 重新计算热度和排名的间隔（秒，为 0 时不运行）
+	BucketSize   int     `mapstructure:"bucket_size"`   // 滑动窗口的分桶大小（秒）
+	Window       int     `mapstructure:"window"`        // 滑动窗口长度（秒），超出窗口的计数不再参与热度
+	HalfLife     int     `mapstructure:"half_life"`     // 热度衰减半衰期（秒）
+	RecentWindow int     `mapstructure:"recent_window"` // 计算上升速度的近期窗口（秒），与窗口内更早的时段对比
+	RisingRatio  float64 `mapstructure:"rising_ratio"`  // 近期速率达到之前速率的多少倍时标记为上升
+	MinCount     int64   `mapstructure:"min_count"`     // 标记新上榜或上升所需的近期最少次数
+	TopN         int     `mapstructure:"top_n"`         // 热搜榜和热门话题的名额
+}
+
+// RecommendConfig 推荐引擎配置
+type RecommendConfig struct {
+	Scenes     map[string]RecommendSceneConfig `mapstructure:"scenes"`     // 按场景（feed、follow、friends 等）配置召回和排序策略，未配置的场景使用 default 场景
+	Similarity SimilarityConfig                `mapstructure:"similarity"` // 视频相似度离线计算
+	Interest   InterestConfig                  `mapstructure:"interest"`   // 用户兴趣实时更新
+}
+
+// InterestConfig 用户兴趣实时更新配置
+type InterestConfig struct {
+	HalfLife      int `mapstructure:"half_life"`      // 兴趣分数衰减半衰期（小时）
+	SkipSeconds   int `mapstructure:"skip_seconds"`   // 观看时长不足多少秒视为快速划走（负向兴趣）
+	FlushInterval int `mapstructure:"flush_interval"` // 兴趣写回 user_interests 表的间隔（秒，为 0 时不写回）
+}
+
+// SimilarityConfig 视频相似度（相关视频）离线计算配置
+type SimilarityConfig struct {
+	Interval       int     `mapstructure:"interval"`        // 重新计算的间隔（秒，为 0 时不运行）
+	Window         int     `mapstructure:"window"`          // 参与计算的用户行为时间窗口（天）
+	TopK           int     `mapstructure:"top_k"`           // 每个视频保存的相似视频数
+	MaxUserVideos  int     `mapstructure:"max_user_videos"` // 每个用户参与计算的最近视频数（控制计算量）
+	CategoryWeight float64 `mapstructure:"category_weight"` // 同分类的加分
+	HashtagWeight  float64 `mapstructure:"hashtag_weight"`  // 话题重合度（Jaccard）的权重
+}
+
+// RecommendSceneConfig 单个场景的推荐策略（未配置的部分使用内置策略）
+type RecommendSceneConfig struct {
+	Recall  []RecallChannelConfig `mapstructure:"recall"`  // 启用的召回通道及配额
+	Fill    *bool                 `mapstructure:"fill"`    // 召回不足时是否用随机热门视频补足
+	Scorers []ScorerConfig        `mapstructure:"scorers"` // 参与排序的打分因子及权重
+}
+
+// RecallChannelConfig 召回通道配置
+type RecallChannelConfig struct {
+	Name  string  `mapstructure:"name"`  // 通道名称，如 collaborative、category、hashtag、local
+	Quota float64 `mapstructure:"quota"` // 配额，占召回总量的比例（0-1）
+}
+
+// ScorerConfig 排序打分因子配置
+type ScorerConfig struct {
+	Name   string  `mapstructure:"name"`   // 打分因子名称，如 ctr、finish、engagement、hot、freshness
+	Weight float64 `mapstructure:"weight"` // 融合权重
+}
+
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -195,6 +326,55 @@ func Load() (*Config, error) {
 	viper.SetDefault("ratelimit.requests_per_second", 100)
 	viper.SetDefault("ratelimit.burst", 200)
 
+	viper.SetDefault("wallet.platform_rate", 0.3)
+
+	viper.SetDefault("live.comment_replay_size", 50)
+	viper.SetDefault("live.rank_top_n", 10)
+	viper.SetDefault("live.fans_club.watch_exp_per_minute", 1)
+	viper.SetDefault("live.fans_club.watch_daily_cap", 60)
+	viper.SetDefault("live.fans_club.comment_exp", 2)
+	viper.SetDefault("live.fans_club.comment_daily_cap", 40)
+	viper.SetDefault("live.fans_club.like_exp", 1)
+	viper.SetDefault("live.fans_club.like_daily_cap", 20)
+	viper.SetDefault("live.fans_club.gift_exp_per_coin", 1)
+	viper.SetDefault("live.fans_club.gift_daily_cap", 0)
+	viper.SetDefault("live.fans_club.decay_interval", 86400)
+	viper.SetDefault("live.fans_club.decay_inactive_days", 7)
+	viper.SetDefault("live.fans_club.decay_exp", 100)
+
+	viper.SetDefault("commerce.payment_provider", "fake")
+	viper.SetDefault("commerce.payment_secret", "")
+	viper.SetDefault("commerce.order_timeout", 900)
+	viper.SetDefault("commerce.expire_interval", 30)
+
+	viper.SetDefault("cluster.broker", "redis")
+
+	viper.SetDefault("video_processing.workers", 2)
+	viper.SetDefault("video_processing.max_attempts", 3)
+	viper.SetDefault("video_processing.poll_interval", 2)
+	viper.SetDefault("video_processing.lease_timeout", 120)
+	viper.SetDefault("video_processing.segment_duration", 4)
+	viper.SetDefault("video_processing.fmp4", false)
+
+	viper.SetDefault("trending.interval", 60)
+	viper.SetDefault("trending.bucket_size", 300)
+	viper.SetDefault("trending.window", 86400)
+	viper.SetDefault("trending.half_life", 21600)
+	viper.SetDefault("trending.recent_window", 3600)
+	viper.SetDefault("trending.rising_ratio", 3.0)
+	viper.SetDefault("trending.min_count", 10)
+	viper.SetDefault("trending.top_n", 50)
+
+	viper.SetDefault("recommend.similarity.interval", 3600)
+	viper.SetDefault("recommend.similarity.window", 30)
+	viper.SetDefault("recommend.similarity.top_k", 50)
+	viper.SetDefault("recommend.similarity.max_user_videos", 50)
+	viper.SetDefault("recommend.similarity.category_weight", 0.1)
+	viper.SetDefault("recommend.similarity.hashtag_weight", 0.2)
+	viper.SetDefault("recommend.interest.half_life", 72)
+	viper.SetDefault("recommend.interest.skip_seconds", 3)
+	viper.SetDefault("recommend.interest.flush_interval", 60)
+
 	viper.SetDefault("upload.maxsize", 104857600) // 100MB
 	viper.SetDefault("upload.allowedtypes", []string{"video/mp4", "video/avi", "image/jpeg", "image/png"})
 	viper.SetDefault("upload.path", "./uploads")
@@ -213,6 +393,10 @@ func Load() (*Config, error) {
 	viper.SetDefault("streaming.default_audio_bitrate", 128)
 	viper.SetDefault("streaming.default_frame_rate", 30)
 	viper.SetDefault("streaming.default_resolution", "720p")
+	viper.SetDefault("streaming.webhook_secret", "")
+	viper.SetDefault("streaming.webhook_allowed_ips", []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"})
+	viper.SetDefault("streaming.stream_key_ttl", 24)
+	viper.SetDefault("streaming.record_dir", "/tmp/recordings")
 
 	// SFU 服务器默认配置
 	viper.SetDefault("sfu.enabled", true)
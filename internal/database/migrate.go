@@ -24,6 +24,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.Hashtag{},
 		&model.VideoHashtag{},
 		&model.VideoStats{},
+		&model.VideoProcessJob{},
 
 		// 社交相关
 		&model.Comment{},
@@ -36,12 +37,17 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.UserVisitor{},
 		&model.CommentMention{},
 		&model.Blacklist{},
+		&model.UserMute{},
 		&model.Report{},
 
 		// 消息相关
 		&model.Message{},
 		&model.Conversation{},
+		&model.ConversationMember{},
 		&model.Notification{},
+		&model.MessageSyncEvent{},
+		&model.MessageSequence{},
+		&model.MessageSyncCursor{},
 
 		// 直播相关（抖音风格完整功能）
 		&model.LiveStream{},     // 直播间主表
@@ -50,17 +56,29 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.LiveGiftRecord{}, // 礼物记录
 		&model.LiveComment{},    // 弹幕评论
 		&model.LiveProduct{},    // 直播商品
+		&model.LiveOrder{},      // 直播带货订单
 		&model.LiveAdmin{},      // 直播管理员
 		&model.LiveBan{},        // 禁言记录
 		&model.LiveShare{},      // 分享记录
 		&model.LiveRankList{},   // 打赏榜
 		&model.LiveFansClub{},   // 粉丝团
 
+		// 钱包相关
+		&model.Wallet{},            // 用户钱包
+		&model.WalletTransaction{}, // 钱包交易
+		&model.WalletLedgerEntry{}, // 记账分录
+
 		// 行为相关
 		&model.UserBehavior{},
 		&model.SearchHistory{},
 		&model.HotSearch{},
 		&model.VideoHistory{},
+
+		// 实验相关
+		&model.Experiment{},             // A/B 实验
+		&model.ExperimentVariant{},      // 实验变体
+		&model.UserBehaviorExperiment{}, // 行为实验标记
+		&model.ExperimentDailyStats{},   // 实验曝光统计
 	)
 
 	if err != nil {
@@ -71,6 +89,9 @@ func AutoMigrate(db *gorm.DB) error {
 	// 创建索引
 	createIndexes(db)
 
+	// 创建全文检索列和索引
+	createSearchVectors(db)
+
 	log.Println("数据库迁移完成")
 	return nil
 }
@@ -104,6 +125,15 @@ func createIndexes(db *gorm.DB) {
 	// conversations 表的组合唯一索引
 	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_user1_user2 ON conversations(user1_id, user2_id)")
 
+	// message_sync_events 表的组合唯一索引（用户内序号唯一，同时用于按序号增量拉取）
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_msg_sync_user_seq ON message_sync_events(user_id, seq)")
+
+	// message_sync_cursors 表的组合唯一索引（每台设备一个游标）
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_msg_sync_cursor_device ON message_sync_cursors(user_id, device_id)")
+
+	// conversation_members 表的组合唯一索引（同一用户在群内只有一条成员记录）
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_conversation_member_user ON conversation_members(conversation_id, user_id)")
+
 	// ========== 行为相关索引 ==========
 	// user_behaviors 表的性能索引
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_user_action_time ON user_behaviors(user_id, action, created_at)")
@@ -121,6 +151,16 @@ func createIndexes(db *gorm.DB) {
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_livestream_stream_type ON live_streams(stream_type)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_livestream_resolution ON live_streams(resolution)")
 
+	// live_admins 表的组合唯一索引（同一用户在直播间只有一条管理员记录）
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_live_admin_user ON live_admins(live_id, user_id)")
+
+	// live_orders 表的幂等唯一索引（同一用户的请求ID只能使用一次）
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_live_order_request ON live_orders(user_id, request_id)")
+
+	// ========== 钱包相关索引 ==========
+	// wallet_transactions 表的幂等唯一索引（同一用户的请求ID只能使用一次）
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_txn_request ON wallet_transactions(user_id, request_id)")
+
 	log.Println("索引创建完成")
 }
 
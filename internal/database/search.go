@@ -0,0 +1,95 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"microvibe-go/pkg/fulltext"
+
+	"gorm.io/gorm"
+)
+
+// searchVectorField 参与全文检索的字段及其权重（A 最高，D 最低）
+type searchVectorField struct {
+	Column string
+	Weight string
+}
+
+// searchVectorTables 需要维护 search_vector 列的表
+var searchVectorTables = []struct {
+	Table  string
+	Fields []searchVectorField
+}{
+	{
+		Table: "videos",
+		Fields: []searchVectorField{
+			{Column: "title", Weight: "A"},
+			{Column: "tags", Weight: "B"},
+			{Column: "description", Weight: "C"},
+		},
+	},
+	{
+		Table: "users",
+		Fields: []searchVectorField{
+			{Column: "username", Weight: "A"},
+			{Column: "nickname", Weight: "A"},
+			{Column: "bio", Weight: "C"},
+		},
+	},
+	{
+		Table: "hashtags",
+		Fields: []searchVectorField{
+			{Column: "name", Weight: "A"},
+		},
+	},
+}
+
+// createSearchVectors 创建全文检索列、GIN 索引和维护触发器
+// search_vector 由触发器在插入或更新相关字段时重新计算，业务代码无需关心
+func createSearchVectors(db *gorm.DB) {
+	log.Println("创建全文检索索引...")
+
+	// 汉字单字切分（与 pkg/fulltext 的查询切分规则保持一致）
+	if err := db.Exec(`
+		CREATE OR REPLACE FUNCTION mv_search_segment(t text) RETURNS text AS $$
+			SELECT regexp_replace(lower(coalesce(t, '')), '([\u3400-\u9fff\uf900-\ufaff])', ' \1 ', 'g')
+		$$ LANGUAGE SQL IMMUTABLE`).Error; err != nil {
+		log.Printf("创建分词函数失败: %v", err)
+		return
+	}
+
+	for _, t := range searchVectorTables {
+		parts := make([]string, 0, len(t.Fields))
+		columns := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			parts = append(parts, fmt.Sprintf("setweight(to_tsvector('%s', mv_search_segment(NEW.%s)), '%s')", fulltext.Config, f.Column, f.Weight))
+			columns = append(columns, f.Column)
+		}
+
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector", t.Table),
+			fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s_search_vector_update() RETURNS trigger AS $$
+				BEGIN
+					NEW.search_vector := %[2]s;
+					RETURN NEW;
+				END
+				$$ LANGUAGE plpgsql`, t.Table, strings.Join(parts, " || ")),
+			fmt.Sprintf("DROP TRIGGER IF EXISTS trg_%s_search_vector ON %s", t.Table, t.Table),
+			fmt.Sprintf("CREATE TRIGGER trg_%[1]s_search_vector BEFORE INSERT OR UPDATE OF %[2]s ON %[1]s FOR EACH ROW EXECUTE FUNCTION %[1]s_search_vector_update()",
+				t.Table, strings.Join(columns, ", ")),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_search_vector ON %s USING GIN(search_vector)", t.Table, t.Table),
+			// 回填历史数据（更新字段为自身以触发触发器）
+			fmt.Sprintf("UPDATE %s SET %s = %s WHERE search_vector IS NULL", t.Table, columns[0], columns[0]),
+		}
+
+		for _, stmt := range statements {
+			if err := db.Exec(stmt).Error; err != nil {
+				log.Printf("创建 %s 全文检索失败: %v", t.Table, err)
+				break
+			}
+		}
+	}
+
+	log.Println("全文检索索引创建完成")
+}
@@ -115,7 +115,7 @@ func (h *AdminHandler) DeleteHotSearch(c *gin.Context) {
 	response.Success(c, nil)
 }
 
-// UpdateHotSearchWeight 调整热搜词热度
+// UpdateHotSearchWeight 调整热搜词的运营加权（叠加在按时间衰减的热度上）
 func (h *AdminHandler) UpdateHotSearchWeight(c *gin.Context) {
 	var req struct {
 		Keyword string `json:"keyword" binding:"required"`
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExperimentHandler A/B 实验管理处理器
+type ExperimentHandler struct {
+	experimentService service.ExperimentService
+}
+
+// NewExperimentHandler 创建 A/B 实验管理处理器实例
+func NewExperimentHandler(experimentService service.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{experimentService: experimentService}
+}
+
+// CreateExperiment 创建实验
+func (h *ExperimentHandler) CreateExperiment(c *gin.Context) {
+	var req service.CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, err.Error())
+		return
+	}
+
+	experiment, err := h.experimentService.CreateExperiment(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.Success(c, experiment)
+}
+
+// ListExperiments 实验列表
+func (h *ExperimentHandler) ListExperiments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	status, _ := strconv.Atoi(c.DefaultQuery("status", "-1"))
+
+	experiments, total, err := h.experimentService.ListExperiments(c.Request.Context(), int8(status), page, pageSize)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.PageSuccess(c, experiments, total, page, pageSize)
+}
+
+// UpdateExperimentStatus 启动或停止实验
+func (h *ExperimentHandler) UpdateExperimentStatus(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	var req struct {
+		Status int8 `json:"status" binding:"required"` // 1:启动, 2:停止
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, err.Error())
+		return
+	}
+	if err := h.experimentService.UpdateExperimentStatus(c.Request.Context(), uint(id), req.Status); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// GetExperimentReport 实验报告（各变体的点击率、完播率和点赞率）
+// from、to 为日期或 RFC3339 时间，默认最近 7 天
+func (h *ExperimentHandler) GetExperimentReport(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -7)
+	if v := c.Query("from"); v != "" {
+		t, err := parseSearchTime(v, false)
+		if err != nil {
+			response.InvalidParam(c, "from 格式错误")
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := parseSearchTime(v, true)
+		if err != nil {
+			response.InvalidParam(c, "to 格式错误")
+			return
+		}
+		to = t
+	}
+
+	report, err := h.experimentService.GetExperimentReport(c.Request.Context(), uint(id), from, to)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.Success(c, report)
+}
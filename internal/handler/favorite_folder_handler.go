@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FavoriteFolderHandler 收藏夹处理器
+type FavoriteFolderHandler struct {
+	folderService service.FavoriteFolderService
+	videoService  service.VideoService
+}
+
+// NewFavoriteFolderHandler 创建收藏夹处理器
+func NewFavoriteFolderHandler(folderService service.FavoriteFolderService, videoService service.VideoService) *FavoriteFolderHandler {
+	return &FavoriteFolderHandler{
+		folderService: folderService,
+		videoService:  videoService,
+	}
+}
+
+// CreateFolder 创建收藏夹
+func (h *FavoriteFolderHandler) CreateFolder(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	var req service.CreateFavoriteFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "创建成功", folder)
+}
+
+// UpdateFolder 更新收藏夹
+func (h *FavoriteFolderHandler) UpdateFolder(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "收藏夹ID格式错误")
+		return
+	}
+
+	var req service.UpdateFavoriteFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	folder, err := h.folderService.UpdateFolder(c.Request.Context(), userID, uint(folderID), &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "更新成功", folder)
+}
+
+// DeleteFolder 删除收藏夹
+func (h *FavoriteFolderHandler) DeleteFolder(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "收藏夹ID格式错误")
+		return
+	}
+
+	if err := h.folderService.DeleteFolder(c.Request.Context(), userID, uint(folderID)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// ListMyFolders 获取当前用户的收藏夹列表
+func (h *FavoriteFolderHandler) ListMyFolders(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	folders, err := h.folderService.ListUserFolders(c.Request.Context(), userID, userID)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, folders)
+}
+
+// ListUserFolders 获取指定用户的收藏夹列表
+func (h *FavoriteFolderHandler) ListUserFolders(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "用户ID格式错误")
+		return
+	}
+
+	currentUserID, _ := middleware.GetUserID(c)
+
+	folders, err := h.folderService.ListUserFolders(c.Request.Context(), uint(userID), currentUserID)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, folders)
+}
+
+// GetFolderVideos 获取收藏夹内的视频列表
+func (h *FavoriteFolderHandler) GetFolderVideos(c *gin.Context) {
+	folderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "收藏夹ID格式错误")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	currentUserID, _ := middleware.GetUserID(c)
+
+	videos, total, err := h.folderService.GetFolderVideos(c.Request.Context(), uint(folderID), currentUserID, page, pageSize)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	// 丰富视频信息
+	enrichedVideos, err := h.videoService.EnrichVideoList(c.Request.Context(), currentUserID, videos)
+	if err != nil {
+		response.ServerError(c, "处理视频信息失败: "+err.Error())
+		return
+	}
+
+	response.PageSuccess(c, enrichedVideos, total, page, pageSize)
+}
+
+// MoveFavorites 在收藏夹之间移动收藏
+func (h *FavoriteFolderHandler) MoveFavorites(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	var req service.MoveFavoritesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	moved, err := h.folderService.MoveFavorites(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "移动成功", gin.H{"moved": moved})
+}
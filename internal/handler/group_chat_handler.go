@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupChatHandler 群聊Handler
+type GroupChatHandler struct {
+	groupService service.GroupChatService
+}
+
+// NewGroupChatHandler 创建群聊Handler
+func NewGroupChatHandler(groupService service.GroupChatService) *GroupChatHandler {
+	return &GroupChatHandler{
+		groupService: groupService,
+	}
+}
+
+// CreateGroup 创建群聊
+// @Summary 创建群聊
+// @Tags 群聊
+// @Param body body service.CreateGroupRequest true "群信息和初始成员"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups [post]
+func (h *GroupChatHandler) CreateGroup(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	var req service.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	conversation, err := h.groupService.CreateGroup(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, conversation)
+}
+
+// GetGroup 获取群信息
+// @Summary 获取群信息
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id} [get]
+func (h *GroupChatHandler) GetGroup(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	conversation, err := h.groupService.GetGroup(c.Request.Context(), userID, conversationID)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, conversation)
+}
+
+// UpdateGroup 更新群信息
+// @Summary 更新群名称、头像和公告
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Param body body service.UpdateGroupRequest true "群信息"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id} [put]
+func (h *GroupChatHandler) UpdateGroup(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	conversation, err := h.groupService.UpdateGroup(c.Request.Context(), userID, conversationID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "更新成功", conversation)
+}
+
+// ListMembers 获取群成员列表
+// @Summary 获取群成员列表
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id}/members [get]
+func (h *GroupChatHandler) ListMembers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	members, total, err := h.groupService.ListMembers(c.Request.Context(), userID, conversationID, page, pageSize)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.PageSuccess(c, members, total, page, pageSize)
+}
+
+// InviteMembers 邀请成员入群
+// @Summary 邀请成员入群
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id}/members [post]
+func (h *GroupChatHandler) InviteMembers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	added, err := h.groupService.InviteMembers(c.Request.Context(), userID, conversationID, req.UserIDs)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "邀请成功", gin.H{"user_ids": added})
+}
+
+// RemoveMember 移除群成员
+// @Summary 移除群成员
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Param user_id path int true "成员用户ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id}/members/{user_id} [delete]
+func (h *GroupChatHandler) RemoveMember(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.groupService.RemoveMember(c.Request.Context(), userID, conversationID, uint(targetID)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "移除成功", nil)
+}
+
+// SetMemberRole 设置成员角色
+// @Summary 设置/取消管理员
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Param user_id path int true "成员用户ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id}/members/{user_id}/role [put]
+func (h *GroupChatHandler) SetMemberRole(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的用户ID")
+		return
+	}
+
+	var req struct {
+		Role int8 `json:"role" binding:"required,oneof=1 2"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.groupService.SetMemberRole(c.Request.Context(), userID, conversationID, uint(targetID), req.Role); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "设置成功", nil)
+}
+
+// LeaveGroup 退出群聊
+// @Summary 退出群聊
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id}/leave [post]
+func (h *GroupChatHandler) LeaveGroup(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.LeaveGroup(c.Request.Context(), userID, conversationID); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "已退出群聊", nil)
+}
+
+// TransferOwner 转让群主
+// @Summary 转让群主
+// @Tags 群聊
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/messages/groups/{id}/transfer [post]
+func (h *GroupChatHandler) TransferOwner(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	conversationID, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.groupService.TransferOwner(c.Request.Context(), userID, conversationID, req.UserID); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "转让成功", nil)
+}
+
+// parseGroupID 解析路径中的群会话ID
+func parseGroupID(c *gin.Context) (uint, bool) {
+	conversationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的会话ID")
+		return 0, false
+	}
+	return uint(conversationID), true
+}
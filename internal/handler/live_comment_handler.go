@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LiveCommentHandler 直播弹幕Handler
+type LiveCommentHandler struct {
+	commentService service.LiveCommentService
+}
+
+// NewLiveCommentHandler 创建弹幕Handler
+func NewLiveCommentHandler(commentService service.LiveCommentService) *LiveCommentHandler {
+	return &LiveCommentHandler{
+		commentService: commentService,
+	}
+}
+
+// ListComments 获取直播间弹幕历史
+// @Summary 获取直播间弹幕历史
+// @Tags 直播弹幕
+// @Param id path int true "直播间ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/{id}/comments [get]
+func (h *LiveCommentHandler) ListComments(c *gin.Context) {
+	liveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的直播间ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	comments, total, err := h.commentService.ListComments(c.Request.Context(), uint(liveID), page, pageSize)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.PageSuccess(c, comments, total, page, pageSize)
+}
+
+// PinComment 精选弹幕
+// @Summary 精选弹幕
+// @Tags 直播弹幕
+// @Param id path int true "直播间ID"
+// @Param comment_id path int true "弹幕ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/{id}/comments/{comment_id}/pin [post]
+func (h *LiveCommentHandler) PinComment(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+// UnpinComment 取消精选弹幕
+// @Summary 取消精选弹幕
+// @Tags 直播弹幕
+// @Param id path int true "直播间ID"
+// @Param comment_id path int true "弹幕ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/{id}/comments/{comment_id}/pin [delete]
+func (h *LiveCommentHandler) UnpinComment(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+// setPinned 设置弹幕精选状态
+func (h *LiveCommentHandler) setPinned(c *gin.Context, pinned bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	liveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的直播间ID")
+		return
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的弹幕ID")
+		return
+	}
+
+	comment, err := h.commentService.PinComment(c.Request.Context(), userID, uint(liveID), uint(commentID), pinned)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "操作成功", comment)
+}
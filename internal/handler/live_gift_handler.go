@@ -128,12 +128,12 @@ func (h *LiveGiftHandler) ListGiftRecords(c *gin.Context) {
 	response.PageSuccess(c, records, total, pageInt, pageSizeInt)
 }
 
-// GetTopGivers 获取送礼榜单
-// @Summary 获取送礼榜单
+// GetTopGivers 获取直播间本场送礼榜单
+// @Summary 获取直播间本场送礼榜单
 // @Tags 直播礼物
 // @Param live_id query int true "直播间ID"
 // @Param limit query int false "数量限制"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=[]service.GiftRankEntry}
 // @Router /api/v1/live/gifts/top [get]
 func (h *LiveGiftHandler) GetTopGivers(c *gin.Context) {
 	liveIDStr := c.Query("live_id")
@@ -156,6 +156,32 @@ func (h *LiveGiftHandler) GetTopGivers(c *gin.Context) {
 	response.Success(c, records)
 }
 
+// GetStreamerRank 获取主播送礼榜单
+// @Summary 获取主播的日榜、周榜或总榜
+// @Tags 直播礼物
+// @Param user_id query int true "主播用户ID"
+// @Param period query string false "榜单周期：day, week, all（默认 day）"
+// @Param limit query int false "数量限制"
+// @Success 200 {object} response.Response{data=[]service.GiftRankEntry}
+// @Router /api/v1/live/gifts/rank [get]
+func (h *LiveGiftHandler) GetStreamerRank(c *gin.Context) {
+	streamerID, err := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的主播ID")
+		return
+	}
+	period := c.DefaultQuery("period", service.RankPeriodDay)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	entries, err := h.giftService.GetStreamerRank(c.Request.Context(), uint(streamerID), period, limit)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, entries)
+}
+
 // GetUserGiftStats 获取用户送礼统计
 // @Summary 获取用户送礼统计
 // @Tags 直播礼物
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/payment"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LiveOrderHandler 直播订单Handler
+type LiveOrderHandler struct {
+	orderService service.LiveOrderService
+	provider     payment.Provider
+}
+
+// NewLiveOrderHandler 创建订单Handler
+func NewLiveOrderHandler(orderService service.LiveOrderService, provider payment.Provider) *LiveOrderHandler {
+	return &LiveOrderHandler{
+		orderService: orderService,
+		provider:     provider,
+	}
+}
+
+// CreateOrder 下单
+// @Summary 购买直播间商品
+// @Tags 直播订单
+// @Accept json
+// @Param request body service.CreateLiveOrderRequest true "下单请求"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders [post]
+func (h *LiveOrderHandler) CreateOrder(c *gin.Context) {
+	// 获取当前登录用户ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	var req service.CreateLiveOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	order, err := h.orderService.CreateOrder(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "下单成功", order)
+}
+
+// ListMyOrders 获取我的订单
+// @Summary 获取我的直播订单列表
+// @Tags 直播订单
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders [get]
+func (h *LiveOrderHandler) ListMyOrders(c *gin.Context) {
+	// 获取当前登录用户ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	pageSize := c.DefaultQuery("page_size", "20")
+
+	pageInt, _ := strconv.Atoi(page)
+	pageSizeInt, _ := strconv.Atoi(pageSize)
+
+	orders, total, err := h.orderService.ListMyOrders(c.Request.Context(), userID, pageInt, pageSizeInt)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.PageSuccess(c, orders, total, pageInt, pageSizeInt)
+}
+
+// GetOrder 获取订单详情
+// @Summary 获取直播订单详情（买家或主播）
+// @Tags 直播订单
+// @Param id path int true "订单ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders/:id [get]
+func (h *LiveOrderHandler) GetOrder(c *gin.Context) {
+	// 获取当前登录用户ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的订单ID")
+		return
+	}
+
+	order, err := h.orderService.GetOrder(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, order)
+}
+
+// CancelOrder 取消订单
+// @Summary 取消待支付的直播订单
+// @Tags 直播订单
+// @Param id path int true "订单ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders/:id/cancel [post]
+func (h *LiveOrderHandler) CancelOrder(c *gin.Context) {
+	// 获取当前登录用户ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的订单ID")
+		return
+	}
+
+	if err := h.orderService.CancelOrder(c.Request.Context(), userID, uint(id)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "取消订单成功", nil)
+}
+
+// RefundOrder 订单退款
+// @Summary 主播对已支付的直播订单原路退款（冲回销量、销售额和佣金）
+// @Tags 直播订单
+// @Param id path int true "订单ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders/:id/refund [post]
+func (h *LiveOrderHandler) RefundOrder(c *gin.Context) {
+	// 获取当前登录用户ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的订单ID")
+		return
+	}
+
+	if err := h.orderService.RefundOrder(c.Request.Context(), userID, uint(id)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "退款成功", nil)
+}
+
+// GetEarnings 获取带货收益
+// @Summary 获取主播带货收益（销售额和佣金）
+// @Tags 直播订单
+// @Param live_id query int false "直播间ID（不传时汇总所有直播间）"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders/earnings [get]
+func (h *LiveOrderHandler) GetEarnings(c *gin.Context) {
+	// 获取当前登录用户ID
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	var liveID uint64
+	if liveIDStr := c.Query("live_id"); liveIDStr != "" {
+		var err error
+		liveID, err = strconv.ParseUint(liveIDStr, 10, 32)
+		if err != nil {
+			response.InvalidParam(c, "无效的直播间ID")
+			return
+		}
+	}
+
+	earnings, err := h.orderService.GetEarnings(c.Request.Context(), userID, uint(liveID))
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, earnings)
+}
+
+// PaymentNotify 支付结果回调
+// @Summary 支付渠道异步回调（由支付渠道调用，校验签名）
+// @Tags 直播订单
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/orders/payment/notify [post]
+func (h *LiveOrderHandler) PaymentNotify(c *gin.Context) {
+	notification, err := h.provider.ParseNotification(c.Request)
+	if err != nil {
+		logger.Warn("支付回调校验失败", zap.Error(err), zap.String("provider", h.provider.Name()))
+		response.Forbidden(c, "支付回调校验失败")
+		return
+	}
+
+	if err := h.orderService.HandlePaymentNotification(c.Request.Context(), notification); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
@@ -484,6 +484,101 @@ func (h *LiveStreamHandler) ListBans(c *gin.Context) {
 	response.PageSuccess(c, bans, total, page, pageSize)
 }
 
+// AddAdmin 任命直播间管理员
+// @Summary 任命直播间管理员
+// @Tags 直播
+// @Accept json
+// @Produce json
+// @Param id path int true "直播间ID"
+// @Param request body service.AddLiveAdminRequest true "任命请求"
+// @Success 200 {object} response.Response{data=model.LiveAdmin}
+// @Router /api/v1/live/{id}/admins [post]
+func (h *LiveStreamHandler) AddAdmin(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	liveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的直播间ID")
+		return
+	}
+
+	var req service.AddLiveAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	admin, err := h.liveService.AddAdmin(c.Request.Context(), userID, uint(liveID), &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "任命成功", admin)
+}
+
+// RemoveAdmin 撤销直播间管理员
+// @Summary 撤销直播间管理员
+// @Tags 直播
+// @Produce json
+// @Param id path int true "直播间ID"
+// @Param user_id path int true "管理员用户ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/{id}/admins/{user_id} [delete]
+func (h *LiveStreamHandler) RemoveAdmin(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	liveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的直播间ID")
+		return
+	}
+
+	adminUserID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的用户ID")
+		return
+	}
+
+	if err := h.liveService.RemoveAdmin(c.Request.Context(), userID, uint(liveID), uint(adminUserID)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "撤销成功", nil)
+}
+
+// ListAdmins 获取直播间管理员列表
+// @Summary 获取直播间管理员列表
+// @Tags 直播
+// @Produce json
+// @Param id path int true "直播间ID"
+// @Success 200 {object} response.Response{data=[]model.LiveAdmin}
+// @Router /api/v1/live/{id}/admins [get]
+func (h *LiveStreamHandler) ListAdmins(c *gin.Context) {
+	liveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.InvalidParam(c, "无效的直播间ID")
+		return
+	}
+
+	admins, err := h.liveService.ListAdmins(c.Request.Context(), uint(liveID))
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, admins)
+}
+
 // GetHotLiveStreams 获取热门直播间
 // @Summary 获取热门直播间
 // @Tags 直播
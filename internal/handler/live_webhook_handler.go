@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.uber.org/zap"
+)
+
+// LiveWebhookHandler 流媒体服务器回调处理器（nginx-rtmp 表单回调、SRS JSON 回调）
+// 返回 2xx 表示允许，其他状态码表示拒绝推流或播放
+type LiveWebhookHandler struct {
+	liveService service.LiveStreamService
+}
+
+// NewLiveWebhookHandler 创建流媒体服务器回调处理器
+func NewLiveWebhookHandler(liveService service.LiveStreamService) *LiveWebhookHandler {
+	return &LiveWebhookHandler{
+		liveService: liveService,
+	}
+}
+
+// mediaCallback 流媒体服务器回调参数
+type mediaCallback struct {
+	App       string // 应用名
+	StreamKey string // 流名称（推流密钥）
+	Expires   int64  // 推流地址签名过期时间
+	Sign      string // 推流地址签名
+	ClientIP  string // 客户端地址
+	Path      string // 录制文件路径（录制完成回调）
+}
+
+// srsCallback SRS HTTP 回调请求体
+type srsCallback struct {
+	Action string `json:"action"`
+	IP     string `json:"ip"`
+	App    string `json:"app"`
+	Stream string `json:"stream"`
+	Param  string `json:"param"` // 推流地址参数，如 ?expires=xxx&sign=xxx
+	Cwd    string `json:"cwd"`
+	File   string `json:"file"` // 录制文件路径（on_dvr）
+}
+
+// bindCallback 解析回调参数，JSON 请求按 SRS 格式解析，其他按 nginx-rtmp 表单解析
+func bindCallback(c *gin.Context) (*mediaCallback, error) {
+	cb := &mediaCallback{}
+	var params url.Values
+
+	if c.ContentType() == binding.MIMEJSON {
+		var req srsCallback
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+		cb.App = req.App
+		cb.StreamKey = req.Stream
+		cb.ClientIP = req.IP
+		cb.Path = req.File
+		if cb.Path != "" && !filepath.IsAbs(cb.Path) && req.Cwd != "" {
+			cb.Path = filepath.Join(req.Cwd, cb.Path)
+		}
+		params, _ = url.ParseQuery(strings.TrimPrefix(req.Param, "?"))
+	} else {
+		if err := c.Request.ParseForm(); err != nil {
+			return nil, err
+		}
+		// nginx-rtmp 会把推流地址中的参数一并放入表单
+		params = c.Request.PostForm
+		cb.App = params.Get("app")
+		cb.StreamKey = params.Get("name")
+		cb.ClientIP = params.Get("addr")
+		cb.Path = params.Get("path")
+	}
+
+	if cb.StreamKey == "" {
+		return nil, errors.New("缺少流名称")
+	}
+	cb.Sign = params.Get("sign")
+	cb.Expires, _ = strconv.ParseInt(params.Get("expires"), 10, 64)
+	return cb, nil
+}
+
+// OnPublish 推流鉴权回调
+// @Summary 推流鉴权回调（on_publish）
+// @Tags 直播回调
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param X-Webhook-Timestamp header int true "回调时间戳（Unix 秒）"
+// @Param X-Webhook-Signature header string true "回调签名"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/live/webhooks/on_publish [post]
+func (h *LiveWebhookHandler) OnPublish(c *gin.Context) {
+	cb, err := bindCallback(c)
+	if err != nil {
+		response.Forbidden(c, "参数错误: "+err.Error())
+		return
+	}
+
+	ok, _, err := h.liveService.VerifyRTMPStream(c.Request.Context(), cb.StreamKey, cb.Expires, cb.Sign)
+	if err != nil {
+		response.ServerError(c, "验证推流失败")
+		return
+	}
+	if !ok {
+		logger.Warn("拒绝推流", zap.String("stream_key", cb.StreamKey), zap.String("client_ip", cb.ClientIP))
+		response.Forbidden(c, "推流鉴权失败")
+		return
+	}
+
+	if err := h.liveService.OnStreamPublish(c.Request.Context(), cb.StreamKey); err != nil {
+		response.ServerError(c, "开始直播失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// OnPublishDone 推流结束回调
+// @Summary 推流结束回调（on_publish_done）
+// @Tags 直播回调
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param X-Webhook-Timestamp header int true "回调时间戳（Unix 秒）"
+// @Param X-Webhook-Signature header string true "回调签名"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/webhooks/on_publish_done [post]
+func (h *LiveWebhookHandler) OnPublishDone(c *gin.Context) {
+	cb, err := bindCallback(c)
+	if err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.liveService.OnStreamUnpublish(c.Request.Context(), cb.StreamKey); err != nil {
+		response.ServerError(c, "结束直播失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// OnPlay 播放鉴权回调
+// @Summary 播放鉴权回调（on_play）
+// @Tags 直播回调
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param X-Webhook-Timestamp header int true "回调时间戳（Unix 秒）"
+// @Param X-Webhook-Signature header string true "回调签名"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/live/webhooks/on_play [post]
+func (h *LiveWebhookHandler) OnPlay(c *gin.Context) {
+	cb, err := bindCallback(c)
+	if err != nil {
+		response.Forbidden(c, "参数错误: "+err.Error())
+		return
+	}
+
+	ok, err := h.liveService.VerifyPlayStream(c.Request.Context(), cb.StreamKey)
+	if err != nil {
+		response.ServerError(c, "验证播放失败")
+		return
+	}
+	if !ok {
+		response.Forbidden(c, "直播未开始")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// OnRecordDone 录制完成回调
+// @Summary 录制完成回调（on_record_done）
+// @Tags 直播回调
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param X-Webhook-Timestamp header int true "回调时间戳（Unix 秒）"
+// @Param X-Webhook-Signature header string true "回调签名"
+// @Success 200 {object} response.Response
+// @Router /api/v1/live/webhooks/on_record_done [post]
+func (h *LiveWebhookHandler) OnRecordDone(c *gin.Context) {
+	cb, err := bindCallback(c)
+	if err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+	if cb.Path == "" {
+		response.InvalidParam(c, "缺少录制文件路径")
+		return
+	}
+
+	if err := h.liveService.OnStreamRecorded(c.Request.Context(), cb.StreamKey, cb.Path); err != nil {
+		response.ServerError(c, "处理录制文件失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
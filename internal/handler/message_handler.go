@@ -6,6 +6,7 @@ import (
 	"microvibe-go/internal/service"
 	"microvibe-go/pkg/response"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -297,25 +298,74 @@ func (h *MessageHandler) GetUnreadNotificationCount(c *gin.Context) {
 	})
 }
 
+// GetPresence 批量查询用户在线状态
+func (h *MessageHandler) GetPresence(c *gin.Context) {
+	raw := strings.Split(c.Query("user_ids"), ",")
+	userIDs := make([]uint, 0, len(raw))
+	for _, item := range raw {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 64)
+		if err != nil {
+			response.InvalidParam(c, "用户ID格式错误")
+			return
+		}
+		userIDs = append(userIDs, uint(id))
+	}
+
+	if len(userIDs) == 0 {
+		response.InvalidParam(c, "user_ids 不能为空")
+		return
+	}
+	if len(userIDs) > 100 {
+		response.InvalidParam(c, "一次最多查询100个用户")
+		return
+	}
+
+	presence, err := h.messageService.GetUserPresence(c.Request.Context(), userIDs)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, presence)
+}
+
 // toConversationVO 将模型转换为VO
 func toConversationVO(c *model.Conversation, currentUserID uint) model.ConversationVO {
 	vo := model.ConversationVO{
 		ID:          c.ID,
+		Type:        c.Type,
+		MemberCount: 2,
 		LastMessage: toMessageVO(c.LastMessage, currentUserID),
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
 	}
 
+	// 群聊展示群信息和当前用户的未读数
+	if c.IsGroup() {
+		vo.Nickname = c.Name
+		vo.Avatar = c.Avatar
+		vo.MemberCount = c.MemberCount
+		for _, member := range c.Members {
+			if member.UserID == currentUserID {
+				vo.UnreadCount = member.UnreadCount
+			}
+		}
+		return vo
+	}
+
 	// 识别对方信息
-	if c.User1ID == currentUserID {
-		vo.UserID = c.User2ID
+	vo.UserID = c.PeerID(currentUserID)
+	if c.User1ID != nil && *c.User1ID == currentUserID {
 		if c.User2 != nil {
 			vo.Nickname = c.User2.Nickname
 			vo.Avatar = c.User2.Avatar
 		}
 		vo.UnreadCount = c.UnreadCount1
 	} else {
-		vo.UserID = c.User1ID
 		if c.User1 != nil {
 			vo.Nickname = c.User1.Nickname
 			vo.Avatar = c.User1.Avatar
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MessageSyncHandler 消息同步处理器
+type MessageSyncHandler struct {
+	syncService service.MessageSyncService
+}
+
+// NewMessageSyncHandler 创建消息同步处理器实例
+func NewMessageSyncHandler(syncService service.MessageSyncService) *MessageSyncHandler {
+	return &MessageSyncHandler{
+		syncService: syncService,
+	}
+}
+
+// Sync 增量同步离线期间的消息、已读回执和删除
+// 查询参数 cursor 为客户端已确认收到的序号（为空时使用当前设备上次确认的游标），limit 为单次返回条数
+func (h *MessageSyncHandler) Sync(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	var cursor *int64
+	if raw := c.Query("cursor"); raw != "" {
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			response.InvalidParam(c, "游标格式错误")
+			return
+		}
+		cursor = &value
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	info := middleware.GetDeviceInfo(c)
+	device := service.SyncDevice{
+		DeviceID: info.DeviceID,
+		Platform: info.Platform,
+	}
+
+	result, err := h.syncService.Sync(c.Request.Context(), userID, device, cursor, limit)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
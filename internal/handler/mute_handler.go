@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MuteHandler 负反馈（不感兴趣、屏蔽）处理器
+type MuteHandler struct {
+	muteService service.MuteService
+}
+
+// NewMuteHandler 创建负反馈处理器实例
+func NewMuteHandler(muteService service.MuteService) *MuteHandler {
+	return &MuteHandler{muteService: muteService}
+}
+
+// MarkNotInterested POST /api/v1/videos/:id/not-interested
+func (h *MuteHandler) MarkNotInterested(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "视频ID格式错误")
+		return
+	}
+
+	if err := h.muteService.MarkNotInterested(c.Request.Context(), userID, uint(videoID)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.SuccessWithMessage(c, "将减少此类内容推荐", nil)
+}
+
+// Mute POST /api/v1/users/mutes
+func (h *MuteHandler) Mute(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Type     int8 `json:"type" binding:"required"`      // 2:作者, 3:话题, 4:分类
+		TargetID uint `json:"target_id" binding:"required"` // 作者、话题或分类ID
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, err.Error())
+		return
+	}
+
+	mute, err := h.muteService.Mute(c.Request.Context(), userID, req.Type, req.TargetID)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.Success(c, mute)
+}
+
+// Unmute DELETE /api/v1/users/mutes/:id
+func (h *MuteHandler) Unmute(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "ID格式错误")
+		return
+	}
+
+	if err := h.muteService.Unmute(c.Request.Context(), userID, uint(id)); err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.SuccessWithMessage(c, "已取消屏蔽", nil)
+}
+
+// ListMutes GET /api/v1/users/mutes
+// type 为 1-不感兴趣的视频、2-作者、3-话题、4-分类，不传时返回所有类型
+func (h *MuteHandler) ListMutes(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	muteType, _ := strconv.Atoi(c.DefaultQuery("type", "0"))
+
+	list, total, err := h.muteService.ListMutes(c.Request.Context(), userID, int8(muteType), page, pageSize)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+	response.PageSuccess(c, list, total, page, pageSize)
+}
@@ -2,9 +2,11 @@ package handler
 
 import (
 	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/model"
 	"microvibe-go/internal/service"
 	"microvibe-go/pkg/response"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -54,6 +56,9 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		PageSize: pageSize,
 		UserID:   userIDPtr,
 	}
+	if !bindSearchFilters(c, req) {
+		return
+	}
 
 	result, err := h.searchService.Search(c.Request.Context(), req)
 	if err != nil {
@@ -64,7 +69,15 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// SearchVideosResult 视频搜索结果（分页数据附带分面统计）
+type SearchVideosResult struct {
+	response.PageData
+	Facets *model.SearchFacets `json:"facets"`
+}
+
 // SearchVideos 搜索视频
+// 筛选参数：category_id, duration(short/medium/long), published_from, published_to, author_id
+// 排序参数：sort(relevance/newest/most_played/most_liked)
 func (h *SearchHandler) SearchVideos(c *gin.Context) {
 	keyword := c.Query("keyword")
 	if keyword == "" {
@@ -85,14 +98,92 @@ func (h *SearchHandler) SearchVideos(c *gin.Context) {
 
 	// 获取用户ID（可选）
 	userID, _ := middleware.GetUserID(c)
+	var userIDPtr *uint
+	if userID > 0 {
+		userIDPtr = &userID
+	}
 
-	videos, total, err := h.searchService.SearchVideos(c.Request.Context(), keyword, userID, page, pageSize)
+	req := &service.SearchRequest{
+		Keyword:  keyword,
+		Category: "video",
+		Page:     page,
+		PageSize: pageSize,
+		UserID:   userIDPtr,
+	}
+	if !bindSearchFilters(c, req) {
+		return
+	}
+
+	result, err := h.searchService.SearchVideos(c.Request.Context(), req)
 	if err != nil {
 		response.Error(c, response.CodeError, err.Error())
 		return
 	}
 
-	response.PageSuccess(c, videos, total, page, pageSize)
+	response.Success(c, SearchVideosResult{
+		PageData: response.PageData{
+			List:     result.Videos,
+			Total:    result.Total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+		Facets: result.Facets,
+	})
+}
+
+// bindSearchFilters 解析视频筛选和排序参数，参数错误时写入响应并返回 false
+func bindSearchFilters(c *gin.Context, req *service.SearchRequest) bool {
+	if v := c.Query("category_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.InvalidParam(c, "无效的分类ID")
+			return false
+		}
+		req.CategoryID = uint(id)
+	}
+
+	if v := c.Query("author_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.InvalidParam(c, "无效的作者ID")
+			return false
+		}
+		req.AuthorID = uint(id)
+	}
+
+	if v := c.Query("published_from"); v != "" {
+		t, err := parseSearchTime(v, false)
+		if err != nil {
+			response.InvalidParam(c, "无效的发布时间: "+v)
+			return false
+		}
+		req.PublishedFrom = &t
+	}
+
+	if v := c.Query("published_to"); v != "" {
+		t, err := parseSearchTime(v, true)
+		if err != nil {
+			response.InvalidParam(c, "无效的发布时间: "+v)
+			return false
+		}
+		req.PublishedTo = &t
+	}
+
+	req.Duration = c.Query("duration")
+	req.Sort = c.Query("sort")
+	return true
+}
+
+// parseSearchTime 解析日期（2006-01-02）或 RFC3339 时间
+// 作为上限时只给出日期表示包含当天，返回次日零点
+func parseSearchTime(value string, upper bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if upper {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
 
 // SearchUsers 搜索用户
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"microvibe-go/internal/algorithm/recommend"
 	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/model"
 	"microvibe-go/internal/service"
 	"microvibe-go/pkg/logger"
 	"microvibe-go/pkg/media"
@@ -22,17 +23,20 @@ import (
 type VideoHandler struct {
 	recommendEngine *recommend.Engine
 	videoService    service.VideoService
+	processService  service.VideoProcessService
 }
 
 // NewVideoHandler 创建视频处理器实例
-func NewVideoHandler(recommendEngine *recommend.Engine, videoService service.VideoService) *VideoHandler {
+func NewVideoHandler(recommendEngine *recommend.Engine, videoService service.VideoService, processService service.VideoProcessService) *VideoHandler {
 	return &VideoHandler{
 		recommendEngine: recommendEngine,
 		videoService:    videoService,
+		processService:  processService,
 	}
 }
 
 // GetRecommendFeed 获取推荐视频流
+// 游标分页：首次请求不带 cursor，之后携带上一页返回的 next_cursor
 func (h *VideoHandler) GetRecommendFeed(c *gin.Context) {
 	// 获取用户ID（可选）
 	userID, _ := middleware.GetUserID(c)
@@ -52,6 +56,7 @@ func (h *VideoHandler) GetRecommendFeed(c *gin.Context) {
 		UserID:   userID,
 		Page:     page,
 		PageSize: pageSize,
+		Cursor:   c.Query("cursor"),
 		Scene:    "feed",
 	})
 
@@ -67,7 +72,7 @@ func (h *VideoHandler) GetRecommendFeed(c *gin.Context) {
 		return
 	}
 
-	response.PageSuccess(c, enrichedVideos, resp.Total, page, pageSize)
+	response.CursorSuccess(c, enrichedVideos, resp.Total, resp.Page, pageSize, resp.NextCursor, resp.HasMore)
 }
 
 // GetFollowFeed 获取关注的人的视频
@@ -91,6 +96,7 @@ func (h *VideoHandler) GetFollowFeed(c *gin.Context) {
 		UserID:   userID,
 		Page:     page,
 		PageSize: pageSize,
+		Cursor:   c.Query("cursor"),
 		Scene:    "follow",
 	})
 
@@ -106,7 +112,7 @@ func (h *VideoHandler) GetFollowFeed(c *gin.Context) {
 		return
 	}
 
-	response.PageSuccess(c, enrichedVideos, resp.Total, page, pageSize)
+	response.CursorSuccess(c, enrichedVideos, resp.Total, resp.Page, pageSize, resp.NextCursor, resp.HasMore)
 }
 
 // GetFriendsFeed 获取朋友的视频 (互相关注)
@@ -130,6 +136,7 @@ func (h *VideoHandler) GetFriendsFeed(c *gin.Context) {
 		UserID:   userID,
 		Page:     page,
 		PageSize: pageSize,
+		Cursor:   c.Query("cursor"),
 		Scene:    "friends",
 	})
 
@@ -145,7 +152,7 @@ func (h *VideoHandler) GetFriendsFeed(c *gin.Context) {
 		return
 	}
 
-	response.PageSuccess(c, enrichedVideos, resp.Total, page, pageSize)
+	response.CursorSuccess(c, enrichedVideos, resp.Total, resp.Page, pageSize, resp.NextCursor, resp.HasMore)
 }
 
 // GetHotFeed 获取热门视频（直接使用 hot_score 排序，绕过推荐管道）
@@ -203,7 +210,7 @@ func (h *VideoHandler) CreateVideo(c *gin.Context) {
 	response.Success(c, enrichedVideo)
 }
 
-// UploadVideo 上传视频文件并由FFmpeg处理
+// UploadVideo 上传视频文件，探测、封面、转码和发布由处理任务异步完成
 func (h *VideoHandler) UploadVideo(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
@@ -272,28 +279,18 @@ func (h *VideoHandler) UploadVideo(c *gin.Context) {
 	coverName := fmt.Sprintf("%d_%d_cover.jpg", userID, timestamp)
 	coverPath := filepath.Join(coverDir, coverName)
 
-	duration, w, hght, err := media.GetVideoMetadata(savePath)
-	if err != nil {
-		response.ServerError(c, "获取视频元数据失败: "+err.Error())
-		return
-	}
-
-	// 自定义封面覆盖
-	if coverErr == nil && coverFile != nil {
+	// 自定义封面覆盖（未上传时由处理任务抽取首帧）
+	customCover := coverErr == nil && coverFile != nil
+	if customCover {
 		if err := c.SaveUploadedFile(coverFile, coverPath); err != nil {
+			os.Remove(savePath)
 			response.ServerError(c, "保存自定义封面失败: "+err.Error())
 			return
 		}
-	} else {
-		// 自动抽取首帧
-		if err := media.ExtractCover(savePath, coverPath); err != nil {
-			response.ServerError(c, "提取视频封面失败: "+err.Error())
-			return
-		}
 	}
 
 	// 构造相对路径 URL
-	videoURL := fmt.Sprintf("/uploads/videos/hls/%d_%d/index.m3u8", userID, timestamp)
+	videoURL := fmt.Sprintf("/uploads/videos/hls/%d_%d/%s", userID, timestamp, media.MasterPlaylistName)
 	coverURL := fmt.Sprintf("/uploads/videos/covers/%s", coverName)
 
 	req := service.CreateVideoRequest{
@@ -302,14 +299,12 @@ func (h *VideoHandler) UploadVideo(c *gin.Context) {
 		Description:  description,
 		VideoURL:     videoURL,
 		CoverURL:     coverURL,
-		Duration:     duration,
-		Width:        w,
-		Height:       hght,
 		FileSize:     file.Size,
 		CategoryID:   categoryID,
 		Tags:         tags,
 		IsPublic:     &isPublic,
 		AllowComment: &allowComment,
+		Processing:   true,
 	}
 
 	video, err := h.videoService.CreateVideo(c.Request.Context(), &req)
@@ -322,21 +317,60 @@ func (h *VideoHandler) UploadVideo(c *gin.Context) {
 		return
 	}
 
-	// 只有在数据库记录创建成功后，才开始异步转码
-	go func() {
-		if err := media.TranscodeToHLS(savePath, hlsDir); err != nil {
-			logger.Error("视频 HLS 转码失败", zap.Error(err), zap.String("path", savePath))
-		} else {
-			// 转码成功后删除原始视频文件以节省空间
-			os.Remove(savePath)
-			logger.Info("视频 HLS 转码成功，已清理原始文件", zap.String("path", savePath))
+	// 只有在数据库记录创建成功后，才提交处理任务；转码成功后视频才会发布
+	job := &model.VideoProcessJob{
+		VideoID:     video.ID,
+		UserID:      userID,
+		SourcePath:  savePath,
+		CoverPath:   coverPath,
+		CustomCover: customCover,
+		HLSDir:      hlsDir,
+		VideoURL:    videoURL,
+		CoverURL:    coverURL,
+	}
+	if err := h.processService.Enqueue(c.Request.Context(), job); err != nil {
+		if statusErr := h.videoService.UpdateVideoStatus(c.Request.Context(), video.ID, model.VideoStatusProcessFailed); statusErr != nil {
+			logger.Error("更新视频状态失败", zap.Error(statusErr), zap.Uint("video_id", video.ID))
 		}
-	}()
+		os.RemoveAll(hlsDir)
+		os.Remove(coverPath)
+		os.Remove(savePath)
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
 
 	enrichedVideo, _ := h.videoService.EnrichVideo(c.Request.Context(), userID, video)
 	response.Success(c, enrichedVideo)
 }
 
+// GetProcessingStatus 查询上传视频的处理进度
+// @Summary 查询视频处理任务状态
+// @Tags 视频
+// @Param id path int true "视频ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/videos/{id}/processing [get]
+func (h *VideoHandler) GetProcessingStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "视频ID格式错误")
+		return
+	}
+
+	job, err := h.processService.GetJobStatus(c.Request.Context(), userID, uint(videoID))
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, job)
+}
+
 // AuditVideo 审核视频
 func (h *VideoHandler) AuditVideo(c *gin.Context) {
 	// 这里可以加上管理员中间件校验，目前简化为一个直接调用的端口
@@ -387,6 +421,42 @@ func (h *VideoHandler) GetVideoDetail(c *gin.Context) {
 	response.Success(c, enrichedVideo)
 }
 
+// GetRelatedVideos 获取相关视频（"接着看"）
+func (h *VideoHandler) GetRelatedVideos(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "视频ID格式错误")
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	video, err := h.videoService.GetVideoByID(c.Request.Context(), uint(videoID))
+	if err != nil {
+		response.Error(c, response.CodeNotFound, err.Error())
+		return
+	}
+
+	videos, err := h.recommendEngine.Related(c.Request.Context(), userID, video, limit)
+	if err != nil {
+		response.ServerError(c, "获取相关视频失败: "+err.Error())
+		return
+	}
+
+	enrichedVideos, err := h.videoService.EnrichVideoList(c.Request.Context(), userID, videos)
+	if err != nil {
+		response.ServerError(c, "处理视频信息失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, enrichedVideos)
+}
+
 // UpdateVideo 更新视频信息
 func (h *VideoHandler) UpdateVideo(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
@@ -485,6 +555,25 @@ func (h *VideoHandler) UnlikeVideo(c *gin.Context) {
 	response.SuccessWithMessage(c, "取消点赞成功", nil)
 }
 
+// SkipVideo 划走视频（降低推荐会话中同分类、同作者视频的顺序）
+func (h *VideoHandler) SkipVideo(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "请先登录")
+		return
+	}
+
+	videoIDStr := c.Param("id")
+	videoID, err := strconv.ParseUint(videoIDStr, 10, 64)
+	if err != nil {
+		response.InvalidParam(c, "视频ID格式错误")
+		return
+	}
+
+	h.recommendEngine.Feedback(c.Request.Context(), userID, uint(videoID), recommend.FeedbackSkip)
+	response.Success(c, nil)
+}
+
 // FavoriteVideo 收藏视频
 func (h *VideoHandler) FavoriteVideo(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
@@ -500,7 +589,18 @@ func (h *VideoHandler) FavoriteVideo(c *gin.Context) {
 		return
 	}
 
-	if err := h.videoService.FavoriteVideo(c.Request.Context(), userID, uint(videoID)); err != nil {
+	// 可选请求体：指定收藏夹
+	var req struct {
+		FolderID *uint `json:"folder_id"` // 收藏夹ID（为空时收藏到默认收藏夹）
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.InvalidParam(c, "参数错误: "+err.Error())
+			return
+		}
+	}
+
+	if err := h.videoService.FavoriteVideo(c.Request.Context(), userID, uint(videoID), req.FolderID); err != nil {
 		response.Error(c, response.CodeError, err.Error())
 		return
 	}
@@ -26,6 +26,7 @@ type PlaybackHistoryRequest struct {
 	Position int  `json:"position" binding:"required,min=0"` // 播放进度 (秒)
 	Duration int  `json:"duration" binding:"required,min=1"` // 视频总时长 (秒)
 	Finished bool `json:"finished"`                          // 是否播放完成
+	Final    bool `json:"final"`                             // 是否为离开视频时的最后一次上报（可选，未上报时按空闲超时判断离开）
 }
 
 // ReportProgress 上报播放进度
@@ -49,7 +50,7 @@ func (h *VideoHistoryHandler) ReportProgress(c *gin.Context) {
 		return
 	}
 
-	finished, err := h.historyService.ReportProgress(c.Request.Context(), userID, uint(videoID), req.Position, req.Duration, req.Finished)
+	finished, err := h.historyService.ReportProgress(c.Request.Context(), userID, uint(videoID), req.Position, req.Duration, req.Finished, req.Final)
 	if err != nil {
 		response.ServerError(c, "上报播放历史失败")
 		return
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"microvibe-go/internal/middleware"
+	"microvibe-go/internal/service"
+	"microvibe-go/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WalletHandler 钱包Handler
+type WalletHandler struct {
+	walletService service.WalletService
+}
+
+// NewWalletHandler 创建钱包Handler
+func NewWalletHandler(walletService service.WalletService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+	}
+}
+
+// GetWallet 获取当前用户钱包
+// @Summary 获取当前用户钱包
+// @Tags 钱包
+// @Success 200 {object} response.Response
+// @Router /api/v1/wallet [get]
+func (h *WalletHandler) GetWallet(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	wallet, err := h.walletService.GetWallet(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.Success(c, wallet)
+}
+
+// ListTransactions 获取当前用户交易记录
+// @Summary 获取当前用户交易记录
+// @Tags 钱包
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response
+// @Router /api/v1/wallet/transactions [get]
+func (h *WalletHandler) ListTransactions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	txns, total, err := h.walletService.ListTransactions(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.PageSuccess(c, txns, total, page, pageSize)
+}
+
+// TopUp 为当前用户充值
+// @Summary 为当前用户充值（按 request_id 幂等，重试时请求ID保持不变）
+// @Tags 钱包
+// @Accept json
+// @Param request body service.TopUpRequest true "充值请求"
+// @Success 200 {object} response.Response
+// @Router /api/v1/wallet/recharge [post]
+func (h *WalletHandler) TopUp(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	var req service.TopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	txn, err := h.walletService.TopUp(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "充值成功", txn)
+}
+
+// Spend 当前用户消费扣费
+// @Summary 当前用户消费扣费（按 request_id 幂等，重试时请求ID保持不变）
+// @Tags 钱包
+// @Accept json
+// @Param request body service.SpendRequest true "消费请求"
+// @Success 200 {object} response.Response
+// @Router /api/v1/wallet/spend [post]
+func (h *WalletHandler) Spend(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	var req service.SpendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	txn, err := h.walletService.Spend(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "扣费成功", txn)
+}
+
+// Recharge 为用户充值（管理员）
+// @Summary 为用户充值
+// @Tags 钱包
+// @Accept json
+// @Param request body service.RechargeRequest true "充值请求"
+// @Success 200 {object} response.Response
+// @Router /api/v1/admin/wallet/recharge [post]
+func (h *WalletHandler) Recharge(c *gin.Context) {
+	var req service.RechargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.InvalidParam(c, "参数错误: "+err.Error())
+		return
+	}
+
+	txn, err := h.walletService.Recharge(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, response.CodeError, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "充值成功", txn)
+}
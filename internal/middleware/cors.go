@@ -28,7 +28,7 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 		if reqHeaders != "" {
 			c.Writer.Header().Set("Access-Control-Allow-Headers", reqHeaders)
 		} else {
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Platform, X-App-Version, X-OS-Version, X-Device-Model")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Platform, X-App-Version, X-OS-Version, X-Device-Model, X-Device-ID")
 		}
 
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
@@ -8,6 +8,7 @@ import (
 
 // DeviceInfo 设备信息
 type DeviceInfo struct {
+	DeviceID    string // 客户端设备标识（用于区分同一平台的多台设备）
 	Platform    string // web, android, ios, windows, macos, linux
 	AppVersion  string
 	OSVersion   string
@@ -21,6 +22,7 @@ const DeviceContextKey = "device_info"
 func DeviceMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		device := DeviceInfo{
+			DeviceID:    c.GetHeader("X-Device-ID"),
 			Platform:    c.GetHeader("X-Platform"),
 			AppVersion:  c.GetHeader("X-App-Version"),
 			OSVersion:   c.GetHeader("X-OS-Version"),
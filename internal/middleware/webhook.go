@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"microvibe-go/internal/config"
+	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/response"
+	"microvibe-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// WebhookTimestampHeader 回调时间戳请求头（Unix 秒）
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader 回调签名请求头，值为 utils.SignWebhook(时间戳, 请求体, 密钥)
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	// webhookSignatureMaxAge 回调时间戳允许的最大偏差，超出视为重放
+	webhookSignatureMaxAge = 5 * time.Minute
+	// webhookMaxBodyBytes 回调请求体大小上限
+	webhookMaxBodyBytes = 64 << 10
+)
+
+// MediaWebhookMiddleware 流媒体服务器回调认证中间件
+// 每次回调都需携带时间戳和对“时间戳.请求体”的 HMAC 签名，时间戳超出允许范围的请求被拒绝，
+// 截获的回调无法在窗口外重放。nginx-rtmp、SRS 无法自行签名，回调经 cmd/webhook-relay 签名后转发。
+// 配置了 webhook_allowed_ips 时还要求请求直接来自允许的地址（按 TCP 连接的对端地址判断，不信任 X-Forwarded-For）
+func MediaWebhookMiddleware(cfg *config.Config) gin.HandlerFunc {
+	allowed := parseIPNets(cfg.Streaming.WebhookAllowedIPs)
+
+	return func(c *gin.Context) {
+		secret := cfg.Streaming.WebhookSecret
+		if secret == "" {
+			response.Forbidden(c, "未配置流媒体回调密钥")
+			c.Abort()
+			return
+		}
+
+		if len(allowed) > 0 && !ipAllowed(c.RemoteIP(), allowed) {
+			authFailuresTotal.WithLabelValues(c.FullPath(), "webhook_ip_not_allowed").Inc()
+			logger.Warn("拒绝非流媒体服务器地址的回调", zap.String("remote_ip", c.RemoteIP()), zap.String("path", c.FullPath()))
+			response.Forbidden(c, "回调来源地址不允许")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, webhookMaxBodyBytes+1))
+		if err != nil || len(body) > webhookMaxBodyBytes {
+			response.InvalidParam(c, "回调请求体无效")
+			c.Abort()
+			return
+		}
+		// 签名校验读取了请求体，还原后供处理器解析
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		timestamp, _ := strconv.ParseInt(c.GetHeader(WebhookTimestampHeader), 10, 64)
+		if err := utils.VerifyWebhook(timestamp, body, c.GetHeader(WebhookSignatureHeader), secret, webhookSignatureMaxAge); err != nil {
+			reason := "invalid_webhook_signature"
+			if errors.Is(err, utils.ErrWebhookSignExpired) {
+				reason = "expired_webhook_signature"
+			}
+			authFailuresTotal.WithLabelValues(c.FullPath(), reason).Inc()
+			response.Unauthorized(c, err.Error())
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// parseIPNets 解析地址白名单（支持单个 IP 和 CIDR），无效条目记录日志后忽略
+func parseIPNets(entries []string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				logger.Error("无效的回调地址白名单条目", zap.String("entry", entry))
+				continue
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			logger.Error("无效的回调地址白名单条目", zap.String("entry", entry), zap.Error(err))
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+// ipAllowed 判断地址是否在白名单内
+func ipAllowed(addr string, nets []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/middleware"
+	"microvibe-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newWebhookRouter(secret string) *gin.Engine {
+	return newWebhookRouterWithIPs(secret, nil)
+}
+
+func newWebhookRouterWithIPs(secret string, allowed []string) *gin.Engine {
+	cfg := &config.Config{Streaming: config.StreamingConfig{WebhookSecret: secret, WebhookAllowedIPs: allowed}}
+	r := setupGin()
+	r.POST("/hook", middleware.MediaWebhookMiddleware(cfg), func(c *gin.Context) {
+		// 处理器仍能读取已校验过的请求体
+		c.JSON(200, gin.H{"name": c.PostForm("name")})
+	})
+	return r
+}
+
+// newSignedRequest 创建带时间戳和签名的回调请求
+func newSignedRequest(body string, ts int64, secret string) *http.Request {
+	req := httptest.NewRequest("POST", "/hook", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(middleware.WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(middleware.WebhookSignatureHeader, utils.SignWebhook(ts, []byte(body), secret))
+	return req
+}
+
+func TestMediaWebhookMiddleware_NoSecret(t *testing.T) {
+	r := newWebhookRouter("")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newSignedRequest("name=abc", time.Now().Unix(), ""))
+
+	if w.Code != 403 {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestMediaWebhookMiddleware_MissingSignature(t *testing.T) {
+	r := newWebhookRouter("hook-secret")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/hook", strings.NewReader("name=abc"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+
+	if w.Code != 401 {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestMediaWebhookMiddleware_WrongSecret(t *testing.T) {
+	r := newWebhookRouter("hook-secret")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newSignedRequest("name=abc", time.Now().Unix(), "other-secret"))
+
+	if w.Code != 401 {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestMediaWebhookMiddleware_ValidSignature(t *testing.T) {
+	r := newWebhookRouter("hook-secret")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newSignedRequest("name=abc", time.Now().Unix(), "hook-secret"))
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"name":"abc"`) {
+		t.Errorf("expected handler to read the form body, got %s", w.Body.String())
+	}
+}
+
+func TestMediaWebhookMiddleware_StaleTimestampRejected(t *testing.T) {
+	r := newWebhookRouter("hook-secret")
+
+	// 截获的回调在时间窗口外重放
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newSignedRequest("name=abc", time.Now().Add(-10*time.Minute).Unix(), "hook-secret"))
+
+	if w.Code != 401 {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestMediaWebhookMiddleware_TamperedBodyRejected(t *testing.T) {
+	r := newWebhookRouter("hook-secret")
+
+	req := newSignedRequest("name=abc", time.Now().Unix(), "hook-secret")
+	req.Body = io.NopCloser(strings.NewReader("name=other"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != 401 {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestMediaWebhookMiddleware_AllowedIPs(t *testing.T) {
+	r := newWebhookRouterWithIPs("hook-secret", []string{"10.0.0.0/8", "192.0.2.7"})
+
+	cases := []struct {
+		remoteAddr string
+		forwarded  string
+		want       int
+	}{
+		{"10.1.2.3:40000", "", 200},
+		{"192.0.2.7:40000", "", 200},
+		{"192.0.2.8:40000", "", 403},
+		// X-Forwarded-For 不能绕过地址限制
+		{"203.0.113.5:40000", "10.1.2.3", 403},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := newSignedRequest("name=abc", time.Now().Unix(), "hook-secret")
+		req.RemoteAddr = tc.remoteAddr
+		if tc.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Errorf("%s (forwarded %q): expected %d, got %d", tc.remoteAddr, tc.forwarded, tc.want, w.Code)
+		}
+	}
+}
+
+func TestMediaWebhookMiddleware_AllowedIPStillRequiresSignature(t *testing.T) {
+	r := newWebhookRouterWithIPs("hook-secret", []string{"10.0.0.0/8"})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/hook", nil)
+	req.RemoteAddr = "10.1.2.3:40000"
+	r.ServeHTTP(w, req)
+
+	if w.Code != 401 {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
@@ -35,9 +35,9 @@ type UserInterest struct {
 	UpdatedAt time.Time `json:"updated_at"`
 
 	UserID     uint    `gorm:"index;not null" json:"user_id"`     // 用户ID
-	CategoryID uint    `gorm:"index;not null" json:"category_id"` // 分类ID
-	TagID      *uint   `gorm:"index" json:"tag_id"`               // 标签ID
-	Score      float64 `gorm:"not null" json:"score"`             // 兴趣分数（0-1）
+	CategoryID uint    `gorm:"index;not null" json:"category_id"` // 分类ID（话题兴趣为 0）
+	TagID      *uint   `gorm:"index" json:"tag_id"`               // 话题ID（分类兴趣为空）
+	Score      float64 `gorm:"not null" json:"score"`             // 兴趣分数（-1~1，负数表示不感兴趣）
 	Weight     float64 `gorm:"default:1.0" json:"weight"`         // 权重
 
 	// 统计
@@ -108,15 +108,75 @@ type HotSearch struct {
 	Keyword     string  `gorm:"size:200;uniqueIndex;not null" json:"keyword"` // 关键词
 	SearchCount int64   `gorm:"default:0;index" json:"search_count"`          // 搜索次数
 	HotScore    float64 `gorm:"default:0;index" json:"hot_score"`             // 热度分数
-	Rank        int     `gorm:"default:0;index" json:"rank"`                  // 排名
+	Rank        int     `gorm:"default:0;index" json:"rank"`                  // 排名（0 表示未上榜）
 	IsSticky    bool    `gorm:"default:false" json:"is_sticky"`               // 是否置顶
+	Boost       float64 `gorm:"default:0" json:"boost"`                       // 运营加权，叠加在衰减后的热度上
+	Label       string  `gorm:"size:20" json:"label"`                         // 趋势标记：new-新上榜，rising-上升中
 }
 
+// 热搜趋势标记
+const (
+	HotSearchLabelNew    = "new"
+	HotSearchLabelRising = "rising"
+)
+
 // TableName 指定表名
 func (HotSearch) TableName() string {
 	return "hot_searches"
 }
 
+// 视频搜索排序方式
+const (
+	SearchSortRelevance  = "relevance"   // 相关度（默认）
+	SearchSortNewest     = "newest"      // 最新发布
+	SearchSortMostPlayed = "most_played" // 最多播放
+	SearchSortMostLiked  = "most_liked"  // 最多点赞
+)
+
+// DurationBucket 视频时长区间（秒，左闭右开，Max 为 0 表示不设上限）
+type DurationBucket struct {
+	Name string
+	Min  int
+	Max  int
+}
+
+// DurationBuckets 搜索支持的时长区间
+var DurationBuckets = []DurationBucket{
+	{Name: "short", Min: 0, Max: 60},    // 1 分钟以内
+	{Name: "medium", Min: 60, Max: 300}, // 1-5 分钟
+	{Name: "long", Min: 300, Max: 0},    // 5 分钟以上
+}
+
+// FindDurationBucket 按名称查找时长区间
+func FindDurationBucket(name string) (DurationBucket, bool) {
+	for _, b := range DurationBuckets {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return DurationBucket{}, false
+}
+
+// CategoryFacet 搜索结果按分类的统计
+type CategoryFacet struct {
+	CategoryID uint   `json:"category_id"` // 0 表示未分类
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+}
+
+// DurationFacet 搜索结果按时长区间的统计
+type DurationFacet struct {
+	Bucket string `json:"bucket"`
+	Count  int64  `json:"count"`
+}
+
+// SearchFacets 搜索结果分面统计
+// 每个维度的统计不受该维度自身筛选条件的影响，便于前端切换选项
+type SearchFacets struct {
+	Categories []*CategoryFacet `json:"categories"`
+	Durations  []*DurationFacet `json:"durations"`
+}
+
 // VideoHistory 视频播放历史
 type VideoHistory struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"time"
+)
+
+// 实验状态
+const (
+	ExperimentStatusDraft   int8 = 0 // 草稿
+	ExperimentStatusRunning int8 = 1 // 运行中
+	ExperimentStatusStopped int8 = 2 // 已停止
+)
+
+// Experiment 推荐流 A/B 实验
+// 同一层（Layer）内的实验互斥，不同层的实验正交，用户按 ID 哈希分流
+type Experiment struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string     `gorm:"size:100;uniqueIndex;not null" json:"name"` // 实验名称
+	Description string     `gorm:"type:text" json:"description"`              // 实验说明
+	Layer       string     `gorm:"size:50;index;not null" json:"layer"`       // 实验层
+	Scene       string     `gorm:"size:20" json:"scene"`                      // 生效的推荐场景，为空时对所有场景生效
+	Traffic     int        `gorm:"not null" json:"traffic"`                   // 在层内占用的流量百分比（1-100）
+	Offset      int        `gorm:"default:0" json:"offset"`                   // 在层内占用的起始流量桶（启动时分配）
+	Status      int8       `gorm:"default:0;index" json:"status"`             // 状态：0-草稿，1-运行中，2-已停止
+	StartedAt   *time.Time `json:"started_at"`                                // 开始时间
+	EndedAt     *time.Time `json:"ended_at"`                                  // 结束时间
+
+	// 关联
+	Variants []*ExperimentVariant `gorm:"foreignKey:ExperimentID" json:"variants,omitempty"`
+}
+
+// TableName 指定表名
+func (Experiment) TableName() string {
+	return "experiments"
+}
+
+// ExperimentVariant 实验变体
+type ExperimentVariant struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ExperimentID uint             `gorm:"index;not null" json:"experiment_id"`     // 实验ID
+	Name         string           `gorm:"size:50;not null" json:"name"`            // 变体名称，如 control、treatment
+	Weight       int              `gorm:"not null" json:"weight"`                  // 实验内的流量权重
+	Params       ExperimentParams `gorm:"type:text;serializer:json" json:"params"` // 对推荐策略的覆盖参数
+}
+
+// TableName 指定表名
+func (ExperimentVariant) TableName() string {
+	return "experiment_variants"
+}
+
+// ExperimentParams 实验变体对推荐策略的覆盖（为空的字段沿用场景配置）
+type ExperimentParams struct {
+	Recall         []ExperimentRecallChannel `json:"recall,omitempty"`          // 召回通道及配额
+	Fill           *bool                     `json:"fill,omitempty"`            // 召回不足时是否随机补足
+	Scorers        []ExperimentScorer        `json:"scorers,omitempty"`         // 打分因子及权重
+	DiversityRatio *float64                  `json:"diversity_ratio,omitempty"` // 多样性打散比例（0-1）
+}
+
+// ExperimentRecallChannel 实验的召回通道配额
+type ExperimentRecallChannel struct {
+	Name  string  `json:"name"`
+	Quota float64 `json:"quota"`
+}
+
+// ExperimentScorer 实验的打分因子权重
+type ExperimentScorer struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// UserBehaviorExperiment 用户行为的实验标记（行为发生时用户所在的实验变体）
+type UserBehaviorExperiment struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	BehaviorID   uint `gorm:"index;not null" json:"behavior_id"`                                   // 行为记录ID
+	ExperimentID uint `gorm:"index:idx_behavior_experiment_variant;not null" json:"experiment_id"` // 实验ID
+	VariantID    uint `gorm:"index:idx_behavior_experiment_variant;not null" json:"variant_id"`    // 变体ID
+}
+
+// TableName 指定表名
+func (UserBehaviorExperiment) TableName() string {
+	return "user_behavior_experiments"
+}
+
+// ExperimentDailyStats 实验变体每日曝光统计
+type ExperimentDailyStats struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ExperimentID uint      `gorm:"index;not null" json:"experiment_id"`                         // 实验ID
+	VariantID    uint      `gorm:"uniqueIndex:idx_variant_date;not null" json:"variant_id"`     // 变体ID
+	Date         time.Time `gorm:"uniqueIndex:idx_variant_date;type:date;not null" json:"date"` // 统计日期
+	Impressions  int64     `gorm:"default:0" json:"impressions"`                                // 曝光数（下发到推荐流的视频数）
+}
+
+// TableName 指定表名
+func (ExperimentDailyStats) TableName() string {
+	return "experiment_daily_stats"
+}
@@ -62,7 +62,7 @@ type LiveStream struct {
 	OnlineCount   int   `gorm:"default:0;index" json:"online_count"` // 当前在线人数
 	PeakCount     int   `gorm:"default:0" json:"peak_count"`         // 峰值在线人数
 	FollowerCount int64 `gorm:"default:0" json:"follower_count"`     // 直播间新增关注数
-	ProductSales  int64 `gorm:"default:0" json:"product_sales"`      // 商品销售额
+	ProductSales  int64 `gorm:"default:0" json:"product_sales"`      // 商品销售额（分）
 
 	// ========== 状态控制 ==========
 	Status     string     `gorm:"size:20;default:'waiting';index" json:"status"` // 状态: waiting-待开播, live-直播中, paused-暂停, ended-已结束, banned-禁播
@@ -247,7 +247,7 @@ type LiveProduct struct {
 
 	// 推广信息
 	ExplainedAt    *time.Time `json:"explained_at"`                     // 讲解时间
-	CommissionRate float64    `gorm:"default:0" json:"commission_rate"` // 佣金比例
+	CommissionRate float64    `gorm:"default:0" json:"commission_rate"` // 主播佣金比例（0-1，按订单实付金额计算）
 
 	// 关联
 	Live *LiveStream `gorm:"foreignKey:LiveID" json:"live,omitempty"`
@@ -258,6 +258,59 @@ func (LiveProduct) TableName() string {
 	return "live_products"
 }
 
+// 直播订单状态
+const (
+	LiveOrderStatusPending   = "pending"   // 待支付（已锁定库存）
+	LiveOrderStatusPaid      = "paid"      // 已支付
+	LiveOrderStatusCancelled = "cancelled" // 买家取消
+	LiveOrderStatusClosed    = "closed"    // 超时未支付关闭
+	LiveOrderStatusRefunded  = "refunded"  // 已退款
+)
+
+// LiveOrder 直播带货订单
+// 下单时按直播价锁定库存，支付成功后计入销量、直播间销售额和主播佣金，取消或超时关闭时释放库存，
+// 已支付订单退款时冲回销量、销售额和佣金
+// (user_id, request_id) 唯一，用于下单接口的幂等
+type LiveOrder struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	OrderNo       string `gorm:"size:32;uniqueIndex;not null" json:"order_no"` // 订单号
+	RequestID     string `gorm:"size:64;not null" json:"-"`                    // 客户端请求ID（幂等键）
+	UserID        uint   `gorm:"index;not null" json:"user_id"`                // 买家ID
+	LiveID        uint   `gorm:"index;not null" json:"live_id"`                // 直播间ID
+	StreamerID    uint   `gorm:"index;not null" json:"streamer_id"`            // 主播ID
+	LiveProductID uint   `gorm:"index;not null" json:"live_product_id"`        // 直播商品ID
+
+	// 下单时的商品快照
+	ProductName  string `gorm:"size:200;not null" json:"product_name"` // 商品名称
+	ProductCover string `gorm:"size:512" json:"product_cover"`         // 商品封面
+	UnitPrice    int64  `gorm:"not null" json:"unit_price"`            // 直播价（分）
+	Quantity     int    `gorm:"not null" json:"quantity"`              // 购买数量
+	TotalAmount  int64  `gorm:"not null" json:"total_amount"`          // 订单金额（分）
+
+	// 佣金
+	CommissionRate float64 `gorm:"default:0" json:"commission_rate"` // 下单时的佣金比例
+	Commission     int64   `gorm:"default:0" json:"commission"`      // 主播佣金（分，支付成功后记入主播钱包，退款时冲回）
+
+	// 支付
+	Status          string     `gorm:"size:20;index;not null" json:"status"`   // 状态：pending, paid, cancelled, closed, refunded
+	PaymentProvider string     `gorm:"size:32" json:"payment_provider"`        // 支付渠道
+	PaymentID       string     `gorm:"size:64;index" json:"payment_id"`        // 渠道支付单号
+	PayURL          string     `gorm:"size:512" json:"pay_url,omitempty"`      // 客户端拉起支付的地址
+	ExpiresAt       time.Time  `gorm:"index;not null" json:"expires_at"`       // 支付截止时间
+	PaidAt          *time.Time `json:"paid_at,omitempty"`                      // 支付时间
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`                    // 取消或关闭时间
+	CloseReason     string     `gorm:"size:100" json:"close_reason,omitempty"` // 取消或关闭原因
+	RefundedAt      *time.Time `json:"refunded_at,omitempty"`                  // 退款时间
+}
+
+// TableName 指定表名
+func (LiveOrder) TableName() string {
+	return "live_orders"
+}
+
 // ==================== 管理相关 ====================
 
 // LiveAdmin 直播间管理员
@@ -376,6 +429,8 @@ type LiveFansClub struct {
 	Privileges  string `gorm:"type:text" json:"privileges"`                 // 特权（JSON格式）
 	IsActivated bool   `gorm:"default:true" json:"is_activated"`            // 是否激活
 
+	LastActiveAt *time.Time `gorm:"index" json:"last_active_at,omitempty"` // 最近一次获得经验的时间（用于等级衰减）
+
 	// 关联
 	Live *LiveStream `gorm:"foreignKey:LiveID" json:"live,omitempty"`
 	User *User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -14,7 +14,7 @@ type Message struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	SenderID       uint   `gorm:"index;not null" json:"sender_id"`   // 发送者ID
-	ReceiverID     uint   `gorm:"index;not null" json:"receiver_id"` // 接收者ID
+	ReceiverID     *uint  `gorm:"index" json:"receiver_id"`          // 接收者ID（群聊消息为空）
 	ConversationID *uint  `gorm:"index" json:"conversation_id"`      // 会话ID
 	Type           int8   `gorm:"default:1" json:"type"`             // 消息类型：1-文本，2-图片，3-视频，4-语音
 	Content        string `gorm:"type:text;not null" json:"content"` // 消息内容
@@ -35,7 +35,7 @@ type Message struct {
 type MessageVO struct {
 	ID             uint       `json:"id"`
 	SenderID       uint       `json:"sender_id"`
-	ReceiverID     uint       `json:"receiver_id"`
+	ReceiverID     *uint      `json:"receiver_id"`
 	ConversationID *uint      `json:"conversation_id"`
 	Type           int8       `json:"type"`
 	Content        string     `json:"content"`
@@ -57,6 +57,12 @@ func (Message) TableName() string {
 	return "messages"
 }
 
+// 会话类型
+const (
+	ConversationTypePrivate int8 = 1 // 私聊
+	ConversationTypeGroup   int8 = 2 // 群聊
+)
+
 // Conversation 会话模型
 type Conversation struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
@@ -64,17 +70,26 @@ type Conversation struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	User1ID       uint   `gorm:"index;not null" json:"user1_id"` // 用户1ID
-	User2ID       uint   `gorm:"index;not null" json:"user2_id"` // 用户2ID
+	Type          int8   `gorm:"default:1;index" json:"type"`    // 会话类型：1-私聊，2-群聊
+	User1ID       *uint  `gorm:"index" json:"user1_id"`          // 用户1ID（仅私聊）
+	User2ID       *uint  `gorm:"index" json:"user2_id"`          // 用户2ID（仅私聊）
 	LastMessageID *uint  `json:"last_message_id"`                // 最后一条消息ID
 	LastContent   string `gorm:"size:500" json:"last_content"`   // 最后消息内容
-	UnreadCount1  int    `gorm:"default:0" json:"unread_count1"` // 用户1未读数
-	UnreadCount2  int    `gorm:"default:0" json:"unread_count2"` // 用户2未读数
+	UnreadCount1  int    `gorm:"default:0" json:"unread_count1"` // 用户1未读数（仅私聊）
+	UnreadCount2  int    `gorm:"default:0" json:"unread_count2"` // 用户2未读数（仅私聊）
+
+	// 群聊信息
+	Name        string `gorm:"size:100" json:"name,omitempty"`    // 群名称
+	Avatar      string `gorm:"size:255" json:"avatar,omitempty"`  // 群头像
+	Notice      string `gorm:"size:1000" json:"notice,omitempty"` // 群公告
+	OwnerID     *uint  `gorm:"index" json:"owner_id,omitempty"`   // 群主ID
+	MemberCount int    `gorm:"default:0" json:"member_count"`     // 群成员数
 
 	// 关联
-	User1       *User    `gorm:"foreignKey:User1ID" json:"user1,omitempty"`
-	User2       *User    `gorm:"foreignKey:User2ID" json:"user2,omitempty"`
-	LastMessage *Message `gorm:"foreignKey:LastMessageID" json:"last_message,omitempty"`
+	User1       *User                 `gorm:"foreignKey:User1ID" json:"user1,omitempty"`
+	User2       *User                 `gorm:"foreignKey:User2ID" json:"user2,omitempty"`
+	LastMessage *Message              `gorm:"foreignKey:LastMessageID" json:"last_message,omitempty"`
+	Members     []*ConversationMember `gorm:"foreignKey:ConversationID" json:"members,omitempty"`
 }
 
 // TableName 指定表名
@@ -82,12 +97,69 @@ func (Conversation) TableName() string {
 	return "conversations"
 }
 
+// IsGroup 是否为群聊
+func (c *Conversation) IsGroup() bool {
+	return c.Type == ConversationTypeGroup
+}
+
+// IsParticipant 私聊会话是否包含该用户
+func (c *Conversation) IsParticipant(userID uint) bool {
+	return (c.User1ID != nil && *c.User1ID == userID) || (c.User2ID != nil && *c.User2ID == userID)
+}
+
+// PeerID 私聊会话中对方的用户ID
+func (c *Conversation) PeerID(userID uint) uint {
+	if c.User1ID != nil && *c.User1ID == userID {
+		if c.User2ID != nil {
+			return *c.User2ID
+		}
+		return 0
+	}
+	if c.User1ID != nil {
+		return *c.User1ID
+	}
+	return 0
+}
+
+// 群成员角色
+const (
+	ConversationRoleMember int8 = 1 // 普通成员
+	ConversationRoleAdmin  int8 = 2 // 管理员
+	ConversationRoleOwner  int8 = 3 // 群主
+)
+
+// ConversationMember 群聊成员
+type ConversationMember struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"` // 入群时间
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ConversationID    uint       `gorm:"index;not null" json:"conversation_id"` // 会话ID
+	UserID            uint       `gorm:"index;not null" json:"user_id"`         // 成员用户ID
+	Role              int8       `gorm:"default:1" json:"role"`                 // 角色：1-成员，2-管理员，3-群主
+	Nickname          string     `gorm:"size:50" json:"nickname"`               // 群昵称
+	InviterID         *uint      `json:"inviter_id,omitempty"`                  // 邀请人ID
+	UnreadCount       int        `gorm:"default:0" json:"unread_count"`         // 未读数
+	LastReadMessageID *uint      `json:"last_read_message_id"`                  // 已读到的消息ID
+	LastReadAt        *time.Time `json:"last_read_at"`                          // 最后阅读时间
+
+	// 关联
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName 指定表名
+func (ConversationMember) TableName() string {
+	return "conversation_members"
+}
+
 // ConversationVO 会话响应对象 (用于 API 视角转换)
 type ConversationVO struct {
 	ID          uint       `json:"id"`
-	UserID      uint       `json:"user_id"`      // 对方用户ID
-	Nickname    string     `json:"nickname"`     // 对方昵称
-	Avatar      string     `json:"avatar"`       // 对方头像
+	Type        int8       `json:"type"`         // 会话类型：1-私聊，2-群聊
+	UserID      uint       `json:"user_id"`      // 对方用户ID（群聊为空）
+	Nickname    string     `json:"nickname"`     // 对方昵称（群聊为群名称）
+	Avatar      string     `json:"avatar"`       // 对方头像（群聊为群头像）
+	MemberCount int        `json:"member_count"` // 群成员数（私聊为 2）
 	LastMessage *MessageVO `json:"last_message"` // 最后一条消息
 	UnreadCount int        `json:"unread_count"` // 当前用户的未读数
 	CreatedAt   time.Time  `json:"created_at"`
@@ -134,3 +206,59 @@ type Notification struct {
 func (Notification) TableName() string {
 	return "notifications"
 }
+
+// 消息同步事件类型
+const (
+	MessageSyncEventMessage = "message" // 新消息
+	MessageSyncEventRead    = "read"    // 已读回执
+	MessageSyncEventDelete  = "delete"  // 消息删除
+)
+
+// MessageSyncEvent 消息同步事件（按用户记录收发消息、已读回执和删除，供离线设备增量同步）
+type MessageSyncEvent struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID         uint   `gorm:"not null" json:"-"`            // 事件所属用户
+	Seq            int64  `gorm:"not null" json:"seq"`          // 用户内单调递增序号
+	Type           string `gorm:"size:20;not null" json:"type"` // 事件类型：message/read/delete
+	ConversationID *uint  `gorm:"index" json:"conversation_id"` // 会话ID
+	MessageID      *uint  `json:"message_id,omitempty"`         // 消息ID（整个会话已读时为空）
+	ActorID        uint   `gorm:"not null" json:"actor_id"`     // 触发事件的用户（发送者/阅读者/删除者）
+
+	// 关联
+	Message *Message `gorm:"foreignKey:MessageID" json:"-"`
+}
+
+// TableName 指定表名
+func (MessageSyncEvent) TableName() string {
+	return "message_sync_events"
+}
+
+// MessageSequence 用户消息序号分配表
+type MessageSequence struct {
+	UserID  uint  `gorm:"primarykey;autoIncrement:false" json:"user_id"`
+	LastSeq int64 `gorm:"not null;default:0" json:"last_seq"` // 已分配的最大序号
+}
+
+// TableName 指定表名
+func (MessageSequence) TableName() string {
+	return "message_sequences"
+}
+
+// MessageSyncCursor 设备同步游标（记录每台设备已确认同步到的序号）
+type MessageSyncCursor struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint   `gorm:"not null" json:"user_id"`
+	DeviceID string `gorm:"size:128;not null" json:"device_id"` // 设备标识（未提供时使用平台名）
+	Platform string `gorm:"size:20" json:"platform"`            // 平台：web, android, ios ...
+	LastSeq  int64  `gorm:"not null;default:0" json:"last_seq"` // 已确认同步到的序号
+}
+
+// TableName 指定表名
+func (MessageSyncCursor) TableName() string {
+	return "message_sync_cursors"
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// 屏蔽类型
+const (
+	MuteTypeVideo    int8 = 1 // 不感兴趣的视频
+	MuteTypeAuthor   int8 = 2 // 屏蔽作者（不拉黑，仍可互动）
+	MuteTypeHashtag  int8 = 3 // 屏蔽话题
+	MuteTypeCategory int8 = 4 // 屏蔽分类
+)
+
+// UserMute 用户的负反馈（不感兴趣、屏蔽作者、屏蔽话题或分类）
+// 推荐流和召回据此过滤内容，排序对与不感兴趣视频同分类、同作者的内容降权
+type UserMute struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID   uint `gorm:"uniqueIndex:idx_user_mute;not null" json:"user_id"`   // 用户ID
+	Type     int8 `gorm:"uniqueIndex:idx_user_mute;not null" json:"type"`      // 类型：1-不感兴趣的视频，2-作者，3-话题，4-分类
+	TargetID uint `gorm:"uniqueIndex:idx_user_mute;not null" json:"target_id"` // 视频、作者、话题或分类ID
+
+	// 不感兴趣的视频的分类和作者（用于对相似内容降权）
+	CategoryID uint `gorm:"default:0" json:"category_id,omitempty"`
+	AuthorID   uint `gorm:"default:0" json:"author_id,omitempty"`
+}
+
+// TableName 指定表名
+func (UserMute) TableName() string {
+	return "user_mutes"
+}
@@ -94,6 +94,8 @@ type UserVO struct {
 	*User
 	Password   string `json:"-"`           // 遮蔽 User 的 Password，确保不被返回
 	IsFollowed bool   `json:"is_followed"` // 当前用户是否已关注该用户
+
+	Highlights map[string]string `json:"highlights,omitempty"` // 搜索命中的高亮片段（字段名 -> 片段）
 }
 
 // ToVO 转换为 UserVO
@@ -23,6 +23,7 @@ type Video struct {
 	Width       int    `gorm:"default:0" json:"width"`             // 视频宽度
 	Height      int    `gorm:"default:0" json:"height"`            // 视频高度
 	FileSize    int64  `gorm:"default:0" json:"file_size"`         // 文件大小（字节）
+	Renditions  string `gorm:"size:100" json:"-"`                  // 多码率档位（逗号分隔，为空表示单码率），VideoURL 为主播放列表
 
 	// 分类和标签
 	CategoryID *uint  `gorm:"index" json:"category_id"` // 分类ID
@@ -40,12 +41,15 @@ type Video struct {
 	QualityScore float64 `gorm:"default:0" json:"quality_score"`                           // 质量分数
 
 	// 状态
-	Status       int8       `gorm:"default:0;index:idx_hot_query;index:idx_new_query,priority:1" json:"status"` // 状态：0-审核中，1-已发布，2-不通过，3-下架
+	Status       int8       `gorm:"default:0;index:idx_hot_query;index:idx_new_query,priority:1" json:"status"` // 状态：0-审核中，1-已发布，2-不通过，3-下架，4-处理中，5-处理失败
 	IsPublic     bool       `gorm:"default:true" json:"is_public"`                                               // 是否公开
 	AllowComment bool       `gorm:"default:true" json:"allow_comment"`                                           // 是否允许评论
 	IsTop        bool       `gorm:"default:false;index" json:"is_top"`                                           // 是否置顶
 	PublishedAt  *time.Time `gorm:"index:idx_hot_query,priority:3;index:idx_new_query,priority:2" json:"published_at"` // 发布时间
 
+	// 直播回放
+	LiveID *uint `gorm:"index" json:"live_id,omitempty"` // 回放对应的直播间ID（普通视频为空）
+
 	// 关联
 	User     *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Category *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
@@ -56,6 +60,16 @@ func (Video) TableName() string {
 	return "videos"
 }
 
+// 视频状态
+const (
+	VideoStatusPending       int8 = 0 // 审核中
+	VideoStatusPublished     int8 = 1 // 已发布
+	VideoStatusRejected      int8 = 2 // 不通过
+	VideoStatusRemoved       int8 = 3 // 下架
+	VideoStatusProcessing    int8 = 4 // 处理中（上传后等待转码）
+	VideoStatusProcessFailed int8 = 5 // 处理失败
+)
+
 // Category 视频分类
 type Category struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
@@ -106,13 +120,89 @@ func (VideoHashtag) TableName() string {
 	return "video_hashtags"
 }
 
+// 视频处理阶段（按顺序执行）
+const (
+	VideoProcessStageProbe     = "probe"     // 探测元数据
+	VideoProcessStageCover     = "cover"     // 抽取封面
+	VideoProcessStageTranscode = "transcode" // HLS 转码
+	VideoProcessStagePublish   = "publish"   // 发布
+)
+
+// VideoProcessStages 视频处理阶段顺序
+var VideoProcessStages = []string{
+	VideoProcessStageProbe,
+	VideoProcessStageCover,
+	VideoProcessStageTranscode,
+	VideoProcessStagePublish,
+}
+
+// 视频处理任务状态
+const (
+	VideoProcessJobPending   = "pending"   // 等待执行（包括失败后等待重试）
+	VideoProcessJobRunning   = "running"   // 执行中
+	VideoProcessJobSucceeded = "succeeded" // 已完成
+	VideoProcessJobFailed    = "failed"    // 重试耗尽后失败
+)
+
+// VideoProcessJob 视频处理任务（上传后的探测、封面、转码和发布）
+type VideoProcessJob struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	VideoID uint `gorm:"index;not null" json:"video_id"` // 视频ID
+	UserID  uint `gorm:"index;not null" json:"user_id"`  // 上传者ID
+
+	// 任务进度
+	Status      string     `gorm:"size:20;default:'pending';index:idx_video_job_queue,priority:1" json:"status"` // 状态：pending, running, succeeded, failed
+	Stage       string     `gorm:"size:20;not null" json:"stage"`                                                // 当前阶段：probe, cover, transcode, publish
+	Attempts    int        `gorm:"default:0" json:"attempts"`                                                    // 当前阶段已尝试次数
+	MaxAttempts int        `gorm:"default:3" json:"max_attempts"`                                                // 每个阶段的最大尝试次数
+	NextRunAt   time.Time  `gorm:"index:idx_video_job_queue,priority:2" json:"next_run_at"`                      // 下次可执行时间（重试退避）
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`                                        // 最近一次失败原因
+	LockedBy    string     `gorm:"size:64" json:"-"`                                                             // 持有任务的 worker
+	LockedAt    *time.Time `json:"-"`                                                                            // 最近一次续约时间
+	StartedAt   *time.Time `json:"started_at"`                                                                   // 首次开始执行时间
+	FinishedAt  *time.Time `json:"finished_at"`                                                                  // 完成或最终失败时间
+
+	// 输入文件
+	SourcePath  string `gorm:"size:500;not null" json:"-"` // 原始视频路径
+	CoverPath   string `gorm:"size:500;not null" json:"-"` // 封面输出路径
+	CustomCover bool   `gorm:"default:false" json:"-"`     // 是否使用上传的自定义封面（跳过抽帧）
+	KeepSource  bool   `gorm:"default:false" json:"-"`     // 发布后保留原始文件（如流媒体服务器的录制文件）
+	HLSDir      string `gorm:"size:500;not null" json:"-"` // HLS 输出目录
+
+	// 阶段产出
+	Duration   int    `gorm:"default:0" json:"duration"`  // 视频时长（秒）
+	Width      int    `gorm:"default:0" json:"width"`     // 视频宽度
+	Height     int    `gorm:"default:0" json:"height"`    // 视频高度
+	VideoURL   string `gorm:"size:255" json:"video_url"`  // HLS 主播放列表地址
+	CoverURL   string `gorm:"size:255" json:"cover_url"`  // 封面地址
+	Renditions string `gorm:"size:100" json:"renditions"` // 转码输出的档位（逗号分隔）
+}
+
+// TableName 指定表名
+func (VideoProcessJob) TableName() string {
+	return "video_process_jobs"
+}
+
 // VideoVO 视频信息视图对象
 type VideoVO struct {
 	*Video
-	User        *AuthorVO `json:"user,omitempty"` // 作者信息
-	IsLiked     bool      `json:"is_liked"`       // 是否已点赞
-	IsFavorited bool      `json:"is_favorited"`   // 是否已收藏
-	IsFollowed  bool      `json:"is_followed"`    // 是否已关注作者
+	MasterURL   string    `json:"master_url,omitempty"` // 多码率主播放列表地址
+	Renditions  []string  `json:"renditions,omitempty"` // 可用的码率档位
+	User        *AuthorVO `json:"user,omitempty"`       // 作者信息
+	IsLiked     bool      `json:"is_liked"`             // 是否已点赞
+	IsFavorited bool      `json:"is_favorited"`         // 是否已收藏
+	IsFollowed  bool      `json:"is_followed"`          // 是否已关注作者
+
+	Highlights map[string]string `json:"highlights,omitempty"` // 搜索命中的高亮片段（字段名 -> 片段）
+}
+
+// HashtagVO 话题视图对象
+type HashtagVO struct {
+	*Hashtag
+	Highlights map[string]string `json:"highlights,omitempty"` // 搜索命中的高亮片段
 }
 
 // MyVideoVO 用户自己查看作品时的视图对象
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// ==================== 钱包相关 ====================
+
+// 钱包交易类型
+const (
+	WalletTxnTypeRecharge = "recharge" // 充值
+	WalletTxnTypeGift     = "gift"     // 送礼消费
+	WalletTxnTypeSpend    = "spend"    // 其他消费
+
+	WalletTxnTypeCommission         = "commission"          // 带货佣金入账
+	WalletTxnTypeCommissionReversal = "commission_reversal" // 带货佣金冲回（订单退款）
+)
+
+// 钱包记账账户
+const (
+	WalletAccountBalance          = "user_balance"      // 用户可用余额
+	WalletAccountEarnings         = "user_earnings"     // 主播收益
+	WalletAccountPlatformRevenue  = "platform_revenue"  // 平台抽成收入
+	WalletAccountPlatformFunding  = "platform_recharge" // 平台充值资金来源（充值入账的对方科目）
+	WalletAccountCommission       = "user_commission"   // 主播带货佣金（分）
+	WalletAccountPlatformCommerce = "platform_commerce" // 平台带货佣金支出（佣金入账的对方科目）
+)
+
+// Wallet 用户钱包（虚拟币）
+type Wallet struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID         uint  `gorm:"uniqueIndex;not null" json:"user_id"`  // 用户ID
+	Balance        int64 `gorm:"not null;default:0" json:"balance"`    // 可用余额（虚拟币）
+	Earnings       int64 `gorm:"not null;default:0" json:"earnings"`   // 主播收益（虚拟币，扣除平台抽成后）
+	Commission     int64 `gorm:"not null;default:0" json:"commission"` // 带货佣金（分，订单退款时冲回）
+	TotalRecharged int64 `gorm:"default:0" json:"total_recharged"`     // 累计充值
+	TotalSpent     int64 `gorm:"default:0" json:"total_spent"`         // 累计消费
+	TotalEarned    int64 `gorm:"default:0" json:"total_earned"`        // 累计收益
+}
+
+// TableName 指定表名
+func (Wallet) TableName() string {
+	return "wallets"
+}
+
+// WalletTransaction 钱包交易（一次业务操作，对应多条记账分录）
+// (user_id, request_id) 唯一，用于充值、消费接口的幂等
+type WalletTransaction struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID    uint   `gorm:"index;not null" json:"user_id"`      // 发起用户ID
+	RequestID string `gorm:"size:64;not null" json:"request_id"` // 客户端请求ID（幂等键）
+	Type      string `gorm:"size:20;index;not null" json:"type"` // 交易类型：recharge, gift, spend, commission, commission_reversal
+	Amount    int64  `gorm:"not null" json:"amount"`             // 交易金额（虚拟币）
+	BizType   string `gorm:"size:32" json:"biz_type"`            // 业务类型：live_gift, live_order 等
+	BizID     uint   `gorm:"index" json:"biz_id"`                // 业务ID（如送礼记录ID、订单ID）
+	Remark    string `gorm:"size:200" json:"remark"`             // 备注
+
+	// 关联
+	Entries []WalletLedgerEntry `gorm:"foreignKey:TransactionID" json:"entries,omitempty"`
+}
+
+// TableName 指定表名
+func (WalletTransaction) TableName() string {
+	return "wallet_transactions"
+}
+
+// WalletLedgerEntry 钱包记账分录（只追加，不修改、不删除）
+// 同一交易下所有分录的 Amount 之和为 0（复式记账）
+type WalletLedgerEntry struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	TransactionID uint   `gorm:"index;not null" json:"transaction_id"`  // 交易ID
+	Account       string `gorm:"size:32;index;not null" json:"account"` // 记账账户：user_balance, user_earnings, user_commission, platform_revenue, platform_recharge, platform_commerce
+	UserID        uint   `gorm:"index" json:"user_id"`                  // 账户所属用户ID（平台账户为0）
+	Amount        int64  `gorm:"not null" json:"amount"`                // 变动金额（正数入账，负数出账）
+	BalanceAfter  int64  `json:"balance_after"`                         // 记账后账户余额（平台账户不维护余额，为0）
+}
+
+// TableName 指定表名
+func (WalletLedgerEntry) TableName() string {
+	return "wallet_ledger_entries"
+}
@@ -13,6 +13,8 @@ type BlacklistRepository interface {
 	Delete(ctx context.Context, userID, blockedUserID uint) error
 	IsBlocked(ctx context.Context, userID, blockedUserID uint) (bool, error)
 	FindByUserID(ctx context.Context, userID uint, limit, offset int) ([]*model.Blacklist, int64, error)
+	// FindBlockersOf 返回 userIDs 中已将 blockedUserID 拉黑的用户
+	FindBlockersOf(ctx context.Context, blockedUserID uint, userIDs []uint) ([]uint, error)
 }
 
 type blacklistRepositoryImpl struct {
@@ -63,3 +65,16 @@ func (r *blacklistRepositoryImpl) FindByUserID(ctx context.Context, userID uint,
 
 	return blacklists, total, err
 }
+
+func (r *blacklistRepositoryImpl) FindBlockersOf(ctx context.Context, blockedUserID uint, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var blockers []uint
+	err := r.db.WithContext(ctx).
+		Model(&model.Blacklist{}).
+		Where("blocked_user_id = ? AND user_id IN ?", blockedUserID, userIDs).
+		Pluck("user_id", &blockers).Error
+	return blockers, err
+}
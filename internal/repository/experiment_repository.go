@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExperimentRepository A/B 实验数据访问层接口
+type ExperimentRepository interface {
+	// Create 创建实验（连同变体）
+	Create(ctx context.Context, experiment *model.Experiment) error
+	// FindByID 查询实验（包含变体）
+	FindByID(ctx context.Context, id uint) (*model.Experiment, error)
+	// FindAll 分页查询实验，status < 0 时不过滤状态
+	FindAll(ctx context.Context, status int8, limit, offset int) ([]*model.Experiment, int64, error)
+	// FindRunning 查询运行中的实验（包含变体）
+	FindRunning(ctx context.Context) ([]*model.Experiment, error)
+	// UpdateFields 更新实验字段
+	UpdateFields(ctx context.Context, id uint, fields map[string]interface{}) error
+
+	// CreateBehaviorTags 批量写入行为的实验标记
+	CreateBehaviorTags(ctx context.Context, tags []*model.UserBehaviorExperiment) error
+	// IncrementImpressions 原子累加变体当天的曝光数
+	IncrementImpressions(ctx context.Context, experimentID, variantID uint, date time.Time, count int) error
+	// SumImpressions 统计时间范围内各变体的曝光数（变体ID -> 曝光数）
+	SumImpressions(ctx context.Context, experimentID uint, from, to time.Time) (map[uint]int64, error)
+	// AggregateVariantMetrics 按变体聚合时间范围内带实验标记的行为
+	AggregateVariantMetrics(ctx context.Context, experimentID uint, from, to time.Time) ([]*ExperimentVariantMetrics, error)
+}
+
+// ExperimentVariantMetrics 实验变体的行为聚合
+// 同一用户对同一视频的多次上报只计一次
+type ExperimentVariantMetrics struct {
+	VariantID uint  `json:"variant_id"`
+	Users     int64 `json:"users"`    // 产生行为的用户数
+	Views     int64 `json:"views"`    // 播放的（用户, 视频）数
+	Finishes  int64 `json:"finishes"` // 完播的（用户, 视频）数
+	Likes     int64 `json:"likes"`    // 播放后点赞的（用户, 视频）数
+}
+
+type experimentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewExperimentRepository 创建 A/B 实验数据访问层实例
+func NewExperimentRepository(db *gorm.DB) ExperimentRepository {
+	return &experimentRepositoryImpl{db: db}
+}
+
+// Create 创建实验（连同变体）
+func (r *experimentRepositoryImpl) Create(ctx context.Context, experiment *model.Experiment) error {
+	return r.db.WithContext(ctx).Create(experiment).Error
+}
+
+// FindByID 查询实验（包含变体）
+func (r *experimentRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.Experiment, error) {
+	var experiment model.Experiment
+	if err := r.db.WithContext(ctx).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&experiment, id).Error; err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// FindAll 分页查询实验
+func (r *experimentRepositoryImpl) FindAll(ctx context.Context, status int8, limit, offset int) ([]*model.Experiment, int64, error) {
+	var experiments []*model.Experiment
+	var total int64
+	db := r.db.WithContext(ctx).Model(&model.Experiment{})
+	if status >= 0 {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Limit(limit).Offset(offset).Order("created_at DESC").Find(&experiments).Error
+	return experiments, total, err
+}
+
+// FindRunning 查询运行中的实验（包含变体）
+func (r *experimentRepositoryImpl) FindRunning(ctx context.Context) ([]*model.Experiment, error) {
+	var experiments []*model.Experiment
+	err := r.db.WithContext(ctx).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("status = ?", model.ExperimentStatusRunning).
+		Order("id ASC").
+		Find(&experiments).Error
+	return experiments, err
+}
+
+// UpdateFields 更新实验字段
+func (r *experimentRepositoryImpl) UpdateFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Experiment{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// CreateBehaviorTags 批量写入行为的实验标记
+func (r *experimentRepositoryImpl) CreateBehaviorTags(ctx context.Context, tags []*model.UserBehaviorExperiment) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&tags).Error
+}
+
+// IncrementImpressions 原子累加变体当天的曝光数
+func (r *experimentRepositoryImpl) IncrementImpressions(ctx context.Context, experimentID, variantID uint, date time.Time, count int) error {
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO experiment_daily_stats (experiment_id, variant_id, date, impressions, created_at, updated_at)
+		VALUES (?, ?, ?, ?, NOW(), NOW())
+		ON CONFLICT (variant_id, date) DO UPDATE SET
+			impressions = experiment_daily_stats.impressions + EXCLUDED.impressions,
+			updated_at = NOW()
+	`, experimentID, variantID, date, count).Error
+}
+
+// SumImpressions 统计时间范围内各变体的曝光数
+func (r *experimentRepositoryImpl) SumImpressions(ctx context.Context, experimentID uint, from, to time.Time) (map[uint]int64, error) {
+	var rows []struct {
+		VariantID   uint
+		Impressions int64
+	}
+	if err := r.db.WithContext(ctx).Model(&model.ExperimentDailyStats{}).
+		Select("variant_id, COALESCE(SUM(impressions), 0) AS impressions").
+		Where("experiment_id = ? AND date >= ? AND date < ?", experimentID, from, to).
+		Group("variant_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		result[row.VariantID] = row.Impressions
+	}
+	return result, nil
+}
+
+// AggregateVariantMetrics 按变体聚合时间范围内带实验标记的行为
+// 播放和完播来自 user_behaviors，点赞按（用户, 视频）关联 likes
+func (r *experimentRepositoryImpl) AggregateVariantMetrics(ctx context.Context, experimentID uint, from, to time.Time) ([]*ExperimentVariantMetrics, error) {
+	var metrics []*ExperimentVariantMetrics
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT ube.variant_id,
+			COUNT(DISTINCT ub.user_id) AS users,
+			COUNT(DISTINCT (ub.user_id, ub.video_id)) AS views,
+			COUNT(DISTINCT (ub.user_id, ub.video_id)) FILTER (WHERE ub.action = 6) AS finishes,
+			COUNT(DISTINCT (ub.user_id, ub.video_id)) FILTER (
+				WHERE EXISTS (SELECT 1 FROM likes l WHERE l.user_id = ub.user_id AND l.video_id = ub.video_id)
+			) AS likes
+		FROM user_behavior_experiments ube
+		INNER JOIN user_behaviors ub ON ub.id = ube.behavior_id
+		WHERE ube.experiment_id = ? AND ub.created_at >= ? AND ub.created_at < ?
+		GROUP BY ube.variant_id
+	`, experimentID, from, to).Scan(&metrics).Error
+	return metrics, err
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrFolderNotEmpty 私密收藏夹内仍有收藏，不能删除
+var ErrFolderNotEmpty = errors.New("favorite folder not empty")
+
+// FavoriteFolderRepository 收藏夹数据访问层接口
+type FavoriteFolderRepository interface {
+	// Create 创建收藏夹
+	Create(ctx context.Context, folder *model.FavoriteFolder) error
+	// Update 更新收藏夹
+	Update(ctx context.Context, folder *model.FavoriteFolder) error
+	// Delete 删除收藏夹，公开收藏夹内的收藏移回默认收藏夹
+	// 默认收藏夹对外可见，私密收藏夹内仍有收藏时返回 ErrFolderNotEmpty，避免私密收藏被公开
+	Delete(ctx context.Context, id uint) error
+	// FindByID 根据ID查询收藏夹
+	FindByID(ctx context.Context, id uint) (*model.FavoriteFolder, error)
+	// FindByUserID 查询用户的收藏夹列表（publicOnly 为 true 时只返回公开收藏夹）
+	FindByUserID(ctx context.Context, userID uint, publicOnly bool) ([]*model.FavoriteFolder, error)
+	// CountByUserID 统计用户收藏夹数量
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+}
+
+type favoriteFolderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewFavoriteFolderRepository 创建收藏夹数据访问层实例
+func NewFavoriteFolderRepository(db *gorm.DB) FavoriteFolderRepository {
+	return &favoriteFolderRepositoryImpl{db: db}
+}
+
+// Create 创建收藏夹
+func (r *favoriteFolderRepositoryImpl) Create(ctx context.Context, folder *model.FavoriteFolder) error {
+	return r.db.WithContext(ctx).Create(folder).Error
+}
+
+// Update 更新收藏夹
+func (r *favoriteFolderRepositoryImpl) Update(ctx context.Context, folder *model.FavoriteFolder) error {
+	return r.db.WithContext(ctx).
+		Model(folder).
+		Select("name", "description", "cover_url", "is_public").
+		Updates(folder).Error
+}
+
+// Delete 删除收藏夹
+func (r *favoriteFolderRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 锁定收藏夹，避免与修改公开状态的请求并发
+		var folder model.FavoriteFolder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&folder, id).Error; err != nil {
+			return err
+		}
+
+		// 2. 私密收藏夹：仍有收藏时拒绝删除；公开收藏夹：收藏移回默认收藏夹
+		if !folder.IsPublic {
+			var count int64
+			if err := tx.Model(&model.Favorite{}).Where("folder_id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrFolderNotEmpty
+			}
+		} else if err := tx.Model(&model.Favorite{}).
+			Where("folder_id = ?", id).
+			Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+
+		// 3. 删除收藏夹
+		return tx.Delete(&model.FavoriteFolder{}, id).Error
+	})
+}
+
+// FindByID 根据ID查询收藏夹
+func (r *favoriteFolderRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.FavoriteFolder, error) {
+	var folder model.FavoriteFolder
+	if err := r.db.WithContext(ctx).First(&folder, id).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindByUserID 查询用户的收藏夹列表
+func (r *favoriteFolderRepositoryImpl) FindByUserID(ctx context.Context, userID uint, publicOnly bool) ([]*model.FavoriteFolder, error) {
+	var folders []*model.FavoriteFolder
+	db := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if publicOnly {
+		db = db.Where("is_public = ?", true)
+	}
+	err := db.Order("created_at ASC").Find(&folders).Error
+	return folders, err
+}
+
+// CountByUserID 统计用户收藏夹数量
+func (r *favoriteFolderRepositoryImpl) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.FavoriteFolder{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// refreshFolderVideoCount 根据收藏记录重新统计收藏夹视频数量
+func refreshFolderVideoCount(tx *gorm.DB, folderIDs ...uint) error {
+	if len(folderIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&model.FavoriteFolder{}).
+		Where("id IN ?", folderIDs).
+		Update("video_count", gorm.Expr("(SELECT COUNT(*) FROM favorites WHERE favorites.folder_id = favorite_folders.id)")).Error
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newMockDB 创建基于 sqlmock 的 gorm 连接，测试结束时校验所有预期 SQL 都已执行
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New failed: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open failed: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet sql expectations: %v", err)
+		}
+	})
+	return db, mock
+}
+
+// expectLockFolder 预期锁定收藏夹的查询
+func expectLockFolder(mock sqlmock.Sqlmock, id uint, isPublic bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "favorite_folders" WHERE "favorite_folders"."id" = $1`)+`.*FOR UPDATE`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "is_public"}).AddRow(id, 7, "folder", isPublic))
+}
+
+// expectCountFavorites 预期统计收藏夹内收藏数量的查询
+func expectCountFavorites(mock sqlmock.Sqlmock, id uint, count int64) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "favorites" WHERE folder_id = $1`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+// expectSoftDeleteFolder 预期软删除收藏夹
+func expectSoftDeleteFolder(mock sqlmock.Sqlmock, id uint) {
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "favorite_folders" SET "deleted_at"=$1 WHERE "favorite_folders"."id" = $2`)).
+		WithArgs(sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestFavoriteFolderDelete_PrivateFolderWithFavoritesStaysPrivate(t *testing.T) {
+	db, mock := newMockDB(t)
+	ctx := context.Background()
+
+	// 删除被拒绝且不修改收藏的 folder_id（folder_id 为空的收藏对外可见）
+	mock.ExpectBegin()
+	expectLockFolder(mock, 3, false)
+	expectCountFavorites(mock, 3, 2)
+	mock.ExpectRollback()
+
+	err := NewFavoriteFolderRepository(db).Delete(ctx, 3)
+	if !errors.Is(err, ErrFolderNotEmpty) {
+		t.Fatalf("expected ErrFolderNotEmpty, got %v", err)
+	}
+
+	// 公开收藏列表只包含未归入收藏夹或归入公开收藏夹的收藏，私密收藏夹内的收藏仍不可见
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "favorites" WHERE user_id = $1 AND (folder_id IS NULL OR folder_id IN (SELECT "id" FROM "favorite_folders" WHERE is_public = $2 AND "favorite_folders"."deleted_at" IS NULL))`)).
+		WithArgs(7, true, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "video_id", "folder_id"}))
+
+	favorites, err := NewFavoriteRepository(db).FindPublicByUserID(ctx, 7, 20, 0)
+	if err != nil {
+		t.Fatalf("FindPublicByUserID failed: %v", err)
+	}
+	if len(favorites) != 0 {
+		t.Fatalf("expected no public favorites, got %d", len(favorites))
+	}
+}
+
+func TestFavoriteFolderDelete_EmptyPrivateFolder(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	expectLockFolder(mock, 3, false)
+	expectCountFavorites(mock, 3, 0)
+	expectSoftDeleteFolder(mock, 3)
+	mock.ExpectCommit()
+
+	if err := NewFavoriteFolderRepository(db).Delete(context.Background(), 3); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+}
+
+func TestFavoriteFolderDelete_PublicFolderMovesFavoritesToDefault(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	expectLockFolder(mock, 4, true)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "favorites" SET "folder_id"=$1 WHERE folder_id = $2`)).
+		WithArgs(nil, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectSoftDeleteFolder(mock, 4)
+	mock.ExpectCommit()
+
+	if err := NewFavoriteFolderRepository(db).Delete(context.Background(), 4); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+}
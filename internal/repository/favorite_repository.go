@@ -23,6 +23,16 @@ type FavoriteRepository interface {
 	CountByUserID(ctx context.Context, userID uint) (int64, error)
 	// FindByVideoID 查找视频的收藏列表
 	FindByVideoID(ctx context.Context, videoID uint, limit, offset int) ([]*model.Favorite, error)
+	// FindPublicByUserID 查找用户对外可见的收藏列表（默认收藏夹和公开收藏夹）
+	FindPublicByUserID(ctx context.Context, userID uint, limit, offset int) ([]*model.Favorite, error)
+	// CountPublicByUserID 统计用户对外可见的收藏数量
+	CountPublicByUserID(ctx context.Context, userID uint) (int64, error)
+	// FindByFolderID 查找收藏夹内的收藏列表
+	FindByFolderID(ctx context.Context, folderID uint, limit, offset int) ([]*model.Favorite, error)
+	// CountByFolderID 统计收藏夹内的收藏数量
+	CountByFolderID(ctx context.Context, folderID uint) (int64, error)
+	// MoveToFolder 将用户的若干收藏移动到指定收藏夹（folderID 为 nil 表示默认收藏夹），返回移动数量
+	MoveToFolder(ctx context.Context, userID uint, videoIDs []uint, folderID *uint) (int64, error)
 }
 
 // favoriteRepositoryImpl 收藏数据访问层实现
@@ -41,7 +51,16 @@ func NewFavoriteRepository(db *gorm.DB) FavoriteRepository {
 func (r *favoriteRepositoryImpl) Create(ctx context.Context, favorite *model.Favorite) error {
 	logger.Debug("创建收藏记录", zap.Uint("user_id", favorite.UserID), zap.Uint("video_id", favorite.VideoID))
 
-	if err := r.db.WithContext(ctx).Create(favorite).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(favorite).Error; err != nil {
+			return err
+		}
+		if favorite.FolderID != nil {
+			return refreshFolderVideoCount(tx, *favorite.FolderID)
+		}
+		return nil
+	})
+	if err != nil {
 		logger.Error("创建收藏记录失败", zap.Error(err))
 		return err
 	}
@@ -54,9 +73,20 @@ func (r *favoriteRepositoryImpl) Create(ctx context.Context, favorite *model.Fav
 func (r *favoriteRepositoryImpl) Delete(ctx context.Context, userID, videoID uint) error {
 	logger.Debug("删除收藏记录", zap.Uint("user_id", userID), zap.Uint("video_id", videoID))
 
-	if err := r.db.WithContext(ctx).
-		Where("user_id = ? AND video_id = ?", userID, videoID).
-		Delete(&model.Favorite{}).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var favorite model.Favorite
+		if err := tx.Where("user_id = ? AND video_id = ?", userID, videoID).First(&favorite).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&favorite).Error; err != nil {
+			return err
+		}
+		if favorite.FolderID != nil {
+			return refreshFolderVideoCount(tx, *favorite.FolderID)
+		}
+		return nil
+	})
+	if err != nil {
 		logger.Error("删除收藏记录失败", zap.Error(err))
 		return err
 	}
@@ -129,3 +159,113 @@ func (r *favoriteRepositoryImpl) FindByVideoID(ctx context.Context, videoID uint
 
 	return favorites, nil
 }
+
+// publicFavoriteScope 对外可见的收藏：未归入收藏夹，或归入未删除的公开收藏夹
+func publicFavoriteScope(db *gorm.DB) *gorm.DB {
+	return db.Where("folder_id IS NULL OR folder_id IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).
+			Model(&model.FavoriteFolder{}).
+			Select("id").
+			Where("is_public = ?", true))
+}
+
+// FindPublicByUserID 查找用户对外可见的收藏列表
+func (r *favoriteRepositoryImpl) FindPublicByUserID(ctx context.Context, userID uint, limit, offset int) ([]*model.Favorite, error) {
+	var favorites []*model.Favorite
+	if err := r.db.WithContext(ctx).
+		Preload("Video").
+		Where("user_id = ?", userID).
+		Scopes(publicFavoriteScope).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&favorites).Error; err != nil {
+		logger.Error("查找用户公开收藏列表失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, err
+	}
+
+	return favorites, nil
+}
+
+// CountPublicByUserID 统计用户对外可见的收藏数量
+func (r *favoriteRepositoryImpl) CountPublicByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.Favorite{}).
+		Where("user_id = ?", userID).
+		Scopes(publicFavoriteScope).
+		Count(&count).Error; err != nil {
+		logger.Error("统计用户公开收藏数量失败", zap.Error(err), zap.Uint("user_id", userID))
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindByFolderID 查找收藏夹内的收藏列表
+func (r *favoriteRepositoryImpl) FindByFolderID(ctx context.Context, folderID uint, limit, offset int) ([]*model.Favorite, error) {
+	var favorites []*model.Favorite
+	if err := r.db.WithContext(ctx).
+		Preload("Video").
+		Where("folder_id = ?", folderID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&favorites).Error; err != nil {
+		logger.Error("查找收藏夹收藏列表失败", zap.Error(err), zap.Uint("folder_id", folderID))
+		return nil, err
+	}
+
+	return favorites, nil
+}
+
+// CountByFolderID 统计收藏夹内的收藏数量
+func (r *favoriteRepositoryImpl) CountByFolderID(ctx context.Context, folderID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.Favorite{}).
+		Where("folder_id = ?", folderID).
+		Count(&count).Error; err != nil {
+		logger.Error("统计收藏夹收藏数量失败", zap.Error(err), zap.Uint("folder_id", folderID))
+		return 0, err
+	}
+	return count, nil
+}
+
+// MoveToFolder 将用户的若干收藏移动到指定收藏夹（同时刷新相关收藏夹的视频数量）
+func (r *favoriteRepositoryImpl) MoveToFolder(ctx context.Context, userID uint, videoIDs []uint, folderID *uint) (int64, error) {
+	logger.Debug("移动收藏", zap.Uint("user_id", userID), zap.Int("count", len(videoIDs)))
+
+	var moved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 记录原收藏夹，用于刷新数量
+		var oldFolderIDs []uint
+		if err := tx.Model(&model.Favorite{}).
+			Where("user_id = ? AND video_id IN ? AND folder_id IS NOT NULL", userID, videoIDs).
+			Distinct().
+			Pluck("folder_id", &oldFolderIDs).Error; err != nil {
+			return err
+		}
+
+		// 2. 移动收藏
+		result := tx.Model(&model.Favorite{}).
+			Where("user_id = ? AND video_id IN ?", userID, videoIDs).
+			Update("folder_id", folderID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		// 3. 刷新原收藏夹和目标收藏夹的视频数量
+		affected := oldFolderIDs
+		if folderID != nil {
+			affected = append(affected, *folderID)
+		}
+		return refreshFolderVideoCount(tx, affected...)
+	})
+	if err != nil {
+		logger.Error("移动收藏失败", zap.Error(err), zap.Uint("user_id", userID))
+		return 0, err
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,392 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+	pkgerrors "microvibe-go/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupConversationRepository 群聊数据访问接口
+type GroupConversationRepository interface {
+	// CreateGroup 创建群聊及初始成员
+	CreateGroup(ctx context.Context, conversation *model.Conversation, members []*model.ConversationMember) error
+
+	// FindGroup 根据ID查询群聊
+	FindGroup(ctx context.Context, conversationID uint) (*model.Conversation, error)
+
+	// UpdateGroup 更新群名称、头像和公告
+	UpdateGroup(ctx context.Context, conversation *model.Conversation) error
+
+	// FindMember 查询群成员
+	FindMember(ctx context.Context, conversationID, userID uint) (*model.ConversationMember, error)
+
+	// ListMembers 分页查询群成员（群主、管理员在前）
+	ListMembers(ctx context.Context, conversationID uint, page, pageSize int) ([]*model.ConversationMember, int64, error)
+
+	// ListMemberIDs 查询所有群成员ID
+	ListMemberIDs(ctx context.Context, conversationID uint) ([]uint, error)
+
+	// AddMembers 添加群成员（已在群内的用户忽略），返回实际加入的用户ID
+	AddMembers(ctx context.Context, conversationID uint, members []*model.ConversationMember) ([]uint, error)
+
+	// RemoveMember 移除群成员
+	RemoveMember(ctx context.Context, conversationID, userID uint) error
+
+	// LeaveGroup 成员退出群聊；群主退出时转让给最早加入的管理员或成员，最后一人退出时解散群聊
+	// 返回新群主ID（未发生转让时为空）
+	LeaveGroup(ctx context.Context, conversationID, userID uint) (*uint, error)
+
+	// UpdateMemberRole 设置成员角色（成员/管理员）
+	UpdateMemberRole(ctx context.Context, conversationID, userID uint, role int8) error
+
+	// TransferOwner 转让群主（原群主变为普通成员）
+	TransferOwner(ctx context.Context, conversationID, fromUserID, toUserID uint) error
+
+	// CreateGroupMessage 创建群消息，并为接收者增加未读数、写入同步事件
+	CreateGroupMessage(ctx context.Context, message *model.Message, recipientIDs []uint) error
+
+	// ListGroupMessages 分页查询群消息（过滤查看者已拉黑用户的消息）
+	ListGroupMessages(ctx context.Context, conversationID, viewerID uint, page, pageSize int) ([]*model.Message, int64, error)
+
+	// MarkGroupAsRead 将群聊标记为已读到最后一条消息
+	MarkGroupAsRead(ctx context.Context, conversationID, userID uint) error
+}
+
+type groupConversationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewGroupConversationRepository 创建群聊Repository
+func NewGroupConversationRepository(db *gorm.DB) GroupConversationRepository {
+	return &groupConversationRepositoryImpl{db: db}
+}
+
+// CreateGroup 创建群聊及初始成员
+func (r *groupConversationRepositoryImpl) CreateGroup(ctx context.Context, conversation *model.Conversation, members []*model.ConversationMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		conversation.Type = model.ConversationTypeGroup
+		conversation.MemberCount = len(members)
+		if err := tx.Omit(clause.Associations).Create(conversation).Error; err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			member.ConversationID = conversation.ID
+		}
+		return tx.Omit(clause.Associations).Create(&members).Error
+	})
+}
+
+// FindGroup 根据ID查询群聊
+func (r *groupConversationRepositoryImpl) FindGroup(ctx context.Context, conversationID uint) (*model.Conversation, error) {
+	var conversation model.Conversation
+	if err := r.db.WithContext(ctx).
+		Where("id = ? AND type = ?", conversationID, model.ConversationTypeGroup).
+		First(&conversation).Error; err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// UpdateGroup 更新群名称、头像和公告
+func (r *groupConversationRepositoryImpl) UpdateGroup(ctx context.Context, conversation *model.Conversation) error {
+	return r.db.WithContext(ctx).Model(conversation).
+		Select("name", "avatar", "notice").
+		Updates(conversation).Error
+}
+
+// FindMember 查询群成员
+func (r *groupConversationRepositoryImpl) FindMember(ctx context.Context, conversationID, userID uint) (*model.ConversationMember, error) {
+	var member model.ConversationMember
+	if err := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembers 分页查询群成员
+func (r *groupConversationRepositoryImpl) ListMembers(ctx context.Context, conversationID uint, page, pageSize int) ([]*model.ConversationMember, int64, error) {
+	var members []*model.ConversationMember
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ConversationMember{}).
+		Where("conversation_id = ?", conversationID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		Preload("User").
+		Order("role DESC, created_at ASC, id ASC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&members).Error
+
+	return members, total, err
+}
+
+// ListMemberIDs 查询所有群成员ID
+func (r *groupConversationRepositoryImpl) ListMemberIDs(ctx context.Context, conversationID uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Model(&model.ConversationMember{}).
+		Where("conversation_id = ?", conversationID).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// AddMembers 添加群成员
+func (r *groupConversationRepositoryImpl) AddMembers(ctx context.Context, conversationID uint, members []*model.ConversationMember) ([]uint, error) {
+	var added []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, member := range members {
+			member.ConversationID = conversationID
+			result := tx.Omit(clause.Associations).
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(member)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				added = append(added, member.UserID)
+			}
+		}
+
+		if len(added) == 0 {
+			return nil
+		}
+		return refreshMemberCount(tx, conversationID)
+	})
+	return added, err
+}
+
+// RemoveMember 移除群成员
+func (r *groupConversationRepositoryImpl) RemoveMember(ctx context.Context, conversationID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+			Delete(&model.ConversationMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return refreshMemberCount(tx, conversationID)
+	})
+}
+
+// LeaveGroup 成员退出群聊
+func (r *groupConversationRepositoryImpl) LeaveGroup(ctx context.Context, conversationID, userID uint) (*uint, error) {
+	var newOwnerID *uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定会话，避免并发退群时转让给已退出的成员
+		var conversation model.Conversation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&conversation, conversationID).Error; err != nil {
+			return err
+		}
+
+		var member model.ConversationMember
+		if err := tx.Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+			First(&member).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+
+		if member.Role == model.ConversationRoleOwner {
+			var successor model.ConversationMember
+			err := tx.Where("conversation_id = ?", conversationID).
+				Order("role DESC, created_at ASC, id ASC").
+				First(&successor).Error
+			if pkgerrors.IsNotFound(err) {
+				// 最后一名成员退出，解散群聊
+				return tx.Delete(&conversation).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&successor).Update("role", model.ConversationRoleOwner).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&conversation).Update("owner_id", successor.UserID).Error; err != nil {
+				return err
+			}
+			newOwnerID = &successor.UserID
+		}
+
+		return refreshMemberCount(tx, conversationID)
+	})
+	return newOwnerID, err
+}
+
+// UpdateMemberRole 设置成员角色
+func (r *groupConversationRepositoryImpl) UpdateMemberRole(ctx context.Context, conversationID, userID uint, role int8) error {
+	result := r.db.WithContext(ctx).Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ? AND role <> ?", conversationID, userID, model.ConversationRoleOwner).
+		Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TransferOwner 转让群主
+func (r *groupConversationRepositoryImpl) TransferOwner(ctx context.Context, conversationID, fromUserID, toUserID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", conversationID, toUserID).
+			Update("role", model.ConversationRoleOwner)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&model.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", conversationID, fromUserID).
+			Update("role", model.ConversationRoleMember).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Conversation{}).
+			Where("id = ?", conversationID).
+			Update("owner_id", toUserID).Error
+	})
+}
+
+// CreateGroupMessage 创建群消息
+func (r *groupConversationRepositoryImpl) CreateGroupMessage(ctx context.Context, message *model.Message, recipientIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 创建消息
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		// 2. 更新会话最后一条消息
+		if err := tx.Model(&model.Conversation{}).
+			Where("id = ?", *message.ConversationID).
+			Updates(map[string]interface{}{
+				"last_message_id": message.ID,
+				"last_content":    message.Content,
+				"updated_at":      time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		// 3. 发送者视为已读到本条消息，接收者未读数加一
+		now := time.Now()
+		if err := tx.Model(&model.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", *message.ConversationID, message.SenderID).
+			Updates(map[string]interface{}{
+				"last_read_message_id": message.ID,
+				"last_read_at":         now,
+			}).Error; err != nil {
+			return err
+		}
+		if len(recipientIDs) > 0 {
+			if err := tx.Model(&model.ConversationMember{}).
+				Where("conversation_id = ? AND user_id IN ?", *message.ConversationID, recipientIDs).
+				Update("unread_count", gorm.Expr("unread_count + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		// 4. 记录同步事件
+		return appendSyncEventForUsers(tx, model.MessageSyncEvent{
+			Type:           model.MessageSyncEventMessage,
+			ConversationID: message.ConversationID,
+			MessageID:      &message.ID,
+			ActorID:        message.SenderID,
+		}, append([]uint{message.SenderID}, recipientIDs...)...)
+	})
+}
+
+// ListGroupMessages 分页查询群消息
+func (r *groupConversationRepositoryImpl) ListGroupMessages(ctx context.Context, conversationID, viewerID uint, page, pageSize int) ([]*model.Message, int64, error) {
+	var messages []*model.Message
+	var total int64
+
+	blocked := r.db.WithContext(ctx).Model(&model.Blacklist{}).
+		Select("blocked_user_id").
+		Where("user_id = ?", viewerID)
+
+	query := r.db.WithContext(ctx).Model(&model.Message{}).
+		Where("conversation_id = ? AND sender_id NOT IN (?)", conversationID, blocked)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		Preload("Sender").
+		Preload("Video").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&messages).Error
+
+	return messages, total, err
+}
+
+// MarkGroupAsRead 将群聊标记为已读
+func (r *groupConversationRepositoryImpl) MarkGroupAsRead(ctx context.Context, conversationID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var conversation model.Conversation
+		if err := tx.First(&conversation, conversationID).Error; err != nil {
+			return err
+		}
+
+		var member model.ConversationMember
+		if err := tx.Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+			First(&member).Error; err != nil {
+			return err
+		}
+
+		// 没有未读且已读位置是最新时无需更新
+		if member.UnreadCount == 0 && (conversation.LastMessageID == nil ||
+			(member.LastReadMessageID != nil && *member.LastReadMessageID >= *conversation.LastMessageID)) {
+			return nil
+		}
+
+		if err := tx.Model(&member).Updates(map[string]interface{}{
+			"unread_count":         0,
+			"last_read_message_id": conversation.LastMessageID,
+			"last_read_at":         time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		// 已读位置同步给阅读者的其他设备
+		return appendSyncEvent(tx, &model.MessageSyncEvent{
+			UserID:         userID,
+			Type:           model.MessageSyncEventRead,
+			ConversationID: &conversation.ID,
+			MessageID:      conversation.LastMessageID,
+			ActorID:        userID,
+		})
+	})
+}
+
+// refreshMemberCount 按成员表重新计算群成员数
+func refreshMemberCount(tx *gorm.DB, conversationID uint) error {
+	return tx.Model(&model.Conversation{}).
+		Where("id = ?", conversationID).
+		Update("member_count", tx.Model(&model.ConversationMember{}).
+			Select("COUNT(*)").
+			Where("conversation_id = ?", conversationID)).Error
+}
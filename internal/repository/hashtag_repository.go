@@ -13,6 +13,8 @@ type HashtagRepository interface {
 	CreateHashtag(ctx context.Context, hashtag *model.Hashtag) error
 	// GetHashtagByID 根据ID获取话题
 	GetHashtagByID(ctx context.Context, id uint) (*model.Hashtag, error)
+	// GetHashtagsByIDs 根据ID列表批量获取话题
+	GetHashtagsByIDs(ctx context.Context, ids []uint) ([]*model.Hashtag, error)
 	// GetHashtagByName 根据名称获取话题
 	GetHashtagByName(ctx context.Context, name string) (*model.Hashtag, error)
 	// GetHotHashtags 获取热门话题
@@ -25,6 +27,14 @@ type HashtagRepository interface {
 	GetHashtagVideos(ctx context.Context, hashtagID uint, page, pageSize int) ([]*model.Video, int64, error)
 	// AddVideoToHashtag 将视频添加到话题
 	AddVideoToHashtag(ctx context.Context, videoID, hashtagID uint) error
+	// ApplyHashtagTrends 写入趋势计算结果，热度前 topN 的话题标记为热门
+	ApplyHashtagTrends(ctx context.Context, trends []*HashtagTrend, topN int) error
+}
+
+// HashtagTrend 话题趋势计算结果
+type HashtagTrend struct {
+	ID    uint
+	Score float64 // 滑动窗口内按时间衰减后的浏览和投稿次数
 }
 
 // hashtagRepositoryImpl 话题仓储层实现
@@ -49,6 +59,16 @@ func (r *hashtagRepositoryImpl) GetHashtagByID(ctx context.Context, id uint) (*m
 	return &hashtag, err
 }
 
+// GetHashtagsByIDs 根据ID列表批量获取话题
+func (r *hashtagRepositoryImpl) GetHashtagsByIDs(ctx context.Context, ids []uint) ([]*model.Hashtag, error) {
+	var hashtags []*model.Hashtag
+	if len(ids) == 0 {
+		return hashtags, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&hashtags).Error
+	return hashtags, err
+}
+
 // GetHashtagByName 根据名称获取话题
 func (r *hashtagRepositoryImpl) GetHashtagByName(ctx context.Context, name string) (*model.Hashtag, error) {
 	var hashtag model.Hashtag
@@ -76,10 +96,7 @@ func (r *hashtagRepositoryImpl) UpdateHashtag(ctx context.Context, hashtag *mode
 func (r *hashtagRepositoryImpl) IncrementViewCount(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Model(&model.Hashtag{}).
 		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"view_count": gorm.Expr("view_count + 1"),
-			"hot_score":  gorm.Expr("hot_score + 0.01"),
-		}).Error
+		Update("view_count", gorm.Expr("view_count + 1")).Error
 }
 
 // GetHashtagVideos 获取话题下的视频
@@ -141,3 +158,34 @@ func (r *hashtagRepositoryImpl) AddVideoToHashtag(ctx context.Context, videoID,
 			Update("video_count", gorm.Expr("video_count + 1")).Error
 	})
 }
+
+// ApplyHashtagTrends 写入趋势计算结果
+func (r *hashtagRepositoryImpl) ApplyHashtagTrends(ctx context.Context, trends []*HashtagTrend, topN int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 窗口内没有热度的话题归零
+		if err := tx.Model(&model.Hashtag{}).
+			Where("hot_score <> 0 OR is_hot = ?", true).
+			Updates(map[string]interface{}{
+				"hot_score": 0,
+				"is_hot":    false,
+			}).Error; err != nil {
+			return err
+		}
+
+		for _, t := range trends {
+			if err := tx.Model(&model.Hashtag{}).
+				Where("id = ?", t.ID).
+				Update("hot_score", t.Score).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(`
+			UPDATE hashtags SET is_hot = TRUE
+			WHERE id IN (
+				SELECT id FROM hashtags WHERE hot_score > 0
+				ORDER BY hot_score DESC, view_count DESC
+				LIMIT ?
+			)`, topN).Error
+	})
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"microvibe-go/internal/model"
+	"microvibe-go/pkg/cache"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LiveAdminRepository 直播间管理员数据访问接口
+type LiveAdminRepository interface {
+	// Create 任命管理员
+	Create(ctx context.Context, admin *model.LiveAdmin) error
+
+	// Update 更新管理员权限
+	Update(ctx context.Context, admin *model.LiveAdmin) error
+
+	// Delete 撤销管理员
+	Delete(ctx context.Context, liveID, userID uint) error
+
+	// FindByLiveAndUser 查询用户在直播间的管理员记录
+	FindByLiveAndUser(ctx context.Context, liveID, userID uint) (*model.LiveAdmin, error)
+
+	// ListByLiveID 查询直播间的管理员列表
+	ListByLiveID(ctx context.Context, liveID uint) ([]*model.LiveAdmin, error)
+}
+
+type liveAdminRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLiveAdminRepository 创建管理员Repository
+func NewLiveAdminRepository(db *gorm.DB) LiveAdminRepository {
+	return &liveAdminRepositoryImpl{db: db}
+}
+
+// Create 任命管理员（自动清除缓存）
+func (r *liveAdminRepositoryImpl) Create(ctx context.Context, admin *model.LiveAdmin) error {
+	key := fmt.Sprintf("live:admin:lu:%d:%d", admin.LiveID, admin.UserID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "liveadmin",
+			KeyPrefix: "live:admin:lu",
+		},
+		func() error {
+			return r.db.WithContext(ctx).Create(admin).Error
+		},
+	)(ctx, key)
+}
+
+// Update 更新管理员权限（自动清除缓存）
+func (r *liveAdminRepositoryImpl) Update(ctx context.Context, admin *model.LiveAdmin) error {
+	key := fmt.Sprintf("live:admin:lu:%d:%d", admin.LiveID, admin.UserID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "liveadmin",
+			KeyPrefix: "live:admin:lu",
+		},
+		func() error {
+			// 布尔权限字段带 default 标签，使用 Select 保证 false 也能写入
+			return r.db.WithContext(ctx).
+				Model(admin).
+				Select("role", "can_ban", "can_kick", "can_pin", "can_manage").
+				Updates(admin).Error
+		},
+	)(ctx, key)
+}
+
+// Delete 撤销管理员（自动清除缓存）
+func (r *liveAdminRepositoryImpl) Delete(ctx context.Context, liveID, userID uint) error {
+	key := fmt.Sprintf("live:admin:lu:%d:%d", liveID, userID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "liveadmin",
+			KeyPrefix: "live:admin:lu",
+		},
+		func() error {
+			return r.db.WithContext(ctx).
+				Where("live_id = ? AND user_id = ?", liveID, userID).
+				Delete(&model.LiveAdmin{}).Error
+		},
+	)(ctx, key)
+}
+
+// FindByLiveAndUser 查询用户在直播间的管理员记录（使用Redis缓存）
+func (r *liveAdminRepositoryImpl) FindByLiveAndUser(ctx context.Context, liveID, userID uint) (*model.LiveAdmin, error) {
+	cacheKey := fmt.Sprintf("%d:%d", liveID, userID)
+
+	return cache.WithCache(
+		cache.CacheConfig{
+			CacheName: "liveadmin",
+			KeyPrefix: "live:admin:lu",
+			TTL:       10 * time.Minute,
+		},
+		func() (*model.LiveAdmin, error) {
+			var admin model.LiveAdmin
+			if err := r.db.WithContext(ctx).
+				Where("live_id = ? AND user_id = ?", liveID, userID).
+				First(&admin).Error; err != nil {
+				return nil, err
+			}
+			return &admin, nil
+		},
+	)(ctx, cacheKey)
+}
+
+// ListByLiveID 查询直播间的管理员列表
+func (r *liveAdminRepositoryImpl) ListByLiveID(ctx context.Context, liveID uint) ([]*model.LiveAdmin, error) {
+	var admins []*model.LiveAdmin
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("live_id = ?", liveID).
+		Order("created_at ASC").
+		Find(&admins).Error
+	return admins, err
+}
@@ -46,9 +46,18 @@ func NewLiveBanRepository(db *gorm.DB) LiveBanRepository {
 	return &liveBanRepositoryImpl{db: db}
 }
 
-// Create 创建禁言记录
+// Create 创建禁言记录（自动清除缓存）
 func (r *liveBanRepositoryImpl) Create(ctx context.Context, ban *model.LiveBan) error {
-	return r.db.WithContext(ctx).Create(ban).Error
+	key := fmt.Sprintf("live:ban:lu:%d:%d", ban.LiveID, ban.UserID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "liveban",
+			KeyPrefix: "live:ban:lu",
+		},
+		func() error {
+			return r.db.WithContext(ctx).Create(ban).Error
+		},
+	)(ctx, key)
 }
 
 // Update 更新禁言记录（自动清除缓存）
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// LiveCommentRepository 直播弹幕数据访问接口
+type LiveCommentRepository interface {
+	// Create 保存弹幕
+	Create(ctx context.Context, comment *model.LiveComment) error
+
+	// FindByID 根据ID查询弹幕
+	FindByID(ctx context.Context, id uint) (*model.LiveComment, error)
+
+	// FindRecentByLiveID 查询直播间最近的弹幕（按时间正序返回）
+	FindRecentByLiveID(ctx context.Context, liveID uint, limit int) ([]*model.LiveComment, error)
+
+	// FindPinnedByLiveID 查询直播间的精选弹幕
+	FindPinnedByLiveID(ctx context.Context, liveID uint) ([]*model.LiveComment, error)
+
+	// ListByLiveID 分页查询直播间弹幕历史（按时间正序）
+	ListByLiveID(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveComment, int64, error)
+
+	// SetPinned 设置弹幕精选状态（同一直播间只保留一条精选弹幕）
+	SetPinned(ctx context.Context, liveID, commentID uint, pinned bool) error
+}
+
+type liveCommentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLiveCommentRepository 创建弹幕Repository
+func NewLiveCommentRepository(db *gorm.DB) LiveCommentRepository {
+	return &liveCommentRepositoryImpl{db: db}
+}
+
+// Create 保存弹幕
+func (r *liveCommentRepositoryImpl) Create(ctx context.Context, comment *model.LiveComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+// FindByID 根据ID查询弹幕
+func (r *liveCommentRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.LiveComment, error) {
+	var comment model.LiveComment
+	if err := r.db.WithContext(ctx).Preload("User").First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// FindRecentByLiveID 查询直播间最近的弹幕
+func (r *liveCommentRepositoryImpl) FindRecentByLiveID(ctx context.Context, liveID uint, limit int) ([]*model.LiveComment, error) {
+	var comments []*model.LiveComment
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("live_id = ? AND is_deleted = ?", liveID, false).
+		Order("id DESC").
+		Limit(limit).
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 倒序查询最近的 N 条，翻转为时间正序便于客户端直接渲染
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+	return comments, nil
+}
+
+// FindPinnedByLiveID 查询直播间的精选弹幕
+func (r *liveCommentRepositoryImpl) FindPinnedByLiveID(ctx context.Context, liveID uint) ([]*model.LiveComment, error) {
+	var comments []*model.LiveComment
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("live_id = ? AND is_pinned = ? AND is_deleted = ?", liveID, true, false).
+		Order("id ASC").
+		Find(&comments).Error
+	return comments, err
+}
+
+// ListByLiveID 分页查询直播间弹幕历史
+func (r *liveCommentRepositoryImpl) ListByLiveID(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveComment, int64, error) {
+	var comments []*model.LiveComment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.LiveComment{}).
+		Where("live_id = ? AND is_deleted = ?", liveID, false)
+
+	// 统计总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := query.
+		Preload("User").
+		Order("id ASC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&comments).Error
+
+	return comments, total, err
+}
+
+// SetPinned 设置弹幕精选状态
+func (r *liveCommentRepositoryImpl) SetPinned(ctx context.Context, liveID, commentID uint, pinned bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pinned {
+			// 精选新弹幕前取消该直播间其他精选
+			if err := tx.Model(&model.LiveComment{}).
+				Where("live_id = ? AND is_pinned = ? AND id <> ?", liveID, true, commentID).
+				Update("is_pinned", false).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&model.LiveComment{}).
+			Where("id = ? AND live_id = ?", commentID, liveID).
+			Update("is_pinned", pinned)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
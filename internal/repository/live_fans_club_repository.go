@@ -30,11 +30,17 @@ type LiveFansClubRepository interface {
 	// Delete 删除粉丝团成员
 	Delete(ctx context.Context, id uint) error
 
-	// AddExperience 增加经验值
-	AddExperience(ctx context.Context, id uint, exp int64) error
+	// AddExperience 增加经验值并刷新活跃时间
+	AddExperience(ctx context.Context, member *model.LiveFansClub, exp int64) error
 
-	// UpdateLevel 更新等级
-	UpdateLevel(ctx context.Context, id uint, level int) error
+	// DecayExperience 扣减经验值（最低为 0）
+	DecayExperience(ctx context.Context, member *model.LiveFansClub, exp int64) error
+
+	// UpdateLevel 更新等级、徽章名称和特权
+	UpdateLevel(ctx context.Context, member *model.LiveFansClub, level int, badgeName, privileges string) error
+
+	// FindInactive 按 ID 顺序分批查询在 before 之前就不再活跃且仍有经验的成员
+	FindInactive(ctx context.Context, before time.Time, afterID uint, limit int) ([]*model.LiveFansClub, error)
 
 	// GetTopMembers 获取粉丝团排行榜
 	GetTopMembers(ctx context.Context, liveID uint, limit int) ([]*model.LiveFansClub, error)
@@ -59,12 +65,7 @@ func (r *liveFansClubRepositoryImpl) Create(ctx context.Context, member *model.L
 
 // Update 更新粉丝团成员（自动清除缓存）
 func (r *liveFansClubRepositoryImpl) Update(ctx context.Context, member *model.LiveFansClub) error {
-	keys := []string{
-		fmt.Sprintf("live:fans:id:%d", member.ID),
-		fmt.Sprintf("live:fans:lu:%d:%d", member.LiveID, member.UserID),
-	}
-
-	return cache.WithMultiCacheEvict("livefans", keys, func() error {
+	return cache.WithMultiCacheEvict("livefans", memberCacheKeys(member), func() error {
 		return r.db.WithContext(ctx).Save(member).Error
 	})(ctx)
 }
@@ -147,48 +148,58 @@ func (r *liveFansClubRepositoryImpl) Delete(ctx context.Context, id uint) error
 		return err
 	}
 
-	keys := []string{
-		fmt.Sprintf("live:fans:id:%d", member.ID),
-		fmt.Sprintf("live:fans:lu:%d:%d", member.LiveID, member.UserID),
-	}
-
-	return cache.WithMultiCacheEvict("livefans", keys, func() error {
+	return cache.WithMultiCacheEvict("livefans", memberCacheKeys(&member), func() error {
 		return r.db.WithContext(ctx).Delete(&model.LiveFansClub{}, id).Error
 	})(ctx)
 }
 
-// AddExperience 增加经验值
-func (r *liveFansClubRepositoryImpl) AddExperience(ctx context.Context, id uint, exp int64) error {
-	key := fmt.Sprintf("live:fans:id:%d", id)
-	return cache.WithCacheEvict(
-		cache.CacheConfig{
-			CacheName: "livefans",
-			KeyPrefix: "live:fans:id",
-		},
-		func() error {
-			return r.db.WithContext(ctx).
-				Model(&model.LiveFansClub{}).
-				Where("id = ?", id).
-				UpdateColumn("experience", gorm.Expr("experience + ?", exp)).Error
-		},
-	)(ctx, key)
+// AddExperience 增加经验值并刷新活跃时间（自动清除缓存）
+func (r *liveFansClubRepositoryImpl) AddExperience(ctx context.Context, member *model.LiveFansClub, exp int64) error {
+	return cache.WithMultiCacheEvict("livefans", memberCacheKeys(member), func() error {
+		return r.db.WithContext(ctx).
+			Model(&model.LiveFansClub{}).
+			Where("id = ?", member.ID).
+			UpdateColumns(map[string]interface{}{
+				"experience":     gorm.Expr("experience + ?", exp),
+				"last_active_at": time.Now(),
+			}).Error
+	})(ctx)
 }
 
-// UpdateLevel 更新等级
-func (r *liveFansClubRepositoryImpl) UpdateLevel(ctx context.Context, id uint, level int) error {
-	key := fmt.Sprintf("live:fans:id:%d", id)
-	return cache.WithCacheEvict(
-		cache.CacheConfig{
-			CacheName: "livefans",
-			KeyPrefix: "live:fans:id",
-		},
-		func() error {
-			return r.db.WithContext(ctx).
-				Model(&model.LiveFansClub{}).
-				Where("id = ?", id).
-				Update("level", level).Error
-		},
-	)(ctx, key)
+// DecayExperience 扣减经验值，最低为 0（自动清除缓存）
+func (r *liveFansClubRepositoryImpl) DecayExperience(ctx context.Context, member *model.LiveFansClub, exp int64) error {
+	return cache.WithMultiCacheEvict("livefans", memberCacheKeys(member), func() error {
+		return r.db.WithContext(ctx).
+			Model(&model.LiveFansClub{}).
+			Where("id = ?", member.ID).
+			UpdateColumn("experience", gorm.Expr("GREATEST(experience - ?, 0)", exp)).Error
+	})(ctx)
+}
+
+// UpdateLevel 更新等级、徽章名称和特权（自动清除缓存）
+func (r *liveFansClubRepositoryImpl) UpdateLevel(ctx context.Context, member *model.LiveFansClub, level int, badgeName, privileges string) error {
+	return cache.WithMultiCacheEvict("livefans", memberCacheKeys(member), func() error {
+		return r.db.WithContext(ctx).
+			Model(&model.LiveFansClub{}).
+			Where("id = ?", member.ID).
+			Updates(map[string]interface{}{
+				"level":      level,
+				"badge_name": badgeName,
+				"privileges": privileges,
+			}).Error
+	})(ctx)
+}
+
+// FindInactive 查询不活跃成员（从未获得经验的成员按加入时间计算）
+func (r *liveFansClubRepositoryImpl) FindInactive(ctx context.Context, before time.Time, afterID uint, limit int) ([]*model.LiveFansClub, error) {
+	var members []*model.LiveFansClub
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND is_activated = ? AND experience > 0", afterID, true).
+		Where("COALESCE(last_active_at, created_at) < ?", before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&members).Error
+	return members, err
 }
 
 // GetTopMembers 获取粉丝团排行榜（使用Redis缓存）
@@ -224,3 +235,11 @@ func (r *liveFansClubRepositoryImpl) CountMembers(ctx context.Context, liveID ui
 		Count(&count).Error
 	return count, err
 }
+
+// memberCacheKeys 成员的缓存键（按 ID 和按直播间+用户）
+func memberCacheKeys(member *model.LiveFansClub) []string {
+	return []string{
+		fmt.Sprintf("live:fans:id:%d", member.ID),
+		fmt.Sprintf("live:fans:lu:%d:%d", member.LiveID, member.UserID),
+	}
+}
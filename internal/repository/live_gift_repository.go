@@ -30,13 +30,16 @@ type LiveGiftRepository interface {
 	// CreateGiftRecord 创建送礼记录
 	CreateGiftRecord(ctx context.Context, record *model.LiveGiftRecord) error
 
+	// FindGiftRecordByID 根据ID查询送礼记录
+	FindGiftRecordByID(ctx context.Context, id uint) (*model.LiveGiftRecord, error)
+
 	// ListGiftRecords 查询送礼记录列表
 	ListGiftRecords(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveGiftRecord, int64, error)
 
 	// GetUserGiftStats 获取用户在直播间的送礼统计
 	GetUserGiftStats(ctx context.Context, liveID, userID uint) (int64, int, error)
 
-	// GetTopGivers 获取直播间送礼榜单
+	// GetTopGivers 获取直播间送礼榜单（Quantity 为用户送出的礼物总数）
 	GetTopGivers(ctx context.Context, liveID uint, limit int) ([]*model.LiveGiftRecord, error)
 }
 
@@ -137,6 +140,16 @@ func (r *liveGiftRepositoryImpl) CreateGiftRecord(ctx context.Context, record *m
 	return r.db.WithContext(ctx).Create(record).Error
 }
 
+// FindGiftRecordByID 根据ID查询送礼记录
+func (r *liveGiftRepositoryImpl) FindGiftRecordByID(ctx context.Context, id uint) (*model.LiveGiftRecord, error) {
+	var record model.LiveGiftRecord
+	err := r.db.WithContext(ctx).Preload("Gift").First(&record, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
 // ListGiftRecords 查询送礼记录列表
 func (r *liveGiftRepositoryImpl) ListGiftRecords(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveGiftRecord, int64, error) {
 	var records []*model.LiveGiftRecord
@@ -198,11 +211,11 @@ func (r *liveGiftRepositoryImpl) GetTopGivers(ctx context.Context, liveID uint,
 		func() ([]*model.LiveGiftRecord, error) {
 			var records []*model.LiveGiftRecord
 
-			// 使用子查询聚合每个用户的送礼总值
+			// 使用子查询聚合每个用户的送礼总值和礼物数量（匿名送礼不计入榜单）
 			err := r.db.WithContext(ctx).
 				Table("live_gift_records").
-				Select("user_id, live_id, SUM(total_value) as total_value, COUNT(*) as gift_count").
-				Where("live_id = ?", liveID).
+				Select("user_id, live_id, SUM(total_value) as total_value, SUM(quantity) as quantity").
+				Where("live_id = ? AND is_anonymous = ?", liveID, false).
 				Group("user_id, live_id").
 				Order("total_value DESC").
 				Limit(limit).
@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microvibe-go/internal/model"
+	"microvibe-go/pkg/cache"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrStockInsufficient 库存不足（商品不存在、已下架或剩余库存小于购买数量）
+	ErrStockInsufficient = errors.New("insufficient stock")
+	// ErrOrderStateChanged 订单状态已被其他操作修改（如支付与超时关闭并发）
+	ErrOrderStateChanged = errors.New("order state changed")
+)
+
+// LiveOrderEarnings 主播带货收益汇总
+type LiveOrderEarnings struct {
+	OrderCount  int64 `json:"order_count"`  // 已支付订单数
+	SalesAmount int64 `json:"sales_amount"` // 销售额（分）
+	Commission  int64 `json:"commission"`   // 佣金收益（分）
+}
+
+// LiveOrderRepository 直播订单数据访问接口
+// 库存在下单时通过条件更新锁定，订单状态只从 pending 流转一次，保证并发下不超卖、不重复释放库存
+type LiveOrderRepository interface {
+	// CreateWithReservation 在同一事务内锁定库存并创建订单，库存不足时返回 ErrStockInsufficient
+	CreateWithReservation(ctx context.Context, order *model.LiveOrder) error
+
+	// FindByID 根据ID查询订单
+	FindByID(ctx context.Context, id uint) (*model.LiveOrder, error)
+
+	// FindByOrderNo 根据订单号查询订单
+	FindByOrderNo(ctx context.Context, orderNo string) (*model.LiveOrder, error)
+
+	// FindByRequestID 根据客户端请求ID查询订单（用于幂等）
+	FindByRequestID(ctx context.Context, userID uint, requestID string) (*model.LiveOrder, error)
+
+	// UpdatePayment 记录渠道支付单
+	UpdatePayment(ctx context.Context, id uint, provider, paymentID, payURL string) error
+
+	// MarkPaid 待支付订单标记为已支付，累加商品销量和直播间销售额，并把佣金记入主播钱包
+	// 订单已不是待支付状态时返回 ErrOrderStateChanged
+	MarkPaid(ctx context.Context, order *model.LiveOrder, paidAt time.Time) error
+
+	// Refund 已支付订单标记为已退款，冲回商品销量、直播间销售额和主播钱包中的佣金
+	// 订单已不是已支付状态时返回 ErrOrderStateChanged
+	Refund(ctx context.Context, order *model.LiveOrder, refundedAt time.Time) error
+
+	// Close 取消或关闭待支付订单并释放库存，订单已不是待支付状态时返回 ErrOrderStateChanged
+	Close(ctx context.Context, order *model.LiveOrder, status, reason string) error
+
+	// ListByUser 分页查询买家订单
+	ListByUser(ctx context.Context, userID uint, page, pageSize int) ([]*model.LiveOrder, int64, error)
+
+	// ListExpired 按 ID 顺序分批查询支付截止时间早于 before 的待支付订单
+	ListExpired(ctx context.Context, before time.Time, afterID uint, limit int) ([]*model.LiveOrder, error)
+
+	// SumEarnings 汇总主播的已支付订单（liveID 为 0 时汇总所有直播间）
+	SumEarnings(ctx context.Context, streamerID, liveID uint) (*LiveOrderEarnings, error)
+}
+
+type liveOrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLiveOrderRepository 创建直播订单Repository
+func NewLiveOrderRepository(db *gorm.DB) LiveOrderRepository {
+	return &liveOrderRepositoryImpl{db: db}
+}
+
+// CreateWithReservation 锁定库存并创建订单（自动清除商品缓存）
+func (r *liveOrderRepositoryImpl) CreateWithReservation(ctx context.Context, order *model.LiveOrder) error {
+	key := fmt.Sprintf("live:product:id:%d", order.LiveProductID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "liveproduct",
+			KeyPrefix: "live:product:id",
+		},
+		func() error {
+			return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				// 1. 锁定库存（条件更新，库存不足或已下架时不影响任何行），库存清零时标记为售罄
+				result := tx.Model(&model.LiveProduct{}).
+					Where("id = ? AND status = ? AND stock >= ?", order.LiveProductID, 1, order.Quantity).
+					Updates(map[string]interface{}{
+						"stock":  gorm.Expr("stock - ?", order.Quantity),
+						"status": gorm.Expr("CASE WHEN stock - ? <= 0 THEN 2 ELSE status END", order.Quantity),
+					})
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return ErrStockInsufficient
+				}
+
+				// 2. 创建订单（request_id 重复时唯一索引报错，整体回滚）
+				return tx.Create(order).Error
+			})
+		},
+	)(ctx, key)
+}
+
+// FindByID 根据ID查询订单
+func (r *liveOrderRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.LiveOrder, error) {
+	var order model.LiveOrder
+	if err := r.db.WithContext(ctx).First(&order, id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindByOrderNo 根据订单号查询订单
+func (r *liveOrderRepositoryImpl) FindByOrderNo(ctx context.Context, orderNo string) (*model.LiveOrder, error) {
+	var order model.LiveOrder
+	if err := r.db.WithContext(ctx).Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindByRequestID 根据客户端请求ID查询订单
+func (r *liveOrderRepositoryImpl) FindByRequestID(ctx context.Context, userID uint, requestID string) (*model.LiveOrder, error) {
+	var order model.LiveOrder
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND request_id = ?", userID, requestID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// UpdatePayment 记录渠道支付单
+func (r *liveOrderRepositoryImpl) UpdatePayment(ctx context.Context, id uint, provider, paymentID, payURL string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.LiveOrder{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"payment_provider": provider,
+			"payment_id":       paymentID,
+			"pay_url":          payURL,
+		}).Error
+}
+
+// MarkPaid 标记已支付（自动清除商品和直播间缓存）
+func (r *liveOrderRepositoryImpl) MarkPaid(ctx context.Context, order *model.LiveOrder, paidAt time.Time) error {
+	productKey := fmt.Sprintf("live:product:id:%d", order.LiveProductID)
+	liveKey := fmt.Sprintf("livestream:id:%d", order.LiveID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "livestream",
+			KeyPrefix: "livestream:id",
+		},
+		func() error {
+			return cache.WithCacheEvict(
+				cache.CacheConfig{
+					CacheName: "liveproduct",
+					KeyPrefix: "live:product:id",
+				},
+				func() error {
+					return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+						// 1. 订单状态 pending -> paid（条件更新，保证只流转一次）
+						result := tx.Model(&model.LiveOrder{}).
+							Where("id = ? AND status = ?", order.ID, model.LiveOrderStatusPending).
+							Updates(map[string]interface{}{
+								"status":  model.LiveOrderStatusPaid,
+								"paid_at": paidAt,
+							})
+						if result.Error != nil {
+							return result.Error
+						}
+						if result.RowsAffected == 0 {
+							return ErrOrderStateChanged
+						}
+
+						// 2. 累加商品销量（库存已在下单时扣减）
+						if err := tx.Model(&model.LiveProduct{}).
+							Where("id = ?", order.LiveProductID).
+							UpdateColumn("sold_count", gorm.Expr("sold_count + ?", order.Quantity)).Error; err != nil {
+							return err
+						}
+
+						// 3. 累加直播间商品销售额
+						if err := tx.Model(&model.LiveStream{}).
+							Where("id = ?", order.LiveID).
+							UpdateColumn("product_sales", gorm.Expr("product_sales + ?", order.TotalAmount)).Error; err != nil {
+							return err
+						}
+
+						// 4. 佣金记入主播钱包
+						if order.Commission <= 0 {
+							return nil
+						}
+						return postCommission(tx, commissionTxn(order, model.WalletTxnTypeCommission))
+					})
+				},
+			)(ctx, productKey)
+		},
+	)(ctx, liveKey)
+}
+
+// Refund 标记已退款（自动清除商品和直播间缓存）
+// 商品可能已发出，退款不恢复库存
+func (r *liveOrderRepositoryImpl) Refund(ctx context.Context, order *model.LiveOrder, refundedAt time.Time) error {
+	productKey := fmt.Sprintf("live:product:id:%d", order.LiveProductID)
+	liveKey := fmt.Sprintf("livestream:id:%d", order.LiveID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "livestream",
+			KeyPrefix: "livestream:id",
+		},
+		func() error {
+			return cache.WithCacheEvict(
+				cache.CacheConfig{
+					CacheName: "liveproduct",
+					KeyPrefix: "live:product:id",
+				},
+				func() error {
+					return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+						// 1. 订单状态 paid -> refunded（条件更新，保证只冲回一次）
+						result := tx.Model(&model.LiveOrder{}).
+							Where("id = ? AND status = ?", order.ID, model.LiveOrderStatusPaid).
+							Updates(map[string]interface{}{
+								"status":      model.LiveOrderStatusRefunded,
+								"refunded_at": refundedAt,
+							})
+						if result.Error != nil {
+							return result.Error
+						}
+						if result.RowsAffected == 0 {
+							return ErrOrderStateChanged
+						}
+
+						// 2. 冲回商品销量
+						if err := tx.Model(&model.LiveProduct{}).
+							Where("id = ?", order.LiveProductID).
+							UpdateColumn("sold_count", gorm.Expr("sold_count - ?", order.Quantity)).Error; err != nil {
+							return err
+						}
+
+						// 3. 冲回直播间商品销售额
+						if err := tx.Model(&model.LiveStream{}).
+							Where("id = ?", order.LiveID).
+							UpdateColumn("product_sales", gorm.Expr("product_sales - ?", order.TotalAmount)).Error; err != nil {
+							return err
+						}
+
+						// 4. 冲回主播钱包中的佣金
+						if order.Commission <= 0 {
+							return nil
+						}
+						return postCommission(tx, commissionTxn(order, model.WalletTxnTypeCommissionReversal))
+					})
+				},
+			)(ctx, productKey)
+		},
+	)(ctx, liveKey)
+}
+
+// Close 取消或关闭订单并释放库存（自动清除商品缓存）
+func (r *liveOrderRepositoryImpl) Close(ctx context.Context, order *model.LiveOrder, status, reason string) error {
+	key := fmt.Sprintf("live:product:id:%d", order.LiveProductID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "liveproduct",
+			KeyPrefix: "live:product:id",
+		},
+		func() error {
+			return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				// 1. 订单状态 pending -> cancelled/closed（条件更新，保证库存只释放一次）
+				result := tx.Model(&model.LiveOrder{}).
+					Where("id = ? AND status = ?", order.ID, model.LiveOrderStatusPending).
+					Updates(map[string]interface{}{
+						"status":       status,
+						"closed_at":    time.Now(),
+						"close_reason": reason,
+					})
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return ErrOrderStateChanged
+				}
+
+				// 2. 释放库存，因售罄下架的商品恢复上架
+				return tx.Model(&model.LiveProduct{}).
+					Where("id = ?", order.LiveProductID).
+					Updates(map[string]interface{}{
+						"stock":  gorm.Expr("stock + ?", order.Quantity),
+						"status": gorm.Expr("CASE WHEN status = 2 THEN 1 ELSE status END"),
+					}).Error
+			})
+		},
+	)(ctx, key)
+}
+
+// commissionTxn 构造订单佣金的钱包交易，request_id 由订单号和交易类型生成，同一订单的入账和冲回各只记一次
+func commissionTxn(order *model.LiveOrder, txnType string) *model.WalletTransaction {
+	remark := fmt.Sprintf("订单%s带货佣金", order.OrderNo)
+	if txnType == model.WalletTxnTypeCommissionReversal {
+		remark = fmt.Sprintf("订单%s退款冲回佣金", order.OrderNo)
+	}
+	return &model.WalletTransaction{
+		UserID:    order.StreamerID,
+		RequestID: fmt.Sprintf("live_order:%s:%s", order.OrderNo, txnType),
+		Type:      txnType,
+		Amount:    order.Commission,
+		BizType:   "live_order",
+		BizID:     order.ID,
+		Remark:    remark,
+	}
+}
+
+// ListByUser 分页查询买家订单
+func (r *liveOrderRepositoryImpl) ListByUser(ctx context.Context, userID uint, page, pageSize int) ([]*model.LiveOrder, int64, error) {
+	var orders []*model.LiveOrder
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.LiveOrder{}).Where("user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
+// ListExpired 查询超时未支付订单
+func (r *liveOrderRepositoryImpl) ListExpired(ctx context.Context, before time.Time, afterID uint, limit int) ([]*model.LiveOrder, error) {
+	var orders []*model.LiveOrder
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND status = ? AND expires_at < ?", afterID, model.LiveOrderStatusPending, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// SumEarnings 汇总主播的已支付订单
+func (r *liveOrderRepositoryImpl) SumEarnings(ctx context.Context, streamerID, liveID uint) (*LiveOrderEarnings, error) {
+	var earnings LiveOrderEarnings
+
+	query := r.db.WithContext(ctx).
+		Model(&model.LiveOrder{}).
+		Select("COUNT(*) AS order_count, COALESCE(SUM(total_amount), 0) AS sales_amount, COALESCE(SUM(commission), 0) AS commission").
+		Where("streamer_id = ? AND status = ?", streamerID, model.LiveOrderStatusPaid)
+	if liveID > 0 {
+		query = query.Where("live_id = ?", liveID)
+	}
+
+	if err := query.Scan(&earnings).Error; err != nil {
+		return nil, err
+	}
+	return &earnings, nil
+}
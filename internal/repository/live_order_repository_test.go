@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"microvibe-go/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newTestOrder() *model.LiveOrder {
+	return &model.LiveOrder{
+		ID:            5,
+		OrderNo:       "LO20261016000000abcd1234",
+		UserID:        7,
+		LiveID:        2,
+		StreamerID:    9,
+		LiveProductID: 3,
+		Quantity:      2,
+		TotalAmount:   1000,
+		Commission:    150,
+	}
+}
+
+// expectPostCommission 预期佣金交易记录、主播钱包变更和两条记账分录
+func expectPostCommission(mock sqlmock.Sqlmock, order *model.LiveOrder, txnType string, amount, balanceAfter int64) {
+	mock.ExpectQuery(`INSERT INTO "wallet_transactions"`).
+		WithArgs(sqlmock.AnyArg(), order.StreamerID, "live_order:"+order.OrderNo+":"+txnType, txnType, order.Commission, "live_order", order.ID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery(`INSERT INTO "wallets" .* ON CONFLICT \("user_id"\) DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`UPDATE "wallets" SET "commission"=commission \+ \$1`).
+		WithArgs(amount, sqlmock.AnyArg(), order.StreamerID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "wallets" WHERE user_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "commission"}).AddRow(1, order.StreamerID, balanceAfter))
+	mock.ExpectQuery(`INSERT INTO "wallet_ledger_entries"`).
+		WithArgs(
+			sqlmock.AnyArg(), uint(11), model.WalletAccountCommission, order.StreamerID, amount, balanceAfter,
+			sqlmock.AnyArg(), uint(11), model.WalletAccountPlatformCommerce, uint(0), -amount, int64(0),
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21).AddRow(22))
+}
+
+func TestLiveOrderMarkPaid_PostsCommissionToStreamerWallet(t *testing.T) {
+	db, mock := newMockDB(t)
+	order := newTestOrder()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "live_orders" SET .* WHERE id = \$\d+ AND status = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "live_products" SET "sold_count"=sold_count \+ \$1`).
+		WithArgs(order.Quantity, order.LiveProductID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "live_streams" SET "product_sales"=product_sales \+ \$1`).
+		WithArgs(order.TotalAmount, order.LiveID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPostCommission(mock, order, model.WalletTxnTypeCommission, 150, 150)
+	mock.ExpectCommit()
+
+	if err := NewLiveOrderRepository(db).MarkPaid(context.Background(), order, time.Now()); err != nil {
+		t.Fatalf("MarkPaid failed: %v", err)
+	}
+}
+
+func TestLiveOrderRefund_ReversesCommission(t *testing.T) {
+	db, mock := newMockDB(t)
+	order := newTestOrder()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "live_orders" SET .* WHERE id = \$\d+ AND status = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "live_products" SET "sold_count"=sold_count - \$1`).
+		WithArgs(order.Quantity, order.LiveProductID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "live_streams" SET "product_sales"=product_sales - \$1`).
+		WithArgs(order.TotalAmount, order.LiveID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPostCommission(mock, order, model.WalletTxnTypeCommissionReversal, -150, 0)
+	mock.ExpectCommit()
+
+	if err := NewLiveOrderRepository(db).Refund(context.Background(), order, time.Now()); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+}
+
+func TestLiveOrderRefund_NotPaidLeavesWalletUntouched(t *testing.T) {
+	db, mock := newMockDB(t)
+	order := newTestOrder()
+
+	// 订单已不是已支付状态（如重复退款），不冲回销量和佣金
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "live_orders" SET .* WHERE id = \$\d+ AND status = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := NewLiveOrderRepository(db).Refund(context.Background(), order, time.Now())
+	if !errors.Is(err, ErrOrderStateChanged) {
+		t.Fatalf("expected ErrOrderStateChanged, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// LiveRankRepository 直播打赏榜快照数据访问接口
+type LiveRankRepository interface {
+	// ReplaceByLiveID 覆盖保存直播间的榜单快照
+	ReplaceByLiveID(ctx context.Context, liveID uint, ranks []*model.LiveRankList) error
+
+	// ListByLiveID 查询直播间的榜单快照（按名次升序）
+	ListByLiveID(ctx context.Context, liveID uint, limit int) ([]*model.LiveRankList, error)
+}
+
+type liveRankRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLiveRankRepository 创建打赏榜Repository
+func NewLiveRankRepository(db *gorm.DB) LiveRankRepository {
+	return &liveRankRepositoryImpl{db: db}
+}
+
+// ReplaceByLiveID 覆盖保存榜单快照（删除旧快照和写入新快照在同一事务内完成）
+func (r *liveRankRepositoryImpl) ReplaceByLiveID(ctx context.Context, liveID uint, ranks []*model.LiveRankList) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("live_id = ?", liveID).Delete(&model.LiveRankList{}).Error; err != nil {
+			return err
+		}
+		if len(ranks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(ranks, 100).Error
+	})
+}
+
+// ListByLiveID 查询榜单快照
+func (r *liveRankRepositoryImpl) ListByLiveID(ctx context.Context, liveID uint, limit int) ([]*model.LiveRankList, error) {
+	var ranks []*model.LiveRankList
+	err := r.db.WithContext(ctx).
+		Where("live_id = ?", liveID).
+		Order("rank ASC").
+		Limit(limit).
+		Find(&ranks).Error
+	return ranks, err
+}
@@ -78,6 +78,9 @@ type LiveStreamRepository interface {
 	// UpdateDuration 更新直播时长
 	UpdateDuration(ctx context.Context, id uint, duration int64) error
 
+	// UpdateRecordURL 更新录制回放地址
+	UpdateRecordURL(ctx context.Context, id uint, recordURL string) error
+
 	// IncrementOnlineCount 增加在线人数
 	IncrementOnlineCount(ctx context.Context, id uint) error
 
@@ -477,6 +480,23 @@ func (r *liveStreamRepositoryImpl) UpdateDuration(ctx context.Context, id uint,
 	)(ctx, key)
 }
 
+// UpdateRecordURL 更新录制回放地址（自动清除缓存）
+func (r *liveStreamRepositoryImpl) UpdateRecordURL(ctx context.Context, id uint, recordURL string) error {
+	key := fmt.Sprintf("livestream:id:%d", id)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "livestream",
+			KeyPrefix: "livestream:id",
+		},
+		func() error {
+			return r.db.WithContext(ctx).
+				Model(&model.LiveStream{}).
+				Where("id = ?", id).
+				Update("record_url", recordURL).Error
+		},
+	)(ctx, key)
+}
+
 // IncrementOnlineCount 增加在线人数
 func (r *liveStreamRepositoryImpl) IncrementOnlineCount(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).
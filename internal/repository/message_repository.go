@@ -48,13 +48,18 @@ func NewMessageRepository(db *gorm.DB) MessageRepository {
 	return &messageRepositoryImpl{db: db}
 }
 
-// CreateMessage 创建消息
+// CreateMessage 创建私聊消息（群聊消息使用 GroupConversationRepository.CreateGroupMessage）
 func (r *messageRepositoryImpl) CreateMessage(ctx context.Context, message *model.Message) error {
+	if message.ReceiverID == nil {
+		return gorm.ErrInvalidValue
+	}
+	receiverID := *message.ReceiverID
+
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. 确保有会话ID
 		var conversation model.Conversation
 		if message.ConversationID == nil {
-			user1ID, user2ID := message.SenderID, message.ReceiverID
+			user1ID, user2ID := message.SenderID, receiverID
 			if user1ID > user2ID {
 				user1ID, user2ID = user2ID, user1ID
 			}
@@ -64,8 +69,9 @@ func (r *messageRepositoryImpl) CreateMessage(ctx context.Context, message *mode
 
 			if pkgerrors.IsNotFound(err) {
 				conversation = model.Conversation{
-					User1ID: user1ID,
-					User2ID: user2ID,
+					Type:    model.ConversationTypePrivate,
+					User1ID: &user1ID,
+					User2ID: &user2ID,
 				}
 				if err := tx.Create(&conversation).Error; err != nil {
 					return err
@@ -93,13 +99,23 @@ func (r *messageRepositoryImpl) CreateMessage(ctx context.Context, message *mode
 		}
 
 		// 增加接收者未读数
-		if message.ReceiverID == conversation.User1ID {
+		if conversation.User1ID != nil && *conversation.User1ID == receiverID {
 			updates["unread_count1"] = gorm.Expr("unread_count1 + 1")
 		} else {
 			updates["unread_count2"] = gorm.Expr("unread_count2 + 1")
 		}
 
-		return tx.Model(&conversation).Updates(updates).Error
+		if err := tx.Model(&conversation).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// 4. 记录同步事件（发送者的其他设备同样需要同步）
+		return appendSyncEventForUsers(tx, model.MessageSyncEvent{
+			Type:           model.MessageSyncEventMessage,
+			ConversationID: message.ConversationID,
+			MessageID:      &message.ID,
+			ActorID:        message.SenderID,
+		}, message.SenderID, receiverID)
 	})
 }
 
@@ -143,13 +159,33 @@ func (r *messageRepositoryImpl) GetConversationMessagesByID(ctx context.Context,
 
 // MarkAsRead 标记消息为已读
 func (r *messageRepositoryImpl) MarkAsRead(ctx context.Context, messageID, userID uint) error {
-	now := time.Now()
-	return r.db.WithContext(ctx).Model(&model.Message{}).
-		Where("id = ? AND receiver_id = ? AND is_read = ?", messageID, userID, false).
-		Updates(map[string]interface{}{
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var message model.Message
+		err := tx.Where("id = ? AND receiver_id = ? AND is_read = ?", messageID, userID, false).
+			First(&message).Error
+		if pkgerrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&message).Updates(map[string]interface{}{
 			"is_read": true,
 			"read_at": now,
-		}).Error
+		}).Error; err != nil {
+			return err
+		}
+
+		// 已读回执同步给发送者，已读状态同步给阅读者的其他设备
+		return appendSyncEventForUsers(tx, model.MessageSyncEvent{
+			Type:           model.MessageSyncEventRead,
+			ConversationID: message.ConversationID,
+			MessageID:      &message.ID,
+			ActorID:        userID,
+		}, userID, message.SenderID)
+	})
 }
 
 // MarkConversationAsRead 标记会话所有消息为已读
@@ -157,14 +193,16 @@ func (r *messageRepositoryImpl) MarkConversationAsRead(ctx context.Context, user
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 标记消息为已读
 		now := time.Now()
-		if err := tx.Model(&model.Message{}).
+		result := tx.Model(&model.Message{}).
 			Where("sender_id = ? AND receiver_id = ? AND is_read = ?", user2ID, user1ID, false).
 			Updates(map[string]interface{}{
 				"is_read": true,
 				"read_at": now,
-			}).Error; err != nil {
-			return err
+			})
+		if result.Error != nil {
+			return result.Error
 		}
+		marked := result.RowsAffected
 
 		// 更新会话未读数
 		uid1, uid2 := user1ID, user2ID
@@ -181,21 +219,35 @@ func (r *messageRepositoryImpl) MarkConversationAsRead(ctx context.Context, user
 		}
 
 		// 标记接收到的消息为已读
-		if err := tx.Model(&model.Message{}).
+		result = tx.Model(&model.Message{}).
 			Where("conversation_id = ? AND receiver_id = ? AND is_read = ?", conversation.ID, user1ID, false).
 			Updates(map[string]interface{}{
 				"is_read": true,
 				"read_at": now,
-			}).Error; err != nil {
-			return err
+			})
+		if result.Error != nil {
+			return result.Error
 		}
+		marked += result.RowsAffected
 
 		// 清零当前用户的未读数
 		field := "unread_count2"
-		if user1ID == conversation.User1ID {
+		if conversation.User1ID != nil && *conversation.User1ID == user1ID {
 			field = "unread_count1"
 		}
-		return tx.Model(&conversation).Update(field, 0).Error
+		if err := tx.Model(&conversation).Update(field, 0).Error; err != nil {
+			return err
+		}
+
+		// 没有新标记的消息时无需同步
+		if marked == 0 {
+			return nil
+		}
+		return appendSyncEventForUsers(tx, model.MessageSyncEvent{
+			Type:           model.MessageSyncEventRead,
+			ConversationID: &conversation.ID,
+			ActorID:        user1ID,
+		}, user1ID, user2ID)
 	})
 }
 
@@ -210,38 +262,88 @@ func (r *messageRepositoryImpl) MarkConversationAsReadByID(ctx context.Context,
 
 		// 2. 标记消息为已读 (收到的消息)
 		now := time.Now()
-		if err := tx.Model(&model.Message{}).
+		result := tx.Model(&model.Message{}).
 			Where("conversation_id = ? AND receiver_id = ? AND is_read = ?", conversationID, userID, false).
 			Updates(map[string]interface{}{
 				"is_read": true,
 				"read_at": now,
-			}).Error; err != nil {
-			return err
+			})
+		if result.Error != nil {
+			return result.Error
 		}
 
 		// 3. 清零当前用户的未读数
-		if userID == conversation.User1ID {
-			return tx.Model(&conversation).Update("unread_count1", 0).Error
+		field := "unread_count2"
+		if conversation.User1ID != nil && *conversation.User1ID == userID {
+			field = "unread_count1"
+		}
+		if err := tx.Model(&conversation).Update(field, 0).Error; err != nil {
+			return err
 		}
-		return tx.Model(&conversation).Update("unread_count2", 0).Error
+
+		// 4. 记录同步事件（已读回执同步给对方，没有新标记的消息时无需同步）
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return appendSyncEventForUsers(tx, model.MessageSyncEvent{
+			Type:           model.MessageSyncEventRead,
+			ConversationID: &conversation.ID,
+			ActorID:        userID,
+		}, userID, conversation.PeerID(userID))
 	})
 }
 
 // DeleteMessage 删除消息
 func (r *messageRepositoryImpl) DeleteMessage(ctx context.Context, messageID, userID uint) error {
-	// 只能删除自己发送的消息
-	return r.db.WithContext(ctx).
-		Where("id = ? AND sender_id = ?", messageID, userID).
-		Delete(&model.Message{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 只能删除自己发送的消息
+		var message model.Message
+		err := tx.Where("id = ? AND sender_id = ?", messageID, userID).First(&message).Error
+		if pkgerrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&message).Error; err != nil {
+			return err
+		}
+
+		// 私聊同步给双方，群聊同步给所有成员
+		userIDs := []uint{message.SenderID}
+		if message.ReceiverID != nil {
+			userIDs = append(userIDs, *message.ReceiverID)
+		} else if message.ConversationID != nil {
+			var memberIDs []uint
+			if err := tx.Model(&model.ConversationMember{}).
+				Where("conversation_id = ?", *message.ConversationID).
+				Pluck("user_id", &memberIDs).Error; err != nil {
+				return err
+			}
+			userIDs = append(userIDs, memberIDs...)
+		}
+
+		return appendSyncEventForUsers(tx, model.MessageSyncEvent{
+			Type:           model.MessageSyncEventDelete,
+			ConversationID: message.ConversationID,
+			MessageID:      &message.ID,
+			ActorID:        userID,
+		}, userIDs...)
+	})
 }
 
-// GetConversationList 获取会话列表
+// GetConversationList 获取会话列表（包括私聊和用户所在的群聊）
 func (r *messageRepositoryImpl) GetConversationList(ctx context.Context, userID uint, page, pageSize int) ([]*model.Conversation, int64, error) {
 	var conversations []*model.Conversation
 	var total int64
 
+	groupIDs := r.db.WithContext(ctx).Model(&model.ConversationMember{}).
+		Select("conversation_id").
+		Where("user_id = ?", userID)
+
 	query := r.db.WithContext(ctx).Model(&model.Conversation{}).
-		Where("user1_id = ? OR user2_id = ?", userID, userID)
+		Where("user1_id = ? OR user2_id = ? OR id IN (?)", userID, userID, groupIDs)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -254,7 +356,9 @@ func (r *messageRepositoryImpl) GetConversationList(ctx context.Context, userID
 		Preload("User1").
 		Preload("User2").
 		Preload("LastMessage").
+		Preload("LastMessage.Sender").
 		Preload("LastMessage.Video").
+		Preload("Members", "user_id = ?", userID). // 群聊只加载当前用户的成员记录（未读数）
 		Order("updated_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -276,8 +380,9 @@ func (r *messageRepositoryImpl) GetOrCreateConversation(ctx context.Context, use
 
 	if err == gorm.ErrRecordNotFound {
 		conversation = model.Conversation{
-			User1ID: user1ID,
-			User2ID: user2ID,
+			Type:    model.ConversationTypePrivate,
+			User1ID: &user1ID,
+			User2ID: &user2ID,
 		}
 		if err := r.db.WithContext(ctx).Create(&conversation).Error; err != nil {
 			return nil, err
@@ -321,11 +426,22 @@ func (r *messageRepositoryImpl) UpdateConversation(ctx context.Context, conversa
 	return r.db.WithContext(ctx).Save(conversation).Error
 }
 
-// GetUnreadMessageCount 获取未读消息总数
+// GetUnreadMessageCount 获取未读消息总数（私聊未读消息 + 群聊未读数）
 func (r *messageRepositoryImpl) GetUnreadMessageCount(ctx context.Context, userID uint) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&model.Message{}).
+	if err := r.db.WithContext(ctx).Model(&model.Message{}).
 		Where("receiver_id = ? AND is_read = ?", userID, false).
-		Count(&count).Error
-	return count, err
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	var groupUnread int64
+	if err := r.db.WithContext(ctx).Model(&model.ConversationMember{}).
+		Select("COALESCE(SUM(unread_count), 0)").
+		Where("user_id = ?", userID).
+		Scan(&groupUnread).Error; err != nil {
+		return 0, err
+	}
+
+	return count + groupUnread, nil
 }
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageSyncRepository 消息同步数据访问接口
+type MessageSyncRepository interface {
+	// ListEvents 查询用户序号大于 afterSeq 的同步事件（按序号升序）
+	ListEvents(ctx context.Context, userID uint, afterSeq int64, limit int) ([]*model.MessageSyncEvent, error)
+
+	// GetLatestSeq 获取用户当前最大序号
+	GetLatestSeq(ctx context.Context, userID uint) (int64, error)
+
+	// FindCursor 查询设备同步游标
+	FindCursor(ctx context.Context, userID uint, deviceID string) (*model.MessageSyncCursor, error)
+
+	// SaveCursor 保存设备同步游标（游标只前进不后退）
+	SaveCursor(ctx context.Context, cursor *model.MessageSyncCursor) error
+}
+
+type messageSyncRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewMessageSyncRepository 创建消息同步Repository
+func NewMessageSyncRepository(db *gorm.DB) MessageSyncRepository {
+	return &messageSyncRepositoryImpl{db: db}
+}
+
+// ListEvents 查询同步事件
+func (r *messageSyncRepositoryImpl) ListEvents(ctx context.Context, userID uint, afterSeq int64, limit int) ([]*model.MessageSyncEvent, error) {
+	var events []*model.MessageSyncEvent
+	err := r.db.WithContext(ctx).
+		Preload("Message").
+		Preload("Message.Sender").
+		Preload("Message.Receiver").
+		Preload("Message.Video").
+		Where("user_id = ? AND seq > ?", userID, afterSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// GetLatestSeq 获取用户当前最大序号
+func (r *messageSyncRepositoryImpl) GetLatestSeq(ctx context.Context, userID uint) (int64, error) {
+	var seq model.MessageSequence
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&seq).Error
+	return seq.LastSeq, err
+}
+
+// FindCursor 查询设备同步游标
+func (r *messageSyncRepositoryImpl) FindCursor(ctx context.Context, userID uint, deviceID string) (*model.MessageSyncCursor, error) {
+	var cursor model.MessageSyncCursor
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND device_id = ?", userID, deviceID).
+		First(&cursor).Error; err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SaveCursor 保存设备同步游标
+func (r *messageSyncRepositoryImpl) SaveCursor(ctx context.Context, cursor *model.MessageSyncCursor) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "device_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"platform":   cursor.Platform,
+			"last_seq":   gorm.Expr("GREATEST(message_sync_cursors.last_seq, EXCLUDED.last_seq)"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(cursor).Error
+}
+
+// appendSyncEvent 在事务中为用户分配下一个序号并写入同步事件
+// 序号行在事务提交前保持锁定，保证同一用户的事件按序号顺序可见
+func appendSyncEvent(tx *gorm.DB, evt *model.MessageSyncEvent) error {
+	var seq int64
+	if err := tx.Raw(
+		"INSERT INTO message_sequences (user_id, last_seq) VALUES (?, 1) "+
+			"ON CONFLICT (user_id) DO UPDATE SET last_seq = message_sequences.last_seq + 1 RETURNING last_seq",
+		evt.UserID,
+	).Scan(&seq).Error; err != nil {
+		return err
+	}
+
+	evt.Seq = seq
+	return tx.Create(evt).Error
+}
+
+// appendSyncEventForUsers 为多个用户写入同一事件（重复用户只写一次）
+// 按用户ID升序分配序号，避免并发事务以相反顺序锁定序号行导致死锁
+func appendSyncEventForUsers(tx *gorm.DB, template model.MessageSyncEvent, userIDs ...uint) error {
+	sorted := append([]uint(nil), userIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	seen := make(map[uint]bool, len(sorted))
+	for _, userID := range sorted {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		evt := template
+		evt.UserID = userID
+		if err := appendSyncEvent(tx, &evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// MuteRepository 负反馈（不感兴趣、屏蔽）数据访问层接口
+type MuteRepository interface {
+	// Create 创建屏蔽记录（已存在时忽略）
+	Create(ctx context.Context, mute *model.UserMute) error
+	// FindByID 根据ID查找屏蔽记录
+	FindByID(ctx context.Context, id uint) (*model.UserMute, error)
+	// Delete 删除用户的屏蔽记录
+	Delete(ctx context.Context, userID, id uint) error
+	// FindByUserID 分页查询用户的屏蔽记录，muteType 为 0 时返回所有类型
+	FindByUserID(ctx context.Context, userID uint, muteType int8, limit, offset int) ([]*model.UserMute, int64, error)
+	// FindAllByUserID 查询用户的所有屏蔽记录（推荐过滤使用）
+	FindAllByUserID(ctx context.Context, userID uint) ([]*model.UserMute, error)
+}
+
+type muteRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewMuteRepository 创建负反馈数据访问层实例
+func NewMuteRepository(db *gorm.DB) MuteRepository {
+	return &muteRepositoryImpl{db: db}
+}
+
+func (r *muteRepositoryImpl) Create(ctx context.Context, mute *model.UserMute) error {
+	return r.db.WithContext(ctx).FirstOrCreate(mute, model.UserMute{
+		UserID:   mute.UserID,
+		Type:     mute.Type,
+		TargetID: mute.TargetID,
+	}).Error
+}
+
+func (r *muteRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.UserMute, error) {
+	var mute model.UserMute
+	if err := r.db.WithContext(ctx).First(&mute, id).Error; err != nil {
+		return nil, err
+	}
+	return &mute, nil
+}
+
+func (r *muteRepositoryImpl) Delete(ctx context.Context, userID, id uint) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&model.UserMute{}).Error
+}
+
+func (r *muteRepositoryImpl) FindByUserID(ctx context.Context, userID uint, muteType int8, limit, offset int) ([]*model.UserMute, int64, error) {
+	var mutes []*model.UserMute
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.UserMute{}).Where("user_id = ?", userID)
+	if muteType > 0 {
+		db = db.Where("type = ?", muteType)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&mutes).Error
+
+	return mutes, total, err
+}
+
+func (r *muteRepositoryImpl) FindAllByUserID(ctx context.Context, userID uint) ([]*model.UserMute, error) {
+	var mutes []*model.UserMute
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Find(&mutes).Error
+	return mutes, err
+}
@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"microvibe-go/internal/model"
+	"microvibe-go/pkg/fulltext"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SearchRepository 搜索仓储层接口
@@ -20,16 +24,18 @@ type SearchRepository interface {
 	GetHotSearches(ctx context.Context, limit int) ([]*model.HotSearch, error)
 	// IncrementSearchCount 增加搜索次数
 	IncrementSearchCount(ctx context.Context, keyword string) error
-	// SearchVideos 搜索视频
-	SearchVideos(ctx context.Context, keyword string, page, pageSize int) ([]*model.Video, int64, error)
-	// SearchUsers 搜索用户
-	SearchUsers(ctx context.Context, keyword string, page, pageSize int) ([]*model.User, int64, error)
+	// SearchVideos 全文搜索视频（按相关度和热度综合排序）
+	SearchVideos(ctx context.Context, query *VideoSearchQuery, page, pageSize int) ([]*model.Video, int64, error)
+	// SearchVideoFacets 统计视频搜索结果的分类和时长分布
+	SearchVideoFacets(ctx context.Context, query *VideoSearchQuery) (*model.SearchFacets, error)
+	// SearchUsers 全文搜索用户，tsQuery 为 to_tsquery 表达式
+	SearchUsers(ctx context.Context, tsQuery string, page, pageSize int) ([]*model.User, int64, error)
 	// SearchBestMatchUser 搜索最佳匹配用户
 	SearchBestMatchUser(ctx context.Context, keyword string) (*model.User, error)
 	// GetUserRecentVideos 获取用户最近/置顶视频
 	GetUserRecentVideos(ctx context.Context, userID uint, limit int) ([]*model.Video, error)
-	// SearchHashtags 搜索话题
-	SearchHashtags(ctx context.Context, keyword string, page, pageSize int) ([]*model.Hashtag, int64, error)
+	// SearchHashtags 全文搜索话题，tsQuery 为 to_tsquery 表达式
+	SearchHashtags(ctx context.Context, tsQuery string, page, pageSize int) ([]*model.Hashtag, int64, error)
 	// RecommendUsers 推荐用户 (当搜索关键字为空时)
 	RecommendUsers(ctx context.Context, userID uint, page, pageSize int) ([]*model.User, int64, error)
 	// GetSuggestUsers 获取搜索建议用户
@@ -38,10 +44,48 @@ type SearchRepository interface {
 	GetSuggestHashtags(ctx context.Context, keyword string, limit int) ([]*model.Hashtag, error)
 	// DeleteHotSearch 删除热搜
 	DeleteHotSearch(ctx context.Context, keyword string) error
-	// UpdateHotSearchCount 更新热度计数
-	UpdateHotSearchCount(ctx context.Context, keyword string, count int64) error
+	// UpdateHotSearchBoost 设置热搜的运营加权（立即生效，不必等待下次趋势计算）
+	UpdateHotSearchBoost(ctx context.Context, keyword string, boost float64) error
+	// ApplyHotSearchTrends 写入趋势计算结果并重新计算排名，未出现在结果中的热搜热度衰减为运营加权
+	ApplyHotSearchTrends(ctx context.Context, trends []*HotSearchTrend, topN int) error
 }
 
+// HotSearchTrend 热搜趋势计算结果
+type HotSearchTrend struct {
+	Keyword string
+	Score   float64 // 滑动窗口内按时间衰减后的搜索次数
+	Label   string  // 趋势标记
+}
+
+// VideoSearchQuery 视频搜索条件
+type VideoSearchQuery struct {
+	TSQuery  string   // to_tsquery 表达式，为空时只按过滤条件查询
+	Hashtags []string // 必须带有的话题（多个话题同时满足）
+	Authors  []string // 作者用户名或昵称（满足其一）
+
+	CategoryID    uint       // 分类ID，0 表示不限
+	AuthorID      uint       // 作者ID，0 表示不限
+	Duration      string     // 时长区间名称（见 model.DurationBuckets），为空表示不限
+	PublishedFrom *time.Time // 发布时间下限（含）
+	PublishedTo   *time.Time // 发布时间上限（不含）
+	Sort          string     // 排序方式（见 model.SearchSort*），为空按相关度
+}
+
+// 分面统计时排除的筛选维度
+const (
+	facetNone = iota
+	facetCategory
+	facetDuration
+)
+
+// 相关度排序时热度和播放量的加权系数
+// 最终得分 = 文本相关度 * (1 + ln(1+热度)*searchHotWeight + ln(1+播放量)*searchPlayWeight)
+const (
+	searchHotWeight      = 0.2
+	searchPlayWeight     = 0.05
+	searchFollowerWeight = 0.1
+)
+
 // searchRepositoryImpl 搜索仓储层实现
 type searchRepositoryImpl struct {
 	db *gorm.DB
@@ -92,11 +136,10 @@ func (r *searchRepositoryImpl) IncrementSearchCount(ctx context.Context, keyword
 	err := r.db.WithContext(ctx).Where("keyword = ?", keyword).First(&hotSearch).Error
 
 	if err == gorm.ErrRecordNotFound {
-		// 不存在则创建
+		// 不存在则创建（热度由趋势任务计算）
 		hotSearch = model.HotSearch{
 			Keyword:     keyword,
 			SearchCount: 1,
-			HotScore:    1.0,
 		}
 		return r.db.WithContext(ctx).Create(&hotSearch).Error
 	}
@@ -105,36 +148,49 @@ func (r *searchRepositoryImpl) IncrementSearchCount(ctx context.Context, keyword
 		return err
 	}
 
-	// 存在则更新计数和热度
-	return r.db.WithContext(ctx).Model(&hotSearch).Updates(map[string]interface{}{
-		"search_count": gorm.Expr("search_count + 1"),
-		"hot_score":    gorm.Expr("hot_score + 0.1"),
-	}).Error
+	// 存在则更新累计搜索次数
+	return r.db.WithContext(ctx).Model(&hotSearch).
+		Update("search_count", gorm.Expr("search_count + 1")).Error
 }
 
-// SearchVideos 搜索视频
-func (r *searchRepositoryImpl) SearchVideos(ctx context.Context, keyword string, page, pageSize int) ([]*model.Video, int64, error) {
+// SearchVideos 全文搜索视频
+func (r *searchRepositoryImpl) SearchVideos(ctx context.Context, q *VideoSearchQuery, page, pageSize int) ([]*model.Video, int64, error) {
 	var videos []*model.Video
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&model.Video{}).
-		Where("status = ?", 1). // 只搜索已发布的视频
-		Where("title LIKE ? OR description LIKE ? OR tags LIKE ?",
-			"%"+keyword+"%",
-			"%"+keyword+"%",
-			"%"+keyword+"%")
+	query := r.videoSearchScope(ctx, q, facetNone)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	switch q.Sort {
+	case model.SearchSortNewest:
+		query = query.Order("videos.published_at DESC, videos.id DESC")
+	case model.SearchSortMostPlayed:
+		query = query.Order("videos.play_count DESC, videos.id DESC")
+	case model.SearchSortMostLiked:
+		query = query.Order("videos.like_count DESC, videos.id DESC")
+	default:
+		// 有关键词时按相关度与热度综合排序，只有过滤条件时按热度排序
+		if q.TSQuery != "" {
+			query = query.Clauses(clause.OrderBy{Expression: clause.Expr{
+				SQL: "ts_rank_cd(videos.search_vector, to_tsquery(?, ?), 32) * " +
+					"(1 + ln(1 + GREATEST(videos.hot_score, 0)) * ? + ln(1 + GREATEST(videos.play_count, 0)) * ?) DESC, videos.id DESC",
+				Vars:               []interface{}{fulltext.Config, q.TSQuery, searchHotWeight, searchPlayWeight},
+				WithoutParentheses: true,
+			}})
+		} else {
+			query = query.Order("videos.hot_score DESC, videos.created_at DESC")
+		}
+	}
+
 	// 分页查询
 	offset := (page - 1) * pageSize
 	err := query.
 		Preload("User").
 		Preload("Category").
-		Order("hot_score DESC, created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
 		Find(&videos).Error
@@ -142,27 +198,113 @@ func (r *searchRepositoryImpl) SearchVideos(ctx context.Context, keyword string,
 	return videos, total, err
 }
 
-// SearchUsers 搜索用户
-func (r *searchRepositoryImpl) SearchUsers(ctx context.Context, keyword string, page, pageSize int) ([]*model.User, int64, error) {
+// SearchVideoFacets 统计视频搜索结果的分类和时长分布
+func (r *searchRepositoryImpl) SearchVideoFacets(ctx context.Context, q *VideoSearchQuery) (*model.SearchFacets, error) {
+	facets := &model.SearchFacets{
+		Categories: []*model.CategoryFacet{},
+		Durations:  []*model.DurationFacet{},
+	}
+
+	// 分类分布（不受分类筛选影响）
+	if err := r.videoSearchScope(ctx, q, facetCategory).
+		Select("COALESCE(videos.category_id, 0) AS category_id, COALESCE(categories.name, '') AS name, COUNT(*) AS count").
+		Joins("LEFT JOIN categories ON categories.id = videos.category_id").
+		Group("videos.category_id, categories.name").
+		Order("count DESC").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	// 时长分布（不受时长筛选影响）
+	var bucketCase strings.Builder
+	bucketCase.WriteString("CASE")
+	for _, b := range model.DurationBuckets {
+		if b.Max > 0 {
+			fmt.Fprintf(&bucketCase, " WHEN videos.duration < %d THEN '%s'", b.Max, b.Name)
+		} else {
+			fmt.Fprintf(&bucketCase, " ELSE '%s'", b.Name)
+		}
+	}
+	bucketCase.WriteString(" END")
+
+	var rows []*model.DurationFacet
+	if err := r.videoSearchScope(ctx, q, facetDuration).
+		Select(bucketCase.String() + " AS bucket, COUNT(*) AS count").
+		Group("bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// 按区间顺序输出，没有结果的区间计数为 0
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket] = row.Count
+	}
+	for _, b := range model.DurationBuckets {
+		facets.Durations = append(facets.Durations, &model.DurationFacet{Bucket: b.Name, Count: counts[b.Name]})
+	}
+
+	return facets, nil
+}
+
+// videoSearchScope 构造视频搜索的查询条件，exclude 指定分面统计时忽略的筛选维度
+func (r *searchRepositoryImpl) videoSearchScope(ctx context.Context, q *VideoSearchQuery, exclude int) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.Video{}).
+		Where("videos.status = ?", model.VideoStatusPublished) // 只搜索已发布的视频
+
+	if q.TSQuery != "" {
+		query = query.Where("videos.search_vector @@ to_tsquery(?, ?)", fulltext.Config, q.TSQuery)
+	}
+	for _, name := range q.Hashtags {
+		query = query.Where("videos.id IN (SELECT vh.video_id FROM video_hashtags vh JOIN hashtags h ON h.id = vh.hashtag_id WHERE h.name = ?)", name)
+	}
+	if len(q.Authors) > 0 {
+		query = query.Where("videos.user_id IN (SELECT id FROM users WHERE username IN ? OR nickname IN ?)", q.Authors, q.Authors)
+	}
+	if q.AuthorID > 0 {
+		query = query.Where("videos.user_id = ?", q.AuthorID)
+	}
+	if q.PublishedFrom != nil {
+		query = query.Where("videos.published_at >= ?", *q.PublishedFrom)
+	}
+	if q.PublishedTo != nil {
+		query = query.Where("videos.published_at < ?", *q.PublishedTo)
+	}
+	if q.CategoryID > 0 && exclude != facetCategory {
+		query = query.Where("videos.category_id = ?", q.CategoryID)
+	}
+	if bucket, ok := model.FindDurationBucket(q.Duration); ok && exclude != facetDuration {
+		query = query.Where("videos.duration >= ?", bucket.Min)
+		if bucket.Max > 0 {
+			query = query.Where("videos.duration < ?", bucket.Max)
+		}
+	}
+
+	return query
+}
+
+// SearchUsers 全文搜索用户
+func (r *searchRepositoryImpl) SearchUsers(ctx context.Context, tsQuery string, page, pageSize int) ([]*model.User, int64, error) {
 	var users []*model.User
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.User{}).
 		Where("status = ?", 1). // 只搜索正常状态用户
-		Where("username LIKE ? OR nickname LIKE ? OR bio LIKE ?",
-			"%"+keyword+"%",
-			"%"+keyword+"%",
-			"%"+keyword+"%")
+		Where("search_vector @@ to_tsquery(?, ?)", fulltext.Config, tsQuery)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 分页查询
+	// 分页查询（相关度与粉丝数综合排序）
 	offset := (page - 1) * pageSize
 	err := query.
-		Order("follower_count DESC, created_at DESC").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank_cd(search_vector, to_tsquery(?, ?), 32) * (1 + ln(1 + GREATEST(follower_count, 0)) * ?) DESC, id DESC",
+			Vars:               []interface{}{fulltext.Config, tsQuery, searchFollowerWeight},
+			WithoutParentheses: true,
+		}}).
 		Offset(offset).
 		Limit(pageSize).
 		Find(&users).Error
@@ -170,23 +312,27 @@ func (r *searchRepositoryImpl) SearchUsers(ctx context.Context, keyword string,
 	return users, total, err
 }
 
-// SearchHashtags 搜索话题
-func (r *searchRepositoryImpl) SearchHashtags(ctx context.Context, keyword string, page, pageSize int) ([]*model.Hashtag, int64, error) {
+// SearchHashtags 全文搜索话题
+func (r *searchRepositoryImpl) SearchHashtags(ctx context.Context, tsQuery string, page, pageSize int) ([]*model.Hashtag, int64, error) {
 	var hashtags []*model.Hashtag
 	var total int64
 
 	query := r.db.WithContext(ctx).Model(&model.Hashtag{}).
-		Where("name LIKE ?", "%"+keyword+"%")
+		Where("search_vector @@ to_tsquery(?, ?)", fulltext.Config, tsQuery)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 分页查询
+	// 分页查询（相关度与热度综合排序）
 	offset := (page - 1) * pageSize
 	err := query.
-		Order("hot_score DESC, view_count DESC").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank_cd(search_vector, to_tsquery(?, ?), 32) * (1 + ln(1 + GREATEST(hot_score, 0)) * ?) DESC, view_count DESC",
+			Vars:               []interface{}{fulltext.Config, tsQuery, searchHotWeight},
+			WithoutParentheses: true,
+		}}).
 		Offset(offset).
 		Limit(pageSize).
 		Find(&hashtags).Error
@@ -342,10 +488,58 @@ func (r *searchRepositoryImpl) DeleteHotSearch(ctx context.Context, keyword stri
 	return r.db.WithContext(ctx).Where("keyword = ?", keyword).Delete(&model.HotSearch{}).Error
 }
 
-// UpdateHotSearchCount 更新热度计数
-func (r *searchRepositoryImpl) UpdateHotSearchCount(ctx context.Context, keyword string, count int64) error {
-	return r.db.WithContext(ctx).Model(&model.HotSearch{}).Where("keyword = ?", keyword).Updates(map[string]interface{}{
-		"search_count": count,
-		"hot_score":    float64(count) * 0.1, // 简化的热度计算
+// UpdateHotSearchBoost 设置热搜的运营加权
+func (r *searchRepositoryImpl) UpdateHotSearchBoost(ctx context.Context, keyword string, boost float64) error {
+	result := r.db.WithContext(ctx).Model(&model.HotSearch{}).Where("keyword = ?", keyword).Updates(map[string]interface{}{
+		"hot_score": gorm.Expr("hot_score - boost + ?", boost),
+		"boost":     boost,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Create(&model.HotSearch{
+		Keyword:  keyword,
+		HotScore: boost,
+		Boost:    boost,
 	}).Error
 }
+
+// ApplyHotSearchTrends 写入趋势计算结果并重新计算排名
+func (r *searchRepositoryImpl) ApplyHotSearchTrends(ctx context.Context, trends []*HotSearchTrend, topN int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先将所有热搜还原为只有运营加权
+		if err := tx.Model(&model.HotSearch{}).
+			Where("hot_score <> boost OR rank <> 0 OR label <> ''").
+			Updates(map[string]interface{}{
+				"hot_score": gorm.Expr("boost"),
+				"rank":      0,
+				"label":     "",
+			}).Error; err != nil {
+			return err
+		}
+
+		for _, t := range trends {
+			if err := tx.Model(&model.HotSearch{}).
+				Where("keyword = ?", t.Keyword).
+				Updates(map[string]interface{}{
+					"hot_score": gorm.Expr("boost + ?", t.Score),
+					"label":     t.Label,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 按热度重新排名，只有前 topN 名上榜
+		return tx.Exec(`
+			UPDATE hot_searches h SET rank = r.rn
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY hot_score DESC, search_count DESC) AS rn
+				FROM hot_searches WHERE hot_score > 0
+			) r
+			WHERE h.id = r.id AND r.rn <= ?`, topN).Error
+	})
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"microvibe-go/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VideoProcessJobRepository 视频处理任务数据访问接口
+type VideoProcessJobRepository interface {
+	// Create 创建任务
+	Create(ctx context.Context, job *model.VideoProcessJob) error
+
+	// FindLatestByVideoID 查询视频最近一次的处理任务
+	FindLatestByVideoID(ctx context.Context, videoID uint) (*model.VideoProcessJob, error)
+
+	// Claim 领取一个可执行的任务（到期的待执行任务，或租约已超时的执行中任务）
+	// 没有可执行任务时返回 gorm.ErrRecordNotFound
+	Claim(ctx context.Context, workerID string, leaseTimeout time.Duration) (*model.VideoProcessJob, error)
+
+	// Renew 续约任务（仅任务仍由该 worker 持有时生效）
+	Renew(ctx context.Context, jobID uint, workerID string) error
+
+	// Update 更新任务字段（仅任务仍由该 worker 持有时生效，租约被接管时返回 gorm.ErrRecordNotFound）
+	Update(ctx context.Context, jobID uint, workerID string, fields map[string]interface{}) error
+}
+
+type videoProcessJobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewVideoProcessJobRepository 创建视频处理任务Repository
+func NewVideoProcessJobRepository(db *gorm.DB) VideoProcessJobRepository {
+	return &videoProcessJobRepositoryImpl{db: db}
+}
+
+// Create 创建任务
+func (r *videoProcessJobRepositoryImpl) Create(ctx context.Context, job *model.VideoProcessJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// FindLatestByVideoID 查询视频最近一次的处理任务
+func (r *videoProcessJobRepositoryImpl) FindLatestByVideoID(ctx context.Context, videoID uint) (*model.VideoProcessJob, error) {
+	var job model.VideoProcessJob
+	if err := r.db.WithContext(ctx).
+		Where("video_id = ?", videoID).
+		Order("id DESC").
+		First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim 领取一个可执行的任务
+// 使用 FOR UPDATE SKIP LOCKED 保证多个 worker（包括其他实例上的 worker）不会领取同一个任务
+func (r *videoProcessJobRepositoryImpl) Claim(ctx context.Context, workerID string, leaseTimeout time.Duration) (*model.VideoProcessJob, error) {
+	now := time.Now()
+
+	var job model.VideoProcessJob
+	result := r.db.WithContext(ctx).Raw(`
+		UPDATE video_process_jobs
+		SET status = ?, locked_by = ?, locked_at = ?, attempts = attempts + 1,
+			started_at = COALESCE(started_at, ?), updated_at = ?
+		WHERE id = (
+			SELECT id FROM video_process_jobs
+			WHERE (status = ? AND next_run_at <= ?) OR (status = ? AND locked_at < ?)
+			ORDER BY next_run_at ASC, id ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.VideoProcessJobRunning, workerID, now, now, now,
+		model.VideoProcessJobPending, now, model.VideoProcessJobRunning, now.Add(-leaseTimeout),
+	).Scan(&job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+// Renew 续约任务
+func (r *videoProcessJobRepositoryImpl) Renew(ctx context.Context, jobID uint, workerID string) error {
+	return r.Update(ctx, jobID, workerID, map[string]interface{}{"locked_at": time.Now()})
+}
+
+// Update 更新任务字段
+func (r *videoProcessJobRepositoryImpl) Update(ctx context.Context, jobID uint, workerID string, fields map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&model.VideoProcessJob{}).
+		Where("id = ? AND locked_by = ? AND status = ?", jobID, workerID, model.VideoProcessJobRunning).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -22,6 +22,15 @@ type VideoStatsRepository interface {
 	SumByUserID(ctx context.Context, userID uint) (*VideoStatsSummary, error)
 	// SumTrendingByUserID 聚合创作者近期趋势统计
 	SumTrendingByUserID(ctx context.Context, userID uint, days int) ([]*TrendingStats, error)
+	// SumByDateRange 聚合全站时间范围内的统计数据
+	SumByDateRange(ctx context.Context, from, to time.Time) (*DateRangeStats, error)
+}
+
+// DateRangeStats 全站时间范围内的统计汇总
+type DateRangeStats struct {
+	PlayCount  int64   `json:"play_count"`
+	LikeCount  int64   `json:"like_count"`
+	FinishRate float64 `json:"finish_rate"` // 按播放量加权的完播率
 }
 
 // TrendingStats 趋势统计项
@@ -188,3 +197,25 @@ func (r *videoStatsRepositoryImpl) SumTrendingByUserID(ctx context.Context, user
 
 	return stats, nil
 }
+
+// SumByDateRange 聚合全站时间范围内的统计数据（to 不包含）
+func (r *videoStatsRepositoryImpl) SumByDateRange(ctx context.Context, from, to time.Time) (*DateRangeStats, error) {
+	var stats DateRangeStats
+
+	err := r.db.WithContext(ctx).
+		Model(&model.VideoStats{}).
+		Select(`
+			COALESCE(SUM(play_count), 0) as play_count,
+			COALESCE(SUM(like_count), 0) as like_count,
+			COALESCE(SUM(finish_rate * play_count) / NULLIF(SUM(play_count), 0), 0) as finish_rate
+		`).
+		Where("date >= ? AND date < ?", from, to).
+		Scan(&stats).Error
+
+	if err != nil {
+		logger.Error("聚合全站统计失败", zap.Error(err))
+		return nil, err
+	}
+
+	return &stats, nil
+}
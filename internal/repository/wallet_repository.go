@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microvibe-go/internal/model"
+	"microvibe-go/pkg/cache"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientBalance 余额不足（扣费时钱包不存在或余额小于扣费金额）
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// WalletRepository 钱包数据访问接口
+// 所有余额变动都在同一事务内写入交易记录和复式记账分录，分录只追加不修改
+type WalletRepository interface {
+	// FindByUserID 查询用户钱包
+	FindByUserID(ctx context.Context, userID uint) (*model.Wallet, error)
+
+	// FindTransactionByRequestID 根据客户端请求ID查询交易（用于幂等）
+	FindTransactionByRequestID(ctx context.Context, userID uint, requestID string) (*model.WalletTransaction, error)
+
+	// ListTransactions 分页查询用户交易记录（含分录）
+	ListTransactions(ctx context.Context, userID uint, page, pageSize int) ([]*model.WalletTransaction, int64, error)
+
+	// Recharge 充值：增加用户余额，平台充值科目出账
+	Recharge(ctx context.Context, txn *model.WalletTransaction) error
+
+	// Spend 消费：扣减用户余额，平台收入科目入账（余额不足时返回 ErrInsufficientBalance）
+	Spend(ctx context.Context, txn *model.WalletTransaction) error
+
+	// SpendForGift 送礼扣费：在同一事务内扣减送礼人余额、创建送礼记录、
+	// 增加主播收益、记录平台抽成并累加直播间礼物统计，任一步失败整体回滚
+	SpendForGift(ctx context.Context, txn *model.WalletTransaction, record *model.LiveGiftRecord, streamerShare int64) error
+}
+
+type walletRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewWalletRepository 创建钱包Repository
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepositoryImpl{db: db}
+}
+
+// FindByUserID 查询用户钱包
+func (r *walletRepositoryImpl) FindByUserID(ctx context.Context, userID uint) (*model.Wallet, error) {
+	var wallet model.Wallet
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// FindTransactionByRequestID 根据客户端请求ID查询交易
+func (r *walletRepositoryImpl) FindTransactionByRequestID(ctx context.Context, userID uint, requestID string) (*model.WalletTransaction, error) {
+	var txn model.WalletTransaction
+	err := r.db.WithContext(ctx).
+		Preload("Entries").
+		Where("user_id = ? AND request_id = ?", userID, requestID).
+		First(&txn).Error
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// ListTransactions 分页查询用户交易记录
+func (r *walletRepositoryImpl) ListTransactions(ctx context.Context, userID uint, page, pageSize int) ([]*model.WalletTransaction, int64, error) {
+	var txns []*model.WalletTransaction
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.WalletTransaction{}).Where("user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		Preload("Entries").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&txns).Error
+
+	return txns, total, err
+}
+
+// Recharge 充值
+func (r *walletRepositoryImpl) Recharge(ctx context.Context, txn *model.WalletTransaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 写交易记录（request_id 重复时唯一索引报错，整体回滚）
+		if err := tx.Omit("Entries").Create(txn).Error; err != nil {
+			return err
+		}
+
+		// 2. 增加用户余额
+		wallet, err := creditWallet(tx, txn.UserID, map[string]interface{}{
+			"balance":         gorm.Expr("balance + ?", txn.Amount),
+			"total_recharged": gorm.Expr("total_recharged + ?", txn.Amount),
+		})
+		if err != nil {
+			return err
+		}
+
+		// 3. 记账：用户余额入账，平台充值科目出账
+		entries := []model.WalletLedgerEntry{
+			{TransactionID: txn.ID, Account: model.WalletAccountBalance, UserID: txn.UserID, Amount: txn.Amount, BalanceAfter: wallet.Balance},
+			{TransactionID: txn.ID, Account: model.WalletAccountPlatformFunding, Amount: -txn.Amount},
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return err
+		}
+		txn.Entries = entries
+		return nil
+	})
+}
+
+// Spend 消费
+func (r *walletRepositoryImpl) Spend(ctx context.Context, txn *model.WalletTransaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 扣减用户余额
+		wallet, err := debitWallet(tx, txn.UserID, txn.Amount)
+		if err != nil {
+			return err
+		}
+
+		// 2. 写交易记录（request_id 重复时唯一索引报错，整体回滚）
+		if err := tx.Omit("Entries").Create(txn).Error; err != nil {
+			return err
+		}
+
+		// 3. 记账：用户余额出账，平台收入入账
+		entries := []model.WalletLedgerEntry{
+			{TransactionID: txn.ID, Account: model.WalletAccountBalance, UserID: txn.UserID, Amount: -txn.Amount, BalanceAfter: wallet.Balance},
+			{TransactionID: txn.ID, Account: model.WalletAccountPlatformRevenue, Amount: txn.Amount},
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return err
+		}
+		txn.Entries = entries
+		return nil
+	})
+}
+
+// SpendForGift 送礼扣费（自动清除直播间缓存）
+func (r *walletRepositoryImpl) SpendForGift(ctx context.Context, txn *model.WalletTransaction, record *model.LiveGiftRecord, streamerShare int64) error {
+	key := fmt.Sprintf("livestream:id:%d", record.LiveID)
+	return cache.WithCacheEvict(
+		cache.CacheConfig{
+			CacheName: "livestream",
+			KeyPrefix: "livestream:id",
+		},
+		func() error {
+			return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				// 1. 扣减送礼人余额
+				sender, err := debitWallet(tx, txn.UserID, txn.Amount)
+				if err != nil {
+					return err
+				}
+
+				// 2. 创建送礼记录
+				if err := tx.Create(record).Error; err != nil {
+					return err
+				}
+
+				// 3. 写交易记录（request_id 重复时唯一索引报错，整体回滚）
+				txn.BizID = record.ID
+				if err := tx.Omit("Entries").Create(txn).Error; err != nil {
+					return err
+				}
+
+				// 4. 增加主播收益
+				streamer, err := creditWallet(tx, record.TargetID, map[string]interface{}{
+					"earnings":     gorm.Expr("earnings + ?", streamerShare),
+					"total_earned": gorm.Expr("total_earned + ?", streamerShare),
+				})
+				if err != nil {
+					return err
+				}
+
+				// 5. 记账：送礼人余额出账 = 主播收益入账 + 平台抽成入账
+				entries := []model.WalletLedgerEntry{
+					{TransactionID: txn.ID, Account: model.WalletAccountBalance, UserID: txn.UserID, Amount: -txn.Amount, BalanceAfter: sender.Balance},
+					{TransactionID: txn.ID, Account: model.WalletAccountEarnings, UserID: record.TargetID, Amount: streamerShare, BalanceAfter: streamer.Earnings},
+				}
+				if platformShare := txn.Amount - streamerShare; platformShare != 0 {
+					entries = append(entries, model.WalletLedgerEntry{
+						TransactionID: txn.ID, Account: model.WalletAccountPlatformRevenue, Amount: platformShare,
+					})
+				}
+				if err := tx.Create(&entries).Error; err != nil {
+					return err
+				}
+				txn.Entries = entries
+
+				// 6. 累加直播间礼物统计
+				return tx.Model(&model.LiveStream{}).
+					Where("id = ?", record.LiveID).
+					Updates(map[string]interface{}{
+						"gift_count": gorm.Expr("gift_count + ?", record.Quantity),
+						"gift_value": gorm.Expr("gift_value + ?", record.TotalValue),
+					}).Error
+			})
+		},
+	)(ctx, key)
+}
+
+// debitWallet 在事务内扣减用户余额并返回扣减后的钱包
+// 条件更新保证余额不会扣成负数，余额不足时不影响任何行并返回 ErrInsufficientBalance
+func debitWallet(tx *gorm.DB, userID uint, amount int64) (*model.Wallet, error) {
+	result := tx.Model(&model.Wallet{}).
+		Where("user_id = ? AND balance >= ?", userID, amount).
+		Updates(map[string]interface{}{
+			"balance":     gorm.Expr("balance - ?", amount),
+			"total_spent": gorm.Expr("total_spent + ?", amount),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInsufficientBalance
+	}
+
+	var wallet model.Wallet
+	if err := tx.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// postCommission 在事务内记入或冲回主播带货佣金，写交易记录和记账分录
+// 佣金由平台带货科目支出；冲回时佣金余额可能为负（佣金已被使用），由后续入账抵扣
+func postCommission(tx *gorm.DB, txn *model.WalletTransaction) error {
+	amount := txn.Amount
+	if txn.Type == model.WalletTxnTypeCommissionReversal {
+		amount = -amount
+	}
+
+	// 1. 写交易记录（request_id 按订单生成，重复记账时唯一索引报错，整体回滚）
+	if err := tx.Omit("Entries").Create(txn).Error; err != nil {
+		return err
+	}
+
+	// 2. 变更主播佣金
+	wallet, err := creditWallet(tx, txn.UserID, map[string]interface{}{
+		"commission": gorm.Expr("commission + ?", amount),
+	})
+	if err != nil {
+		return err
+	}
+
+	// 3. 记账：主播佣金入账（冲回时出账），平台带货科目反向记账
+	entries := []model.WalletLedgerEntry{
+		{TransactionID: txn.ID, Account: model.WalletAccountCommission, UserID: txn.UserID, Amount: amount, BalanceAfter: wallet.Commission},
+		{TransactionID: txn.ID, Account: model.WalletAccountPlatformCommerce, Amount: -amount},
+	}
+	if err := tx.Create(&entries).Error; err != nil {
+		return err
+	}
+	txn.Entries = entries
+	return nil
+}
+
+// creditWallet 在事务内按需创建钱包并执行入账更新，返回更新后的钱包
+func creditWallet(tx *gorm.DB, userID uint, updates map[string]interface{}) (*model.Wallet, error) {
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(&model.Wallet{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&model.Wallet{}).Where("user_id = ?", userID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	var wallet model.Wallet
+	if err := tx.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
@@ -1,13 +1,16 @@
 package router
 
 import (
+	"context"
 	"microvibe-go/internal/algorithm/recommend"
 	"microvibe-go/internal/config"
 	"microvibe-go/internal/handler"
 	"microvibe-go/internal/middleware"
 	"microvibe-go/internal/repository"
 	"microvibe-go/internal/service"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/payment"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -16,8 +19,8 @@ import (
 	"gorm.io/gorm"
 )
 
-// Setup 设置路由
-func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engine {
+// Setup 设置路由，返回的 cleanup 函数在服务关闭时调用，用于停止后台组件
+func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) (*gin.Engine, func()) {
 	r := gin.Default()
 
 	// CORS 中间件
@@ -54,27 +57,42 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 	videoRepo := repository.NewVideoRepository(db)
 	likeRepo := repository.NewLikeRepository(db)
 	favoriteRepo := repository.NewFavoriteRepository(db)
+	favoriteFolderRepo := repository.NewFavoriteFolderRepository(db)
 	commentRepo := repository.NewCommentRepository(db, redisClient)
 	liveRepo := repository.NewLiveStreamRepository(db)
 	banRepo := repository.NewLiveBanRepository(db)
+	liveAdminRepo := repository.NewLiveAdminRepository(db)
+	liveGiftRepo := repository.NewLiveGiftRepository(db)
+	liveRankRepo := repository.NewLiveRankRepository(db)
+	liveCommentRepo := repository.NewLiveCommentRepository(db)
+	liveFansClubRepo := repository.NewLiveFansClubRepository(db)
+	liveProductRepo := repository.NewLiveProductRepository(db)
+	liveOrderRepo := repository.NewLiveOrderRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
 	searchRepo := repository.NewSearchRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
+	messageSyncRepo := repository.NewMessageSyncRepository(db)
+	groupConversationRepo := repository.NewGroupConversationRepository(db)
+	videoProcessJobRepo := repository.NewVideoProcessJobRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
 	hashtagRepo := repository.NewHashtagRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
 	blacklistRepo := repository.NewBlacklistRepository(db)
+	muteRepo := repository.NewMuteRepository(db)
 	reportRepo := repository.NewReportRepository(db)
 	shareRepo := repository.NewShareRepository(db)
 	videoStatsRepo := repository.NewVideoStatsRepository(db)
 	videoHistoryRepo := repository.NewVideoHistoryRepository(db)
 	userVisitorRepo := repository.NewUserVisitorRepository(db)
 	behaviorRepo := repository.NewBehaviorRepository(db)
+	experimentRepo := repository.NewExperimentRepository(db)
 
 	// 初始化 Service 层
 	userService := service.NewUserService(userRepo, followRepo, profileRepo)
 	videoService := service.NewVideoService(videoRepo, likeRepo, favoriteRepo, followRepo, cfg)
 	commentService := service.NewCommentService(commentRepo, videoRepo)
-	liveService := service.NewLiveStreamService(liveRepo, banRepo, cfg)
+	favoriteFolderService := service.NewFavoriteFolderService(favoriteFolderRepo, favoriteRepo, userRepo)
+	liveService := service.NewLiveStreamService(liveRepo, banRepo, liveAdminRepo, cfg)
 
 	// 初始化 SFU 客户端服务（如果启用）
 	var sfuClient service.SFUClientService
@@ -85,9 +103,69 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 	// 初始化信令服务
 	signalingService := service.NewLiveSignalingService(liveService, sfuClient, cfg.SFU.Enabled, cfg)
 
+	// 多实例部署时通过 Redis 共享直播间广播和在线人数
+	var roomBroker service.LiveRoomBroker
+	if cfg.Cluster.Broker == "redis" && redisClient != nil {
+		if ss, ok := signalingService.(interface{ SetRoomBroker(service.LiveRoomBroker) }); ok {
+			roomBroker = service.NewRedisLiveRoomBroker(redisClient)
+			ss.SetRoomBroker(roomBroker)
+		}
+	}
+
+	walletService := service.NewWalletService(walletRepo, liveGiftRepo, cfg)
+	liveRankService := service.NewLiveRankService(redisClient, liveRankRepo, liveGiftRepo, userRepo, cfg)
+	liveGiftService := service.NewLiveGiftService(liveGiftRepo, liveRepo, userRepo, walletService, liveRankService)
+	if redisClient != nil {
+		// 直播结束时保存本场礼物榜快照
+		if err := liveRankService.RegisterHandlers(event.GetGlobalEventBus()); err != nil {
+			logger.Error("订阅礼物榜事件失败", zap.Error(err))
+		}
+	}
+	liveFansClubService := service.NewLiveFansClubService(liveFansClubRepo, liveRepo, redisClient, cfg)
+	// 观看、弹幕、点赞、送礼按规则增加粉丝团经验
+	if err := liveFansClubService.RegisterHandlers(event.GetGlobalEventBus()); err != nil {
+		logger.Error("订阅粉丝团经验事件失败", zap.Error(err))
+	}
+	// 不活跃成员的等级衰减（依赖 Redis，多实例部署时每个周期只有一个实例执行）
+	if redisClient != nil && cfg.Live.FansClub.DecayInterval > 0 {
+		liveFansClubService.Start(context.Background())
+	}
+	liveProductService := service.NewLiveProductService(liveProductRepo, liveRepo)
+	paymentProvider, err := payment.NewProvider(cfg.Commerce.PaymentProvider, cfg.Commerce.PaymentSecret)
+	if err != nil {
+		logger.Fatal("初始化支付渠道失败", zap.Error(err))
+	}
+	liveOrderService := service.NewLiveOrderService(liveOrderRepo, liveProductRepo, liveRepo, userRepo, paymentProvider, cfg)
+	// 关闭超时未支付的订单并释放库存（订单状态条件更新，多实例同时扫描也只关闭一次）
+	if cfg.Commerce.ExpireInterval > 0 {
+		liveOrderService.Start(context.Background())
+	}
+	liveCommentService := service.NewLiveCommentService(liveCommentRepo, liveRepo, liveAdminRepo, cfg)
+
 	searchService := service.NewSearchService(searchRepo, followRepo, likeRepo, favoriteRepo)
-	messageService := service.NewMessageService(messageRepo, notificationRepo, userRepo, videoRepo)
+	messageService := service.NewMessageService(messageRepo, groupConversationRepo, notificationRepo, userRepo, videoRepo, blacklistRepo)
 	messageSignalingService := service.NewMessageSignalingService(cfg)
+	messageSyncService := service.NewMessageSyncService(messageSyncRepo)
+	groupChatService := service.NewGroupChatService(groupConversationRepo, userRepo, blacklistRepo)
+	videoProcessService := service.NewVideoProcessService(videoProcessJobRepo, videoRepo, cfg)
+
+	// 启动视频处理 worker（worker 数为 0 时本实例只接收上传，不处理任务）
+	if cfg.VideoProcessing.Workers > 0 {
+		videoProcessService.Start(context.Background())
+	}
+
+	// 直播录制文件经视频处理任务转码后发布为回放
+	liveReplayService := service.NewLiveReplayService(videoRepo, liveRepo, followRepo, videoProcessService, messageService, cfg)
+	if err := liveReplayService.RegisterHandlers(event.GetGlobalEventBus()); err != nil {
+		logger.Error("订阅直播回放事件失败", zap.Error(err))
+	}
+
+	// 多实例部署时通过 Redis 登记在线状态并跨实例路由私信推送
+	if cfg.Cluster.Broker == "redis" && redisClient != nil {
+		if ms, ok := messageSignalingService.(interface{ SetHubBroker(service.MessageHubBroker) }); ok {
+			ms.SetHubBroker(service.NewRedisMessageHubBroker(redisClient))
+		}
+	}
 
 	// 注入信令服务到消息服务
 	if ms, ok := messageService.(interface {
@@ -99,15 +177,39 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 	hashtagService := service.NewHashtagService(hashtagRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
 	blacklistService := service.NewBlacklistService(blacklistRepo, userRepo)
+	muteService := service.NewMuteService(muteRepo, videoRepo, userRepo, hashtagRepo, categoryRepo)
 	reportService := service.NewReportService(reportRepo, userRepo, videoRepo, commentRepo)
 	shareService := service.NewShareService(shareRepo, videoRepo)
-	videoHistoryService := service.NewVideoHistoryService(videoHistoryRepo, behaviorRepo, likeRepo, favoriteRepo, followRepo)
+	videoHistoryService := service.NewVideoHistoryService(videoHistoryRepo, behaviorRepo, likeRepo, favoriteRepo, followRepo, redisClient)
+	// 客户端不上报离开时，按空闲超时结束观看并发出观看信号（快速划走记为负向兴趣）
+	videoHistoryService.Start(context.Background())
 	videoStatsService := service.NewVideoStatsService(videoStatsRepo, videoRepo, followRepo)
 	userVisitorService := service.NewUserVisitorService(userVisitorRepo, followRepo)
 	adminService := service.NewAdminService(userRepo, videoRepo, commentRepo, searchRepo, reportRepo)
 
-	// 推荐引擎
-	recommendEngine := recommend.NewEngine(db, redisClient)
+	// 热搜和热门话题按时间衰减的趋势计算（依赖 Redis）
+	trendingService := service.NewTrendingService(redisClient, searchRepo, hashtagRepo, cfg)
+	if redisClient != nil && cfg.Trending.Interval > 0 {
+		trendingService.Start(context.Background())
+	}
+
+	// 推荐引擎和 A/B 实验
+	recommendEngine := recommend.NewEngine(db, redisClient, &cfg.Recommend)
+	experimentService := service.NewExperimentService(experimentRepo, videoStatsRepo)
+	recommendEngine.SetExperimentProvider(experimentService)
+
+	// 视频相似度离线计算（依赖 Redis，多实例部署时只有一个实例执行计算）
+	if redisClient != nil && cfg.Recommend.Similarity.Interval > 0 {
+		recommend.NewSimilarityBuilder(db, redisClient, &cfg.Recommend.Similarity).Start(context.Background())
+	}
+
+	// 用户兴趣由事件总线上的观看、点赞、评论、分享事件实时更新（依赖 Redis）
+	if redisClient != nil {
+		if err := recommendEngine.SubscribeEvents(event.GetGlobalEventBus()); err != nil {
+			logger.Error("订阅用户兴趣事件失败", zap.Error(err))
+		}
+		recommendEngine.StartInterestFlush(context.Background())
+	}
 
 	// 后置注入依赖
 	if vs, ok := videoService.(interface{ SetRecommendEngine(*recommend.Engine) }); ok {
@@ -122,9 +224,18 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 	if ss, ok := shareService.(interface{ SetRecommendEngine(*recommend.Engine) }); ok {
 		ss.SetRecommendEngine(recommendEngine)
 	}
+	if ms, ok := muteService.(interface{ SetRecommendEngine(*recommend.Engine) }); ok {
+		ms.SetRecommendEngine(recommendEngine)
+	}
 	if ms, ok := messageService.(interface{ SetSignalingService(service.MessageSignalingService) }); ok {
 		ms.SetSignalingService(messageSignalingService)
 	}
+	if ms, ok := messageSignalingService.(interface{ SetSyncService(service.MessageSyncService) }); ok {
+		ms.SetSyncService(messageSyncService)
+	}
+	if gs, ok := groupChatService.(interface{ SetSignalingService(service.MessageSignalingService) }); ok {
+		gs.SetSignalingService(messageSignalingService)
+	}
 	if vs, ok := videoService.(interface{ SetHashtagService(service.HashtagService) }); ok {
 		vs.SetHashtagService(hashtagService)
 	}
@@ -137,6 +248,9 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 	if vs, ok := videoService.(interface{ SetUserRepo(repository.UserRepository) }); ok {
 		vs.SetUserRepo(userRepo)
 	}
+	if vs, ok := videoService.(interface{ SetFavoriteFolderRepo(repository.FavoriteFolderRepository) }); ok {
+		vs.SetFavoriteFolderRepo(favoriteFolderRepo)
+	}
 	if cs, ok := commentService.(interface{ SetMessageService(service.MessageService) }); ok {
 		cs.SetMessageService(messageService)
 	}
@@ -152,18 +266,69 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 	if us, ok := userService.(interface{ SetMessageService(service.MessageService) }); ok {
 		us.SetMessageService(messageService)
 	}
+	if ls, ok := liveService.(interface{ SetSignalingService(service.LiveSignalingService) }); ok {
+		ls.SetSignalingService(signalingService)
+	}
+	if ls, ok := liveService.(interface{ SetReplayService(service.LiveReplayService) }); ok {
+		ls.SetReplayService(liveReplayService)
+	}
+	if ss, ok := signalingService.(interface{ SetCommentService(service.LiveCommentService) }); ok {
+		ss.SetCommentService(liveCommentService)
+	}
+	if cs, ok := liveCommentService.(interface{ SetSignalingService(service.LiveSignalingService) }); ok {
+		cs.SetSignalingService(signalingService)
+	}
+	if cs, ok := liveCommentService.(interface{ SetFansClubService(service.LiveFansClubService) }); ok {
+		cs.SetFansClubService(liveFansClubService)
+	}
+	if ss, ok := signalingService.(interface{ SetFansClubService(service.LiveFansClubService) }); ok {
+		ss.SetFansClubService(liveFansClubService)
+	}
+	if gs, ok := liveGiftService.(interface{ SetSignalingService(service.LiveSignalingService) }); ok {
+		gs.SetSignalingService(signalingService)
+	}
+	if rs, ok := liveRankService.(interface{ SetSignalingService(service.LiveSignalingService) }); ok {
+		rs.SetSignalingService(signalingService)
+	}
+	if ps, ok := liveProductService.(interface{ SetSignalingService(service.LiveSignalingService) }); ok {
+		ps.SetSignalingService(signalingService)
+	}
+	if ls, ok := liveOrderService.(interface{ SetSignalingService(service.LiveSignalingService) }); ok {
+		ls.SetSignalingService(signalingService)
+	}
+	if ss, ok := searchService.(interface{ SetTrendingService(service.TrendingService) }); ok {
+		ss.SetTrendingService(trendingService)
+	}
+	if hs, ok := hashtagService.(interface{ SetTrendingService(service.TrendingService) }); ok {
+		hs.SetTrendingService(trendingService)
+	}
+	if hs, ok := videoHistoryService.(interface{ SetExperimentService(service.ExperimentService) }); ok {
+		hs.SetExperimentService(experimentService)
+	}
 
 	// 初始化 Handler 层
 	userHandler := handler.NewUserHandler(userService, userVisitorService, cfg, tokenBlacklist)
 	adminHandler := handler.NewAdminHandler(adminService)
-	videoHandler := handler.NewVideoHandler(recommendEngine, videoService)
+	experimentHandler := handler.NewExperimentHandler(experimentService)
+	videoHandler := handler.NewVideoHandler(recommendEngine, videoService, videoProcessService)
 	commentHandler := handler.NewCommentHandler(commentService)
+	favoriteFolderHandler := handler.NewFavoriteFolderHandler(favoriteFolderService, videoService)
 	liveHandler := handler.NewLiveStreamHandler(liveService, cfg)
+	liveWebhookHandler := handler.NewLiveWebhookHandler(liveService)
+	liveGiftHandler := handler.NewLiveGiftHandler(liveGiftService)
+	liveFansClubHandler := handler.NewLiveFansClubHandler(liveFansClubService)
+	liveProductHandler := handler.NewLiveProductHandler(liveProductService)
+	liveOrderHandler := handler.NewLiveOrderHandler(liveOrderService, paymentProvider)
+	liveCommentHandler := handler.NewLiveCommentHandler(liveCommentService)
+	walletHandler := handler.NewWalletHandler(walletService)
 	searchHandler := handler.NewSearchHandler(searchService)
 	messageHandler := handler.NewMessageHandler(messageService)
+	messageSyncHandler := handler.NewMessageSyncHandler(messageSyncService)
+	groupChatHandler := handler.NewGroupChatHandler(groupChatService)
 	hashtagHandler := handler.NewHashtagHandler(hashtagService, videoService)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
 	blacklistHandler := handler.NewBlacklistHandler(blacklistService)
+	muteHandler := handler.NewMuteHandler(muteService)
 	reportHandler := handler.NewReportHandler(reportService)
 	shareHandler := handler.NewShareHandler(shareService)
 	videoHistoryHandler := handler.NewVideoHistoryHandler(videoHistoryService)
@@ -237,6 +402,9 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 				users.POST("/blacklist", blacklistHandler.BlockUser)
 				users.DELETE("/blacklist/:id", blacklistHandler.UnblockUser)
 				users.GET("/blacklist", blacklistHandler.GetBlacklist)
+				users.GET("/mutes", muteHandler.ListMutes)
+				users.POST("/mutes", muteHandler.Mute)
+				users.DELETE("/mutes/:id", muteHandler.Unmute)
 				users.GET("/me/history", videoHistoryHandler.GetHistory)
 				users.DELETE("/me/history", videoHistoryHandler.ClearHistory)
 				users.DELETE("/me/history/:id", videoHistoryHandler.DeleteHistory)
@@ -256,6 +424,7 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 			videos.GET("/feed", optAuth(), videoHandler.GetRecommendFeed)
 			videos.GET("/hot", optAuth(), videoHandler.GetHotFeed)
 			videos.GET("/:id", optAuth(), videoHandler.GetVideoDetail)
+			videos.GET("/:id/related", optAuth(), videoHandler.GetRelatedVideos)
 			videos.GET("/:id/likers", videoHandler.GetVideoLikers)
 			videos.GET("/:id/favoriters", videoHandler.GetVideoFavoriters)
 
@@ -264,6 +433,7 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 			{
 				authenticated.POST("", videoHandler.CreateVideo)
 				authenticated.POST("/upload", videoHandler.UploadVideo)
+				authenticated.GET("/:id/processing", videoHandler.GetProcessingStatus)
 				authenticated.PUT("/:id", videoHandler.UpdateVideo)
 				authenticated.DELETE("/:id", videoHandler.DeleteVideo)
 				authenticated.POST("/:id/audit", videoHandler.AuditVideo)
@@ -272,6 +442,8 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 				authenticated.POST("/:id/history", videoHistoryHandler.ReportProgress)
 				authenticated.POST("/:id/like", videoHandler.LikeVideo)
 				authenticated.DELETE("/:id/like", videoHandler.UnlikeVideo)
+				authenticated.POST("/:id/skip", videoHandler.SkipVideo)
+				authenticated.POST("/:id/not-interested", muteHandler.MarkNotInterested)
 				authenticated.POST("/:id/favorite", videoHandler.FavoriteVideo)
 				authenticated.DELETE("/:id/favorite", videoHandler.UnfavoriteVideo)
 				authenticated.GET("/:id/stats", videoStatsHandler.GetVideoStats)
@@ -284,8 +456,25 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 
 		v1.GET("/users/:id/videos", optAuth(), videoHandler.GetUserVideos)
 		v1.GET("/users/:id/favorites", optAuth(), videoHandler.GetUserFavorites)
+		v1.GET("/users/:id/favorite-folders", optAuth(), favoriteFolderHandler.ListUserFolders)
 		v1.GET("/users/:id/likes", optAuth(), videoHandler.GetUserLikes)
 
+		// 收藏夹
+		favoriteFolders := v1.Group("/favorite-folders")
+		{
+			favoriteFolders.GET("/:id/videos", optAuth(), favoriteFolderHandler.GetFolderVideos)
+
+			authenticated := favoriteFolders.Group("")
+			authenticated.Use(auth())
+			{
+				authenticated.GET("", favoriteFolderHandler.ListMyFolders)
+				authenticated.POST("", favoriteFolderHandler.CreateFolder)
+				authenticated.PUT("/:id", favoriteFolderHandler.UpdateFolder)
+				authenticated.DELETE("/:id", favoriteFolderHandler.DeleteFolder)
+				authenticated.POST("/move", favoriteFolderHandler.MoveFavorites)
+			}
+		}
+
 		// 评论
 		comments := v1.Group("/comments")
 		{
@@ -313,6 +502,21 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 			live.POST("/join/:room_id", liveHandler.JoinLiveStream)
 			live.POST("/leave/:room_id", liveHandler.LeaveLiveStream)
 			live.GET("/ws", signalingService.HandleWebSocket)
+			live.GET("/:id/comments", liveCommentHandler.ListComments)
+
+			// 流媒体服务器回调（nginx-rtmp / SRS，经 webhook-relay 签名转发），未配置回调密钥时不注册
+			if cfg.Streaming.WebhookSecret != "" {
+				webhooks := live.Group("/webhooks")
+				webhooks.Use(middleware.MediaWebhookMiddleware(cfg))
+				{
+					webhooks.POST("/on_publish", liveWebhookHandler.OnPublish)
+					webhooks.POST("/on_publish_done", liveWebhookHandler.OnPublishDone)
+					webhooks.POST("/on_play", liveWebhookHandler.OnPlay)
+					webhooks.POST("/on_record_done", liveWebhookHandler.OnRecordDone)
+				}
+			} else {
+				logger.Warn("未配置 streaming.webhook_secret，跳过流媒体回调路由注册")
+			}
 
 			authenticated := live.Group("")
 			authenticated.Use(auth())
@@ -323,6 +527,76 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 				authenticated.GET("/my", liveHandler.GetMyLiveStream)
 				authenticated.DELETE("/:id", liveHandler.DeleteLiveStream)
 				authenticated.POST("/:id/like", liveHandler.IncrementLike)
+
+				// 房间管理：管理员任免（主播）与禁言、踢出、拉黑（主播或管理员）
+				authenticated.GET("/:id/admins", liveHandler.ListAdmins)
+				authenticated.POST("/:id/admins", liveHandler.AddAdmin)
+				authenticated.DELETE("/:id/admins/:user_id", liveHandler.RemoveAdmin)
+				authenticated.POST("/ban", liveHandler.BanUser)
+				authenticated.POST("/unban", liveHandler.UnbanUser)
+				authenticated.GET("/check-banned", liveHandler.CheckBanned)
+				authenticated.GET("/bans", liveHandler.ListBans)
+
+				// 弹幕精选（主播或有精选权限的管理员）
+				authenticated.POST("/:id/comments/:comment_id/pin", liveCommentHandler.PinComment)
+				authenticated.DELETE("/:id/comments/:comment_id/pin", liveCommentHandler.UnpinComment)
+			}
+
+			// 直播礼物
+			gifts := live.Group("/gifts")
+			gifts.Use(auth())
+			{
+				gifts.GET("", liveGiftHandler.ListGifts)
+				gifts.GET("/records", liveGiftHandler.ListGiftRecords)
+				gifts.GET("/top", liveGiftHandler.GetTopGivers)
+				gifts.GET("/rank", liveGiftHandler.GetStreamerRank)
+				gifts.GET("/stats", liveGiftHandler.GetUserGiftStats)
+				gifts.GET("/:id", liveGiftHandler.GetGift)
+				gifts.POST("/send", liveGiftHandler.SendGift)
+			}
+
+			// 粉丝团
+			fansClub := live.Group("/fans-club")
+			fansClub.Use(auth())
+			{
+				fansClub.POST("/join", liveFansClubHandler.JoinFansClub)
+				fansClub.POST("/quit", liveFansClubHandler.QuitFansClub)
+				fansClub.GET("/member", liveFansClubHandler.GetMemberInfo)
+				fansClub.GET("/members", liveFansClubHandler.ListMembers)
+				fansClub.GET("/top", liveFansClubHandler.GetTopMembers)
+				fansClub.GET("/count", liveFansClubHandler.GetMemberCount)
+			}
+
+			// 直播商品
+			products := live.Group("/products")
+			products.Use(auth())
+			{
+				products.GET("", liveProductHandler.ListProducts)
+				products.POST("", liveProductHandler.AddProduct)
+				products.GET("/hot", liveProductHandler.GetHotProducts)
+				products.GET("/:id", liveProductHandler.GetProduct)
+				products.PUT("/:id", liveProductHandler.UpdateProduct)
+				products.DELETE("/:id", liveProductHandler.DeleteProduct)
+				products.POST("/:id/explain", liveProductHandler.ExplainProduct)
+				products.PUT("/:id/stock", liveProductHandler.UpdateStock)
+			}
+
+			// 直播订单
+			orders := live.Group("/orders")
+			{
+				// 支付渠道回调（渠道签名校验，无需登录）
+				orders.POST("/payment/notify", liveOrderHandler.PaymentNotify)
+
+				authenticated := orders.Group("")
+				authenticated.Use(auth())
+				{
+					authenticated.POST("", liveOrderHandler.CreateOrder)
+					authenticated.GET("", liveOrderHandler.ListMyOrders)
+					authenticated.GET("/earnings", liveOrderHandler.GetEarnings)
+					authenticated.GET("/:id", liveOrderHandler.GetOrder)
+					authenticated.POST("/:id/cancel", liveOrderHandler.CancelOrder)
+					authenticated.POST("/:id/refund", liveOrderHandler.RefundOrder)
+				}
 			}
 		}
 
@@ -361,6 +635,19 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 				authenticated.POST("/:id/read", messageHandler.MarkAsRead)
 				authenticated.DELETE("/:id", messageHandler.DeleteMessage)
 				authenticated.GET("/unread/count", messageHandler.GetUnreadMessageCount)
+				authenticated.GET("/presence", messageHandler.GetPresence)
+				authenticated.GET("/sync", messageSyncHandler.Sync)
+
+				// 群聊
+				authenticated.POST("/groups", groupChatHandler.CreateGroup)
+				authenticated.GET("/groups/:id", groupChatHandler.GetGroup)
+				authenticated.PUT("/groups/:id", groupChatHandler.UpdateGroup)
+				authenticated.GET("/groups/:id/members", groupChatHandler.ListMembers)
+				authenticated.POST("/groups/:id/members", groupChatHandler.InviteMembers)
+				authenticated.DELETE("/groups/:id/members/:user_id", groupChatHandler.RemoveMember)
+				authenticated.PUT("/groups/:id/members/:user_id/role", groupChatHandler.SetMemberRole)
+				authenticated.POST("/groups/:id/leave", groupChatHandler.LeaveGroup)
+				authenticated.POST("/groups/:id/transfer", groupChatHandler.TransferOwner)
 			}
 		}
 
@@ -395,6 +682,16 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 			reports.POST("", reportHandler.CreateReport)
 			reports.GET("", reportHandler.GetMyReports)
 		}
+
+		// 钱包
+		wallet := v1.Group("/wallet")
+		wallet.Use(auth())
+		{
+			wallet.GET("", walletHandler.GetWallet)
+			wallet.GET("/transactions", walletHandler.ListTransactions)
+			wallet.POST("/recharge", walletHandler.TopUp)
+			wallet.POST("/spend", walletHandler.Spend)
+		}
 	}
 
 	// Admin 管理路由
@@ -412,7 +709,21 @@ func Setup(db *gorm.DB, redisClient *redis.Client, cfg *config.Config) *gin.Engi
 		admin.DELETE("/search/hot", adminHandler.DeleteHotSearch)
 		admin.POST("/search/hot/weight", adminHandler.UpdateHotSearchWeight)
 		admin.GET("/reports", adminHandler.ListReports)
+		admin.GET("/experiments", experimentHandler.ListExperiments)
+		admin.POST("/experiments", experimentHandler.CreateExperiment)
+		admin.POST("/experiments/:id/status", experimentHandler.UpdateExperimentStatus)
+		admin.GET("/experiments/:id/report", experimentHandler.GetExperimentReport)
+		admin.POST("/wallet/recharge", walletHandler.Recharge)
+	}
+
+	// cleanup 在服务关闭时停止后台组件
+	cleanup := func() {
+		if roomBroker != nil {
+			if err := roomBroker.Close(); err != nil {
+				logger.Warn("关闭直播间消息代理失败", zap.Error(err))
+			}
+		}
 	}
 
-	return r
+	return r, cleanup
 }
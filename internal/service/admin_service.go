@@ -83,7 +83,7 @@ func (s *adminServiceImpl) DeleteHotSearch(ctx context.Context, keyword string)
 }
 
 func (s *adminServiceImpl) UpdateHotSearchWeight(ctx context.Context, keyword string, count int64) error {
-	return s.searchRepo.UpdateHotSearchCount(ctx, keyword, count)
+	return s.searchRepo.UpdateHotSearchBoost(ctx, keyword, float64(count))
 }
 
 func (s *adminServiceImpl) ListVideos(ctx context.Context, page, pageSize int, status *int8) ([]*model.Video, int64, error) {
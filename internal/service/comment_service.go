@@ -7,6 +7,7 @@ import (
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
 	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
 	"regexp"
 	"strconv"
@@ -165,6 +166,7 @@ func (s *commentServiceImpl) CreateComment(ctx context.Context, userID uint, req
 				Action:  3, // 3-评论
 			})
 		}
+		_ = event.PublishAsync(ctx, event.NewVideoCommentedEvent(req.VideoID, userID, comment.ID, comment.Content))
 	}()
 
 	// 发送通知（异步）
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/algorithm/recommend"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/abtest"
+	"microvibe-go/pkg/logger"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// experimentCacheTTL 运行中实验列表的本地缓存时间，启停实验后其他实例最迟在此时间后生效
+const experimentCacheTTL = 30 * time.Second
+
+// ExperimentService 推荐流 A/B 实验服务
+// 实现 recommend.ExperimentProvider，为登录用户按命中的实验变体覆盖推荐策略
+type ExperimentService interface {
+	recommend.ExperimentProvider
+
+	// CreateExperiment 创建实验（草稿状态）
+	CreateExperiment(ctx context.Context, req *CreateExperimentRequest) (*model.Experiment, error)
+
+	// ListExperiments 分页查询实验，status < 0 时不过滤状态
+	ListExperiments(ctx context.Context, status int8, page, pageSize int) ([]*model.Experiment, int64, error)
+
+	// UpdateExperimentStatus 启动或停止实验
+	UpdateExperimentStatus(ctx context.Context, id uint, status int8) error
+
+	// GetExperimentReport 获取实验各变体的点击率、完播率和点赞率（to 不包含）
+	GetExperimentReport(ctx context.Context, id uint, from, to time.Time) (*ExperimentReport, error)
+
+	// TagBehavior 为行为记录打上用户当前所在的实验变体标记
+	TagBehavior(ctx context.Context, behavior *model.UserBehavior)
+}
+
+// CreateExperimentRequest 创建实验请求
+type CreateExperimentRequest struct {
+	Name        string                    `json:"name" binding:"required,max=100"`
+	Description string                    `json:"description"`
+	Layer       string                    `json:"layer" binding:"required,max=50"`
+	Scene       string                    `json:"scene" binding:"max=20"`
+	Traffic     int                       `json:"traffic" binding:"required"`
+	Variants    []CreateExperimentVariant `json:"variants" binding:"required,dive"`
+}
+
+// CreateExperimentVariant 创建实验变体
+type CreateExperimentVariant struct {
+	Name   string                 `json:"name" binding:"required,max=50"`
+	Weight int                    `json:"weight" binding:"required"`
+	Params model.ExperimentParams `json:"params"`
+}
+
+// ExperimentReport 实验报告
+type ExperimentReport struct {
+	Experiment *model.Experiment          `json:"experiment"`
+	From       time.Time                  `json:"from"`
+	To         time.Time                  `json:"to"`
+	Variants   []*ExperimentVariantReport `json:"variants"`
+	Baseline   *ExperimentBaseline        `json:"baseline"` // 同期全站指标（来自 video_stats）
+}
+
+// ExperimentVariantReport 实验变体指标
+type ExperimentVariantReport struct {
+	VariantID   uint    `json:"variant_id"`
+	Name        string  `json:"name"`
+	Users       int64   `json:"users"`       // 产生行为的用户数
+	Impressions int64   `json:"impressions"` // 曝光数
+	Views       int64   `json:"views"`       // 播放数（同一用户对同一视频只计一次）
+	Finishes    int64   `json:"finishes"`    // 完播数
+	Likes       int64   `json:"likes"`       // 点赞数
+	CTR         float64 `json:"ctr"`         // 点击率 = 播放 / 曝光
+	FinishRate  float64 `json:"finish_rate"` // 完播率 = 完播 / 播放
+	LikeRate    float64 `json:"like_rate"`   // 点赞率 = 点赞 / 播放
+}
+
+// ExperimentBaseline 同期全站指标
+type ExperimentBaseline struct {
+	PlayCount  int64   `json:"play_count"`
+	FinishRate float64 `json:"finish_rate"`
+	LikeRate   float64 `json:"like_rate"`
+}
+
+// experimentAssignment 用户命中的实验变体
+type experimentAssignment struct {
+	Experiment *model.Experiment
+	Variant    *model.ExperimentVariant
+}
+
+type experimentServiceImpl struct {
+	experimentRepo repository.ExperimentRepository
+	statsRepo      repository.VideoStatsRepository
+
+	mu       sync.RWMutex
+	running  []*model.Experiment
+	loadedAt time.Time
+}
+
+// NewExperimentService 创建 A/B 实验服务实例
+func NewExperimentService(
+	experimentRepo repository.ExperimentRepository,
+	statsRepo repository.VideoStatsRepository,
+) ExperimentService {
+	return &experimentServiceImpl{
+		experimentRepo: experimentRepo,
+		statsRepo:      statsRepo,
+	}
+}
+
+// CreateExperiment 创建实验
+func (s *experimentServiceImpl) CreateExperiment(ctx context.Context, req *CreateExperimentRequest) (*model.Experiment, error) {
+	if req.Traffic < 1 || req.Traffic > abtest.TrafficBuckets {
+		return nil, errors.New("实验流量必须在 1-100 之间")
+	}
+	if len(req.Variants) < 2 {
+		return nil, errors.New("实验至少需要两个变体")
+	}
+
+	names := make(map[string]bool, len(req.Variants))
+	variants := make([]*model.ExperimentVariant, 0, len(req.Variants))
+	for _, v := range req.Variants {
+		if v.Weight <= 0 {
+			return nil, errors.New("变体权重必须大于 0")
+		}
+		if names[v.Name] {
+			return nil, errors.New("变体名称重复")
+		}
+		names[v.Name] = true
+		if err := validateExperimentParams(&v.Params); err != nil {
+			return nil, err
+		}
+		variants = append(variants, &model.ExperimentVariant{
+			Name:   v.Name,
+			Weight: v.Weight,
+			Params: v.Params,
+		})
+	}
+
+	experiment := &model.Experiment{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Layer:       strings.TrimSpace(req.Layer),
+		Scene:       strings.TrimSpace(req.Scene),
+		Traffic:     req.Traffic,
+		Status:      model.ExperimentStatusDraft,
+		Variants:    variants,
+	}
+	if err := s.experimentRepo.Create(ctx, experiment); err != nil {
+		logger.Error("创建实验失败", zap.Error(err), zap.String("name", experiment.Name))
+		return nil, errors.New("创建实验失败，请检查实验名称是否重复")
+	}
+
+	return experiment, nil
+}
+
+// validateExperimentParams 校验变体参数的取值范围（通道和因子名称由推荐引擎在运行时校验）
+func validateExperimentParams(params *model.ExperimentParams) error {
+	for _, c := range params.Recall {
+		if c.Name == "" || c.Quota < 0 || c.Quota > 1 {
+			return errors.New("召回通道配额必须在 0-1 之间")
+		}
+	}
+	for _, sc := range params.Scorers {
+		if sc.Name == "" || sc.Weight < 0 {
+			return errors.New("打分因子权重不能为负数")
+		}
+	}
+	if params.DiversityRatio != nil && (*params.DiversityRatio < 0 || *params.DiversityRatio > 1) {
+		return errors.New("多样性打散比例必须在 0-1 之间")
+	}
+	return nil
+}
+
+// ListExperiments 分页查询实验
+func (s *experimentServiceImpl) ListExperiments(ctx context.Context, status int8, page, pageSize int) ([]*model.Experiment, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.experimentRepo.FindAll(ctx, status, pageSize, (page-1)*pageSize)
+}
+
+// UpdateExperimentStatus 启动或停止实验
+// 只允许草稿 -> 运行中 -> 已停止，已停止的实验不能重新启动，避免前后两段数据混在同一份报告里
+func (s *experimentServiceImpl) UpdateExperimentStatus(ctx context.Context, id uint, status int8) error {
+	experiment, err := s.experimentRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("实验不存在")
+		}
+		return err
+	}
+
+	now := time.Now()
+	fields := map[string]interface{}{"status": status}
+	switch {
+	case status == model.ExperimentStatusRunning && experiment.Status == model.ExperimentStatusDraft:
+		// 在同层运行中的实验之外分配一段连续的流量区间
+		running, err := s.experimentRepo.FindRunning(ctx)
+		if err != nil {
+			return err
+		}
+		var occupied []abtest.Experiment
+		for _, e := range running {
+			if e.Layer == experiment.Layer {
+				occupied = append(occupied, abtest.Experiment{ID: e.ID, Offset: e.Offset, Traffic: e.Traffic})
+			}
+		}
+		offset, ok := abtest.Allocate(occupied, experiment.Traffic)
+		if !ok {
+			return errors.New("实验层剩余流量不足")
+		}
+		fields["offset"] = offset
+		fields["started_at"] = now
+	case status == model.ExperimentStatusStopped && experiment.Status == model.ExperimentStatusRunning:
+		fields["ended_at"] = now
+	default:
+		return errors.New("不支持的状态变更")
+	}
+
+	if err := s.experimentRepo.UpdateFields(ctx, id, fields); err != nil {
+		return err
+	}
+
+	s.invalidate()
+	logger.Info("实验状态已变更", zap.Uint("experiment_id", id), zap.Int8("status", status))
+	return nil
+}
+
+// GetExperimentReport 获取实验报告
+func (s *experimentServiceImpl) GetExperimentReport(ctx context.Context, id uint, from, to time.Time) (*ExperimentReport, error) {
+	if !from.Before(to) {
+		return nil, errors.New("开始时间必须早于结束时间")
+	}
+
+	experiment, err := s.experimentRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实验不存在")
+		}
+		return nil, err
+	}
+
+	impressions, err := s.experimentRepo.SumImpressions(ctx, id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.experimentRepo.AggregateVariantMetrics(ctx, id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	metricsByVariant := make(map[uint]*repository.ExperimentVariantMetrics, len(metrics))
+	for _, m := range metrics {
+		metricsByVariant[m.VariantID] = m
+	}
+
+	report := &ExperimentReport{
+		Experiment: experiment,
+		From:       from,
+		To:         to,
+		Variants:   make([]*ExperimentVariantReport, 0, len(experiment.Variants)),
+	}
+	for _, v := range experiment.Variants {
+		vr := &ExperimentVariantReport{
+			VariantID:   v.ID,
+			Name:        v.Name,
+			Impressions: impressions[v.ID],
+		}
+		if m, ok := metricsByVariant[v.ID]; ok {
+			vr.Users = m.Users
+			vr.Views = m.Views
+			vr.Finishes = m.Finishes
+			vr.Likes = m.Likes
+		}
+		vr.CTR = safeRatio(vr.Views, vr.Impressions)
+		vr.FinishRate = safeRatio(vr.Finishes, vr.Views)
+		vr.LikeRate = safeRatio(vr.Likes, vr.Views)
+		report.Variants = append(report.Variants, vr)
+	}
+
+	if stats, err := s.statsRepo.SumByDateRange(ctx, from, to); err == nil {
+		report.Baseline = &ExperimentBaseline{
+			PlayCount:  stats.PlayCount,
+			FinishRate: stats.FinishRate,
+			LikeRate:   safeRatio(stats.LikeCount, stats.PlayCount),
+		}
+	}
+
+	return report, nil
+}
+
+// safeRatio 计算比率，分母为 0 时返回 0
+func safeRatio(numerator, denominator int64) float64 {
+	if denominator <= 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// Override 合并用户在场景下命中的各层实验参数（按层名顺序，后面的层覆盖前面的层）
+func (s *experimentServiceImpl) Override(ctx context.Context, userID uint, scene string) *recommend.Override {
+	assignments := s.assign(ctx, userID, scene)
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	override := &recommend.Override{}
+	for _, a := range assignments {
+		params := a.Variant.Params
+		if len(params.Recall) > 0 {
+			override.Recall = make([]config.RecallChannelConfig, 0, len(params.Recall))
+			for _, c := range params.Recall {
+				override.Recall = append(override.Recall, config.RecallChannelConfig{Name: c.Name, Quota: c.Quota})
+			}
+		}
+		if params.Fill != nil {
+			override.Fill = params.Fill
+		}
+		if len(params.Scorers) > 0 {
+			override.Scorers = make([]config.ScorerConfig, 0, len(params.Scorers))
+			for _, sc := range params.Scorers {
+				override.Scorers = append(override.Scorers, config.ScorerConfig{Name: sc.Name, Weight: sc.Weight})
+			}
+		}
+		if params.DiversityRatio != nil {
+			override.DiversityRatio = params.DiversityRatio
+		}
+	}
+	return override
+}
+
+// RecordImpressions 异步累加用户在场景下命中的各变体的曝光数
+func (s *experimentServiceImpl) RecordImpressions(ctx context.Context, userID uint, scene string, count int) {
+	if count <= 0 {
+		return
+	}
+	assignments := s.assign(ctx, userID, scene)
+	if len(assignments) == 0 {
+		return
+	}
+
+	// 按本地日期分桶（与行为记录的日期一致），Truncate 会按 UTC 截断
+	now := time.Now()
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	go func() {
+		bgCtx := context.Background()
+		for _, a := range assignments {
+			if err := s.experimentRepo.IncrementImpressions(bgCtx, a.Experiment.ID, a.Variant.ID, today, count); err != nil {
+				logger.Warn("记录实验曝光失败", zap.Error(err), zap.Uint("variant_id", a.Variant.ID))
+			}
+		}
+	}()
+}
+
+// TagBehavior 为行为记录打上用户当前所在的实验变体标记（不区分场景）
+func (s *experimentServiceImpl) TagBehavior(ctx context.Context, behavior *model.UserBehavior) {
+	if behavior.ID == 0 || behavior.UserID == 0 {
+		return
+	}
+	assignments := s.assign(ctx, behavior.UserID, "")
+	if len(assignments) == 0 {
+		return
+	}
+
+	tags := make([]*model.UserBehaviorExperiment, 0, len(assignments))
+	for _, a := range assignments {
+		tags = append(tags, &model.UserBehaviorExperiment{
+			BehaviorID:   behavior.ID,
+			ExperimentID: a.Experiment.ID,
+			VariantID:    a.Variant.ID,
+		})
+	}
+	if err := s.experimentRepo.CreateBehaviorTags(ctx, tags); err != nil {
+		logger.Warn("记录行为实验标记失败", zap.Error(err), zap.Uint("behavior_id", behavior.ID))
+	}
+}
+
+// assign 计算用户命中的实验变体
+// 分流基于所有运行中的实验（保证层内流量互斥），scene 不为空时只返回对该场景生效的实验
+func (s *experimentServiceImpl) assign(ctx context.Context, userID uint, scene string) []*experimentAssignment {
+	running := s.runningExperiments(ctx)
+	if len(running) == 0 || userID == 0 {
+		return nil
+	}
+
+	experiments := make([]abtest.Experiment, 0, len(running))
+	byID := make(map[uint]*model.Experiment, len(running))
+	for _, e := range running {
+		ae := abtest.Experiment{ID: e.ID, Layer: e.Layer, Offset: e.Offset, Traffic: e.Traffic}
+		for _, v := range e.Variants {
+			ae.Variants = append(ae.Variants, abtest.Variant{ID: v.ID, Weight: v.Weight})
+		}
+		experiments = append(experiments, ae)
+		byID[e.ID] = e
+	}
+
+	var assignments []*experimentAssignment
+	for _, a := range abtest.Assign(userID, experiments) {
+		experiment := byID[a.ExperimentID]
+		if scene != "" && experiment.Scene != "" && experiment.Scene != scene {
+			continue
+		}
+		for _, v := range experiment.Variants {
+			if v.ID == a.VariantID {
+				assignments = append(assignments, &experimentAssignment{Experiment: experiment, Variant: v})
+				break
+			}
+		}
+	}
+	return assignments
+}
+
+// runningExperiments 获取运行中的实验（本地缓存 experimentCacheTTL）
+func (s *experimentServiceImpl) runningExperiments(ctx context.Context) []*model.Experiment {
+	s.mu.RLock()
+	if time.Since(s.loadedAt) < experimentCacheTTL {
+		running := s.running
+		s.mu.RUnlock()
+		return running
+	}
+	s.mu.RUnlock()
+
+	running, err := s.experimentRepo.FindRunning(ctx)
+	if err != nil {
+		logger.Warn("加载运行中的实验失败", zap.Error(err))
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.running
+	}
+
+	s.mu.Lock()
+	s.running = running
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return running
+}
+
+// invalidate 清除本地缓存
+func (s *experimentServiceImpl) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
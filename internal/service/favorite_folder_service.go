@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxFavoriteFoldersPerUser 每个用户最多可创建的收藏夹数量
+const maxFavoriteFoldersPerUser = 100
+
+// CreateFavoriteFolderRequest 创建收藏夹请求
+type CreateFavoriteFolderRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`         // 收藏夹名称
+	Description string `json:"description" binding:"omitempty,max=255"` // 描述
+	CoverURL    string `json:"cover_url" binding:"omitempty,max=255"`   // 封面
+	IsPublic    *bool  `json:"is_public"`                               // 是否公开（默认公开）
+}
+
+// UpdateFavoriteFolderRequest 更新收藏夹请求（字段为空表示不修改）
+type UpdateFavoriteFolderRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`  // 收藏夹名称
+	Description *string `json:"description" binding:"omitempty,max=255"` // 描述
+	CoverURL    *string `json:"cover_url" binding:"omitempty,max=255"`   // 封面
+	IsPublic    *bool   `json:"is_public"`                               // 是否公开
+}
+
+// MoveFavoritesRequest 移动收藏请求
+type MoveFavoritesRequest struct {
+	VideoIDs []uint `json:"video_ids" binding:"required,min=1,max=100"` // 要移动的收藏视频ID
+	FolderID *uint  `json:"folder_id"`                                  // 目标收藏夹ID（为空表示移回默认收藏夹）
+}
+
+// FavoriteFolderService 收藏夹服务接口
+type FavoriteFolderService interface {
+	// CreateFolder 创建收藏夹
+	CreateFolder(ctx context.Context, userID uint, req *CreateFavoriteFolderRequest) (*model.FavoriteFolder, error)
+	// UpdateFolder 更新收藏夹
+	UpdateFolder(ctx context.Context, userID, folderID uint, req *UpdateFavoriteFolderRequest) (*model.FavoriteFolder, error)
+	// DeleteFolder 删除收藏夹（公开收藏夹内的收藏移回默认收藏夹，私密收藏夹需先移出收藏）
+	DeleteFolder(ctx context.Context, userID, folderID uint) error
+	// ListUserFolders 获取用户的收藏夹列表（他人只能看到公开收藏夹，且需对方公开收藏列表）
+	ListUserFolders(ctx context.Context, targetUserID, currentUserID uint) ([]*model.FavoriteFolder, error)
+	// GetFolderVideos 分页获取收藏夹内的视频（带隐私检查）
+	GetFolderVideos(ctx context.Context, folderID, currentUserID uint, page, pageSize int) ([]*model.Video, int64, error)
+	// MoveFavorites 在收藏夹之间移动收藏
+	MoveFavorites(ctx context.Context, userID uint, req *MoveFavoritesRequest) (int64, error)
+}
+
+type favoriteFolderServiceImpl struct {
+	folderRepo   repository.FavoriteFolderRepository
+	favoriteRepo repository.FavoriteRepository
+	userRepo     repository.UserRepository
+}
+
+// NewFavoriteFolderService 创建收藏夹服务实例
+func NewFavoriteFolderService(
+	folderRepo repository.FavoriteFolderRepository,
+	favoriteRepo repository.FavoriteRepository,
+	userRepo repository.UserRepository,
+) FavoriteFolderService {
+	return &favoriteFolderServiceImpl{
+		folderRepo:   folderRepo,
+		favoriteRepo: favoriteRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// CreateFolder 创建收藏夹
+func (s *favoriteFolderServiceImpl) CreateFolder(ctx context.Context, userID uint, req *CreateFavoriteFolderRequest) (*model.FavoriteFolder, error) {
+	count, err := s.folderRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("统计收藏夹数量失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, errors.New("创建收藏夹失败")
+	}
+	if count >= maxFavoriteFoldersPerUser {
+		return nil, errors.New("收藏夹数量已达上限")
+	}
+
+	folder := &model.FavoriteFolder{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		CoverURL:    req.CoverURL,
+		IsPublic:    true,
+	}
+	if req.IsPublic != nil {
+		folder.IsPublic = *req.IsPublic
+	}
+
+	if err := s.folderRepo.Create(ctx, folder); err != nil {
+		logger.Error("创建收藏夹失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, errors.New("创建收藏夹失败")
+	}
+
+	// gorm 对带 default 标签的零值字段不会写入，私密收藏夹需单独更新
+	if !folder.IsPublic {
+		if err := s.folderRepo.Update(ctx, folder); err != nil {
+			logger.Error("设置收藏夹私密失败", zap.Error(err), zap.Uint("folder_id", folder.ID))
+			return nil, errors.New("创建收藏夹失败")
+		}
+	}
+
+	logger.Info("创建收藏夹成功", zap.Uint("user_id", userID), zap.Uint("folder_id", folder.ID))
+	return folder, nil
+}
+
+// UpdateFolder 更新收藏夹
+func (s *favoriteFolderServiceImpl) UpdateFolder(ctx context.Context, userID, folderID uint, req *UpdateFavoriteFolderRequest) (*model.FavoriteFolder, error) {
+	folder, err := s.getOwnedFolder(ctx, userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		folder.Name = *req.Name
+	}
+	if req.Description != nil {
+		folder.Description = *req.Description
+	}
+	if req.CoverURL != nil {
+		folder.CoverURL = *req.CoverURL
+	}
+	if req.IsPublic != nil {
+		folder.IsPublic = *req.IsPublic
+	}
+
+	if err := s.folderRepo.Update(ctx, folder); err != nil {
+		logger.Error("更新收藏夹失败", zap.Error(err), zap.Uint("folder_id", folderID))
+		return nil, errors.New("更新收藏夹失败")
+	}
+
+	return folder, nil
+}
+
+// DeleteFolder 删除收藏夹
+func (s *favoriteFolderServiceImpl) DeleteFolder(ctx context.Context, userID, folderID uint) error {
+	if _, err := s.getOwnedFolder(ctx, userID, folderID); err != nil {
+		return err
+	}
+
+	if err := s.folderRepo.Delete(ctx, folderID); err != nil {
+		if errors.Is(err, repository.ErrFolderNotEmpty) {
+			return errors.New("私密收藏夹内还有收藏，请先移出后再删除")
+		}
+		logger.Error("删除收藏夹失败", zap.Error(err), zap.Uint("folder_id", folderID))
+		return errors.New("删除收藏夹失败")
+	}
+
+	logger.Info("删除收藏夹成功", zap.Uint("user_id", userID), zap.Uint("folder_id", folderID))
+	return nil
+}
+
+// ListUserFolders 获取用户的收藏夹列表
+func (s *favoriteFolderServiceImpl) ListUserFolders(ctx context.Context, targetUserID, currentUserID uint) ([]*model.FavoriteFolder, error) {
+	isOwner := targetUserID == currentUserID
+	if !isOwner {
+		if err := s.checkFavoritesVisible(ctx, targetUserID); err != nil {
+			return nil, err
+		}
+	}
+
+	folders, err := s.folderRepo.FindByUserID(ctx, targetUserID, !isOwner)
+	if err != nil {
+		logger.Error("查询收藏夹列表失败", zap.Error(err), zap.Uint("user_id", targetUserID))
+		return nil, errors.New("查询收藏夹列表失败")
+	}
+
+	return folders, nil
+}
+
+// GetFolderVideos 分页获取收藏夹内的视频
+func (s *favoriteFolderServiceImpl) GetFolderVideos(ctx context.Context, folderID, currentUserID uint, page, pageSize int) ([]*model.Video, int64, error) {
+	folder, err := s.folderRepo.FindByID(ctx, folderID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, 0, errors.New("收藏夹不存在")
+		}
+		logger.Error("查询收藏夹失败", zap.Error(err), zap.Uint("folder_id", folderID))
+		return nil, 0, errors.New("查询收藏夹失败")
+	}
+
+	// 非本人查看：需对方公开收藏列表且收藏夹为公开
+	if folder.UserID != currentUserID {
+		if !folder.IsPublic {
+			return nil, 0, pkgerrors.NewAppError(pkgerrors.CodeForbidden, "该收藏夹未公开")
+		}
+		if err := s.checkFavoritesVisible(ctx, folder.UserID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	favorites, err := s.favoriteRepo.FindByFolderID(ctx, folderID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, pkgerrors.ConvertDBError(err)
+	}
+
+	total, err := s.favoriteRepo.CountByFolderID(ctx, folderID)
+	if err != nil {
+		return nil, 0, pkgerrors.ConvertDBError(err)
+	}
+
+	videos := make([]*model.Video, 0, len(favorites))
+	for _, f := range favorites {
+		if f.Video != nil {
+			videos = append(videos, f.Video)
+		}
+	}
+
+	return videos, total, nil
+}
+
+// MoveFavorites 在收藏夹之间移动收藏
+func (s *favoriteFolderServiceImpl) MoveFavorites(ctx context.Context, userID uint, req *MoveFavoritesRequest) (int64, error) {
+	if req.FolderID != nil {
+		if _, err := s.getOwnedFolder(ctx, userID, *req.FolderID); err != nil {
+			return 0, err
+		}
+	}
+
+	moved, err := s.favoriteRepo.MoveToFolder(ctx, userID, req.VideoIDs, req.FolderID)
+	if err != nil {
+		return 0, errors.New("移动收藏失败")
+	}
+
+	logger.Info("移动收藏成功", zap.Uint("user_id", userID), zap.Int64("moved", moved))
+	return moved, nil
+}
+
+// getOwnedFolder 查询收藏夹并校验归属（不属于当前用户时按不存在处理）
+func (s *favoriteFolderServiceImpl) getOwnedFolder(ctx context.Context, userID, folderID uint) (*model.FavoriteFolder, error) {
+	folder, err := s.folderRepo.FindByID(ctx, folderID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("收藏夹不存在")
+		}
+		logger.Error("查询收藏夹失败", zap.Error(err), zap.Uint("folder_id", folderID))
+		return nil, errors.New("查询收藏夹失败")
+	}
+	if folder.UserID != userID {
+		return nil, errors.New("收藏夹不存在")
+	}
+	return folder, nil
+}
+
+// checkFavoritesVisible 检查用户是否公开了收藏列表
+func (s *favoriteFolderServiceImpl) checkFavoritesVisible(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error("查询用户失败", zap.Error(err), zap.Uint("user_id", userID))
+		return pkgerrors.ErrUserNotFound
+	}
+	if !user.ShowFavorites {
+		return pkgerrors.NewAppError(pkgerrors.CodeForbidden, "该用户的收藏列表未公开")
+	}
+	return nil
+}
@@ -0,0 +1,552 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/logger"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxGroupMembers 群成员上限
+	maxGroupMembers = 500
+	// maxGroupNameLength 群名称最大字符数
+	maxGroupNameLength = 50
+	// maxGroupNoticeLength 群公告最大字符数
+	maxGroupNoticeLength = 1000
+	// defaultGroupName 未设置群名称时的默认名称
+	defaultGroupName = "群聊"
+)
+
+// 群聊事件类型（通过消息 WebSocket 以 "group" 类型推送给群成员）
+const (
+	GroupEventCreated          = "created"           // 创建群聊
+	GroupEventUpdated          = "updated"           // 群信息变更
+	GroupEventMemberJoined     = "member_joined"     // 成员加入
+	GroupEventMemberLeft       = "member_left"       // 成员退出
+	GroupEventMemberRemoved    = "member_removed"    // 成员被移除
+	GroupEventRoleChanged      = "role_changed"      // 成员角色变更
+	GroupEventOwnerTransferred = "owner_transferred" // 群主转让
+)
+
+// CreateGroupRequest 创建群聊请求
+type CreateGroupRequest struct {
+	Name      string `json:"name"`
+	Avatar    string `json:"avatar"`
+	MemberIDs []uint `json:"member_ids" binding:"required,min=1"`
+}
+
+// UpdateGroupRequest 更新群信息请求（为空的字段不修改）
+type UpdateGroupRequest struct {
+	Name   *string `json:"name"`
+	Avatar *string `json:"avatar"`
+	Notice *string `json:"notice"`
+}
+
+// GroupEvent 群聊事件推送内容
+type GroupEvent struct {
+	Event          string `json:"event"`
+	ConversationID uint   `json:"conversation_id"`
+	OperatorID     uint   `json:"operator_id"`
+	UserIDs        []uint `json:"user_ids,omitempty"` // 事件涉及的成员
+	Role           int8   `json:"role,omitempty"`     // 角色变更后的角色
+	Timestamp      int64  `json:"timestamp"`
+}
+
+// GroupChatService 群聊服务接口
+type GroupChatService interface {
+	// CreateGroup 创建群聊，创建者为群主（已将创建者拉黑的用户不会被加入）
+	CreateGroup(ctx context.Context, ownerID uint, req *CreateGroupRequest) (*model.Conversation, error)
+
+	// GetGroup 获取群信息（仅群成员）
+	GetGroup(ctx context.Context, userID, conversationID uint) (*model.Conversation, error)
+
+	// UpdateGroup 更新群名称、头像和公告（群主或管理员）
+	UpdateGroup(ctx context.Context, operatorID, conversationID uint, req *UpdateGroupRequest) (*model.Conversation, error)
+
+	// ListMembers 分页获取群成员（仅群成员）
+	ListMembers(ctx context.Context, userID, conversationID uint, page, pageSize int) ([]*model.ConversationMember, int64, error)
+
+	// InviteMembers 邀请用户入群（群主或管理员），返回实际加入的用户ID
+	InviteMembers(ctx context.Context, operatorID, conversationID uint, userIDs []uint) ([]uint, error)
+
+	// LeaveGroup 退出群聊（群主退出时自动转让）
+	LeaveGroup(ctx context.Context, userID, conversationID uint) error
+
+	// RemoveMember 移除群成员（群主可移除任何人，管理员只能移除普通成员）
+	RemoveMember(ctx context.Context, operatorID, conversationID, userID uint) error
+
+	// SetMemberRole 设置/取消管理员（仅群主）
+	SetMemberRole(ctx context.Context, operatorID, conversationID, userID uint, role int8) error
+
+	// TransferOwner 转让群主（仅群主）
+	TransferOwner(ctx context.Context, operatorID, conversationID, newOwnerID uint) error
+}
+
+type groupChatServiceImpl struct {
+	groupRepo        repository.GroupConversationRepository
+	userRepo         repository.UserRepository
+	blacklistRepo    repository.BlacklistRepository
+	signalingService MessageSignalingService
+}
+
+// NewGroupChatService 创建群聊服务
+func NewGroupChatService(
+	groupRepo repository.GroupConversationRepository,
+	userRepo repository.UserRepository,
+	blacklistRepo repository.BlacklistRepository,
+) GroupChatService {
+	return &groupChatServiceImpl{
+		groupRepo:     groupRepo,
+		userRepo:      userRepo,
+		blacklistRepo: blacklistRepo,
+	}
+}
+
+// SetSignalingService 设置消息信令服务（延迟注入，用于推送群事件）
+func (s *groupChatServiceImpl) SetSignalingService(signalingService MessageSignalingService) {
+	s.signalingService = signalingService
+}
+
+// CreateGroup 创建群聊
+func (s *groupChatServiceImpl) CreateGroup(ctx context.Context, ownerID uint, req *CreateGroupRequest) (*model.Conversation, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultGroupName
+	}
+	if utf8.RuneCountInString(name) > maxGroupNameLength {
+		return nil, errors.New("群名称过长")
+	}
+
+	inviteeIDs, err := s.filterInvitees(ctx, ownerID, req.MemberIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(inviteeIDs) == 0 {
+		return nil, errors.New("至少需要邀请一名有效成员")
+	}
+	if len(inviteeIDs)+1 > maxGroupMembers {
+		return nil, errors.New("群成员数量超过上限")
+	}
+
+	members := make([]*model.ConversationMember, 0, len(inviteeIDs)+1)
+	members = append(members, &model.ConversationMember{UserID: ownerID, Role: model.ConversationRoleOwner})
+	for _, userID := range inviteeIDs {
+		members = append(members, &model.ConversationMember{
+			UserID:    userID,
+			Role:      model.ConversationRoleMember,
+			InviterID: &ownerID,
+		})
+	}
+
+	conversation := &model.Conversation{
+		Name:    name,
+		Avatar:  req.Avatar,
+		OwnerID: &ownerID,
+	}
+	if err := s.groupRepo.CreateGroup(ctx, conversation, members); err != nil {
+		logger.Error("创建群聊失败", zap.Error(err), zap.Uint("owner_id", ownerID))
+		return nil, errors.New("创建群聊失败")
+	}
+
+	s.pushGroupEvent(ctx, conversation.ID, &GroupEvent{
+		Event:      GroupEventCreated,
+		OperatorID: ownerID,
+		UserIDs:    inviteeIDs,
+	})
+
+	logger.Info("创建群聊成功",
+		zap.Uint("conversation_id", conversation.ID),
+		zap.Uint("owner_id", ownerID),
+		zap.Int("member_count", conversation.MemberCount))
+
+	return conversation, nil
+}
+
+// GetGroup 获取群信息
+func (s *groupChatServiceImpl) GetGroup(ctx context.Context, userID, conversationID uint) (*model.Conversation, error) {
+	conversation, err := s.findGroup(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.requireMember(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// UpdateGroup 更新群信息
+func (s *groupChatServiceImpl) UpdateGroup(ctx context.Context, operatorID, conversationID uint, req *UpdateGroupRequest) (*model.Conversation, error) {
+	conversation, err := s.findGroup(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	operator, err := s.requireMember(ctx, conversationID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if operator.Role < model.ConversationRoleAdmin {
+		return nil, errors.New("只有群主或管理员可以修改群信息")
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("群名称不能为空")
+		}
+		if utf8.RuneCountInString(name) > maxGroupNameLength {
+			return nil, errors.New("群名称过长")
+		}
+		conversation.Name = name
+	}
+	if req.Avatar != nil {
+		conversation.Avatar = *req.Avatar
+	}
+	if req.Notice != nil {
+		if utf8.RuneCountInString(*req.Notice) > maxGroupNoticeLength {
+			return nil, errors.New("群公告过长")
+		}
+		conversation.Notice = *req.Notice
+	}
+
+	if err := s.groupRepo.UpdateGroup(ctx, conversation); err != nil {
+		logger.Error("更新群信息失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return nil, errors.New("更新群信息失败")
+	}
+
+	s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+		Event:      GroupEventUpdated,
+		OperatorID: operatorID,
+	})
+
+	return conversation, nil
+}
+
+// ListMembers 分页获取群成员
+func (s *groupChatServiceImpl) ListMembers(ctx context.Context, userID, conversationID uint, page, pageSize int) ([]*model.ConversationMember, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	if _, err := s.findGroup(ctx, conversationID); err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.requireMember(ctx, conversationID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	members, total, err := s.groupRepo.ListMembers(ctx, conversationID, page, pageSize)
+	if err != nil {
+		logger.Error("查询群成员失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return nil, 0, errors.New("查询群成员失败")
+	}
+	return members, total, nil
+}
+
+// InviteMembers 邀请用户入群
+func (s *groupChatServiceImpl) InviteMembers(ctx context.Context, operatorID, conversationID uint, userIDs []uint) ([]uint, error) {
+	conversation, err := s.findGroup(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	operator, err := s.requireMember(ctx, conversationID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if operator.Role < model.ConversationRoleAdmin {
+		return nil, errors.New("只有群主或管理员可以邀请成员")
+	}
+
+	inviteeIDs, err := s.filterInvitees(ctx, operatorID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(inviteeIDs) == 0 {
+		return []uint{}, nil
+	}
+	if conversation.MemberCount+len(inviteeIDs) > maxGroupMembers {
+		return nil, errors.New("群成员数量超过上限")
+	}
+
+	members := make([]*model.ConversationMember, 0, len(inviteeIDs))
+	for _, userID := range inviteeIDs {
+		members = append(members, &model.ConversationMember{
+			UserID:    userID,
+			Role:      model.ConversationRoleMember,
+			InviterID: &operatorID,
+		})
+	}
+
+	added, err := s.groupRepo.AddMembers(ctx, conversationID, members)
+	if err != nil {
+		logger.Error("邀请群成员失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return nil, errors.New("邀请成员失败")
+	}
+
+	if len(added) > 0 {
+		s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+			Event:      GroupEventMemberJoined,
+			OperatorID: operatorID,
+			UserIDs:    added,
+		})
+	}
+
+	return added, nil
+}
+
+// LeaveGroup 退出群聊
+func (s *groupChatServiceImpl) LeaveGroup(ctx context.Context, userID, conversationID uint) error {
+	if _, err := s.findGroup(ctx, conversationID); err != nil {
+		return err
+	}
+
+	newOwnerID, err := s.groupRepo.LeaveGroup(ctx, conversationID, userID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return errors.New("你不是该群成员")
+		}
+		logger.Error("退出群聊失败", zap.Error(err), zap.Uint("conversation_id", conversationID), zap.Uint("user_id", userID))
+		return errors.New("退出群聊失败")
+	}
+
+	s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+		Event:      GroupEventMemberLeft,
+		OperatorID: userID,
+		UserIDs:    []uint{userID},
+	}, userID)
+
+	if newOwnerID != nil {
+		s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+			Event:      GroupEventOwnerTransferred,
+			OperatorID: userID,
+			UserIDs:    []uint{*newOwnerID},
+			Role:       model.ConversationRoleOwner,
+		})
+	}
+
+	return nil
+}
+
+// RemoveMember 移除群成员
+func (s *groupChatServiceImpl) RemoveMember(ctx context.Context, operatorID, conversationID, userID uint) error {
+	if operatorID == userID {
+		return errors.New("不能移除自己，请使用退出群聊")
+	}
+	if _, err := s.findGroup(ctx, conversationID); err != nil {
+		return err
+	}
+
+	operator, err := s.requireMember(ctx, conversationID, operatorID)
+	if err != nil {
+		return err
+	}
+	target, err := s.groupRepo.FindMember(ctx, conversationID, userID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return errors.New("该用户不是群成员")
+		}
+		logger.Error("查询群成员失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return errors.New("移除成员失败")
+	}
+	if operator.Role < model.ConversationRoleAdmin || operator.Role <= target.Role {
+		return errors.New("无权移除该成员")
+	}
+
+	if err := s.groupRepo.RemoveMember(ctx, conversationID, userID); err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return errors.New("该用户不是群成员")
+		}
+		logger.Error("移除群成员失败", zap.Error(err), zap.Uint("conversation_id", conversationID), zap.Uint("user_id", userID))
+		return errors.New("移除成员失败")
+	}
+
+	s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+		Event:      GroupEventMemberRemoved,
+		OperatorID: operatorID,
+		UserIDs:    []uint{userID},
+	}, userID)
+
+	return nil
+}
+
+// SetMemberRole 设置/取消管理员
+func (s *groupChatServiceImpl) SetMemberRole(ctx context.Context, operatorID, conversationID, userID uint, role int8) error {
+	if role != model.ConversationRoleMember && role != model.ConversationRoleAdmin {
+		return errors.New("无效的角色")
+	}
+	if _, err := s.findGroup(ctx, conversationID); err != nil {
+		return err
+	}
+
+	operator, err := s.requireMember(ctx, conversationID, operatorID)
+	if err != nil {
+		return err
+	}
+	if operator.Role != model.ConversationRoleOwner {
+		return errors.New("只有群主可以设置管理员")
+	}
+	if operatorID == userID {
+		return errors.New("不能修改自己的角色")
+	}
+
+	if err := s.groupRepo.UpdateMemberRole(ctx, conversationID, userID, role); err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return errors.New("该用户不是群成员")
+		}
+		logger.Error("设置群成员角色失败", zap.Error(err), zap.Uint("conversation_id", conversationID), zap.Uint("user_id", userID))
+		return errors.New("设置角色失败")
+	}
+
+	s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+		Event:      GroupEventRoleChanged,
+		OperatorID: operatorID,
+		UserIDs:    []uint{userID},
+		Role:       role,
+	})
+
+	return nil
+}
+
+// TransferOwner 转让群主
+func (s *groupChatServiceImpl) TransferOwner(ctx context.Context, operatorID, conversationID, newOwnerID uint) error {
+	if operatorID == newOwnerID {
+		return errors.New("不能转让给自己")
+	}
+	if _, err := s.findGroup(ctx, conversationID); err != nil {
+		return err
+	}
+
+	operator, err := s.requireMember(ctx, conversationID, operatorID)
+	if err != nil {
+		return err
+	}
+	if operator.Role != model.ConversationRoleOwner {
+		return errors.New("只有群主可以转让群")
+	}
+
+	if err := s.groupRepo.TransferOwner(ctx, conversationID, operatorID, newOwnerID); err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return errors.New("该用户不是群成员")
+		}
+		logger.Error("转让群主失败", zap.Error(err), zap.Uint("conversation_id", conversationID), zap.Uint("new_owner_id", newOwnerID))
+		return errors.New("转让群主失败")
+	}
+
+	s.pushGroupEvent(ctx, conversationID, &GroupEvent{
+		Event:      GroupEventOwnerTransferred,
+		OperatorID: operatorID,
+		UserIDs:    []uint{newOwnerID},
+		Role:       model.ConversationRoleOwner,
+	})
+
+	return nil
+}
+
+// findGroup 查询群聊
+func (s *groupChatServiceImpl) findGroup(ctx context.Context, conversationID uint) (*model.Conversation, error) {
+	conversation, err := s.groupRepo.FindGroup(ctx, conversationID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("群聊不存在")
+		}
+		logger.Error("查询群聊失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return nil, errors.New("查询群聊失败")
+	}
+	return conversation, nil
+}
+
+// requireMember 校验用户是群成员
+func (s *groupChatServiceImpl) requireMember(ctx context.Context, conversationID, userID uint) (*model.ConversationMember, error) {
+	member, err := s.groupRepo.FindMember(ctx, conversationID, userID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("你不是该群成员")
+		}
+		logger.Error("查询群成员失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return nil, errors.New("查询群成员失败")
+	}
+	return member, nil
+}
+
+// filterInvitees 去重并过滤邀请对象：排除邀请人自己、不存在或状态异常的用户，以及已将邀请人拉黑的用户
+func (s *groupChatServiceImpl) filterInvitees(ctx context.Context, inviterID uint, userIDs []uint) ([]uint, error) {
+	seen := make(map[uint]bool, len(userIDs))
+	candidates := make([]uint, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID == 0 || userID == inviterID || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		candidates = append(candidates, userID)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if len(candidates) > maxGroupMembers {
+		return nil, errors.New("群成员数量超过上限")
+	}
+
+	users, err := s.userRepo.FindByIDs(ctx, candidates)
+	if err != nil {
+		logger.Error("查询用户失败", zap.Error(err))
+		return nil, errors.New("查询用户失败")
+	}
+	valid := make(map[uint]bool, len(users))
+	for _, user := range users {
+		if user.Status == 1 {
+			valid[user.ID] = true
+		}
+	}
+
+	blockers, err := s.blacklistRepo.FindBlockersOf(ctx, inviterID, candidates)
+	if err != nil {
+		logger.Error("查询黑名单失败", zap.Error(err), zap.Uint("inviter_id", inviterID))
+		return nil, errors.New("查询用户失败")
+	}
+	for _, userID := range blockers {
+		delete(valid, userID)
+	}
+
+	result := make([]uint, 0, len(valid))
+	for _, userID := range candidates {
+		if valid[userID] {
+			result = append(result, userID)
+		}
+	}
+	return result, nil
+}
+
+// pushGroupEvent 推送群事件给当前所有成员（extraUserIDs 用于通知已离开的成员）
+func (s *groupChatServiceImpl) pushGroupEvent(ctx context.Context, conversationID uint, evt *GroupEvent, extraUserIDs ...uint) {
+	if s.signalingService == nil {
+		return
+	}
+
+	memberIDs, err := s.groupRepo.ListMemberIDs(ctx, conversationID)
+	if err != nil {
+		logger.Warn("查询群成员失败，跳过群事件推送", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		return
+	}
+
+	evt.ConversationID = conversationID
+	evt.Timestamp = time.Now().Unix()
+	recipients := append(memberIDs, extraUserIDs...)
+
+	go func() {
+		if err := s.signalingService.PushToUsers(recipients, "group", evt); err != nil {
+			logger.Error("推送群事件失败", zap.Error(err), zap.Uint("conversation_id", conversationID))
+		}
+	}()
+}
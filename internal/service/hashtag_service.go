@@ -30,6 +30,8 @@ type HashtagService interface {
 // hashtagServiceImpl 话题服务层实现
 type hashtagServiceImpl struct {
 	hashtagRepo repository.HashtagRepository
+
+	trendingService TrendingService // 可选，记录浏览和投稿用于计算话题热度
 }
 
 // NewHashtagService 创建话题服务实例
@@ -39,6 +41,11 @@ func NewHashtagService(hashtagRepo repository.HashtagRepository) HashtagService
 	}
 }
 
+// SetTrendingService 设置趋势服务
+func (s *hashtagServiceImpl) SetTrendingService(trendingService TrendingService) {
+	s.trendingService = trendingService
+}
+
 // CreateHashtagRequest 创建话题请求
 type CreateHashtagRequest struct {
 	Name string `json:"name" binding:"required,min=1,max=50"`
@@ -85,6 +92,9 @@ func (s *hashtagServiceImpl) GetHashtagDetail(ctx context.Context, id uint) (*mo
 		if err := s.hashtagRepo.IncrementViewCount(context.Background(), id); err != nil {
 			logger.Error("增加话题浏览量失败", zap.Error(err))
 		}
+		if s.trendingService != nil {
+			s.trendingService.RecordHashtagView(context.Background(), id)
+		}
 	}()
 
 	return hashtag, nil
@@ -128,8 +138,7 @@ func (s *hashtagServiceImpl) GetOrCreateHashtagByName(ctx context.Context, name
 
 	// 创建新话题
 	hashtag = &model.Hashtag{
-		Name:     name,
-		HotScore: 1.0,
+		Name: name,
 	}
 
 	if err := s.hashtagRepo.CreateHashtag(ctx, hashtag); err != nil {
@@ -156,6 +165,9 @@ func (s *hashtagServiceImpl) AddVideoToHashtag(ctx context.Context, videoID uint
 			logger.Error("添加视频到话题失败", zap.Error(err))
 			continue
 		}
+		if s.trendingService != nil {
+			s.trendingService.RecordHashtagVideo(ctx, hashtag.ID)
+		}
 	}
 
 	return nil
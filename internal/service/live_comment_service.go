@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/logger"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxLiveCommentLength 单条弹幕最大字符数
+	maxLiveCommentLength = 200
+	// defaultCommentReplaySize 进房回放的默认弹幕条数
+	defaultCommentReplaySize = 50
+	// defaultLiveCommentColor 默认弹幕颜色
+	defaultLiveCommentColor = "#FFFFFF"
+)
+
+// 弹幕位置
+const (
+	LiveCommentPositionScroll int8 = 1 // 滚动
+	LiveCommentPositionTop    int8 = 2 // 顶部
+	LiveCommentPositionBottom int8 = 3 // 底部
+)
+
+// 弹幕字号
+const (
+	LiveCommentFontSmall  int8 = 1 // 小
+	LiveCommentFontMedium int8 = 2 // 中
+	LiveCommentFontLarge  int8 = 3 // 大
+)
+
+// liveCommentColorPattern 弹幕颜色格式（#RRGGBB）
+var liveCommentColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// PostLiveCommentRequest 发送弹幕请求
+type PostLiveCommentRequest struct {
+	Content  string `json:"content"`
+	Color    string `json:"color"`     // 颜色（#RRGGBB，非法或没有彩色弹幕特权时使用默认白色）
+	Position int8   `json:"position"`  // 位置：1-滚动，2-顶部，3-底部
+	FontSize int8   `json:"font_size"` // 字号：1-小，2-中，3-大
+}
+
+// LiveCommentReplay 进房弹幕回放
+type LiveCommentReplay struct {
+	Pinned   []*model.LiveComment `json:"pinned"`   // 精选弹幕
+	Comments []*model.LiveComment `json:"comments"` // 最近弹幕（时间正序）
+}
+
+// LiveCommentService 直播弹幕服务接口
+type LiveCommentService interface {
+	// PostComment 保存一条弹幕
+	PostComment(ctx context.Context, liveID, userID uint, req *PostLiveCommentRequest) (*model.LiveComment, error)
+
+	// GetReplay 获取新观众进房时回放的弹幕（最近 N 条 + 精选弹幕）
+	GetReplay(ctx context.Context, liveID uint) (*LiveCommentReplay, error)
+
+	// ListComments 分页获取直播间弹幕历史
+	ListComments(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveComment, int64, error)
+
+	// PinComment 精选/取消精选弹幕（主播或有精选权限的管理员）
+	PinComment(ctx context.Context, operatorID, liveID, commentID uint, pinned bool) (*model.LiveComment, error)
+}
+
+type liveCommentServiceImpl struct {
+	commentRepo      repository.LiveCommentRepository
+	liveStreamRepo   repository.LiveStreamRepository
+	adminRepo        repository.LiveAdminRepository
+	signalingService LiveSignalingService
+	fansClubService  LiveFansClubService
+	config           *config.Config
+}
+
+// NewLiveCommentService 创建弹幕服务
+func NewLiveCommentService(
+	commentRepo repository.LiveCommentRepository,
+	liveStreamRepo repository.LiveStreamRepository,
+	adminRepo repository.LiveAdminRepository,
+	cfg *config.Config,
+) LiveCommentService {
+	return &liveCommentServiceImpl{
+		commentRepo:    commentRepo,
+		liveStreamRepo: liveStreamRepo,
+		adminRepo:      adminRepo,
+		config:         cfg,
+	}
+}
+
+// SetSignalingService 设置信令服务（延迟注入，用于向直播间推送精选弹幕）
+func (s *liveCommentServiceImpl) SetSignalingService(signalingService LiveSignalingService) {
+	s.signalingService = signalingService
+}
+
+// SetFansClubService 设置粉丝团服务（延迟注入，用于校验彩色弹幕特权）
+func (s *liveCommentServiceImpl) SetFansClubService(fansClubService LiveFansClubService) {
+	s.fansClubService = fansClubService
+}
+
+// PostComment 保存一条弹幕
+func (s *liveCommentServiceImpl) PostComment(ctx context.Context, liveID, userID uint, req *PostLiveCommentRequest) (*model.LiveComment, error) {
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, errors.New("弹幕内容不能为空")
+	}
+	if utf8.RuneCountInString(content) > maxLiveCommentLength {
+		return nil, errors.New("弹幕内容过长")
+	}
+
+	comment := &model.LiveComment{
+		LiveID:   liveID,
+		UserID:   userID,
+		Content:  content,
+		Color:    defaultLiveCommentColor,
+		Position: LiveCommentPositionScroll,
+		FontSize: LiveCommentFontMedium,
+	}
+	if liveCommentColorPattern.MatchString(req.Color) && s.canUseColoredDanmaku(ctx, liveID, userID) {
+		comment.Color = strings.ToUpper(req.Color)
+	}
+	if req.Position >= LiveCommentPositionScroll && req.Position <= LiveCommentPositionBottom {
+		comment.Position = req.Position
+	}
+	if req.FontSize >= LiveCommentFontSmall && req.FontSize <= LiveCommentFontLarge {
+		comment.FontSize = req.FontSize
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		logger.Error("保存弹幕失败", zap.Error(err), zap.Uint("live_id", liveID), zap.Uint("user_id", userID))
+		return nil, errors.New("发送弹幕失败")
+	}
+
+	return comment, nil
+}
+
+// canUseColoredDanmaku 主播和拥有彩色弹幕特权的粉丝团成员可以发送彩色弹幕
+func (s *liveCommentServiceImpl) canUseColoredDanmaku(ctx context.Context, liveID, userID uint) bool {
+	if s.fansClubService == nil {
+		return true
+	}
+
+	if liveStream, err := s.liveStreamRepo.FindByID(ctx, liveID); err == nil && liveStream.OwnerID == userID {
+		return true
+	}
+
+	identity, err := s.fansClubService.GetIdentity(ctx, liveID, userID)
+	if err != nil {
+		logger.Warn("查询粉丝团特权失败", zap.Error(err), zap.Uint("live_id", liveID), zap.Uint("user_id", userID))
+		return false
+	}
+	return identity != nil && identity.Privileges.ColoredDanmaku
+}
+
+// GetReplay 获取进房回放弹幕
+func (s *liveCommentServiceImpl) GetReplay(ctx context.Context, liveID uint) (*LiveCommentReplay, error) {
+	size := s.config.Live.CommentReplaySize
+	if size <= 0 {
+		size = defaultCommentReplaySize
+	}
+
+	pinned, err := s.commentRepo.FindPinnedByLiveID(ctx, liveID)
+	if err != nil {
+		logger.Error("查询精选弹幕失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("查询弹幕失败")
+	}
+
+	recent, err := s.commentRepo.FindRecentByLiveID(ctx, liveID, size)
+	if err != nil {
+		logger.Error("查询最近弹幕失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("查询弹幕失败")
+	}
+
+	return &LiveCommentReplay{
+		Pinned:   pinned,
+		Comments: recent,
+	}, nil
+}
+
+// ListComments 分页获取直播间弹幕历史
+func (s *liveCommentServiceImpl) ListComments(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveComment, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 50
+	}
+
+	if _, err := s.liveStreamRepo.FindByID(ctx, liveID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, errors.New("直播间不存在")
+		}
+		logger.Error("查询直播间失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, 0, errors.New("查询直播间失败")
+	}
+
+	comments, total, err := s.commentRepo.ListByLiveID(ctx, liveID, page, pageSize)
+	if err != nil {
+		logger.Error("查询弹幕历史失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, 0, errors.New("查询弹幕历史失败")
+	}
+
+	return comments, total, nil
+}
+
+// PinComment 精选/取消精选弹幕
+func (s *liveCommentServiceImpl) PinComment(ctx context.Context, operatorID, liveID, commentID uint, pinned bool) (*model.LiveComment, error) {
+	liveStream, err := s.liveStreamRepo.FindByID(ctx, liveID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("直播间不存在")
+		}
+		logger.Error("查询直播间失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("查询直播间失败")
+	}
+
+	// 验证权限（主播或有精选权限的管理员）
+	if liveStream.OwnerID != operatorID {
+		admin, err := s.adminRepo.FindByLiveAndUser(ctx, liveID, operatorID)
+		if err != nil || !admin.CanPin {
+			return nil, errors.New("无权限操作")
+		}
+	}
+
+	if err := s.commentRepo.SetPinned(ctx, liveID, commentID, pinned); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("弹幕不存在")
+		}
+		logger.Error("设置精选弹幕失败", zap.Error(err), zap.Uint("comment_id", commentID))
+		return nil, errors.New("操作失败")
+	}
+
+	comment, err := s.commentRepo.FindByID(ctx, commentID)
+	if err != nil {
+		logger.Error("查询弹幕失败", zap.Error(err), zap.Uint("comment_id", commentID))
+		return nil, errors.New("操作失败")
+	}
+
+	// 推送精选变更到直播间
+	if s.signalingService != nil {
+		s.signalingService.BroadcastToRoom(liveStream.RoomID, &SignalingMessage{
+			Type:      MessageTypeCommentPinned,
+			RoomID:    liveStream.RoomID,
+			UserID:    operatorID,
+			Payload:   comment,
+			Timestamp: time.Now().Unix(),
+		}, 0)
+	}
+
+	logger.Info("设置精选弹幕成功",
+		zap.Uint("live_id", liveID),
+		zap.Uint("comment_id", commentID),
+		zap.Bool("pinned", pinned))
+
+	return comment, nil
+}
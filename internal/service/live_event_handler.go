@@ -323,26 +323,21 @@ func (h *LiveEventHandler) handleGiftReceived(ctx context.Context, e event.Event
 		zap.Int("amount", evt.Amount),
 		zap.Int64("value", evt.Value))
 
-	// Business logic:
-	// 1. Increment gift count (use Amount field)
-	if err := h.liveRepo.IncrementGiftCount(ctx, evt.LiveID, evt.Amount); err != nil {
-		logger.Error("Failed to increment gift count", zap.Error(err), zap.Uint("live_id", evt.LiveID))
-		return err
-	}
-
-	// 2. Increment gift value
-	if err := h.liveRepo.IncrementGiftValue(ctx, evt.LiveID, evt.Value); err != nil {
-		logger.Error("Failed to increment gift value", zap.Error(err), zap.Uint("live_id", evt.LiveID))
-		return err
+	// Gifts are charged through the wallet, which updates the room gift stats in the payment
+	// transaction. Events without a gift record were not paid for and must not change the stats.
+	if evt.RecordID == 0 {
+		logger.Warn("Ignoring gift event without a gift record", zap.Uint("live_id", evt.LiveID), zap.Uint("user_id", evt.UserID))
+		return nil
 	}
 
-	// 3. Send gift effect message
+	// Business logic:
+	// 1. Send gift effect message
 	// chatService.SendGiftEffect(evt.RoomID, evt.UserID, evt.GiftID, evt.Count)
 
-	// 4. Update streamer revenue
+	// 2. Update streamer revenue
 	// incomeService.AddIncome(evt.OwnerID, evt.Value)
 
-	// 5. Trigger ranking update
+	// 3. Trigger ranking update
 	// rankingService.UpdateGiftRanking(evt.LiveID, evt.UserID, evt.Value)
 
 	return nil
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"microvibe-go/pkg/event"
+	"microvibe-go/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// fansExpKeyPrefix 每日经验计数（STRING），完整键为 live:fans:exp:{liveID}:{userID}:{来源}:{日期}
+	fansExpKeyPrefix = "live:fans:exp"
+	// fansExpCounterTTL 每日经验计数的过期时间（覆盖跨日时区差异）
+	fansExpCounterTTL = 48 * time.Hour
+	// fansDecayLockKey 衰减任务锁，保证多实例部署时每个周期只有一个实例执行
+	fansDecayLockKey = "live:fans:decay:lock"
+	// fansDecayBatch 衰减任务每批处理的成员数
+	fansDecayBatch = 500
+)
+
+// 经验来源
+const (
+	fansExpSourceWatch   = "watch"
+	fansExpSourceComment = "comment"
+	fansExpSourceLike    = "like"
+	fansExpSourceGift    = "gift"
+)
+
+// RegisterHandlers 订阅直播间互动事件
+func (s *liveFansClubServiceImpl) RegisterHandlers(bus event.EventBus) error {
+	handlers := []struct {
+		eventName string
+		id        string
+		handler   event.EventHandler
+	}{
+		{event.EventLiveUserLeft, "live_fans_watch_handler", s.handleUserLeft},
+		{event.EventLiveCommentReceived, "live_fans_comment_handler", s.handleCommentReceived},
+		{event.EventLiveLikeReceived, "live_fans_like_handler", s.handleLikeReceived},
+		{event.EventLiveGiftReceived, "live_fans_gift_handler", s.handleGiftReceived},
+	}
+
+	for _, h := range handlers {
+		if err := bus.Subscribe(h.eventName, event.NewEventListener(h.id, h.handler, true)); err != nil {
+			return fmt.Errorf("订阅%s事件失败: %w", h.eventName, err)
+		}
+	}
+	return nil
+}
+
+// handleUserLeft 离开直播间时按观看时长（整分钟）增加经验
+func (s *liveFansClubServiceImpl) handleUserLeft(ctx context.Context, e event.Event) error {
+	evt, ok := e.(*event.LiveUserLeftEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type: expected LiveUserLeftEvent")
+	}
+
+	exp := evt.WatchDuration / 60 * int64(s.config.WatchExpPerMinute)
+	return s.grantExperience(ctx, evt.LiveID, evt.UserID, fansExpSourceWatch, exp, s.config.WatchDailyCap)
+}
+
+// handleCommentReceived 发送弹幕增加经验
+func (s *liveFansClubServiceImpl) handleCommentReceived(ctx context.Context, e event.Event) error {
+	evt, ok := e.(*event.LiveCommentReceivedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type: expected LiveCommentReceivedEvent")
+	}
+
+	return s.grantExperience(ctx, evt.LiveID, evt.UserID, fansExpSourceComment, int64(s.config.CommentExp), s.config.CommentDailyCap)
+}
+
+// handleLikeReceived 点赞增加经验（连击点赞按次数计算）
+func (s *liveFansClubServiceImpl) handleLikeReceived(ctx context.Context, e event.Event) error {
+	evt, ok := e.(*event.LiveLikeReceivedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type: expected LiveLikeReceivedEvent")
+	}
+
+	exp := int64(evt.Count) * int64(s.config.LikeExp)
+	return s.grantExperience(ctx, evt.LiveID, evt.UserID, fansExpSourceLike, exp, s.config.LikeDailyCap)
+}
+
+// handleGiftReceived 送礼按礼物价值增加经验
+// 只有扣费成功的送礼（带送礼记录ID）计入经验
+func (s *liveFansClubServiceImpl) handleGiftReceived(ctx context.Context, e event.Event) error {
+	evt, ok := e.(*event.LiveGiftReceivedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type: expected LiveGiftReceivedEvent")
+	}
+	if evt.RecordID == 0 {
+		return nil
+	}
+
+	exp := evt.Value * int64(s.config.GiftExpPerCoin)
+	return s.grantExperience(ctx, evt.LiveID, evt.UserID, fansExpSourceGift, exp, s.config.GiftDailyCap)
+}
+
+// grantExperience 为粉丝团成员增加经验（不是有效成员时忽略），超出每日上限的部分不计入
+func (s *liveFansClubServiceImpl) grantExperience(ctx context.Context, liveID, userID uint, source string, exp, dailyCap int64) error {
+	if exp <= 0 || userID == 0 {
+		return nil
+	}
+
+	member, err := s.fansClubRepo.FindByLiveAndUser(ctx, liveID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !member.IsActivated {
+		return nil
+	}
+
+	exp = s.capExperience(ctx, liveID, userID, source, exp, dailyCap)
+	if exp <= 0 {
+		return nil
+	}
+
+	if err := s.fansClubRepo.AddExperience(ctx, member, exp); err != nil {
+		logger.Error("增加粉丝团经验失败", zap.Error(err), zap.Uint("member_id", member.ID), zap.String("source", source))
+		return err
+	}
+
+	logger.Debug("增加粉丝团经验",
+		zap.Uint("user_id", userID),
+		zap.Uint("live_id", liveID),
+		zap.String("source", source),
+		zap.Int64("exp", exp))
+
+	member.Experience += exp
+	return s.syncLevel(ctx, member, false)
+}
+
+// capExperience 计入当日经验计数，返回未超出上限的部分
+func (s *liveFansClubServiceImpl) capExperience(ctx context.Context, liveID, userID uint, source string, exp, dailyCap int64) int64 {
+	if dailyCap <= 0 || s.redis == nil {
+		return exp
+	}
+
+	key := fmt.Sprintf("%s:%d:%d:%s:%s", fansExpKeyPrefix, liveID, userID, source, time.Now().Format("20060102"))
+	pipe := s.redis.TxPipeline()
+	incr := pipe.IncrBy(ctx, key, exp)
+	pipe.Expire(ctx, key, fansExpCounterTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("记录粉丝团每日经验失败", zap.Error(err), zap.String("key", key))
+		return 0
+	}
+
+	// 本次计入前的当日经验已达上限时不再增加
+	before := incr.Val() - exp
+	if before >= dailyCap {
+		return 0
+	}
+	if incr.Val() > dailyCap {
+		return dailyCap - before
+	}
+	return exp
+}
+
+// Start 启动等级衰减定时任务
+func (s *liveFansClubServiceImpl) Start(ctx context.Context) {
+	if s.config.DecayInterval <= 0 || s.config.DecayExp <= 0 {
+		return
+	}
+
+	interval := time.Duration(s.config.DecayInterval) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.DecayInactive(ctx); err != nil {
+				logger.Error("粉丝团等级衰减失败", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Info("粉丝团等级衰减任务已启动", zap.Duration("interval", interval))
+}
+
+// DecayInactive 扣减不活跃成员的经验，经验低于当前等级门槛时降级
+func (s *liveFansClubServiceImpl) DecayInactive(ctx context.Context) error {
+	if s.config.DecayExp <= 0 {
+		return nil
+	}
+
+	// 锁在一个周期后自动过期，不主动释放，保证每个周期只执行一次
+	if s.redis != nil {
+		lockTTL := time.Duration(s.config.DecayInterval) * time.Second
+		if lockTTL <= 0 {
+			lockTTL = time.Minute
+		}
+		acquired, err := s.redis.SetNX(ctx, fansDecayLockKey, s.instanceID, lockTTL).Result()
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+	}
+
+	before := time.Now().AddDate(0, 0, -s.config.DecayInactiveDays)
+	decayed := 0
+	var lastID uint
+	for {
+		members, err := s.fansClubRepo.FindInactive(ctx, before, lastID, fansDecayBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			lastID = member.ID
+			if err := s.fansClubRepo.DecayExperience(ctx, member, s.config.DecayExp); err != nil {
+				logger.Error("扣减粉丝团经验失败", zap.Error(err), zap.Uint("member_id", member.ID))
+				continue
+			}
+
+			member.Experience -= s.config.DecayExp
+			if member.Experience < 0 {
+				member.Experience = 0
+			}
+			if err := s.syncLevel(ctx, member, true); err != nil {
+				continue
+			}
+			decayed++
+		}
+
+		if len(members) < fansDecayBatch {
+			break
+		}
+	}
+
+	logger.Info("粉丝团等级衰减完成", zap.Int("count", decayed), zap.Time("inactive_before", before))
+	return nil
+}
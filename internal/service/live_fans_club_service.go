@@ -2,16 +2,62 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"microvibe-go/internal/config"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+const (
+	// fansLevelExp 每级所需经验
+	fansLevelExp = 1000
+	// fansMaxLevel 最高等级
+	fansMaxLevel = 10
+
+	// fansColoredDanmakuLevel 解锁彩色弹幕的等级
+	fansColoredDanmakuLevel = 3
+	// fansEntryEffectLevel 解锁进场特效的等级
+	fansEntryEffectLevel = 5
+)
+
+// 粉丝团进场特效
+const (
+	FansEntryEffectGlow   = "fans_glow"   // 5-6 级
+	FansEntryEffectFlame  = "fans_flame"  // 7-8 级
+	FansEntryEffectLegend = "fans_legend" // 9-10 级
+)
+
+// FansClubPrivileges 粉丝团特权（由等级决定，等级变化时写入成员的 privileges 字段）
+type FansClubPrivileges struct {
+	ChatBadge      bool   `json:"chat_badge"`             // 弹幕和进场消息展示粉丝团徽章
+	ColoredDanmaku bool   `json:"colored_danmaku"`        // 可以发送彩色弹幕
+	EntryEffect    string `json:"entry_effect,omitempty"` // 进场特效
+}
+
+// FansBadge 粉丝团徽章
+type FansBadge struct {
+	Name  string `json:"name"`
+	Icon  string `json:"icon,omitempty"`
+	Level int    `json:"level"`
+}
+
+// FansClubIdentity 用户在直播间的粉丝团身份
+type FansClubIdentity struct {
+	Badge      *FansBadge
+	Privileges *FansClubPrivileges
+}
+
 // LiveFansClubService 粉丝团服务接口
+// 观看、弹幕、点赞、送礼事件按规则为成员增加经验（每日封顶），长期不活跃的成员定期扣减经验并降级，
+// 徽章展示、彩色弹幕和进场特效等特权按等级生效
 type LiveFansClubService interface {
 	// JoinFansClub 加入粉丝团
 	JoinFansClub(ctx context.Context, userID, liveID uint) (*model.LiveFansClub, error)
@@ -36,21 +82,41 @@ type LiveFansClubService interface {
 
 	// GetMemberCount 获取粉丝团人数
 	GetMemberCount(ctx context.Context, liveID uint) (int64, error)
+
+	// GetIdentity 获取用户在直播间的粉丝团徽章和特权（不是有效成员时返回 nil）
+	GetIdentity(ctx context.Context, liveID, userID uint) (*FansClubIdentity, error)
+
+	// RegisterHandlers 订阅直播间观看、弹幕、点赞、送礼事件，按规则增加经验
+	RegisterHandlers(bus event.EventBus) error
+
+	// DecayInactive 扣减不活跃成员的经验并按经验重新计算等级（其他实例正在执行时跳过）
+	DecayInactive(ctx context.Context) error
+
+	// Start 启动等级衰减定时任务，ctx 取消后停止
+	Start(ctx context.Context)
 }
 
 type liveFansClubServiceImpl struct {
 	fansClubRepo   repository.LiveFansClubRepository
 	liveStreamRepo repository.LiveStreamRepository
+	redis          *redis.Client
+	config         config.FansClubConfig
+	instanceID     string
 }
 
-// NewLiveFansClubService 创建粉丝团服务
+// NewLiveFansClubService 创建粉丝团服务（redisClient 为空时经验不做每日封顶）
 func NewLiveFansClubService(
 	fansClubRepo repository.LiveFansClubRepository,
 	liveStreamRepo repository.LiveStreamRepository,
+	redisClient *redis.Client,
+	cfg *config.Config,
 ) LiveFansClubService {
 	return &liveFansClubServiceImpl{
 		fansClubRepo:   fansClubRepo,
 		liveStreamRepo: liveStreamRepo,
+		redis:          redisClient,
+		config:         cfg.Live.FansClub,
+		instanceID:     generateInstanceID(),
 	}
 }
 
@@ -72,8 +138,10 @@ func (s *liveFansClubServiceImpl) JoinFansClub(ctx context.Context, userID, live
 		if existingMember.IsActivated {
 			return nil, errors.New("已经是粉丝团成员")
 		}
-		// 如果之前退出过，重新激活
+		// 如果之前退出过，重新激活（从重新加入时开始计算活跃时间）
+		now := time.Now()
 		existingMember.IsActivated = true
+		existingMember.LastActiveAt = &now
 		if err := s.fansClubRepo.Update(ctx, existingMember); err != nil {
 			logger.Error("重新激活粉丝团失败", zap.Error(err))
 			return nil, errors.New("加入粉丝团失败")
@@ -83,13 +151,16 @@ func (s *liveFansClubServiceImpl) JoinFansClub(ctx context.Context, userID, live
 	}
 
 	// 3. 创建粉丝团成员
+	now := time.Now()
 	member := &model.LiveFansClub{
-		LiveID:      liveID,
-		UserID:      userID,
-		Level:       1,
-		Experience:  0,
-		BadgeName:   "初级粉丝",
-		IsActivated: true,
+		LiveID:       liveID,
+		UserID:       userID,
+		Level:        1,
+		Experience:   0,
+		BadgeName:    getBadgeName(1),
+		Privileges:   marshalFansPrivileges(1),
+		IsActivated:  true,
+		LastActiveAt: &now,
 	}
 
 	if err := s.fansClubRepo.Create(ctx, member); err != nil {
@@ -213,7 +284,7 @@ func (s *liveFansClubServiceImpl) AddExperience(ctx context.Context, userID, liv
 	}
 
 	// 2. 增加经验值
-	if err := s.fansClubRepo.AddExperience(ctx, member.ID, exp); err != nil {
+	if err := s.fansClubRepo.AddExperience(ctx, member, exp); err != nil {
 		logger.Error("增加经验值失败", zap.Error(err))
 		return errors.New("增加经验值失败")
 	}
@@ -237,37 +308,31 @@ func (s *liveFansClubServiceImpl) UpgradeLevel(ctx context.Context, userID, live
 		return errors.New("查询粉丝团成员失败")
 	}
 
-	// 2. 计算等级（简单的升级规则：每1000经验值升1级，最高10级）
-	newLevel := int(member.Experience/1000) + 1
-	if newLevel > 10 {
-		newLevel = 10
-	}
-
-	// 3. 检查是否需要升级
-	if newLevel <= member.Level {
-		return nil // 不需要升级
-	}
+	// 2. 只升级不降级（降级由等级衰减任务处理）
+	return s.syncLevel(ctx, member, false)
+}
 
-	// 4. 更新等级
-	if err := s.fansClubRepo.UpdateLevel(ctx, member.ID, newLevel); err != nil {
-		logger.Error("升级失败", zap.Error(err))
-		return errors.New("升级失败")
+// syncLevel 按经验值重新计算等级，等级变化时同步更新徽章名称和特权
+func (s *liveFansClubServiceImpl) syncLevel(ctx context.Context, member *model.LiveFansClub, allowDowngrade bool) error {
+	newLevel := fansLevelForExp(member.Experience)
+	if newLevel == member.Level || (newLevel < member.Level && !allowDowngrade) {
+		return nil
 	}
 
-	// 5. 更新徽章名称
 	badgeName := getBadgeName(newLevel)
-	member.BadgeName = badgeName
-	member.Level = newLevel
-	if err := s.fansClubRepo.Update(ctx, member); err != nil {
-		logger.Warn("更新徽章名称失败", zap.Error(err))
+	if err := s.fansClubRepo.UpdateLevel(ctx, member, newLevel, badgeName, marshalFansPrivileges(newLevel)); err != nil {
+		logger.Error("更新粉丝团等级失败", zap.Error(err), zap.Uint("member_id", member.ID))
+		return errors.New("更新粉丝团等级失败")
 	}
 
-	logger.Info("粉丝团升级成功",
-		zap.Uint("user_id", userID),
-		zap.Uint("live_id", liveID),
+	logger.Info("粉丝团等级变化",
+		zap.Uint("user_id", member.UserID),
+		zap.Uint("live_id", member.LiveID),
 		zap.Int("old_level", member.Level),
 		zap.Int("new_level", newLevel))
 
+	member.Level = newLevel
+	member.BadgeName = badgeName
 	return nil
 }
 
@@ -282,6 +347,66 @@ func (s *liveFansClubServiceImpl) GetMemberCount(ctx context.Context, liveID uin
 	return count, nil
 }
 
+// GetIdentity 获取用户在直播间的粉丝团身份，特权按当前等级计算
+func (s *liveFansClubServiceImpl) GetIdentity(ctx context.Context, liveID, userID uint) (*FansClubIdentity, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+
+	member, err := s.fansClubRepo.FindByLiveAndUser(ctx, liveID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !member.IsActivated {
+		return nil, nil
+	}
+
+	return &FansClubIdentity{
+		Badge: &FansBadge{
+			Name:  member.BadgeName,
+			Icon:  member.BadgeIcon,
+			Level: member.Level,
+		},
+		Privileges: fansPrivilegesForLevel(member.Level),
+	}, nil
+}
+
+// fansLevelForExp 根据经验值计算等级（每 1000 经验升 1 级，最高 10 级）
+func fansLevelForExp(exp int64) int {
+	level := int(exp/fansLevelExp) + 1
+	if level > fansMaxLevel {
+		level = fansMaxLevel
+	}
+	return level
+}
+
+// fansPrivilegesForLevel 等级对应的特权
+func fansPrivilegesForLevel(level int) *FansClubPrivileges {
+	privileges := &FansClubPrivileges{
+		ChatBadge:      true,
+		ColoredDanmaku: level >= fansColoredDanmakuLevel,
+	}
+
+	switch {
+	case level >= 9:
+		privileges.EntryEffect = FansEntryEffectLegend
+	case level >= 7:
+		privileges.EntryEffect = FansEntryEffectFlame
+	case level >= fansEntryEffectLevel:
+		privileges.EntryEffect = FansEntryEffectGlow
+	}
+	return privileges
+}
+
+// marshalFansPrivileges 等级对应特权的 JSON（写入成员记录供客户端展示）
+func marshalFansPrivileges(level int) string {
+	data, _ := json.Marshal(fansPrivilegesForLevel(level))
+	return string(data)
+}
+
 // getBadgeName 根据等级获取徽章名称
 func getBadgeName(level int) string {
 	badgeNames := map[int]string{
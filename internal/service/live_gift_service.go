@@ -5,7 +5,9 @@ import (
 	"errors"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -18,6 +20,7 @@ type SendGiftRequest struct {
 	Quantity    int    `json:"quantity" binding:"required,min=1,max=999"` // 数量
 	IsAnonymous bool   `json:"is_anonymous"`                              // 是否匿名
 	Message     string `json:"message"`                                   // 附带消息
+	RequestID   string `json:"request_id" binding:"omitempty,max=64"`     // 客户端请求ID（幂等键，重试时保持不变）
 }
 
 // LiveGiftService 直播礼物服务接口
@@ -34,17 +37,23 @@ type LiveGiftService interface {
 	// ListGiftRecords 获取送礼记录
 	ListGiftRecords(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveGiftRecord, int64, error)
 
-	// GetTopGivers 获取送礼榜单
-	GetTopGivers(ctx context.Context, liveID uint, limit int) ([]*model.LiveGiftRecord, error)
+	// GetTopGivers 获取直播间本场送礼榜单
+	GetTopGivers(ctx context.Context, liveID uint, limit int) ([]*GiftRankEntry, error)
+
+	// GetStreamerRank 获取主播的日榜、周榜或总榜
+	GetStreamerRank(ctx context.Context, streamerID uint, period string, limit int) ([]*GiftRankEntry, error)
 
 	// GetUserGiftStats 获取用户送礼统计
 	GetUserGiftStats(ctx context.Context, liveID, userID uint) (int64, int, error)
 }
 
 type liveGiftServiceImpl struct {
-	giftRepo       repository.LiveGiftRepository
-	liveStreamRepo repository.LiveStreamRepository
-	userRepo       repository.UserRepository
+	giftRepo         repository.LiveGiftRepository
+	liveStreamRepo   repository.LiveStreamRepository
+	userRepo         repository.UserRepository
+	walletService    WalletService
+	rankService      LiveRankService
+	signalingService LiveSignalingService
 }
 
 // NewLiveGiftService 创建礼物服务
@@ -52,14 +61,23 @@ func NewLiveGiftService(
 	giftRepo repository.LiveGiftRepository,
 	liveStreamRepo repository.LiveStreamRepository,
 	userRepo repository.UserRepository,
+	walletService WalletService,
+	rankService LiveRankService,
 ) LiveGiftService {
 	return &liveGiftServiceImpl{
 		giftRepo:       giftRepo,
 		liveStreamRepo: liveStreamRepo,
 		userRepo:       userRepo,
+		walletService:  walletService,
+		rankService:    rankService,
 	}
 }
 
+// SetSignalingService 设置信令服务（延迟注入，用于向直播间推送礼物消息）
+func (s *liveGiftServiceImpl) SetSignalingService(signalingService LiveSignalingService) {
+	s.signalingService = signalingService
+}
+
 // ListGifts 获取礼物列表
 func (s *liveGiftServiceImpl) ListGifts(ctx context.Context, giftType int8, status int8) ([]*model.LiveGift, error) {
 	gifts, err := s.giftRepo.List(ctx, giftType, status)
@@ -126,9 +144,7 @@ func (s *liveGiftServiceImpl) SendGift(ctx context.Context, userID uint, req *Se
 	// 6. 计算总价值
 	totalValue := gift.Price * int64(req.Quantity)
 
-	// TODO: 这里应该检查用户余额并扣费，暂时省略
-
-	// 7. 创建送礼记录
+	// 7. 扣费并创建送礼记录（余额扣减、主播分成、送礼记录、直播间礼物统计在同一事务内完成）
 	record := &model.LiveGiftRecord{
 		LiveID:      req.LiveID,
 		UserID:      userID,
@@ -143,15 +159,14 @@ func (s *liveGiftServiceImpl) SendGift(ctx context.Context, userID uint, req *Se
 		Message:     req.Message,
 	}
 
-	if err := s.giftRepo.CreateGiftRecord(ctx, record); err != nil {
-		logger.Error("创建送礼记录失败", zap.Error(err))
-		return nil, errors.New("送礼失败")
+	record, replayed, err := s.walletService.PayGift(ctx, req.RequestID, record)
+	if err != nil {
+		return nil, err
 	}
-
-	// 8. 更新直播间礼物统计
-	if err := s.liveStreamRepo.UpdateGiftStats(ctx, req.LiveID, int64(req.Quantity), totalValue); err != nil {
-		logger.Error("更新直播间礼物统计失败", zap.Error(err))
-		// 不影响主流程，继续执行
+	if replayed {
+		// 重复请求：返回首次送礼结果，不重复扣费和推送
+		logger.Info("重复的送礼请求", zap.Uint("user_id", userID), zap.String("request_id", req.RequestID))
+		return record, nil
 	}
 
 	logger.Info("送礼成功",
@@ -161,11 +176,55 @@ func (s *liveGiftServiceImpl) SendGift(ctx context.Context, userID uint, req *Se
 		zap.Int("quantity", req.Quantity),
 		zap.Int64("total_value", totalValue))
 
+	// 8. 推送礼物消息到直播间
+	s.broadcastGift(ctx, liveStream, gift, record)
+
+	// 9. 更新礼物榜（榜单失败不影响送礼结果）
+	_ = s.rankService.RecordGift(ctx, liveStream, record)
+
+	// 10. 发布礼物事件（粉丝团经验等）
+	giftEvent := event.NewLiveGiftReceivedEvent(liveStream.ID, liveStream.RoomID, userID, gift.ID, gift.Name, record.Quantity, record.TotalValue)
+	giftEvent.RecordID = record.ID
+	_ = event.PublishAsync(context.Background(), giftEvent)
+
 	// 重新查询以获取关联信息
 	record.Gift = gift
 	return record, nil
 }
 
+// broadcastGift 通过信令服务向直播间广播礼物消息（匿名送礼不暴露送礼人）
+func (s *liveGiftServiceImpl) broadcastGift(ctx context.Context, liveStream *model.LiveStream, gift *model.LiveGift, record *model.LiveGiftRecord) {
+	if s.signalingService == nil {
+		return
+	}
+
+	msg := &SignalingMessage{
+		Type:   MessageTypeGift,
+		RoomID: liveStream.RoomID,
+		Payload: &GiftPayload{
+			GiftID:      gift.ID,
+			GiftName:    gift.Name,
+			Amount:      record.Quantity,
+			Icon:        gift.Icon,
+			Animation:   gift.Animation,
+			Value:       record.TotalValue,
+			RecordID:    record.ID,
+			IsAnonymous: record.IsAnonymous,
+			Message:     record.Message,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+
+	if !record.IsAnonymous {
+		msg.UserID = record.UserID
+		if user, err := s.userRepo.FindByID(ctx, record.UserID); err == nil && user != nil {
+			msg.Username = user.Username
+		}
+	}
+
+	s.signalingService.BroadcastToRoom(liveStream.RoomID, msg, 0)
+}
+
 // ListGiftRecords 获取送礼记录
 func (s *liveGiftServiceImpl) ListGiftRecords(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveGiftRecord, int64, error) {
 	// 默认分页参数
@@ -191,20 +250,14 @@ func (s *liveGiftServiceImpl) ListGiftRecords(ctx context.Context, liveID uint,
 	return records, total, nil
 }
 
-// GetTopGivers 获取送礼榜单
-func (s *liveGiftServiceImpl) GetTopGivers(ctx context.Context, liveID uint, limit int) ([]*model.LiveGiftRecord, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 10
-	}
-
-	records, err := s.giftRepo.GetTopGivers(ctx, liveID, limit)
-	if err != nil {
-		logger.Error("查询送礼榜单失败", zap.Error(err), zap.Uint("live_id", liveID))
-		return nil, errors.New("查询送礼榜单失败")
-	}
+// GetTopGivers 获取直播间本场送礼榜单
+func (s *liveGiftServiceImpl) GetTopGivers(ctx context.Context, liveID uint, limit int) ([]*GiftRankEntry, error) {
+	return s.rankService.GetRoomRank(ctx, liveID, limit)
+}
 
-	logger.Info("查询送礼榜单成功", zap.Uint("live_id", liveID), zap.Int("count", len(records)))
-	return records, nil
+// GetStreamerRank 获取主播榜单
+func (s *liveGiftServiceImpl) GetStreamerRank(ctx context.Context, streamerID uint, period string, limit int) ([]*GiftRankEntry, error) {
+	return s.rankService.GetStreamerRank(ctx, streamerID, period, limit)
 }
 
 // GetUserGiftStats 获取用户送礼统计
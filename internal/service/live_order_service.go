@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/payment"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultOrderTimeout 默认支付时限
+	defaultOrderTimeout = 15 * time.Minute
+	// orderExpireBatch 超时扫描每批处理的订单数
+	orderExpireBatch = 200
+)
+
+// CreateLiveOrderRequest 直播下单请求
+type CreateLiveOrderRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`            // 直播商品ID
+	Quantity  int    `json:"quantity" binding:"required,min=1,max=99"` // 购买数量
+	RequestID string `json:"request_id" binding:"omitempty,max=64"`    // 客户端请求ID（幂等键，重试时保持不变）
+}
+
+// LiveOrderService 直播带货订单服务接口
+// 下单锁定库存并通过支付渠道创建支付单，支付回调成功后计入销量、销售额，佣金记入主播钱包并在直播间播报，
+// 买家取消或超时未支付时关闭订单并释放库存，主播对已支付订单退款时冲回销量、销售额和佣金
+type LiveOrderService interface {
+	// CreateOrder 下单（按 user_id + request_id 幂等）
+	CreateOrder(ctx context.Context, userID uint, req *CreateLiveOrderRequest) (*model.LiveOrder, error)
+
+	// CancelOrder 买家取消待支付订单
+	CancelOrder(ctx context.Context, userID, orderID uint) error
+
+	// RefundOrder 主播对已支付订单原路退款
+	RefundOrder(ctx context.Context, streamerID, orderID uint) error
+
+	// GetOrder 获取订单详情（买家或主播）
+	GetOrder(ctx context.Context, userID, orderID uint) (*model.LiveOrder, error)
+
+	// ListMyOrders 获取买家订单列表
+	ListMyOrders(ctx context.Context, userID uint, page, pageSize int) ([]*model.LiveOrder, int64, error)
+
+	// GetEarnings 获取主播带货收益（liveID 为 0 时汇总所有直播间）
+	GetEarnings(ctx context.Context, streamerID, liveID uint) (*repository.LiveOrderEarnings, error)
+
+	// HandlePaymentNotification 处理支付渠道回调（重复回调幂等）
+	HandlePaymentNotification(ctx context.Context, n *payment.Notification) error
+
+	// ExpireOrders 关闭超时未支付的订单并释放库存
+	ExpireOrders(ctx context.Context) error
+
+	// Start 启动超时订单扫描，ctx 取消后停止
+	Start(ctx context.Context)
+}
+
+type liveOrderServiceImpl struct {
+	orderRepo        repository.LiveOrderRepository
+	productRepo      repository.LiveProductRepository
+	liveStreamRepo   repository.LiveStreamRepository
+	userRepo         repository.UserRepository
+	provider         payment.Provider
+	signalingService LiveSignalingService
+	config           config.CommerceConfig
+}
+
+// NewLiveOrderService 创建直播订单服务
+func NewLiveOrderService(
+	orderRepo repository.LiveOrderRepository,
+	productRepo repository.LiveProductRepository,
+	liveStreamRepo repository.LiveStreamRepository,
+	userRepo repository.UserRepository,
+	provider payment.Provider,
+	cfg *config.Config,
+) LiveOrderService {
+	return &liveOrderServiceImpl{
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		liveStreamRepo: liveStreamRepo,
+		userRepo:       userRepo,
+		provider:       provider,
+		config:         cfg.Commerce,
+	}
+}
+
+// SetSignalingService 设置信令服务（延迟注入，用于在直播间播报下单消息）
+func (s *liveOrderServiceImpl) SetSignalingService(signalingService LiveSignalingService) {
+	s.signalingService = signalingService
+}
+
+// CreateOrder 下单
+func (s *liveOrderServiceImpl) CreateOrder(ctx context.Context, userID uint, req *CreateLiveOrderRequest) (*model.LiveOrder, error) {
+	if req.RequestID == "" {
+		req.RequestID = generateRequestID()
+	}
+
+	// 1. 幂等：相同请求ID直接返回首次创建的订单
+	if existing, err := s.findOrderReplay(ctx, userID, req.RequestID); existing != nil || err != nil {
+		return existing, err
+	}
+
+	// 2. 查询商品和直播间
+	product, err := s.productRepo.FindByID(ctx, req.ProductID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("商品不存在")
+		}
+		logger.Error("查询商品失败", zap.Error(err), zap.Uint("product_id", req.ProductID))
+		return nil, errors.New("查询商品失败")
+	}
+	if product.Status != 1 {
+		return nil, errors.New("商品已下架或已售罄")
+	}
+
+	liveStream, err := s.liveStreamRepo.FindByID(ctx, product.LiveID)
+	if err != nil {
+		logger.Error("查询直播间失败", zap.Error(err), zap.Uint("live_id", product.LiveID))
+		return nil, errors.New("查询直播间失败")
+	}
+	if liveStream.OwnerID == userID {
+		return nil, errors.New("不能购买自己直播间的商品")
+	}
+
+	// 3. 按直播价计算订单金额和主播佣金
+	unitPrice := yuanToCents(product.SalePrice)
+	totalAmount := unitPrice * int64(req.Quantity)
+	if totalAmount <= 0 {
+		return nil, errors.New("商品价格异常")
+	}
+
+	order := &model.LiveOrder{
+		OrderNo:        generateOrderNo(),
+		RequestID:      req.RequestID,
+		UserID:         userID,
+		LiveID:         liveStream.ID,
+		StreamerID:     liveStream.OwnerID,
+		LiveProductID:  product.ID,
+		ProductName:    product.Name,
+		ProductCover:   product.Cover,
+		UnitPrice:      unitPrice,
+		Quantity:       req.Quantity,
+		TotalAmount:    totalAmount,
+		CommissionRate: product.CommissionRate,
+		Commission:     commissionOf(totalAmount, product.CommissionRate),
+		Status:         model.LiveOrderStatusPending,
+		ExpiresAt:      time.Now().Add(s.orderTimeout()),
+	}
+
+	// 4. 锁定库存并创建订单
+	if err := s.orderRepo.CreateWithReservation(ctx, order); err != nil {
+		if errors.Is(err, repository.ErrStockInsufficient) {
+			return nil, errors.New("库存不足")
+		}
+		// 并发的相同请求被唯一索引拦截，返回先完成的那一笔
+		if pkgerrors.IsDuplicateKey(err) {
+			return s.findOrderReplay(ctx, userID, req.RequestID)
+		}
+		logger.Error("创建订单失败", zap.Error(err), zap.Uint("user_id", userID), zap.Uint("product_id", product.ID))
+		return nil, errors.New("创建订单失败")
+	}
+
+	// 5. 创建支付单，失败时关闭订单释放库存
+	pay, err := s.provider.CreatePayment(ctx, &payment.Request{
+		OrderNo:   order.OrderNo,
+		Amount:    order.TotalAmount,
+		Subject:   order.ProductName,
+		ExpiresAt: order.ExpiresAt,
+	})
+	if err != nil {
+		logger.Error("创建支付单失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		if closeErr := s.orderRepo.Close(ctx, order, model.LiveOrderStatusClosed, "创建支付单失败"); closeErr != nil {
+			logger.Error("关闭订单失败", zap.Error(closeErr), zap.String("order_no", order.OrderNo))
+		}
+		return nil, errors.New("创建支付失败")
+	}
+
+	if err := s.orderRepo.UpdatePayment(ctx, order.ID, s.provider.Name(), pay.PaymentID, pay.PayURL); err != nil {
+		logger.Error("记录支付单失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		// 没有支付单的订单无法支付，关闭订单释放库存并关闭渠道支付单（失败已在 closeOrder 中记录）
+		order.PaymentID = pay.PaymentID
+		_ = s.closeOrder(ctx, order, model.LiveOrderStatusClosed, "记录支付单失败")
+		return nil, errors.New("创建支付失败")
+	}
+	order.PaymentProvider = s.provider.Name()
+	order.PaymentID = pay.PaymentID
+	order.PayURL = pay.PayURL
+
+	logger.Info("下单成功",
+		zap.String("order_no", order.OrderNo),
+		zap.Uint("user_id", userID),
+		zap.Uint("product_id", product.ID),
+		zap.Int("quantity", order.Quantity),
+		zap.Int64("total_amount", order.TotalAmount))
+
+	return order, nil
+}
+
+// CancelOrder 买家取消订单
+func (s *liveOrderServiceImpl) CancelOrder(ctx context.Context, userID, orderID uint) error {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID != userID {
+		return errors.New("订单不存在")
+	}
+	if order.Status != model.LiveOrderStatusPending {
+		return errors.New("订单当前状态不能取消")
+	}
+
+	if err := s.closeOrder(ctx, order, model.LiveOrderStatusCancelled, "买家取消"); err != nil {
+		if errors.Is(err, repository.ErrOrderStateChanged) {
+			return errors.New("订单当前状态不能取消")
+		}
+		return errors.New("取消订单失败")
+	}
+
+	logger.Info("取消订单成功", zap.String("order_no", order.OrderNo), zap.Uint("user_id", userID))
+	return nil
+}
+
+// RefundOrder 主播退款
+// 先通过支付渠道退款再冲回订单，更新订单失败时可重试（订单仍为已支付状态）
+func (s *liveOrderServiceImpl) RefundOrder(ctx context.Context, streamerID, orderID uint) error {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.StreamerID != streamerID {
+		return errors.New("订单不存在")
+	}
+	if order.Status != model.LiveOrderStatusPaid {
+		return errors.New("订单当前状态不能退款")
+	}
+
+	if err := s.provider.Refund(ctx, order.PaymentID, order.TotalAmount); err != nil {
+		logger.Error("退款失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		return errors.New("退款失败")
+	}
+
+	if err := s.orderRepo.Refund(ctx, order, time.Now()); err != nil {
+		// 并发的退款请求已完成冲回
+		if errors.Is(err, repository.ErrOrderStateChanged) {
+			if latest, findErr := s.orderRepo.FindByID(ctx, order.ID); findErr == nil && latest.Status == model.LiveOrderStatusRefunded {
+				return nil
+			}
+			return errors.New("订单当前状态不能退款")
+		}
+		logger.Error("更新订单退款状态失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		return errors.New("更新订单失败")
+	}
+
+	logger.Info("订单退款成功",
+		zap.String("order_no", order.OrderNo),
+		zap.Uint("streamer_id", streamerID),
+		zap.Int64("total_amount", order.TotalAmount),
+		zap.Int64("commission", order.Commission))
+	return nil
+}
+
+// GetOrder 获取订单详情
+func (s *liveOrderServiceImpl) GetOrder(ctx context.Context, userID, orderID uint) (*model.LiveOrder, error) {
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("订单不存在")
+		}
+		logger.Error("查询订单失败", zap.Error(err), zap.Uint("order_id", orderID))
+		return nil, errors.New("查询订单失败")
+	}
+	if order.UserID != userID && order.StreamerID != userID {
+		return nil, errors.New("订单不存在")
+	}
+	return order, nil
+}
+
+// ListMyOrders 获取买家订单列表
+func (s *liveOrderServiceImpl) ListMyOrders(ctx context.Context, userID uint, page, pageSize int) ([]*model.LiveOrder, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	orders, total, err := s.orderRepo.ListByUser(ctx, userID, page, pageSize)
+	if err != nil {
+		logger.Error("查询订单列表失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, 0, errors.New("查询订单列表失败")
+	}
+	return orders, total, nil
+}
+
+// GetEarnings 获取主播带货收益
+func (s *liveOrderServiceImpl) GetEarnings(ctx context.Context, streamerID, liveID uint) (*repository.LiveOrderEarnings, error) {
+	earnings, err := s.orderRepo.SumEarnings(ctx, streamerID, liveID)
+	if err != nil {
+		logger.Error("查询带货收益失败", zap.Error(err), zap.Uint("streamer_id", streamerID))
+		return nil, errors.New("查询带货收益失败")
+	}
+	return earnings, nil
+}
+
+// HandlePaymentNotification 处理支付回调
+func (s *liveOrderServiceImpl) HandlePaymentNotification(ctx context.Context, n *payment.Notification) error {
+	order, err := s.orderRepo.FindByOrderNo(ctx, n.OrderNo)
+	if err != nil {
+		logger.Warn("支付回调的订单不存在", zap.Error(err), zap.String("order_no", n.OrderNo))
+		return errors.New("订单不存在")
+	}
+	if order.PaymentID != "" && order.PaymentID != n.PaymentID {
+		logger.Warn("支付回调的支付单号不一致",
+			zap.String("order_no", order.OrderNo),
+			zap.String("payment_id", n.PaymentID))
+		return errors.New("支付单号不一致")
+	}
+	if n.Amount != order.TotalAmount {
+		logger.Warn("支付回调的金额不一致",
+			zap.String("order_no", order.OrderNo),
+			zap.Int64("amount", n.Amount),
+			zap.Int64("total_amount", order.TotalAmount))
+		return errors.New("支付金额不一致")
+	}
+	if !n.Paid {
+		return nil
+	}
+
+	switch order.Status {
+	case model.LiveOrderStatusPaid:
+		return nil
+	case model.LiveOrderStatusPending:
+		err = s.orderRepo.MarkPaid(ctx, order, time.Now())
+	default:
+		err = repository.ErrOrderStateChanged
+	}
+
+	if errors.Is(err, repository.ErrOrderStateChanged) {
+		// 重复回调与首次回调并发时订单已是已支付状态；否则支付在订单关闭后才到达，原路退款
+		if latest, findErr := s.orderRepo.FindByID(ctx, order.ID); findErr == nil && latest.Status == model.LiveOrderStatusPaid {
+			return nil
+		}
+		logger.Warn("订单已关闭，退款", zap.String("order_no", order.OrderNo), zap.String("payment_id", n.PaymentID))
+		if err := s.provider.Refund(ctx, n.PaymentID, n.Amount); err != nil {
+			logger.Error("退款失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+			return errors.New("退款失败")
+		}
+		return nil
+	}
+	if err != nil {
+		logger.Error("更新订单支付状态失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		return errors.New("更新订单失败")
+	}
+
+	logger.Info("订单支付成功",
+		zap.String("order_no", order.OrderNo),
+		zap.Uint("user_id", order.UserID),
+		zap.Int64("total_amount", order.TotalAmount),
+		zap.Int64("commission", order.Commission))
+
+	s.broadcastPurchase(ctx, order)
+	return nil
+}
+
+// broadcastPurchase 在直播间播报下单消息
+func (s *liveOrderServiceImpl) broadcastPurchase(ctx context.Context, order *model.LiveOrder) {
+	if s.signalingService == nil {
+		return
+	}
+
+	liveStream, err := s.liveStreamRepo.FindByID(ctx, order.LiveID)
+	if err != nil {
+		return
+	}
+
+	payload := &ProductPurchasedPayload{
+		ProductID: order.LiveProductID,
+		Name:      order.ProductName,
+		Quantity:  order.Quantity,
+	}
+	if product, err := s.productRepo.FindByID(ctx, order.LiveProductID); err == nil {
+		payload.Stock = product.Stock
+	}
+
+	msg := &SignalingMessage{
+		Type:      MessageTypeProductPurchased,
+		RoomID:    liveStream.RoomID,
+		UserID:    order.UserID,
+		Payload:   payload,
+		Timestamp: time.Now().Unix(),
+	}
+	if user, err := s.userRepo.FindByID(ctx, order.UserID); err == nil && user != nil {
+		msg.Username = user.Username
+	}
+
+	s.signalingService.BroadcastToRoom(liveStream.RoomID, msg, 0)
+}
+
+// Start 启动超时订单扫描
+// 订单状态通过条件更新只流转一次，多个实例同时扫描也不会重复释放库存
+func (s *liveOrderServiceImpl) Start(ctx context.Context) {
+	if s.config.ExpireInterval <= 0 {
+		return
+	}
+
+	interval := time.Duration(s.config.ExpireInterval) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.ExpireOrders(ctx); err != nil {
+				logger.Error("关闭超时订单失败", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Info("超时订单扫描已启动", zap.Duration("interval", interval))
+}
+
+// ExpireOrders 关闭超时未支付的订单
+func (s *liveOrderServiceImpl) ExpireOrders(ctx context.Context) error {
+	now := time.Now()
+	closed := 0
+	var lastID uint
+	for {
+		orders, err := s.orderRepo.ListExpired(ctx, now, lastID, orderExpireBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			lastID = order.ID
+			if err := s.closeOrder(ctx, order, model.LiveOrderStatusClosed, "支付超时"); err != nil {
+				continue
+			}
+			closed++
+		}
+
+		if len(orders) < orderExpireBatch {
+			break
+		}
+	}
+
+	if closed > 0 {
+		logger.Info("已关闭超时订单", zap.Int("count", closed))
+	}
+	return nil
+}
+
+// closeOrder 关闭订单、释放库存并关闭渠道支付单
+func (s *liveOrderServiceImpl) closeOrder(ctx context.Context, order *model.LiveOrder, status, reason string) error {
+	if err := s.orderRepo.Close(ctx, order, status, reason); err != nil {
+		if !errors.Is(err, repository.ErrOrderStateChanged) {
+			logger.Error("关闭订单失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		}
+		return err
+	}
+
+	// 支付单关闭失败不影响订单关闭，之后到达的支付回调会原路退款
+	if order.PaymentID != "" {
+		if err := s.provider.ClosePayment(ctx, order.PaymentID); err != nil {
+			logger.Warn("关闭支付单失败", zap.Error(err), zap.String("order_no", order.OrderNo))
+		}
+	}
+	return nil
+}
+
+// findOrderReplay 查询已存在的同请求ID订单
+func (s *liveOrderServiceImpl) findOrderReplay(ctx context.Context, userID uint, requestID string) (*model.LiveOrder, error) {
+	order, err := s.orderRepo.FindByRequestID(ctx, userID, requestID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		logger.Error("查询订单失败", zap.Error(err), zap.Uint("user_id", userID), zap.String("request_id", requestID))
+		return nil, errors.New("查询订单失败")
+	}
+	return order, nil
+}
+
+// orderTimeout 支付时限（未配置时使用默认值）
+func (s *liveOrderServiceImpl) orderTimeout() time.Duration {
+	if s.config.OrderTimeout <= 0 {
+		return defaultOrderTimeout
+	}
+	return time.Duration(s.config.OrderTimeout) * time.Second
+}
+
+// yuanToCents 元转分（四舍五入）
+func yuanToCents(yuan float64) int64 {
+	return int64(math.Round(yuan * 100))
+}
+
+// commissionOf 按佣金比例计算佣金（分，四舍五入，比例非法时为 0）
+func commissionOf(amount int64, rate float64) int64 {
+	if rate <= 0 || rate > 1 {
+		return 0
+	}
+	return int64(math.Round(float64(amount) * rate))
+}
+
+// generateOrderNo 生成订单号：LO + 时间 + 随机串
+func generateOrderNo() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("LO%d", time.Now().UnixNano())
+	}
+	return "LO" + time.Now().Format("20060102150405") + hex.EncodeToString(b)
+}
@@ -24,7 +24,7 @@ type AddProductRequest struct {
 	Sort           int     `json:"sort"`
 	Discount       int     `json:"discount"`
 	Description    string  `json:"description"`
-	CommissionRate float64 `json:"commission_rate"`
+	CommissionRate float64 `json:"commission_rate" binding:"min=0,max=1"`
 }
 
 // UpdateProductRequest 更新商品请求
@@ -72,8 +72,9 @@ type LiveProductService interface {
 }
 
 type liveProductServiceImpl struct {
-	productRepo    repository.LiveProductRepository
-	liveStreamRepo repository.LiveStreamRepository
+	productRepo      repository.LiveProductRepository
+	liveStreamRepo   repository.LiveStreamRepository
+	signalingService LiveSignalingService
 }
 
 // NewLiveProductService 创建商品服务
@@ -87,6 +88,11 @@ func NewLiveProductService(
 	}
 }
 
+// SetSignalingService 设置信令服务（延迟注入，用于向直播间推送讲解消息）
+func (s *liveProductServiceImpl) SetSignalingService(signalingService LiveSignalingService) {
+	s.signalingService = signalingService
+}
+
 // AddProduct 添加商品到直播间
 func (s *liveProductServiceImpl) AddProduct(ctx context.Context, userID uint, req *AddProductRequest) (*model.LiveProduct, error) {
 	// 1. 查询直播间
@@ -288,10 +294,37 @@ func (s *liveProductServiceImpl) ExplainProduct(ctx context.Context, userID uint
 		return errors.New("更新讲解时间失败")
 	}
 
+	// 4. 推送讲解消息到直播间
+	if s.signalingService != nil {
+		s.signalingService.BroadcastToRoom(liveStream.RoomID, &SignalingMessage{
+			Type:     MessageTypeProductExplain,
+			RoomID:   liveStream.RoomID,
+			UserID:   userID,
+			Username: liveStreamOwnerName(liveStream),
+			Payload: &ProductExplainPayload{
+				ProductID: product.ID,
+				Name:      product.Name,
+				Cover:     product.Cover,
+				Price:     product.Price,
+				SalePrice: product.SalePrice,
+				Stock:     product.Stock,
+			},
+			Timestamp: now.Unix(),
+		}, 0)
+	}
+
 	logger.Info("讲解商品成功", zap.Uint("product_id", productID), zap.String("name", product.Name))
 	return nil
 }
 
+// liveStreamOwnerName 获取主播用户名（Owner 未预加载时返回空）
+func liveStreamOwnerName(liveStream *model.LiveStream) string {
+	if liveStream.Owner == nil {
+		return ""
+	}
+	return liveStream.Owner.Username
+}
+
 // UpdateStock 更新库存
 func (s *liveProductServiceImpl) UpdateStock(ctx context.Context, userID uint, productID uint, quantity int) error {
 	// 1. 查询商品
@@ -345,9 +378,9 @@ func (s *liveProductServiceImpl) SellProduct(ctx context.Context, productID uint
 		return errors.New("销售商品失败")
 	}
 
-	// 4. 更新直播间商品销售额
-	totalSales := product.SalePrice * float64(quantity)
-	if err := s.liveStreamRepo.UpdateProductStats(ctx, product.LiveID, int64(totalSales)); err != nil {
+	// 4. 更新直播间商品销售额（分）
+	totalSales := yuanToCents(product.SalePrice) * int64(quantity)
+	if err := s.liveStreamRepo.UpdateProductStats(ctx, product.LiveID, totalSales); err != nil {
 		logger.Error("更新直播间销售统计失败", zap.Error(err))
 		// 不影响主流程
 	}
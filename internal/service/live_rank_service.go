@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
+	"microvibe-go/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 主播榜周期
+const (
+	RankPeriodDay  = "day"  // 日榜
+	RankPeriodWeek = "week" // 周榜
+	RankPeriodAll  = "all"  // 总榜
+)
+
+const (
+	// liveRankRoomKey 直播间本场榜（ZSET，成员为用户ID，分数为贡献值）
+	liveRankRoomKey = "live:rank:room:%d"
+	// liveRankStreamerKey 主播榜，周期为 day:20060102、week:2006-W01 或 all
+	liveRankStreamerKey = "live:rank:streamer:%d:%s"
+	// liveRankCountSuffix 榜单对应的礼物数量（HASH，用户ID -> 礼物数量）
+	liveRankCountSuffix = ":count"
+
+	liveRankRoomTTL = 7 * 24 * time.Hour // 本场榜保留时间（结束时已保存快照）
+	liveRankDayTTL  = 2 * 24 * time.Hour
+	liveRankWeekTTL = 8 * 24 * time.Hour
+
+	// liveRankSnapshotSize 直播结束时保存到数据库的名次数
+	liveRankSnapshotSize = 100
+	defaultLiveRankTopN  = 10
+)
+
+// GiftRankEntry 礼物榜单项
+type GiftRankEntry struct {
+	Rank       int    `json:"rank"`
+	UserID     uint   `json:"user_id"`
+	Nickname   string `json:"nickname"`
+	Avatar     string `json:"avatar"`
+	TotalValue int64  `json:"total_value"` // 贡献值（虚拟币）
+	GiftCount  int    `json:"gift_count"`  // 礼物数量
+}
+
+// LiveRankService 直播礼物榜服务接口
+// 每次送礼实时累加到 Redis 有序集合：直播间本场榜，以及主播的日榜、周榜和总榜；
+// 匿名送礼不计入榜单。直播结束时本场榜保存到 live_rank_lists
+type LiveRankService interface {
+	// RecordGift 送礼计入榜单，本场榜前 N 名变化时推送到直播间
+	RecordGift(ctx context.Context, liveStream *model.LiveStream, record *model.LiveGiftRecord) error
+
+	// GetRoomRank 获取直播间本场榜（已结束的直播读取快照）
+	GetRoomRank(ctx context.Context, liveID uint, limit int) ([]*GiftRankEntry, error)
+
+	// GetStreamerRank 获取主播的日榜、周榜或总榜
+	GetStreamerRank(ctx context.Context, streamerID uint, period string, limit int) ([]*GiftRankEntry, error)
+
+	// SaveSnapshot 保存直播间本场榜快照
+	SaveSnapshot(ctx context.Context, liveID uint) error
+
+	// RegisterHandlers 订阅结束直播事件，直播结束时保存榜单快照
+	RegisterHandlers(bus event.EventBus) error
+}
+
+type liveRankServiceImpl struct {
+	redis            *redis.Client
+	rankRepo         repository.LiveRankRepository
+	giftRepo         repository.LiveGiftRepository
+	userRepo         repository.UserRepository
+	cfg              *config.Config
+	signalingService LiveSignalingService
+}
+
+// NewLiveRankService 创建礼物榜服务（redisClient 为空时只能从数据库读取本场榜）
+func NewLiveRankService(
+	redisClient *redis.Client,
+	rankRepo repository.LiveRankRepository,
+	giftRepo repository.LiveGiftRepository,
+	userRepo repository.UserRepository,
+	cfg *config.Config,
+) LiveRankService {
+	return &liveRankServiceImpl{
+		redis:    redisClient,
+		rankRepo: rankRepo,
+		giftRepo: giftRepo,
+		userRepo: userRepo,
+		cfg:      cfg,
+	}
+}
+
+// SetSignalingService 设置信令服务（延迟注入，用于向直播间推送榜单变化）
+func (s *liveRankServiceImpl) SetSignalingService(signalingService LiveSignalingService) {
+	s.signalingService = signalingService
+}
+
+// RecordGift 送礼计入榜单
+func (s *liveRankServiceImpl) RecordGift(ctx context.Context, liveStream *model.LiveStream, record *model.LiveGiftRecord) error {
+	if s.redis == nil || record.IsAnonymous {
+		return nil
+	}
+
+	now := time.Now()
+	year, week := now.ISOWeek()
+	roomKey := fmt.Sprintf(liveRankRoomKey, liveStream.ID)
+	boards := []struct {
+		key string
+		ttl time.Duration
+	}{
+		{roomKey, liveRankRoomTTL},
+		{fmt.Sprintf(liveRankStreamerKey, liveStream.OwnerID, "day:"+now.Format("20060102")), liveRankDayTTL},
+		{fmt.Sprintf(liveRankStreamerKey, liveStream.OwnerID, fmt.Sprintf("week:%d-W%02d", year, week)), liveRankWeekTTL},
+		{fmt.Sprintf(liveRankStreamerKey, liveStream.OwnerID, RankPeriodAll), 0},
+	}
+
+	member := strconv.FormatUint(uint64(record.UserID), 10)
+	pipe := s.redis.TxPipeline()
+	for _, b := range boards {
+		pipe.ZIncrBy(ctx, b.key, float64(record.TotalValue), member)
+		pipe.HIncrBy(ctx, b.key+liveRankCountSuffix, member, int64(record.Quantity))
+		if b.ttl > 0 {
+			pipe.Expire(ctx, b.key, b.ttl)
+			pipe.Expire(ctx, b.key+liveRankCountSuffix, b.ttl)
+		}
+	}
+	rank := pipe.ZRevRank(ctx, roomKey, member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Error("更新礼物榜失败", zap.Error(err), zap.Uint("live_id", liveStream.ID))
+		return err
+	}
+
+	// 送礼人进入前 N 名时前 N 名必然发生了变化（名次或贡献值）
+	if rank.Val() < int64(s.topN()) {
+		s.pushRoomRank(ctx, liveStream)
+	}
+	return nil
+}
+
+// pushRoomRank 向直播间推送本场榜前 N 名
+func (s *liveRankServiceImpl) pushRoomRank(ctx context.Context, liveStream *model.LiveStream) {
+	if s.signalingService == nil {
+		return
+	}
+
+	entries, err := s.readBoard(ctx, fmt.Sprintf(liveRankRoomKey, liveStream.ID), s.topN())
+	if err != nil {
+		logger.Error("读取礼物榜失败", zap.Error(err), zap.Uint("live_id", liveStream.ID))
+		return
+	}
+
+	s.signalingService.BroadcastToRoom(liveStream.RoomID, &SignalingMessage{
+		Type:      MessageTypeGiftRank,
+		RoomID:    liveStream.RoomID,
+		Payload:   &GiftRankPayload{LiveID: liveStream.ID, List: entries},
+		Timestamp: time.Now().Unix(),
+	}, 0)
+}
+
+// GetRoomRank 获取直播间本场榜：优先读取 Redis，其次读取结束时保存的快照，最后从送礼记录聚合
+func (s *liveRankServiceImpl) GetRoomRank(ctx context.Context, liveID uint, limit int) ([]*GiftRankEntry, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	if s.redis != nil {
+		entries, err := s.readBoard(ctx, fmt.Sprintf(liveRankRoomKey, liveID), limit)
+		if err != nil {
+			logger.Warn("读取礼物榜失败，回退到数据库", zap.Error(err), zap.Uint("live_id", liveID))
+		} else if len(entries) > 0 {
+			return entries, nil
+		}
+	}
+
+	ranks, err := s.rankRepo.ListByLiveID(ctx, liveID, limit)
+	if err != nil {
+		logger.Error("查询榜单快照失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("查询送礼榜单失败")
+	}
+	if len(ranks) > 0 {
+		entries := make([]*GiftRankEntry, 0, len(ranks))
+		for _, r := range ranks {
+			entries = append(entries, &GiftRankEntry{Rank: r.Rank, UserID: r.UserID, TotalValue: r.TotalValue, GiftCount: r.GiftCount})
+		}
+		s.fillUsers(ctx, entries)
+		return entries, nil
+	}
+
+	records, err := s.giftRepo.GetTopGivers(ctx, liveID, limit)
+	if err != nil {
+		logger.Error("查询送礼榜单失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("查询送礼榜单失败")
+	}
+	entries := make([]*GiftRankEntry, 0, len(records))
+	for i, r := range records {
+		entries = append(entries, &GiftRankEntry{Rank: i + 1, UserID: r.UserID, TotalValue: r.TotalValue, GiftCount: r.Quantity})
+	}
+	s.fillUsers(ctx, entries)
+	return entries, nil
+}
+
+// GetStreamerRank 获取主播榜
+func (s *liveRankServiceImpl) GetStreamerRank(ctx context.Context, streamerID uint, period string, limit int) ([]*GiftRankEntry, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	if s.redis == nil {
+		return nil, errors.New("榜单暂不可用")
+	}
+
+	now := time.Now()
+	var suffix string
+	switch period {
+	case RankPeriodDay:
+		suffix = "day:" + now.Format("20060102")
+	case RankPeriodWeek:
+		year, week := now.ISOWeek()
+		suffix = fmt.Sprintf("week:%d-W%02d", year, week)
+	case RankPeriodAll:
+		suffix = RankPeriodAll
+	default:
+		return nil, errors.New("榜单周期错误")
+	}
+
+	entries, err := s.readBoard(ctx, fmt.Sprintf(liveRankStreamerKey, streamerID, suffix), limit)
+	if err != nil {
+		logger.Error("读取主播榜失败", zap.Error(err), zap.Uint("streamer_id", streamerID), zap.String("period", period))
+		return nil, errors.New("查询主播榜单失败")
+	}
+	return entries, nil
+}
+
+// SaveSnapshot 保存本场榜快照（Redis 中没有榜单时保留已有快照）
+func (s *liveRankServiceImpl) SaveSnapshot(ctx context.Context, liveID uint) error {
+	if s.redis == nil {
+		return nil
+	}
+
+	entries, err := s.readBoard(ctx, fmt.Sprintf(liveRankRoomKey, liveID), liveRankSnapshotSize)
+	if err != nil {
+		logger.Error("读取礼物榜失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ranks := make([]*model.LiveRankList, 0, len(entries))
+	for _, e := range entries {
+		ranks = append(ranks, &model.LiveRankList{
+			LiveID:     liveID,
+			UserID:     e.UserID,
+			Rank:       e.Rank,
+			TotalValue: e.TotalValue,
+			GiftCount:  e.GiftCount,
+		})
+	}
+	if err := s.rankRepo.ReplaceByLiveID(ctx, liveID, ranks); err != nil {
+		logger.Error("保存榜单快照失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return err
+	}
+
+	logger.Info("榜单快照已保存", zap.Uint("live_id", liveID), zap.Int("count", len(ranks)))
+	return nil
+}
+
+// RegisterHandlers 订阅结束直播事件
+func (s *liveRankServiceImpl) RegisterHandlers(bus event.EventBus) error {
+	if err := bus.Subscribe(event.EventLiveStreamEnded, event.NewEventListener(
+		"live_rank_snapshot_handler", s.handleLiveStreamEnded, true,
+	)); err != nil {
+		return fmt.Errorf("订阅结束直播事件失败: %w", err)
+	}
+	return nil
+}
+
+// handleLiveStreamEnded 直播结束时保存本场榜快照
+func (s *liveRankServiceImpl) handleLiveStreamEnded(ctx context.Context, e event.Event) error {
+	evt, ok := e.(*event.LiveStreamEndedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type: expected LiveStreamEndedEvent")
+	}
+	return s.SaveSnapshot(ctx, evt.LiveID)
+}
+
+// readBoard 读取榜单前 limit 名及礼物数量和用户信息
+func (s *liveRankServiceImpl) readBoard(ctx context.Context, key string, limit int) ([]*GiftRankEntry, error) {
+	members, err := s.redis.ZRevRangeWithScores(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return []*GiftRankEntry{}, nil
+	}
+
+	fields := make([]string, 0, len(members))
+	for _, m := range members {
+		fields = append(fields, m.Member.(string))
+	}
+	counts, err := s.redis.HMGet(ctx, key+liveRankCountSuffix, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*GiftRankEntry, 0, len(members))
+	for i, m := range members {
+		userID, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			continue
+		}
+		entry := &GiftRankEntry{
+			Rank:       len(entries) + 1,
+			UserID:     uint(userID),
+			TotalValue: int64(m.Score),
+		}
+		if c, ok := counts[i].(string); ok {
+			entry.GiftCount, _ = strconv.Atoi(c)
+		}
+		entries = append(entries, entry)
+	}
+
+	s.fillUsers(ctx, entries)
+	return entries, nil
+}
+
+// fillUsers 填充榜单用户的昵称和头像
+func (s *liveRankServiceImpl) fillUsers(ctx context.Context, entries []*GiftRankEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.UserID)
+	}
+	users, err := s.userRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		logger.Warn("查询榜单用户失败", zap.Error(err))
+		return
+	}
+
+	userMap := make(map[uint]*model.User, len(users))
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+	for _, e := range entries {
+		if u, ok := userMap[e.UserID]; ok {
+			e.Nickname = u.Nickname
+			e.Avatar = u.Avatar
+		}
+	}
+}
+
+func (s *liveRankServiceImpl) topN() int {
+	if n := s.cfg.Live.RankTopN; n > 0 {
+		return n
+	}
+	return defaultLiveRankTopN
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
+	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/media"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// replayTitleMaxLen 回放标题最大字符数（与视频标题字段长度一致）
+	replayTitleMaxLen = 200
+	// replayNotifyBatch 通知粉丝时每批查询的粉丝数
+	replayNotifyBatch = 500
+)
+
+// LiveReplayService 直播回放服务接口
+// 直播录制文件提交给视频处理任务（探测、封面、HLS 转码），处理完成后作为关联直播间的视频发布，
+// 发布后回填直播间的回放地址并通知主播的粉丝
+type LiveReplayService interface {
+	// CreateReplay 为录制完成的直播创建回放视频并提交处理任务
+	CreateReplay(ctx context.Context, liveStream *model.LiveStream, recordPath string) (*model.Video, error)
+
+	// RegisterHandlers 订阅视频发布事件，回放发布后回填回放地址并通知粉丝
+	RegisterHandlers(bus event.EventBus) error
+}
+
+type liveReplayServiceImpl struct {
+	videoRepo      repository.VideoRepository
+	liveRepo       repository.LiveStreamRepository
+	followRepo     repository.FollowRepository
+	processService VideoProcessService
+	messageService MessageService
+	recordDir      string // 流媒体服务器录制目录
+	uploadDir      string // 上传文件根目录
+}
+
+// NewLiveReplayService 创建直播回放服务
+func NewLiveReplayService(
+	videoRepo repository.VideoRepository,
+	liveRepo repository.LiveStreamRepository,
+	followRepo repository.FollowRepository,
+	processService VideoProcessService,
+	messageService MessageService,
+	cfg *config.Config,
+) LiveReplayService {
+	return &liveReplayServiceImpl{
+		videoRepo:      videoRepo,
+		liveRepo:       liveRepo,
+		followRepo:     followRepo,
+		processService: processService,
+		messageService: messageService,
+		recordDir:      cfg.Streaming.RecordDir,
+		uploadDir:      cfg.Upload.Path,
+	}
+}
+
+// CreateReplay 创建回放视频（处理中状态），转码成功后由处理任务发布
+func (s *liveReplayServiceImpl) CreateReplay(ctx context.Context, liveStream *model.LiveStream, recordPath string) (*model.Video, error) {
+	// 路径来自回调参数，只接受录制目录下的文件
+	recordPath, err := confineRecordPath(s.recordDir, recordPath)
+	if err != nil {
+		logger.Warn("拒绝录制文件", zap.Error(err), zap.String("path", recordPath), zap.Uint("live_id", liveStream.ID))
+		return nil, errors.New("录制文件路径不合法")
+	}
+
+	info, err := os.Stat(recordPath)
+	if err != nil || !info.Mode().IsRegular() {
+		logger.Error("录制文件不存在", zap.Error(err), zap.String("path", recordPath), zap.Uint("live_id", liveStream.ID))
+		return nil, errors.New("录制文件不存在")
+	}
+
+	// 与上传视频使用相同的目录结构
+	name := fmt.Sprintf("live_%d_%d", liveStream.ID, time.Now().Unix())
+	uploadDir := s.uploadDir
+	if uploadDir == "" {
+		uploadDir = "./uploads"
+	}
+	baseDir := filepath.Join(uploadDir, "videos")
+	hlsDir := filepath.Join(baseDir, "hls", name)
+	coverDir := filepath.Join(baseDir, "covers")
+	if err := os.MkdirAll(hlsDir, os.ModePerm); err != nil {
+		logger.Error("创建回放目录失败", zap.Error(err), zap.String("dir", hlsDir))
+		return nil, errors.New("创建回放失败")
+	}
+	if err := os.MkdirAll(coverDir, os.ModePerm); err != nil {
+		os.RemoveAll(hlsDir)
+		logger.Error("创建封面目录失败", zap.Error(err), zap.String("dir", coverDir))
+		return nil, errors.New("创建回放失败")
+	}
+
+	coverName := name + "_cover.jpg"
+	videoURL := fmt.Sprintf("/uploads/videos/hls/%s/%s", name, media.MasterPlaylistName)
+	coverURL := fmt.Sprintf("/uploads/videos/covers/%s", coverName)
+
+	liveID := liveStream.ID
+	video := &model.Video{
+		UserID:       liveStream.OwnerID,
+		Title:        replayTitle(liveStream.Title),
+		Description:  liveStream.Description,
+		CoverURL:     coverURL,
+		VideoURL:     videoURL,
+		FileSize:     info.Size(),
+		CategoryID:   liveStream.CategoryID,
+		Tags:         liveStream.Tags,
+		Status:       model.VideoStatusProcessing,
+		IsPublic:     true,
+		AllowComment: true,
+		LiveID:       &liveID,
+	}
+	if err := s.videoRepo.Create(ctx, video); err != nil {
+		os.RemoveAll(hlsDir)
+		logger.Error("创建回放视频失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("创建回放失败")
+	}
+
+	// 私密直播的回放同样不公开（布尔字段带 default 标签，false 值在创建时不会写入）
+	if liveStream.IsPrivate {
+		if err := s.videoRepo.UpdateFields(ctx, video.ID, map[string]interface{}{"is_public": false}); err != nil {
+			logger.Error("更新回放可见性失败", zap.Error(err), zap.Uint("video_id", video.ID))
+		}
+		video.IsPublic = false
+	}
+
+	job := &model.VideoProcessJob{
+		VideoID:    video.ID,
+		UserID:     liveStream.OwnerID,
+		SourcePath: recordPath,
+		KeepSource: true, // 录制文件属于流媒体服务器，由其按自身策略清理
+		CoverPath:  filepath.Join(coverDir, coverName),
+		HLSDir:     hlsDir,
+		VideoURL:   videoURL,
+		CoverURL:   coverURL,
+	}
+	if err := s.processService.Enqueue(ctx, job); err != nil {
+		if statusErr := s.videoRepo.UpdateFields(ctx, video.ID, map[string]interface{}{
+			"status": model.VideoStatusProcessFailed,
+		}); statusErr != nil {
+			logger.Error("更新视频状态失败", zap.Error(statusErr), zap.Uint("video_id", video.ID))
+		}
+		os.RemoveAll(hlsDir)
+		return nil, err
+	}
+
+	logger.Info("直播回放已提交处理",
+		zap.Uint("live_id", liveID),
+		zap.Uint("video_id", video.ID),
+		zap.String("path", recordPath))
+
+	return video, nil
+}
+
+// RegisterHandlers 订阅视频发布事件
+func (s *liveReplayServiceImpl) RegisterHandlers(bus event.EventBus) error {
+	if err := bus.Subscribe(event.EventVideoPublished, event.NewEventListener(
+		"live_replay_published_handler", s.handleVideoPublished, true,
+	)); err != nil {
+		return fmt.Errorf("订阅视频发布事件失败: %w", err)
+	}
+	return nil
+}
+
+// handleVideoPublished 回放视频发布后回填直播间回放地址并通知粉丝
+func (s *liveReplayServiceImpl) handleVideoPublished(ctx context.Context, e event.Event) error {
+	evt, ok := e.(*event.VideoPublishedEvent)
+	if !ok {
+		return fmt.Errorf("invalid event type: expected VideoPublishedEvent")
+	}
+
+	video, err := s.videoRepo.FindByID(ctx, evt.VideoID)
+	if err != nil {
+		return err
+	}
+	if video.LiveID == nil {
+		return nil
+	}
+
+	if err := s.liveRepo.UpdateRecordURL(ctx, *video.LiveID, video.VideoURL); err != nil {
+		logger.Error("更新直播回放地址失败", zap.Error(err), zap.Uint("live_id", *video.LiveID))
+		return err
+	}
+
+	logger.Info("直播回放已发布",
+		zap.Uint("live_id", *video.LiveID),
+		zap.Uint("video_id", video.ID))
+
+	if video.IsPublic {
+		s.notifyFollowers(ctx, video)
+	}
+	return nil
+}
+
+// notifyFollowers 分批通知主播的粉丝
+func (s *liveReplayServiceImpl) notifyFollowers(ctx context.Context, video *model.Video) {
+	if s.messageService == nil {
+		return
+	}
+
+	notified := 0
+	for offset := 0; ; offset += replayNotifyBatch {
+		follows, err := s.followRepo.FindFollowers(ctx, video.UserID, replayNotifyBatch, offset)
+		if err != nil {
+			logger.Error("查询粉丝失败", zap.Error(err), zap.Uint("user_id", video.UserID))
+			return
+		}
+
+		for _, f := range follows {
+			if err := s.messageService.CreateNotification(ctx, &CreateNotificationRequest{
+				UserID:        f.UserID,
+				Type:          NotifyTypeSystem,
+				SenderID:      &video.UserID,
+				RelatedID:     video.LiveID,
+				Title:         "直播回放",
+				Content:       "你关注的主播发布了直播回放",
+				VideoID:       &video.ID,
+				VideoCoverURL: video.CoverURL,
+				VideoTitle:    video.Title,
+			}); err == nil {
+				notified++
+			}
+		}
+
+		if len(follows) < replayNotifyBatch {
+			break
+		}
+	}
+
+	logger.Info("直播回放通知已发送", zap.Uint("video_id", video.ID), zap.Int("count", notified))
+}
+
+// replayTitle 回放标题：直播标题加回放前缀，超长时按字符截断
+func replayTitle(title string) string {
+	runes := []rune("【直播回放】" + title)
+	if len(runes) > replayTitleMaxLen {
+		runes = runes[:replayTitleMaxLen]
+	}
+	return string(runes)
+}
+
+// confineRecordPath 将录制文件路径规范为绝对路径（解析符号链接），不在录制目录下时返回错误
+func confineRecordPath(recordDir, path string) (string, error) {
+	if recordDir == "" {
+		return path, errors.New("未配置录制目录")
+	}
+
+	dir, err := filepath.Abs(recordDir)
+	if err != nil {
+		return path, err
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	abs, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return path, err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return path, err
+	}
+
+	rel, err := filepath.Rel(dir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return path, fmt.Errorf("不在录制目录 %s 下", dir)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"microvibe-go/pkg/logger"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 房间控制指令（随房间事件跨实例分发，由各实例对本地连接执行）
+const (
+	RoomControlMute   = "mute"   // 禁言
+	RoomControlUnmute = "unmute" // 解除禁言
+	RoomControlKick   = "kick"   // 断开目标用户的连接
+	RoomControlClose  = "close"  // 关闭房间
+)
+
+const (
+	// liveRoomEventChannel 房间事件的 Redis 频道
+	liveRoomEventChannel = "live:room:events"
+	// liveRoomOnlineKeyPrefix 房间在线人数（Hash：实例ID -> 本实例在线数）
+	liveRoomOnlineKeyPrefix = "live:room:online:"
+	// liveBrokerInstanceKeyPrefix 实例存活标记
+	liveBrokerInstanceKeyPrefix = "live:broker:instance:"
+
+	liveBrokerHeartbeatInterval = 10 * time.Second
+	liveBrokerInstanceTTL       = 30 * time.Second
+	liveRoomOnlineKeyTTL        = 24 * time.Hour
+	liveBrokerCloseTimeout      = 3 * time.Second
+)
+
+// RoomEvent 房间事件（广播消息和控制指令）
+type RoomEvent struct {
+	RoomID        string          `json:"room_id"`
+	ExcludeUserID uint            `json:"exclude_user_id,omitempty"` // 不接收消息的用户
+	Message       json.RawMessage `json:"message,omitempty"`         // 已序列化的 SignalingMessage
+	Control       string          `json:"control,omitempty"`         // 控制指令
+	TargetUserID  uint            `json:"target_user_id,omitempty"`  // 控制指令的目标用户
+	ExpiredAt     *time.Time      `json:"expired_at,omitempty"`      // 禁言过期时间
+}
+
+// RoomEventHandler 房间事件处理函数（将事件投递到本实例的连接）
+type RoomEventHandler func(evt *RoomEvent)
+
+// LiveRoomBroker 直播间消息代理：负责房间事件在各服务实例间的分发以及在线人数汇总
+type LiveRoomBroker interface {
+	// Subscribe 设置本实例的事件处理函数
+	Subscribe(handler RoomEventHandler)
+
+	// Publish 发布房间事件（包括投递给本实例）
+	Publish(ctx context.Context, evt *RoomEvent) error
+
+	// SetLocalCount 上报本实例的房间在线人数
+	SetLocalCount(ctx context.Context, roomID string, count int) error
+
+	// OnlineCount 获取房间在所有实例上的在线人数
+	OnlineCount(ctx context.Context, roomID string) (int, error)
+
+	// Close 停止订阅和心跳并释放资源（服务关闭时调用）
+	Close() error
+}
+
+// ========== 内存实现（单实例部署） ==========
+
+type memoryLiveRoomBroker struct {
+	handler RoomEventHandler
+	counts  map[string]int
+	mu      sync.RWMutex
+}
+
+// NewMemoryLiveRoomBroker 创建进程内消息代理（单实例部署使用）
+func NewMemoryLiveRoomBroker() LiveRoomBroker {
+	return &memoryLiveRoomBroker{
+		counts: make(map[string]int),
+	}
+}
+
+// Subscribe 设置事件处理函数
+func (b *memoryLiveRoomBroker) Subscribe(handler RoomEventHandler) {
+	b.mu.Lock()
+	b.handler = handler
+	b.mu.Unlock()
+}
+
+// Publish 直接投递给本实例
+func (b *memoryLiveRoomBroker) Publish(ctx context.Context, evt *RoomEvent) error {
+	b.mu.RLock()
+	handler := b.handler
+	b.mu.RUnlock()
+
+	if handler != nil {
+		handler(evt)
+	}
+	return nil
+}
+
+// SetLocalCount 记录房间在线人数
+func (b *memoryLiveRoomBroker) SetLocalCount(ctx context.Context, roomID string, count int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if count <= 0 {
+		delete(b.counts, roomID)
+		return nil
+	}
+	b.counts[roomID] = count
+	return nil
+}
+
+// OnlineCount 获取房间在线人数
+func (b *memoryLiveRoomBroker) OnlineCount(ctx context.Context, roomID string) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.counts[roomID], nil
+}
+
+// Close 进程内代理无需释放资源
+func (b *memoryLiveRoomBroker) Close() error {
+	return nil
+}
+
+// ========== Redis 实现（多实例部署） ==========
+
+// redisRoomEnvelope Redis 频道中传输的事件
+type redisRoomEnvelope struct {
+	Origin string     `json:"origin"` // 发布实例ID
+	Event  *RoomEvent `json:"event"`
+}
+
+type redisLiveRoomBroker struct {
+	client     *redis.Client
+	instanceID string
+	handler    RoomEventHandler
+	mu         sync.RWMutex
+
+	// 后台订阅和心跳的生命周期，Close 时取消并等待退出
+	pubsub    *redis.PubSub
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewRedisLiveRoomBroker 创建基于 Redis Pub/Sub 的消息代理，并启动订阅和心跳（Close 时停止）
+func NewRedisLiveRoomBroker(client *redis.Client) LiveRoomBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &redisLiveRoomBroker{
+		client:     client,
+		instanceID: generateInstanceID(),
+		pubsub:     client.Subscribe(ctx, liveRoomEventChannel),
+		cancel:     cancel,
+	}
+
+	b.wg.Add(2)
+	go b.receiveLoop(ctx, b.pubsub.Channel())
+	go b.heartbeatLoop(ctx)
+
+	logger.Info("直播间 Redis 消息代理已启动", zap.String("instance_id", b.instanceID))
+	return b
+}
+
+// Subscribe 设置事件处理函数
+func (b *redisLiveRoomBroker) Subscribe(handler RoomEventHandler) {
+	b.mu.Lock()
+	b.handler = handler
+	b.mu.Unlock()
+}
+
+// Publish 先投递给本实例，再通过 Redis 分发到其他实例
+func (b *redisLiveRoomBroker) Publish(ctx context.Context, evt *RoomEvent) error {
+	b.dispatch(evt)
+
+	data, err := json.Marshal(&redisRoomEnvelope{Origin: b.instanceID, Event: evt})
+	if err != nil {
+		return fmt.Errorf("序列化房间事件失败: %w", err)
+	}
+	return b.client.Publish(ctx, liveRoomEventChannel, data).Err()
+}
+
+// SetLocalCount 上报本实例的房间在线人数
+func (b *redisLiveRoomBroker) SetLocalCount(ctx context.Context, roomID string, count int) error {
+	key := liveRoomOnlineKeyPrefix + roomID
+	if count <= 0 {
+		return b.client.HDel(ctx, key, b.instanceID).Err()
+	}
+
+	pipe := b.client.TxPipeline()
+	pipe.HSet(ctx, key, b.instanceID, count)
+	pipe.Expire(ctx, key, liveRoomOnlineKeyTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// OnlineCount 汇总所有存活实例上报的在线人数（清理已下线实例的残留数据）
+func (b *redisLiveRoomBroker) OnlineCount(ctx context.Context, roomID string) (int, error) {
+	key := liveRoomOnlineKeyPrefix + roomID
+	counts, err := b.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+
+	instances := make([]string, 0, len(counts))
+	pipe := b.client.Pipeline()
+	alive := make(map[string]*redis.IntCmd, len(counts))
+	for instanceID := range counts {
+		instances = append(instances, instanceID)
+		alive[instanceID] = pipe.Exists(ctx, liveBrokerInstanceKeyPrefix+instanceID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	var stale []string
+	for _, instanceID := range instances {
+		if alive[instanceID].Val() == 0 {
+			stale = append(stale, instanceID)
+			continue
+		}
+		n, _ := strconv.Atoi(counts[instanceID])
+		total += n
+	}
+
+	if len(stale) > 0 {
+		_ = b.client.HDel(ctx, key, stale...).Err()
+	}
+
+	return total, nil
+}
+
+// Close 停止订阅和心跳，并删除实例存活标记，使其他实例立即忽略本实例上报的在线人数
+func (b *redisLiveRoomBroker) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		b.cancel()
+		err = b.pubsub.Close()
+		b.wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), liveBrokerCloseTimeout)
+		defer cancel()
+		if delErr := b.client.Del(ctx, liveBrokerInstanceKeyPrefix+b.instanceID).Err(); delErr != nil {
+			logger.Warn("删除实例存活标记失败", zap.Error(delErr), zap.String("instance_id", b.instanceID))
+		}
+
+		logger.Info("直播间 Redis 消息代理已停止", zap.String("instance_id", b.instanceID))
+	})
+	return err
+}
+
+// receiveLoop 订阅房间事件频道，投递其他实例发布的事件
+func (b *redisLiveRoomBroker) receiveLoop(ctx context.Context, ch <-chan *redis.Message) {
+	defer b.wg.Done()
+
+	for {
+		var msg *redis.Message
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			msg = m
+		}
+
+		var envelope redisRoomEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			logger.Error("解析房间事件失败", zap.Error(err))
+			continue
+		}
+
+		// 本实例发布的事件已在 Publish 时投递
+		if envelope.Origin == b.instanceID || envelope.Event == nil {
+			continue
+		}
+		b.dispatch(envelope.Event)
+	}
+}
+
+// heartbeatLoop 定期刷新实例存活标记，供 OnlineCount 判断上报数据是否有效
+func (b *redisLiveRoomBroker) heartbeatLoop(ctx context.Context) {
+	defer b.wg.Done()
+
+	key := liveBrokerInstanceKeyPrefix + b.instanceID
+	ticker := time.NewTicker(liveBrokerHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.client.Set(ctx, key, 1, liveBrokerInstanceTTL).Err(); err != nil && ctx.Err() == nil {
+			logger.Warn("刷新实例存活标记失败", zap.Error(err), zap.String("instance_id", b.instanceID))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch 调用本实例的事件处理函数
+func (b *redisLiveRoomBroker) dispatch(evt *RoomEvent) {
+	b.mu.RLock()
+	handler := b.handler
+	b.mu.RUnlock()
+
+	if handler != nil {
+		handler(evt)
+	}
+}
+
+// generateInstanceID 生成实例ID
+func generateInstanceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("inst_%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryLiveRoomBroker_PublishDeliversToSubscriber(t *testing.T) {
+	b := NewMemoryLiveRoomBroker()
+	ctx := context.Background()
+
+	// 未订阅时发布不报错
+	if err := b.Publish(ctx, &RoomEvent{RoomID: "r1"}); err != nil {
+		t.Fatalf("Publish without handler failed: %v", err)
+	}
+
+	var got []*RoomEvent
+	b.Subscribe(func(evt *RoomEvent) { got = append(got, evt) })
+
+	evt := &RoomEvent{RoomID: "r1", Control: RoomControlKick, TargetUserID: 7}
+	if err := b.Publish(ctx, evt); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != evt {
+		t.Fatalf("expected the published event to be delivered once, got %v", got)
+	}
+}
+
+func TestMemoryLiveRoomBroker_OnlineCount(t *testing.T) {
+	b := NewMemoryLiveRoomBroker()
+	ctx := context.Background()
+
+	if n, _ := b.OnlineCount(ctx, "r1"); n != 0 {
+		t.Fatalf("expected 0 for unknown room, got %d", n)
+	}
+
+	_ = b.SetLocalCount(ctx, "r1", 3)
+	_ = b.SetLocalCount(ctx, "r2", 1)
+	if n, _ := b.OnlineCount(ctx, "r1"); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+
+	_ = b.SetLocalCount(ctx, "r1", 0)
+	if n, _ := b.OnlineCount(ctx, "r1"); n != 0 {
+		t.Fatalf("expected 0 after room emptied, got %d", n)
+	}
+	if n, _ := b.OnlineCount(ctx, "r2"); n != 1 {
+		t.Fatalf("expected other rooms unaffected, got %d", n)
+	}
+}
+
+func TestRedisLiveRoomBroker_CloseStopsBackgroundLoops(t *testing.T) {
+	// Redis 不可达时订阅和心跳持续重试，Close 仍需及时返回
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+
+	b := NewRedisLiveRoomBroker(client)
+
+	done := make(chan struct{})
+	go func() {
+		_ = b.Close()
+		_ = b.Close() // 重复关闭不阻塞
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop the broker loops")
+	}
+}
+
+// newTestSignaling 创建使用内存代理的信令服务
+func newTestSignaling(t *testing.T) *liveSignalingServiceImpl {
+	t.Helper()
+	s, ok := NewLiveSignalingService(nil, nil, false, &config.Config{}).(*liveSignalingServiceImpl)
+	if !ok {
+		t.Fatal("unexpected signaling service implementation")
+	}
+	return s
+}
+
+// joinTestClient 建立一条 WebSocket 连接并以 userID 加入房间，返回客户端一侧的连接
+func joinTestClient(t *testing.T, s *liveSignalingServiceImpl, roomID string, userID uint) *websocket.Conn {
+	t.Helper()
+
+	serverConns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s.addClient(&Client{Conn: <-serverConns, UserID: userID, RoomID: roomID, JoinTime: time.Now()})
+	return conn
+}
+
+// readModeration 读取一条房间消息并解析处罚动作
+func readModeration(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	var msg struct {
+		Type    SignalingMessageType `json:"type"`
+		Payload ModerationPayload    `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if msg.Type != MessageTypeModeration {
+		t.Fatalf("expected moderation message, got %q", msg.Type)
+	}
+	return msg.Payload.Action
+}
+
+func TestSignaling_KickDisconnectsOnlyTargetUser(t *testing.T) {
+	s := newTestSignaling(t)
+	target := joinTestClient(t, s, "r1", 7)
+	other := joinTestClient(t, s, "r1", 8)
+
+	if n := s.GetRoomOnlineCount("r1"); n != 2 {
+		t.Fatalf("expected 2 online, got %d", n)
+	}
+
+	s.ApplyBan("r1", &model.LiveBan{UserID: 7, Type: LiveBanTypeKick})
+
+	// 房间内所有人都收到处罚通知
+	if action := readModeration(t, target); action != "kick" {
+		t.Fatalf("expected kick notice, got %q", action)
+	}
+	if action := readModeration(t, other); action != "kick" {
+		t.Fatalf("expected kick notice, got %q", action)
+	}
+
+	// 目标用户的连接被关闭
+	_ = target.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := target.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("expected policy violation close, got %v", err)
+	}
+
+	// 其他用户仍能收到消息
+	s.LiftBan("r1", 9)
+	if action := readModeration(t, other); action != "unmute" {
+		t.Fatalf("expected unmute notice, got %q", action)
+	}
+}
+
+func TestSignaling_MuteAndUnmuteControl(t *testing.T) {
+	s := newTestSignaling(t)
+	conn := joinTestClient(t, s, "r1", 7)
+
+	s.ApplyBan("r1", &model.LiveBan{UserID: 7, Type: LiveBanTypeMute})
+	if action := readModeration(t, conn); action != "mute" {
+		t.Fatalf("expected mute notice, got %q", action)
+	}
+	if !s.isMuted("r1", 7) {
+		t.Fatal("expected user to be muted")
+	}
+	if s.isMuted("r2", 7) {
+		t.Fatal("mute must not leak into other rooms")
+	}
+
+	s.LiftBan("r1", 7)
+	if action := readModeration(t, conn); action != "unmute" {
+		t.Fatalf("expected unmute notice, got %q", action)
+	}
+	if s.isMuted("r1", 7) {
+		t.Fatal("expected user to be unmuted")
+	}
+
+	// 已过期的禁言不再生效
+	expired := time.Now().Add(-time.Minute)
+	s.ApplyBan("r1", &model.LiveBan{UserID: 7, Type: LiveBanTypeMute, ExpiredAt: &expired})
+	if s.isMuted("r1", 7) {
+		t.Fatal("expected expired mute to be ignored")
+	}
+}
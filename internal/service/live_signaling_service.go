@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
 	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
 	"microvibe-go/pkg/utils"
@@ -30,13 +31,21 @@ const (
 	MessageTypeICE    SignalingMessageType = "ice"    // ICE Candidate
 
 	// 直播间消息类型
-	MessageTypeChat SignalingMessageType = "chat" // 聊天消息
-	MessageTypeLike SignalingMessageType = "like" // 点赞
-	MessageTypeGift SignalingMessageType = "gift" // 送礼物
+	MessageTypeChat          SignalingMessageType = "chat"           // 聊天消息
+	MessageTypeChatHistory   SignalingMessageType = "chat_history"   // 进房弹幕回放
+	MessageTypeCommentPinned SignalingMessageType = "comment_pinned" // 精选弹幕变更
+	MessageTypeLike          SignalingMessageType = "like"           // 点赞
+	MessageTypeGift          SignalingMessageType = "gift"           // 送礼物
+	MessageTypeGiftRank      SignalingMessageType = "gift_rank"      // 礼物榜前 N 名变更
+
+	// 直播带货消息类型
+	MessageTypeProductExplain   SignalingMessageType = "product_explain"   // 商品讲解
+	MessageTypeProductPurchased SignalingMessageType = "product_purchased" // 有人下单（支付成功）
 
 	// 系统消息类型
 	MessageTypeUserJoined SignalingMessageType = "user_joined" // 用户加入通知
 	MessageTypeUserLeft   SignalingMessageType = "user_left"   // 用户离开通知
+	MessageTypeModeration SignalingMessageType = "moderation"  // 房间管理通知（禁言、踢出、解除）
 	MessageTypeError      SignalingMessageType = "error"       // 错误消息
 )
 
@@ -52,14 +61,69 @@ type SignalingMessage struct {
 
 // ChatPayload 聊天消息内容
 type ChatPayload struct {
-	Message string `json:"message"`
+	Message   string     `json:"message"`
+	CommentID uint       `json:"comment_id,omitempty"` // 弹幕记录ID（服务端持久化后填充）
+	Color     string     `json:"color,omitempty"`      // 弹幕颜色
+	Position  int8       `json:"position,omitempty"`   // 位置：1-滚动，2-顶部，3-底部
+	FontSize  int8       `json:"font_size,omitempty"`  // 字号：1-小，2-中，3-大
+	Badge     *FansBadge `json:"badge,omitempty"`      // 粉丝团徽章（服务端填充）
+}
+
+// UserJoinedPayload 用户加入消息内容（粉丝团成员携带徽章和进场特效）
+type UserJoinedPayload struct {
+	Badge       *FansBadge `json:"badge,omitempty"`        // 粉丝团徽章
+	EntryEffect string     `json:"entry_effect,omitempty"` // 进场特效
 }
 
 // GiftPayload 礼物消息内容
 type GiftPayload struct {
-	GiftID   uint   `json:"gift_id"`
-	GiftName string `json:"gift_name"`
-	Amount   int    `json:"amount"`
+	GiftID      uint   `json:"gift_id"`
+	GiftName    string `json:"gift_name"`
+	Amount      int    `json:"amount"`
+	Icon        string `json:"icon,omitempty"`         // 礼物图标
+	Animation   string `json:"animation,omitempty"`    // 礼物动画
+	Value       int64  `json:"value,omitempty"`        // 礼物总价值
+	RecordID    uint   `json:"record_id,omitempty"`    // 送礼记录ID（服务端送礼时填充）
+	IsAnonymous bool   `json:"is_anonymous,omitempty"` // 是否匿名
+	Message     string `json:"message,omitempty"`      // 附带消息
+}
+
+// GiftRankPayload 礼物榜变更消息内容
+type GiftRankPayload struct {
+	LiveID uint             `json:"live_id"`
+	List   []*GiftRankEntry `json:"list"` // 本场榜前 N 名
+}
+
+// ProductExplainPayload 商品讲解消息内容
+type ProductExplainPayload struct {
+	ProductID uint    `json:"product_id"`
+	Name      string  `json:"name"`
+	Cover     string  `json:"cover"`
+	Price     float64 `json:"price"`
+	SalePrice float64 `json:"sale_price"`
+	Stock     int     `json:"stock"`
+}
+
+// ProductPurchasedPayload 下单消息内容（"某某刚刚购买了"）
+type ProductPurchasedPayload struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Stock     int    `json:"stock"` // 剩余库存
+}
+
+// ModerationPayload 房间管理消息内容
+type ModerationPayload struct {
+	Action       string     `json:"action"`               // mute-禁言，kick-踢出，block-拉黑，unmute-解除
+	TargetUserID uint       `json:"target_user_id"`       // 被处罚用户ID
+	Duration     int        `json:"duration"`             // 处罚时长（分钟，0表示永久）
+	ExpiredAt    *time.Time `json:"expired_at,omitempty"` // 过期时间
+	Reason       string     `json:"reason,omitempty"`     // 原因
+}
+
+// muteState 房间内的禁言状态
+type muteState struct {
+	expiredAt *time.Time // 过期时间（nil 表示永久）
 }
 
 // Client WebSocket 客户端信息
@@ -87,19 +151,38 @@ type LiveSignalingService interface {
 
 	// CloseRoom 关闭房间（踢出所有用户）
 	CloseRoom(roomID string)
+
+	// ApplyBan 在房间内执行处罚：禁言立即生效，踢出/拉黑断开该用户的连接
+	ApplyBan(roomID string, ban *model.LiveBan)
+
+	// LiftBan 解除用户在房间内的禁言
+	LiftBan(roomID string, userID uint)
 }
 
 type liveSignalingServiceImpl struct {
-	// rooms 房间映射 roomID -> []*Client
+	// rooms 本实例的房间映射 roomID -> []*Client
 	rooms      map[string][]*Client
 	roomsMutex sync.RWMutex
 
+	// broker 房间消息代理（跨实例分发广播与控制指令、汇总在线人数）
+	broker LiveRoomBroker
+
+	// mutes 禁言状态 roomID -> userID -> 禁言信息（在加入房间和执行处罚时维护，避免每条弹幕查库）
+	mutes      map[string]map[uint]*muteState
+	mutesMutex sync.RWMutex
+
 	// upgrader WebSocket 升级器
 	upgrader websocket.Upgrader
 
 	// liveService 直播业务服务（用于更新在线人数等）
 	liveService LiveStreamService
 
+	// commentService 弹幕服务（用于持久化弹幕和进房回放）
+	commentService LiveCommentService
+
+	// fansClubService 粉丝团服务（用于弹幕徽章和进场特效）
+	fansClubService LiveFansClubService
+
 	// sfuClient SFU 客户端服务（用于 WebRTC 流分发）
 	sfuClient SFUClientService
 
@@ -115,8 +198,9 @@ type liveSignalingServiceImpl struct {
 
 // NewLiveSignalingService 创建信令服务
 func NewLiveSignalingService(liveService LiveStreamService, sfuClient SFUClientService, enableSFU bool, cfg *config.Config) LiveSignalingService {
-	return &liveSignalingServiceImpl{
+	s := &liveSignalingServiceImpl{
 		rooms: make(map[string][]*Client),
+		mutes: make(map[string]map[uint]*muteState),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -131,6 +215,26 @@ func NewLiveSignalingService(liveService LiveStreamService, sfuClient SFUClientS
 		eventBus:    event.GetGlobalEventBus(), // 使用全局事件总线
 		config:      cfg,
 	}
+
+	// 默认使用进程内代理，多实例部署时通过 SetRoomBroker 替换
+	s.SetRoomBroker(NewMemoryLiveRoomBroker())
+	return s
+}
+
+// SetRoomBroker 设置房间消息代理（延迟注入）
+func (s *liveSignalingServiceImpl) SetRoomBroker(broker LiveRoomBroker) {
+	broker.Subscribe(s.handleRoomEvent)
+	s.broker = broker
+}
+
+// SetCommentService 设置弹幕服务（延迟注入）
+func (s *liveSignalingServiceImpl) SetCommentService(commentService LiveCommentService) {
+	s.commentService = commentService
+}
+
+// SetFansClubService 设置粉丝团服务（延迟注入）
+func (s *liveSignalingServiceImpl) SetFansClubService(fansClubService LiveFansClubService) {
+	s.fansClubService = fansClubService
 }
 
 // HandleWebSocket 处理 WebSocket 连接
@@ -163,6 +267,19 @@ func (s *liveSignalingServiceImpl) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	// 检查处罚：被踢出/拉黑的用户在到期前不能重新进入，被禁言的用户记录禁言状态
+	if userID != 0 && s.liveService != nil {
+		if liveStream, err := s.liveService.GetLiveStreamByRoomID(c.Request.Context(), roomID); err == nil && liveStream != nil {
+			if ban, err := s.liveService.GetActiveBan(c.Request.Context(), liveStream.ID, userID); err == nil && ban != nil {
+				if ban.Type != LiveBanTypeMute {
+					c.JSON(http.StatusForbidden, gin.H{"error": "you have been removed from this room", "expired_at": ban.ExpiredAt})
+					return
+				}
+				s.setMute(roomID, userID, ban.ExpiredAt)
+			}
+		}
+	}
+
 	// 升级 HTTP 连接到 WebSocket
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -196,11 +313,17 @@ func (s *liveSignalingServiceImpl) HandleWebSocket(c *gin.Context) {
 		Username:  username,
 		Timestamp: time.Now().Unix(),
 	}
+	if payload := s.userJoinedPayload(c.Request.Context(), roomID, userID); payload != nil {
+		welcomeMsg.Payload = payload
+	}
 	s.sendToClient(client, welcomeMsg)
 
 	// 广播用户加入消息（排除自己）
 	s.BroadcastToRoom(roomID, welcomeMsg, userID)
 
+	// 回放最近弹幕和精选弹幕
+	s.sendCommentReplay(c.Request.Context(), client)
+
 	logger.Info("用户加入 WebSocket",
 		zap.String("room_id", roomID),
 		zap.Uint("user_id", userID),
@@ -324,36 +447,14 @@ func (s *liveSignalingServiceImpl) handleMessage(client *Client, msg *SignalingM
 		s.handleICECandidate(client, msg)
 
 	case MessageTypeChat:
-		// 聊天消息，广播给所有人（包括自己）
-		s.BroadcastToRoom(msg.RoomID, msg, 0)
-
-		// 发布评论事件
-		if s.eventBus != nil && s.liveService != nil {
-			if liveStream, err := s.liveService.GetLiveStreamByRoomID(context.Background(), msg.RoomID); err == nil && liveStream != nil {
-				// 提取聊天内容
-				content := ""
-				if payload, ok := msg.Payload.(map[string]interface{}); ok {
-					if message, exists := payload["message"]; exists {
-						if messageStr, ok := message.(string); ok {
-							content = messageStr
-						}
-					}
-				} else if payloadStr, ok := msg.Payload.(string); ok {
-					content = payloadStr
-				}
-
-				if content != "" {
-					commentEvent := event.NewLiveCommentReceivedEvent(
-						liveStream.ID,
-						msg.RoomID,
-						msg.UserID,
-						content,
-					)
-					_ = s.eventBus.PublishAsync(context.Background(), commentEvent)
-				}
-			}
+		// 被禁言用户不能发送弹幕
+		if s.isMuted(msg.RoomID, msg.UserID) {
+			s.sendError(client, "You have been muted")
+			return
 		}
 
+		s.handleChat(client, msg)
+
 	case MessageTypeLike:
 		// 点赞消息，广播给所有人
 		s.BroadcastToRoom(msg.RoomID, msg, 0)
@@ -384,59 +485,8 @@ func (s *liveSignalingServiceImpl) handleMessage(client *Client, msg *SignalingM
 		}
 
 	case MessageTypeGift:
-		// 礼物消息，广播给所有人
-		s.BroadcastToRoom(msg.RoomID, msg, 0)
-
-		// 发布礼物事件（自动记录礼物）
-		if s.eventBus != nil && s.liveService != nil {
-			if liveStream, err := s.liveService.GetLiveStreamByRoomID(context.Background(), msg.RoomID); err == nil && liveStream != nil {
-				// 从 payload 中提取礼物信息
-				var giftID uint
-				var giftName string
-				var count int
-				var value int64
-
-				if payload, ok := msg.Payload.(map[string]interface{}); ok {
-					if id, exists := payload["gift_id"]; exists {
-						if idFloat, ok := id.(float64); ok {
-							giftID = uint(idFloat)
-						}
-					}
-					if name, exists := payload["gift_name"]; exists {
-						if nameStr, ok := name.(string); ok {
-							giftName = nameStr
-						}
-					}
-					if c, exists := payload["count"]; exists {
-						if countFloat, ok := c.(float64); ok {
-							count = int(countFloat)
-						} else if countInt, ok := c.(int); ok {
-							count = countInt
-						}
-					}
-					if v, exists := payload["value"]; exists {
-						if valueFloat, ok := v.(float64); ok {
-							value = int64(valueFloat)
-						} else if valueInt, ok := v.(int64); ok {
-							value = valueInt
-						}
-					}
-				}
-
-				if giftID > 0 && count > 0 {
-					giftEvent := event.NewLiveGiftReceivedEvent(
-						liveStream.ID,
-						msg.RoomID,
-						msg.UserID,
-						giftID,
-						giftName,
-						count,
-						value,
-					)
-					_ = s.eventBus.PublishAsync(context.Background(), giftEvent)
-				}
-			}
-		}
+		// 礼物只能通过送礼接口扣费发送，扣费成功后由 broadcastGift 推送到房间
+		s.sendError(client, "Gifts must be sent through the gift API")
 
 	case MessageTypeLeave:
 		// 主动离开，关闭连接
@@ -448,6 +498,132 @@ func (s *liveSignalingServiceImpl) handleMessage(client *Client, msg *SignalingM
 	}
 }
 
+// handleChat 处理弹幕：持久化后广播给所有人（包括自己），并发布评论事件
+func (s *liveSignalingServiceImpl) handleChat(client *Client, msg *SignalingMessage) {
+	// 解析弹幕内容和样式（兼容直接发送字符串的旧客户端）
+	var payload ChatPayload
+	if payloadStr, ok := msg.Payload.(string); ok {
+		payload.Message = payloadStr
+	} else if raw, err := json.Marshal(msg.Payload); err == nil {
+		_ = json.Unmarshal(raw, &payload)
+	}
+
+	if payload.Message == "" {
+		return
+	}
+
+	var liveStream *model.LiveStream
+	if s.liveService != nil {
+		if ls, err := s.liveService.GetLiveStreamByRoomID(context.Background(), msg.RoomID); err == nil {
+			liveStream = ls
+		}
+	}
+
+	if liveStream != nil && s.commentService != nil {
+		comment, err := s.commentService.PostComment(context.Background(), liveStream.ID, msg.UserID, &PostLiveCommentRequest{
+			Content:  payload.Message,
+			Color:    payload.Color,
+			Position: payload.Position,
+			FontSize: payload.FontSize,
+		})
+		if err != nil {
+			s.sendError(client, err.Error())
+			return
+		}
+
+		payload = ChatPayload{
+			Message:   comment.Content,
+			CommentID: comment.ID,
+			Color:     comment.Color,
+			Position:  comment.Position,
+			FontSize:  comment.FontSize,
+		}
+	}
+	// 徽章只由服务端按粉丝团特权填充
+	payload.Badge = nil
+	if liveStream != nil {
+		if identity := s.fansIdentity(context.Background(), liveStream.ID, msg.UserID); identity != nil && identity.Privileges.ChatBadge {
+			payload.Badge = identity.Badge
+		}
+	}
+	msg.Payload = payload
+
+	s.BroadcastToRoom(msg.RoomID, msg, 0)
+
+	// 发布评论事件
+	if s.eventBus != nil && liveStream != nil {
+		commentEvent := event.NewLiveCommentReceivedEvent(
+			liveStream.ID,
+			msg.RoomID,
+			msg.UserID,
+			payload.Message,
+		)
+		_ = s.eventBus.PublishAsync(context.Background(), commentEvent)
+	}
+}
+
+// userJoinedPayload 粉丝团成员进房时的徽章和进场特效（不是成员时返回 nil）
+func (s *liveSignalingServiceImpl) userJoinedPayload(ctx context.Context, roomID string, userID uint) *UserJoinedPayload {
+	if s.fansClubService == nil || s.liveService == nil {
+		return nil
+	}
+
+	liveStream, err := s.liveService.GetLiveStreamByRoomID(ctx, roomID)
+	if err != nil || liveStream == nil {
+		return nil
+	}
+
+	identity := s.fansIdentity(ctx, liveStream.ID, userID)
+	if identity == nil {
+		return nil
+	}
+
+	payload := &UserJoinedPayload{EntryEffect: identity.Privileges.EntryEffect}
+	if identity.Privileges.ChatBadge {
+		payload.Badge = identity.Badge
+	}
+	return payload
+}
+
+// fansIdentity 查询用户在直播间的粉丝团身份（查询失败按非成员处理）
+func (s *liveSignalingServiceImpl) fansIdentity(ctx context.Context, liveID, userID uint) *FansClubIdentity {
+	if s.fansClubService == nil || userID == 0 {
+		return nil
+	}
+
+	identity, err := s.fansClubService.GetIdentity(ctx, liveID, userID)
+	if err != nil {
+		logger.Warn("查询粉丝团身份失败", zap.Error(err), zap.Uint("live_id", liveID), zap.Uint("user_id", userID))
+		return nil
+	}
+	return identity
+}
+
+// sendCommentReplay 向新加入的客户端回放最近弹幕和精选弹幕
+func (s *liveSignalingServiceImpl) sendCommentReplay(ctx context.Context, client *Client) {
+	if s.commentService == nil || s.liveService == nil {
+		return
+	}
+
+	liveStream, err := s.liveService.GetLiveStreamByRoomID(ctx, client.RoomID)
+	if err != nil || liveStream == nil {
+		return
+	}
+
+	replay, err := s.commentService.GetReplay(ctx, liveStream.ID)
+	if err != nil {
+		logger.Warn("获取弹幕回放失败", zap.Error(err), zap.String("room_id", client.RoomID))
+		return
+	}
+
+	s.sendToClient(client, &SignalingMessage{
+		Type:      MessageTypeChatHistory,
+		RoomID:    client.RoomID,
+		Payload:   replay,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
 // handleOffer 处理 Offer 消息（通过 SFU）
 func (s *liveSignalingServiceImpl) handleOffer(client *Client, msg *SignalingMessage) {
 	// 如果未启用 SFU，使用传统的 P2P 转发
@@ -586,19 +762,19 @@ func (s *liveSignalingServiceImpl) handleLeave(client *Client) {
 // addClient 添加客户端到房间
 func (s *liveSignalingServiceImpl) addClient(client *Client) {
 	s.roomsMutex.Lock()
-	defer s.roomsMutex.Unlock()
-
 	if s.rooms[client.RoomID] == nil {
 		s.rooms[client.RoomID] = make([]*Client, 0)
 	}
 	s.rooms[client.RoomID] = append(s.rooms[client.RoomID], client)
+	count := len(s.rooms[client.RoomID])
+	s.roomsMutex.Unlock()
+
+	s.reportOnlineCount(client.RoomID, count)
 }
 
 // removeClient 从房间移除客户端
 func (s *liveSignalingServiceImpl) removeClient(client *Client) {
 	s.roomsMutex.Lock()
-	defer s.roomsMutex.Unlock()
-
 	clients := s.rooms[client.RoomID]
 	for i, c := range clients {
 		if c == client {
@@ -609,13 +785,74 @@ func (s *liveSignalingServiceImpl) removeClient(client *Client) {
 	}
 
 	// 如果房间为空，删除房间
-	if len(s.rooms[client.RoomID]) == 0 {
+	count := len(s.rooms[client.RoomID])
+	if count == 0 {
 		delete(s.rooms, client.RoomID)
+		s.clearMutes(client.RoomID)
+	}
+	s.roomsMutex.Unlock()
+
+	s.reportOnlineCount(client.RoomID, count)
+}
+
+// reportOnlineCount 向消息代理上报本实例的房间在线人数
+func (s *liveSignalingServiceImpl) reportOnlineCount(roomID string, count int) {
+	if err := s.broker.SetLocalCount(context.Background(), roomID, count); err != nil {
+		logger.Warn("上报房间在线人数失败", zap.Error(err), zap.String("room_id", roomID))
 	}
 }
 
-// BroadcastToRoom 广播消息到房间
+// BroadcastToRoom 广播消息到房间（通过消息代理分发到所有实例）
 func (s *liveSignalingServiceImpl) BroadcastToRoom(roomID string, message *SignalingMessage, excludeUserID uint) {
+	s.publishRoomEvent(message, &RoomEvent{
+		RoomID:        roomID,
+		ExcludeUserID: excludeUserID,
+	})
+}
+
+// publishRoomEvent 附带消息发布房间事件（message 为 nil 时只发布控制指令）
+func (s *liveSignalingServiceImpl) publishRoomEvent(message *SignalingMessage, evt *RoomEvent) {
+	if message != nil {
+		msgBytes, err := json.Marshal(message)
+		if err != nil {
+			logger.Error("序列化消息失败", zap.Error(err))
+			return
+		}
+		evt.Message = msgBytes
+	}
+
+	if err := s.broker.Publish(context.Background(), evt); err != nil {
+		logger.Error("发布房间事件失败",
+			zap.Error(err),
+			zap.String("room_id", evt.RoomID),
+			zap.String("control", evt.Control))
+	}
+}
+
+// handleRoomEvent 处理消息代理投递的房间事件：先推送消息，再对本实例的连接执行控制指令
+func (s *liveSignalingServiceImpl) handleRoomEvent(evt *RoomEvent) {
+	if len(evt.Message) > 0 {
+		s.deliverToLocalRoom(evt.RoomID, evt.Message, evt.ExcludeUserID)
+	}
+
+	switch evt.Control {
+	case RoomControlMute:
+		s.setMute(evt.RoomID, evt.TargetUserID, evt.ExpiredAt)
+	case RoomControlUnmute:
+		s.mutesMutex.Lock()
+		if roomMutes := s.mutes[evt.RoomID]; roomMutes != nil {
+			delete(roomMutes, evt.TargetUserID)
+		}
+		s.mutesMutex.Unlock()
+	case RoomControlKick:
+		s.disconnectUser(evt.RoomID, evt.TargetUserID)
+	case RoomControlClose:
+		s.closeLocalRoom(evt.RoomID)
+	}
+}
+
+// deliverToLocalRoom 推送消息给本实例房间内的客户端
+func (s *liveSignalingServiceImpl) deliverToLocalRoom(roomID string, msgBytes []byte, excludeUserID uint) {
 	s.roomsMutex.RLock()
 	defer s.roomsMutex.RUnlock()
 
@@ -624,19 +861,17 @@ func (s *liveSignalingServiceImpl) BroadcastToRoom(roomID string, message *Signa
 		return
 	}
 
-	msgBytes, err := json.Marshal(message)
-	if err != nil {
-		logger.Error("序列化消息失败", zap.Error(err))
-		return
-	}
-
 	// 广播给房间内所有客户端（排除指定用户）
 	for _, client := range clients {
 		if excludeUserID != 0 && client.UserID == excludeUserID {
 			continue
 		}
 
-		if err := client.Conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+		// 广播可能来自 HTTP 请求（送礼、讲解商品等），与读循环并发，需加写锁
+		client.writeMu.Lock()
+		err := client.Conn.WriteMessage(websocket.TextMessage, msgBytes)
+		client.writeMu.Unlock()
+		if err != nil {
 			logger.Error("发送消息失败",
 				zap.Error(err),
 				zap.Uint("user_id", client.UserID),
@@ -673,24 +908,22 @@ func (s *liveSignalingServiceImpl) sendError(client *Client, errorMsg string) {
 	s.sendToClient(client, msg)
 }
 
-// GetRoomOnlineCount 获取房间在线人数
+// GetRoomOnlineCount 获取房间在线人数（所有实例合计，代理不可用时退回本实例人数）
 func (s *liveSignalingServiceImpl) GetRoomOnlineCount(roomID string) int {
+	count, err := s.broker.OnlineCount(context.Background(), roomID)
+	if err == nil {
+		return count
+	}
+	logger.Warn("获取房间在线人数失败", zap.Error(err), zap.String("room_id", roomID))
+
 	s.roomsMutex.RLock()
 	defer s.roomsMutex.RUnlock()
 
 	return len(s.rooms[roomID])
 }
 
-// CloseRoom 关闭房间（踢出所有用户）
+// CloseRoom 关闭房间（踢出所有实例上的用户）
 func (s *liveSignalingServiceImpl) CloseRoom(roomID string) {
-	s.roomsMutex.Lock()
-	defer s.roomsMutex.Unlock()
-
-	clients := s.rooms[roomID]
-	if clients == nil {
-		return
-	}
-
 	// 发送房间关闭消息
 	closeMsg := &SignalingMessage{
 		Type:      MessageTypeError,
@@ -699,16 +932,145 @@ func (s *liveSignalingServiceImpl) CloseRoom(roomID string) {
 		Timestamp: time.Now().Unix(),
 	}
 
-	msgBytes, _ := json.Marshal(closeMsg)
+	s.publishRoomEvent(closeMsg, &RoomEvent{
+		RoomID:  roomID,
+		Control: RoomControlClose,
+	})
+}
+
+// closeLocalRoom 关闭本实例上的房间连接
+func (s *liveSignalingServiceImpl) closeLocalRoom(roomID string) {
+	s.roomsMutex.Lock()
+	clients := s.rooms[roomID]
+	if clients == nil {
+		s.roomsMutex.Unlock()
+		return
+	}
 
 	// 关闭所有连接
 	for _, client := range clients {
-		_ = client.Conn.WriteMessage(websocket.TextMessage, msgBytes)
 		client.Conn.Close()
 	}
 
 	// 删除房间
 	delete(s.rooms, roomID)
+	s.clearMutes(roomID)
+	s.roomsMutex.Unlock()
+
+	s.reportOnlineCount(roomID, 0)
 
 	logger.Info("房间已关闭", zap.String("room_id", roomID))
 }
+
+// ApplyBan 在房间内执行处罚
+func (s *liveSignalingServiceImpl) ApplyBan(roomID string, ban *model.LiveBan) {
+	action := "mute"
+	control := RoomControlMute
+	switch ban.Type {
+	case LiveBanTypeKick:
+		action = "kick"
+		control = RoomControlKick
+	case LiveBanTypeBlock:
+		action = "block"
+		control = RoomControlKick
+	}
+
+	// 通知房间内所有人，并由各实例执行禁言或断开连接
+	s.publishRoomEvent(&SignalingMessage{
+		Type:   MessageTypeModeration,
+		RoomID: roomID,
+		Payload: &ModerationPayload{
+			Action:       action,
+			TargetUserID: ban.UserID,
+			Duration:     ban.Duration,
+			ExpiredAt:    ban.ExpiredAt,
+			Reason:       ban.Reason,
+		},
+		Timestamp: time.Now().Unix(),
+	}, &RoomEvent{
+		RoomID:       roomID,
+		Control:      control,
+		TargetUserID: ban.UserID,
+		ExpiredAt:    ban.ExpiredAt,
+	})
+}
+
+// disconnectUser 关闭用户在本实例房间内的所有连接，读循环退出后会自动清理
+func (s *liveSignalingServiceImpl) disconnectUser(roomID string, userID uint) {
+	s.roomsMutex.RLock()
+	var targets []*Client
+	for _, client := range s.rooms[roomID] {
+		if client.UserID == userID {
+			targets = append(targets, client)
+		}
+	}
+	s.roomsMutex.RUnlock()
+
+	if len(targets) == 0 {
+		return
+	}
+
+	for _, client := range targets {
+		client.writeMu.Lock()
+		_ = client.Conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "removed from room"),
+			time.Now().Add(time.Second))
+		client.writeMu.Unlock()
+		client.Conn.Close()
+	}
+
+	logger.Info("用户已被移出房间",
+		zap.String("room_id", roomID),
+		zap.Uint("user_id", userID),
+		zap.Int("connections", len(targets)))
+}
+
+// LiftBan 解除用户在房间内的禁言
+func (s *liveSignalingServiceImpl) LiftBan(roomID string, userID uint) {
+	s.publishRoomEvent(&SignalingMessage{
+		Type:      MessageTypeModeration,
+		RoomID:    roomID,
+		Payload:   &ModerationPayload{Action: "unmute", TargetUserID: userID},
+		Timestamp: time.Now().Unix(),
+	}, &RoomEvent{
+		RoomID:       roomID,
+		Control:      RoomControlUnmute,
+		TargetUserID: userID,
+	})
+}
+
+// setMute 记录用户禁言状态
+func (s *liveSignalingServiceImpl) setMute(roomID string, userID uint, expiredAt *time.Time) {
+	s.mutesMutex.Lock()
+	defer s.mutesMutex.Unlock()
+
+	if s.mutes[roomID] == nil {
+		s.mutes[roomID] = make(map[uint]*muteState)
+	}
+	s.mutes[roomID][userID] = &muteState{expiredAt: expiredAt}
+}
+
+// isMuted 检查用户是否处于禁言中（过期的禁言自动清除）
+func (s *liveSignalingServiceImpl) isMuted(roomID string, userID uint) bool {
+	s.mutesMutex.RLock()
+	state := s.mutes[roomID][userID]
+	s.mutesMutex.RUnlock()
+
+	if state == nil {
+		return false
+	}
+	if state.expiredAt != nil && state.expiredAt.Before(time.Now()) {
+		s.mutesMutex.Lock()
+		delete(s.mutes[roomID], userID)
+		s.mutesMutex.Unlock()
+		return false
+	}
+	return true
+}
+
+// clearMutes 清除房间的禁言状态
+func (s *liveSignalingServiceImpl) clearMutes(roomID string) {
+	s.mutesMutex.Lock()
+	delete(s.mutes, roomID)
+	s.mutesMutex.Unlock()
+}
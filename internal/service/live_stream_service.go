@@ -6,12 +6,15 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"microvibe-go/internal/config"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -53,13 +56,28 @@ type LiveStreamResponse struct {
 	IsOwner bool `json:"is_owner"` // 是否是房主
 }
 
+// 直播间处罚类型（对应 LiveBan.Type）
+const (
+	LiveBanTypeMute  int8 = 1 // 禁言：不能发送弹幕
+	LiveBanTypeKick  int8 = 2 // 踢出：断开连接，到期前不能重新进入
+	LiveBanTypeBlock int8 = 3 // 拉黑：同踢出，通常为永久
+)
+
 // BanUserRequest 禁言请求
 type BanUserRequest struct {
 	LiveID   uint   `json:"live_id" binding:"required"`
 	UserID   uint   `json:"user_id" binding:"required"`
-	Type     int8   `json:"type" binding:"required"` // 1-禁言，2-踢出，3-拉黑
-	Duration int    `json:"duration"`                // 禁言时长（分钟，0表示永久）
-	Reason   string `json:"reason"`
+	Type     int8   `json:"type" binding:"required,oneof=1 2 3"` // 1-禁言，2-踢出，3-拉黑
+	Duration int    `json:"duration" binding:"min=0"`            // 处罚时长（分钟，0表示永久）
+	Reason   string `json:"reason" binding:"max=200"`
+}
+
+// AddLiveAdminRequest 任命直播间管理员请求（权限字段为空时使用默认值）
+type AddLiveAdminRequest struct {
+	UserID  uint  `json:"user_id" binding:"required"`
+	CanBan  *bool `json:"can_ban"`  // 可以禁言（默认 true）
+	CanKick *bool `json:"can_kick"` // 可以踢人（默认 true）
+	CanPin  *bool `json:"can_pin"`  // 可以置顶消息（默认 true）
 }
 
 // LiveStreamService 直播服务接口
@@ -109,32 +127,74 @@ type LiveStreamService interface {
 	// ListBans 获取禁言列表
 	ListBans(ctx context.Context, liveID uint, page, pageSize int) ([]*model.LiveBan, int64, error)
 
+	// GetActiveBan 获取用户在直播间当前生效的处罚（无处罚时返回 nil）
+	GetActiveBan(ctx context.Context, liveID, userID uint) (*model.LiveBan, error)
+
+	// AddAdmin 任命直播间管理员（仅主播）
+	AddAdmin(ctx context.Context, ownerID, liveID uint, req *AddLiveAdminRequest) (*model.LiveAdmin, error)
+
+	// RemoveAdmin 撤销直播间管理员（仅主播）
+	RemoveAdmin(ctx context.Context, ownerID, liveID, userID uint) error
+
+	// ListAdmins 获取直播间管理员列表
+	ListAdmins(ctx context.Context, liveID uint) ([]*model.LiveAdmin, error)
+
 	// GetHotLiveStreams 获取热门直播间
 	GetHotLiveStreams(ctx context.Context, limit int) ([]*model.LiveStream, error)
 
 	// ListByCategory 根据分类获取直播间列表
 	ListByCategory(ctx context.Context, categoryID uint, status string, page, pageSize int) ([]*model.LiveStream, int64, error)
+
+	// VerifyRTMPStream 验证推流密钥及签名（流媒体服务器 on_publish 回调），返回是否允许推流和主播ID
+	VerifyRTMPStream(ctx context.Context, streamKey string, expires int64, sign string) (bool, uint, error)
+
+	// OnStreamPublish 推流开始（流媒体服务器 on_publish 回调）
+	OnStreamPublish(ctx context.Context, streamKey string) error
+
+	// OnStreamUnpublish 推流结束（流媒体服务器 on_publish_done 回调）
+	OnStreamUnpublish(ctx context.Context, streamKey string) error
+
+	// VerifyPlayStream 验证流是否允许播放（流媒体服务器 on_play 回调）
+	VerifyPlayStream(ctx context.Context, streamKey string) (bool, error)
+
+	// OnStreamRecorded 录制完成（流媒体服务器 on_record_done 回调），path 为录制文件路径
+	OnStreamRecorded(ctx context.Context, streamKey, path string) error
 }
 
 type liveStreamServiceImpl struct {
-	liveRepo repository.LiveStreamRepository
-	banRepo  repository.LiveBanRepository
-	cfg      *config.Config
+	liveRepo         repository.LiveStreamRepository
+	banRepo          repository.LiveBanRepository
+	adminRepo        repository.LiveAdminRepository
+	cfg              *config.Config
+	signalingService LiveSignalingService
+	replayService    LiveReplayService
 }
 
 // NewLiveStreamService 创建直播服务
 func NewLiveStreamService(
 	liveRepo repository.LiveStreamRepository,
 	banRepo repository.LiveBanRepository,
+	adminRepo repository.LiveAdminRepository,
 	cfg *config.Config,
 ) LiveStreamService {
 	return &liveStreamServiceImpl{
-		liveRepo: liveRepo,
-		banRepo:  banRepo,
-		cfg:      cfg,
+		liveRepo:  liveRepo,
+		banRepo:   banRepo,
+		adminRepo: adminRepo,
+		cfg:       cfg,
 	}
 }
 
+// SetSignalingService 设置信令服务（延迟注入，信令服务依赖本服务，用于在房间内执行禁言、踢人）
+func (s *liveStreamServiceImpl) SetSignalingService(signalingService LiveSignalingService) {
+	s.signalingService = signalingService
+}
+
+// SetReplayService 设置直播回放服务（延迟注入，录制完成后生成回放）
+func (s *liveStreamServiceImpl) SetReplayService(replayService LiveReplayService) {
+	s.replayService = replayService
+}
+
 // CreateLiveStream 创建直播间
 func (s *liveStreamServiceImpl) CreateLiveStream(ctx context.Context, userID uint, req *CreateLiveStreamRequest) (*model.LiveStream, error) {
 	// 检查用户是否已有进行中的直播间
@@ -225,16 +285,12 @@ func (s *liveStreamServiceImpl) CreateLiveStream(ctx context.Context, userID uin
 		// 但通常 SFU（如 Pion Ion）会在第一个 peer 加入时自动创建房间
 	}
 
-	// 🔥 新增：RTMP 推流时，可以配置 RTMP 服务器的推流认证
+	// RTMP/SRT 推流由流媒体服务器在 on_publish 回调中校验推流地址签名（见 VerifyRTMPStream）
 	if pushProtocol == "rtmp" {
 		logger.Info("创建 RTMP 直播间，推流地址已生成",
 			zap.String("stream_key", streamKey),
 			zap.String("stream_url", streamURL),
 			zap.String("play_url", playURL))
-
-		// 可选：如果你的 RTMP 服务器（如 nginx-rtmp）支持动态认证
-		// 可以将 streamKey 注册到 Redis 或认证服务器
-		// 例如：s.registerRTMPAuth(ctx, streamKey, userID)
 	}
 
 	logger.Info("创建直播间成功",
@@ -245,7 +301,12 @@ func (s *liveStreamServiceImpl) CreateLiveStream(ctx context.Context, userID uin
 		zap.Uint("owner_id", userID))
 
 	// 重新查询以获取关联的 Owner 信息
-	return s.liveRepo.FindByID(ctx, liveStream.ID)
+	created, err := s.liveRepo.FindByID(ctx, liveStream.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.signStreamURL(created)
+	return created, nil
 }
 
 // StartLiveStream 开始直播
@@ -289,6 +350,7 @@ func (s *liveStreamServiceImpl) StartLiveStream(ctx context.Context, userID uint
 		zap.String("room_id", liveStream.RoomID),
 		zap.Uint("owner_id", userID))
 
+	publishLiveStarted(liveStream)
 	return nil
 }
 
@@ -319,6 +381,9 @@ func (s *liveStreamServiceImpl) EndLiveStream(ctx context.Context, userID uint,
 	liveStream.Status = "ended"
 	liveStream.EndedAt = &now
 	liveStream.OnlineCount = 0 // 重置在线人数
+	if liveStream.StartedAt != nil {
+		liveStream.Duration = int64(now.Sub(*liveStream.StartedAt).Seconds())
+	}
 
 	if err := s.liveRepo.Update(ctx, liveStream); err != nil {
 		logger.Error("结束直播失败", zap.Error(err), zap.Uint("live_id", liveStream.ID))
@@ -330,6 +395,7 @@ func (s *liveStreamServiceImpl) EndLiveStream(ctx context.Context, userID uint,
 		zap.String("room_id", liveStream.RoomID),
 		zap.Uint("owner_id", userID))
 
+	publishLiveEnded(liveStream)
 	return nil
 }
 
@@ -372,6 +438,8 @@ func (s *liveStreamServiceImpl) GetMyLiveStream(ctx context.Context, userID uint
 		return nil, errors.New("查询直播间失败")
 	}
 
+	// 每次获取都重新签名，过期的推流地址刷新后即可继续使用
+	s.signStreamURL(liveStream)
 	return liveStream, nil
 }
 
@@ -554,7 +622,7 @@ func getOrDefaultInt(value, configDefault, fallback int) int {
 	return fallback
 }
 
-// BanUser 禁言用户
+// BanUser 禁言用户（主播或有对应权限的管理员可操作；新的处罚会覆盖该用户之前生效的处罚）
 func (s *liveStreamServiceImpl) BanUser(ctx context.Context, operatorID uint, req *BanUserRequest) error {
 	// 1. 查询直播间
 	liveStream, err := s.liveRepo.FindByID(ctx, req.LiveID)
@@ -566,23 +634,23 @@ func (s *liveStreamServiceImpl) BanUser(ctx context.Context, operatorID uint, re
 		return errors.New("查询直播间失败")
 	}
 
-	// 2. 验证权限（只有主播可以禁言）
-	if liveStream.OwnerID != operatorID {
-		return errors.New("无权限操作")
-	}
-
-	// 3. 不能禁言自己
+	// 2. 不能处罚自己和主播
 	if req.UserID == operatorID {
 		return errors.New("不能禁言自己")
 	}
+	if req.UserID == liveStream.OwnerID {
+		return errors.New("不能处罚主播")
+	}
 
-	// 4. 检查是否已经被禁言
-	isBanned, err := s.banRepo.CheckBanned(ctx, req.LiveID, req.UserID)
-	if err != nil {
-		logger.Error("检查禁言状态失败", zap.Error(err))
+	// 3. 验证权限
+	if err := s.checkModerator(ctx, liveStream, operatorID, req.UserID, req.Type); err != nil {
+		return err
 	}
-	if isBanned {
-		return errors.New("该用户已被禁言")
+
+	// 4. 解除之前生效的处罚，保证同一用户只有一条生效记录
+	if err := s.banRepo.UnbanUser(ctx, req.LiveID, req.UserID); err != nil {
+		logger.Error("解除原有处罚失败", zap.Error(err))
+		return errors.New("禁言失败")
 	}
 
 	// 5. 计算过期时间
@@ -609,6 +677,11 @@ func (s *liveStreamServiceImpl) BanUser(ctx context.Context, operatorID uint, re
 		return errors.New("禁言失败")
 	}
 
+	// 7. 在房间内立即生效（禁言、踢出在线连接）
+	if s.signalingService != nil {
+		s.signalingService.ApplyBan(liveStream.RoomID, ban)
+	}
+
 	logger.Info("禁言用户成功",
 		zap.Uint("operator_id", operatorID),
 		zap.Uint("live_id", req.LiveID),
@@ -629,9 +702,9 @@ func (s *liveStreamServiceImpl) UnbanUser(ctx context.Context, operatorID uint,
 		return errors.New("查询直播间失败")
 	}
 
-	// 2. 验证权限
-	if liveStream.OwnerID != operatorID {
-		return errors.New("无权限操作")
+	// 2. 验证权限（解除处罚需要禁言权限）
+	if err := s.checkModerator(ctx, liveStream, operatorID, userID, LiveBanTypeMute); err != nil {
+		return err
 	}
 
 	// 3. 解除禁言
@@ -640,6 +713,10 @@ func (s *liveStreamServiceImpl) UnbanUser(ctx context.Context, operatorID uint,
 		return errors.New("解除禁言失败")
 	}
 
+	if s.signalingService != nil {
+		s.signalingService.LiftBan(liveStream.RoomID, userID)
+	}
+
 	logger.Info("解除禁言成功",
 		zap.Uint("operator_id", operatorID),
 		zap.Uint("live_id", liveID),
@@ -648,6 +725,46 @@ func (s *liveStreamServiceImpl) UnbanUser(ctx context.Context, operatorID uint,
 	return nil
 }
 
+// checkModerator 校验操作人是否可以对目标用户执行指定类型的处罚
+// 主播拥有全部权限；管理员需具备对应权限，且只有可管理管理员的管理员才能处罚其他管理员
+func (s *liveStreamServiceImpl) checkModerator(ctx context.Context, liveStream *model.LiveStream, operatorID, targetID uint, banType int8) error {
+	if liveStream.OwnerID == operatorID {
+		return nil
+	}
+
+	admin, err := s.adminRepo.FindByLiveAndUser(ctx, liveStream.ID, operatorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("无权限操作")
+		}
+		logger.Error("查询管理员失败", zap.Error(err), zap.Uint("live_id", liveStream.ID))
+		return errors.New("查询管理员失败")
+	}
+
+	switch banType {
+	case LiveBanTypeMute:
+		if !admin.CanBan {
+			return errors.New("无权限操作")
+		}
+	case LiveBanTypeKick:
+		if !admin.CanKick {
+			return errors.New("无权限操作")
+		}
+	default:
+		if !admin.CanBan || !admin.CanKick {
+			return errors.New("无权限操作")
+		}
+	}
+
+	if !admin.CanManage {
+		if _, err := s.adminRepo.FindByLiveAndUser(ctx, liveStream.ID, targetID); err == nil {
+			return errors.New("不能处罚其他管理员")
+		}
+	}
+
+	return nil
+}
+
 // CheckBanned 检查用户是否被禁言
 func (s *liveStreamServiceImpl) CheckBanned(ctx context.Context, liveID, userID uint) (bool, error) {
 	isBanned, err := s.banRepo.CheckBanned(ctx, liveID, userID)
@@ -684,6 +801,119 @@ func (s *liveStreamServiceImpl) ListBans(ctx context.Context, liveID uint, page,
 	return bans, total, nil
 }
 
+// GetActiveBan 获取用户在直播间当前生效的处罚
+func (s *liveStreamServiceImpl) GetActiveBan(ctx context.Context, liveID, userID uint) (*model.LiveBan, error) {
+	ban, err := s.banRepo.FindActiveBan(ctx, liveID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.Error("查询处罚记录失败", zap.Error(err), zap.Uint("live_id", liveID), zap.Uint("user_id", userID))
+		return nil, errors.New("查询处罚记录失败")
+	}
+
+	// 缓存中的记录可能已经过期
+	if ban.ExpiredAt != nil && ban.ExpiredAt.Before(time.Now()) {
+		return nil, nil
+	}
+
+	return ban, nil
+}
+
+// AddAdmin 任命直播间管理员（已是管理员时更新权限）
+func (s *liveStreamServiceImpl) AddAdmin(ctx context.Context, ownerID, liveID uint, req *AddLiveAdminRequest) (*model.LiveAdmin, error) {
+	liveStream, err := s.liveRepo.FindByID(ctx, liveID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("直播间不存在")
+		}
+		return nil, errors.New("查询直播间失败")
+	}
+
+	if liveStream.OwnerID != ownerID {
+		return nil, errors.New("无权限操作")
+	}
+	if req.UserID == ownerID {
+		return nil, errors.New("不能任命自己为管理员")
+	}
+
+	admin, err := s.adminRepo.FindByLiveAndUser(ctx, liveID, req.UserID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		logger.Error("查询管理员失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("任命管理员失败")
+	}
+	if isNew {
+		admin = &model.LiveAdmin{LiveID: liveID, UserID: req.UserID, Role: 1, CanBan: true, CanKick: true, CanPin: true}
+	}
+
+	if req.CanBan != nil {
+		admin.CanBan = *req.CanBan
+	}
+	if req.CanKick != nil {
+		admin.CanKick = *req.CanKick
+	}
+	if req.CanPin != nil {
+		admin.CanPin = *req.CanPin
+	}
+
+	if isNew {
+		err = s.adminRepo.Create(ctx, admin)
+		// 布尔字段带 default 标签，false 值在创建时不会写入，需再更新一次
+		if err == nil && !(admin.CanBan && admin.CanKick && admin.CanPin) {
+			err = s.adminRepo.Update(ctx, admin)
+		}
+	} else {
+		err = s.adminRepo.Update(ctx, admin)
+	}
+	if err != nil {
+		logger.Error("保存管理员失败", zap.Error(err), zap.Uint("live_id", liveID), zap.Uint("user_id", req.UserID))
+		return nil, errors.New("任命管理员失败")
+	}
+
+	logger.Info("任命直播间管理员成功",
+		zap.Uint("live_id", liveID),
+		zap.Uint("user_id", req.UserID))
+
+	return admin, nil
+}
+
+// RemoveAdmin 撤销直播间管理员
+func (s *liveStreamServiceImpl) RemoveAdmin(ctx context.Context, ownerID, liveID, userID uint) error {
+	liveStream, err := s.liveRepo.FindByID(ctx, liveID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("直播间不存在")
+		}
+		return errors.New("查询直播间失败")
+	}
+
+	if liveStream.OwnerID != ownerID {
+		return errors.New("无权限操作")
+	}
+
+	if err := s.adminRepo.Delete(ctx, liveID, userID); err != nil {
+		logger.Error("撤销管理员失败", zap.Error(err), zap.Uint("live_id", liveID), zap.Uint("user_id", userID))
+		return errors.New("撤销管理员失败")
+	}
+
+	logger.Info("撤销直播间管理员成功",
+		zap.Uint("live_id", liveID),
+		zap.Uint("user_id", userID))
+
+	return nil
+}
+
+// ListAdmins 获取直播间管理员列表
+func (s *liveStreamServiceImpl) ListAdmins(ctx context.Context, liveID uint) ([]*model.LiveAdmin, error) {
+	admins, err := s.adminRepo.ListByLiveID(ctx, liveID)
+	if err != nil {
+		logger.Error("查询管理员列表失败", zap.Error(err), zap.Uint("live_id", liveID))
+		return nil, errors.New("查询管理员列表失败")
+	}
+	return admins, nil
+}
+
 // GetHotLiveStreams 获取热门直播间
 func (s *liveStreamServiceImpl) GetHotLiveStreams(ctx context.Context, limit int) ([]*model.LiveStream, error) {
 	if limit <= 0 || limit > 100 {
@@ -728,34 +958,56 @@ func (s *liveStreamServiceImpl) ListByCategory(ctx context.Context, categoryID u
 
 // ========== 流媒体服务集成辅助方法 ==========
 
-// registerRTMPAuth 注册 RTMP 推流认证（可选）
-// 如果你的 RTMP 服务器（如 nginx-rtmp）支持动态认证，可以实现这个方法
-// 例如：将 streamKey 存储到 Redis，nginx-rtmp 通过 HTTP 回调验证
-func (s *liveStreamServiceImpl) registerRTMPAuth(_ context.Context, streamKey string, userID uint) error {
-	// 示例实现：将推流密钥存储到 Redis，设置过期时间
-	// 格式: rtmp:auth:{streamKey} -> {userID}:{expireTime}
-	//
-	// 你的 nginx-rtmp 配置中可以添加 HTTP 回调：
-	// on_publish http://your-api/api/v1/live/verify-stream;
-	//
-	// 实现示例：
-	// authKey := fmt.Sprintf("rtmp:auth:%s", streamKey)
-	// authValue := fmt.Sprintf("%d:%d", userID, time.Now().Add(24*time.Hour).Unix())
-	// err := s.redisClient.Set(ctx, authKey, authValue, 24*time.Hour).Err()
-	// if err != nil {
-	//     logger.Error("注册 RTMP 认证失败", zap.Error(err))
-	//     return err
-	// }
-
-	logger.Info("RTMP 推流认证已注册（功能待实现）",
-		zap.String("stream_key", streamKey),
-		zap.Uint("user_id", userID))
-	return nil
+// signStreamURL 为推流地址附加有效期和签名（未配置回调密钥时保持原样）
+// 格式: rtmp://server/app/streamKey?expires=1700000000&sign=xxx
+func (s *liveStreamServiceImpl) signStreamURL(liveStream *model.LiveStream) {
+	secret := s.cfg.Streaming.WebhookSecret
+	if secret == "" || liveStream.StreamURL == "" {
+		return
+	}
+
+	ttl := s.cfg.Streaming.StreamKeyTTL
+	if ttl <= 0 {
+		ttl = 24
+	}
+	expires := time.Now().Add(time.Duration(ttl) * time.Hour).Unix()
+
+	base, _, _ := strings.Cut(liveStream.StreamURL, "?")
+	liveStream.StreamURL = fmt.Sprintf("%s?expires=%d&sign=%s",
+		base, expires, utils.SignStreamKey(liveStream.StreamKey, expires, secret))
+}
+
+// publishLiveStarted 发布开始直播事件
+func publishLiveStarted(liveStream *model.LiveStream) {
+	_ = event.PublishAsync(context.Background(), event.NewLiveStreamStartedEvent(liveStream.ID, liveStream.RoomID, liveStream.OwnerID))
+}
+
+// publishLiveEnded 发布结束直播事件（携带本场时长和统计数据）
+func publishLiveEnded(liveStream *model.LiveStream) {
+	_ = event.PublishAsync(context.Background(), event.NewLiveStreamEndedEvent(
+		liveStream.ID,
+		liveStream.RoomID,
+		liveStream.OwnerID,
+		liveStream.Duration,
+		int(liveStream.ViewCount),
+		int(liveStream.LikeCount),
+		liveStream.GiftValue,
+	))
 }
 
-// VerifyRTMPStream 验证 RTMP 推流权限（供 nginx-rtmp on_publish 回调使用）
-// 这个方法应该在 Handler 层暴露为 HTTP 端点
-func (s *liveStreamServiceImpl) VerifyRTMPStream(ctx context.Context, streamKey string) (bool, uint, error) {
+// VerifyRTMPStream 验证推流权限（供 nginx-rtmp / SRS on_publish 回调使用）
+// 推流地址必须携带未过期的签名，未配置回调密钥时拒绝所有推流
+func (s *liveStreamServiceImpl) VerifyRTMPStream(ctx context.Context, streamKey string, expires int64, sign string) (bool, uint, error) {
+	secret := s.cfg.Streaming.WebhookSecret
+	if secret == "" {
+		logger.Warn("未配置回调密钥，拒绝推流", zap.String("stream_key", streamKey))
+		return false, 0, nil
+	}
+	if err := utils.VerifyStreamKey(streamKey, expires, sign, secret); err != nil {
+		logger.Warn("推流签名校验失败", zap.Error(err), zap.String("stream_key", streamKey))
+		return false, 0, nil
+	}
+
 	// 查询数据库，验证 streamKey 是否存在且直播间状态为 waiting 或 live
 	liveStream, err := s.liveRepo.FindByStreamKey(ctx, streamKey)
 	if err != nil {
@@ -783,7 +1035,7 @@ func (s *liveStreamServiceImpl) VerifyRTMPStream(ctx context.Context, streamKey
 	return true, liveStream.OwnerID, nil
 }
 
-// OnStreamPublish RTMP 推流开始回调（供 nginx-rtmp on_publish 使用）
+// OnStreamPublish 推流开始回调（供 nginx-rtmp / SRS on_publish 使用）
 func (s *liveStreamServiceImpl) OnStreamPublish(ctx context.Context, streamKey string) error {
 	// 查找直播间
 	liveStream, err := s.liveRepo.FindByStreamKey(ctx, streamKey)
@@ -792,6 +1044,11 @@ func (s *liveStreamServiceImpl) OnStreamPublish(ctx context.Context, streamKey s
 		return err
 	}
 
+	// 断线重推时直播已在进行中，不重复开播
+	if liveStream.Status == "live" {
+		return nil
+	}
+
 	// 更新直播间状态为 live
 	now := time.Now()
 	liveStream.Status = "live"
@@ -807,10 +1064,11 @@ func (s *liveStreamServiceImpl) OnStreamPublish(ctx context.Context, streamKey s
 		zap.String("stream_key", streamKey),
 		zap.Uint("owner_id", liveStream.OwnerID))
 
+	publishLiveStarted(liveStream)
 	return nil
 }
 
-// OnStreamUnpublish RTMP 推流结束回调（供 nginx-rtmp on_publish_done 使用）
+// OnStreamUnpublish 推流结束回调（供 nginx-rtmp on_publish_done / SRS on_unpublish 使用）
 func (s *liveStreamServiceImpl) OnStreamUnpublish(ctx context.Context, streamKey string) error {
 	// 查找直播间
 	liveStream, err := s.liveRepo.FindByStreamKey(ctx, streamKey)
@@ -819,6 +1077,11 @@ func (s *liveStreamServiceImpl) OnStreamUnpublish(ctx context.Context, streamKey
 		return err
 	}
 
+	// 主播已手动结束直播
+	if liveStream.Status == "ended" {
+		return nil
+	}
+
 	// 计算直播时长
 	now := time.Now()
 	if liveStream.StartedAt != nil {
@@ -829,6 +1092,7 @@ func (s *liveStreamServiceImpl) OnStreamUnpublish(ctx context.Context, streamKey
 	// 更新直播间状态为 ended
 	liveStream.Status = "ended"
 	liveStream.EndedAt = &now
+	liveStream.OnlineCount = 0
 
 	if err := s.liveRepo.Update(ctx, liveStream); err != nil {
 		logger.Error("更新直播间状态失败", zap.Error(err), zap.Uint("live_id", liveStream.ID))
@@ -840,5 +1104,45 @@ func (s *liveStreamServiceImpl) OnStreamUnpublish(ctx context.Context, streamKey
 		zap.String("stream_key", streamKey),
 		zap.Int64("duration", liveStream.Duration))
 
+	publishLiveEnded(liveStream)
+	return nil
+}
+
+// VerifyPlayStream 验证流是否允许播放（供 nginx-rtmp / SRS on_play 使用），只有直播中的流可以拉取
+func (s *liveStreamServiceImpl) VerifyPlayStream(ctx context.Context, streamKey string) (bool, error) {
+	liveStream, err := s.liveRepo.FindByStreamKey(ctx, streamKey)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		logger.Error("验证播放流失败", zap.Error(err), zap.String("stream_key", streamKey))
+		return false, err
+	}
+
+	return liveStream.Status == "live", nil
+}
+
+// OnStreamRecorded 录制完成回调（供 nginx-rtmp on_record_done / SRS on_dvr 使用），开启录制的直播生成回放
+func (s *liveStreamServiceImpl) OnStreamRecorded(ctx context.Context, streamKey, path string) error {
+	liveStream, err := s.liveRepo.FindByStreamKey(ctx, streamKey)
+	if err != nil {
+		logger.Error("查找直播间失败", zap.Error(err), zap.String("stream_key", streamKey))
+		return err
+	}
+
+	logger.Info("直播录制完成",
+		zap.Uint("live_id", liveStream.ID),
+		zap.String("stream_key", streamKey),
+		zap.String("path", path),
+		zap.Bool("is_recorded", liveStream.IsRecorded))
+
+	// 主播关闭了录制时不生成回放
+	if !liveStream.IsRecorded || s.replayService == nil {
+		return nil
+	}
+
+	if _, err := s.replayService.CreateReplay(ctx, liveStream, path); err != nil {
+		return err
+	}
 	return nil
 }
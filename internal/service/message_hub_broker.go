@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"microvibe-go/pkg/logger"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// messagePresenceKeyPrefix 用户在线登记（Hash：实例ID -> 该实例上的连接数）
+	messagePresenceKeyPrefix = "msg:presence:"
+	// messageLastSeenKeyPrefix 用户最后在线时间（Unix 秒）
+	messageLastSeenKeyPrefix = "msg:last_seen:"
+	// messageNodeAliveKeyPrefix 实例存活标记
+	messageNodeAliveKeyPrefix = "msg:node:alive:"
+	// messageNodeChannelPrefix 实例专属的投递频道
+	messageNodeChannelPrefix = "msg:node:"
+
+	messageNodeHeartbeatInterval = 10 * time.Second
+	messageNodeAliveTTL          = 30 * time.Second
+	messagePresenceKeyTTL        = 24 * time.Hour
+	messageLastSeenTTL           = 30 * 24 * time.Hour
+)
+
+// UserPresence 用户在线状态
+type UserPresence struct {
+	UserID     uint       `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"` // 最后在线时间（离线时返回）
+}
+
+// UserMessageHandler 用户消息处理函数（将消息写入本实例上该用户的连接）
+type UserMessageHandler func(userID uint, data []byte)
+
+// MessageHubBroker 消息中心代理：维护用户在线登记，并将推送路由到用户连接所在的实例
+type MessageHubBroker interface {
+	// Subscribe 设置本实例的消息处理函数
+	Subscribe(handler UserMessageHandler)
+
+	// Deliver 将消息投递到用户连接所在的所有实例（包括本实例）
+	Deliver(ctx context.Context, userID uint, data []byte) error
+
+	// SetLocalConnections 登记用户在本实例上的连接数（为 0 时表示已从本实例断开）
+	SetLocalConnections(ctx context.Context, userID uint, count int) error
+
+	// Presence 批量查询用户在线状态
+	Presence(ctx context.Context, userIDs []uint) ([]*UserPresence, error)
+}
+
+// ========== 内存实现（单实例部署） ==========
+
+type memoryMessageHubBroker struct {
+	handler  UserMessageHandler
+	counts   map[uint]int
+	lastSeen map[uint]time.Time
+	mu       sync.RWMutex
+}
+
+// NewMemoryMessageHubBroker 创建进程内消息中心代理（单实例部署使用）
+func NewMemoryMessageHubBroker() MessageHubBroker {
+	return &memoryMessageHubBroker{
+		counts:   make(map[uint]int),
+		lastSeen: make(map[uint]time.Time),
+	}
+}
+
+// Subscribe 设置消息处理函数
+func (b *memoryMessageHubBroker) Subscribe(handler UserMessageHandler) {
+	b.mu.Lock()
+	b.handler = handler
+	b.mu.Unlock()
+}
+
+// Deliver 直接投递给本实例
+func (b *memoryMessageHubBroker) Deliver(ctx context.Context, userID uint, data []byte) error {
+	b.mu.RLock()
+	handler := b.handler
+	online := b.counts[userID] > 0
+	b.mu.RUnlock()
+
+	if handler != nil && online {
+		handler(userID, data)
+	}
+	return nil
+}
+
+// SetLocalConnections 登记用户连接数
+func (b *memoryMessageHubBroker) SetLocalConnections(ctx context.Context, userID uint, count int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if count <= 0 {
+		delete(b.counts, userID)
+		b.lastSeen[userID] = time.Now()
+		return nil
+	}
+	b.counts[userID] = count
+	return nil
+}
+
+// Presence 批量查询用户在线状态
+func (b *memoryMessageHubBroker) Presence(ctx context.Context, userIDs []uint) ([]*UserPresence, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	result := make([]*UserPresence, 0, len(userIDs))
+	for _, userID := range userIDs {
+		presence := &UserPresence{UserID: userID, Online: b.counts[userID] > 0}
+		if !presence.Online {
+			if t, ok := b.lastSeen[userID]; ok {
+				presence.LastSeenAt = &t
+			}
+		}
+		result = append(result, presence)
+	}
+	return result, nil
+}
+
+// ========== Redis 实现（多实例部署） ==========
+
+// redisUserMessage 实例间投递的用户消息
+type redisUserMessage struct {
+	UserID uint            `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type redisMessageHubBroker struct {
+	client  *redis.Client
+	nodeID  string
+	handler UserMessageHandler
+	mu      sync.RWMutex
+}
+
+// NewRedisMessageHubBroker 创建基于 Redis 的消息中心代理，并启动本实例的订阅和心跳
+func NewRedisMessageHubBroker(client *redis.Client) MessageHubBroker {
+	b := &redisMessageHubBroker{
+		client: client,
+		nodeID: generateInstanceID(),
+	}
+
+	go b.receiveLoop()
+	go b.heartbeatLoop()
+
+	logger.Info("消息中心 Redis 代理已启动", zap.String("node_id", b.nodeID))
+	return b
+}
+
+// Subscribe 设置消息处理函数
+func (b *redisMessageHubBroker) Subscribe(handler UserMessageHandler) {
+	b.mu.Lock()
+	b.handler = handler
+	b.mu.Unlock()
+}
+
+// Deliver 根据在线登记将消息路由到用户连接所在的实例
+func (b *redisMessageHubBroker) Deliver(ctx context.Context, userID uint, data []byte) error {
+	nodes, err := b.aliveNodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var envelope []byte
+	for _, nodeID := range nodes {
+		if nodeID == b.nodeID {
+			b.dispatch(userID, data)
+			continue
+		}
+
+		if envelope == nil {
+			if envelope, err = json.Marshal(&redisUserMessage{UserID: userID, Data: data}); err != nil {
+				return fmt.Errorf("序列化用户消息失败: %w", err)
+			}
+		}
+		if err := b.client.Publish(ctx, messageNodeChannelPrefix+nodeID, envelope).Err(); err != nil {
+			logger.Warn("跨实例投递消息失败", zap.Error(err), zap.String("node_id", nodeID), zap.Uint("user_id", userID))
+		}
+	}
+	return nil
+}
+
+// SetLocalConnections 登记用户在本实例上的连接数
+func (b *redisMessageHubBroker) SetLocalConnections(ctx context.Context, userID uint, count int) error {
+	key := messagePresenceKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+
+	if count > 0 {
+		pipe := b.client.TxPipeline()
+		pipe.HSet(ctx, key, b.nodeID, count)
+		pipe.Expire(ctx, key, messagePresenceKeyTTL)
+		_, err := pipe.Exec(ctx)
+		return err
+	}
+
+	if err := b.client.HDel(ctx, key, b.nodeID).Err(); err != nil {
+		return err
+	}
+
+	// 用户在所有实例上都已离线时记录最后在线时间
+	nodes, err := b.aliveNodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		lastSeenKey := messageLastSeenKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+		return b.client.Set(ctx, lastSeenKey, time.Now().Unix(), messageLastSeenTTL).Err()
+	}
+	return nil
+}
+
+// Presence 批量查询用户在线状态
+func (b *redisMessageHubBroker) Presence(ctx context.Context, userIDs []uint) ([]*UserPresence, error) {
+	result := make([]*UserPresence, 0, len(userIDs))
+	for _, userID := range userIDs {
+		nodes, err := b.aliveNodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		presence := &UserPresence{UserID: userID, Online: len(nodes) > 0}
+		if !presence.Online {
+			lastSeenKey := messageLastSeenKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+			if ts, err := b.client.Get(ctx, lastSeenKey).Int64(); err == nil {
+				t := time.Unix(ts, 0)
+				presence.LastSeenAt = &t
+			}
+		}
+		result = append(result, presence)
+	}
+	return result, nil
+}
+
+// aliveNodes 查询用户连接所在的存活实例（清理已下线实例的残留登记）
+func (b *redisMessageHubBroker) aliveNodes(ctx context.Context, userID uint) ([]string, error) {
+	key := messagePresenceKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+	counts, err := b.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+
+	nodeIDs := make([]string, 0, len(counts))
+	pipe := b.client.Pipeline()
+	alive := make(map[string]*redis.IntCmd, len(counts))
+	for nodeID := range counts {
+		nodeIDs = append(nodeIDs, nodeID)
+		alive[nodeID] = pipe.Exists(ctx, messageNodeAliveKeyPrefix+nodeID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	var nodes, stale []string
+	for _, nodeID := range nodeIDs {
+		if alive[nodeID].Val() == 0 {
+			stale = append(stale, nodeID)
+			continue
+		}
+		if n, _ := strconv.Atoi(counts[nodeID]); n > 0 {
+			nodes = append(nodes, nodeID)
+		}
+	}
+
+	if len(stale) > 0 {
+		_ = b.client.HDel(ctx, key, stale...).Err()
+	}
+
+	return nodes, nil
+}
+
+// receiveLoop 订阅本实例的投递频道
+func (b *redisMessageHubBroker) receiveLoop() {
+	pubsub := b.client.Subscribe(context.Background(), messageNodeChannelPrefix+b.nodeID)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var envelope redisUserMessage
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			logger.Error("解析跨实例消息失败", zap.Error(err))
+			continue
+		}
+		b.dispatch(envelope.UserID, envelope.Data)
+	}
+}
+
+// heartbeatLoop 定期刷新实例存活标记
+func (b *redisMessageHubBroker) heartbeatLoop() {
+	key := messageNodeAliveKeyPrefix + b.nodeID
+	ticker := time.NewTicker(messageNodeHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.client.Set(context.Background(), key, 1, messageNodeAliveTTL).Err(); err != nil {
+			logger.Warn("刷新实例存活标记失败", zap.Error(err), zap.String("node_id", b.nodeID))
+		}
+		<-ticker.C
+	}
+}
+
+// dispatch 调用本实例的消息处理函数
+func (b *redisMessageHubBroker) dispatch(userID uint, data []byte) {
+	b.mu.RLock()
+	handler := b.handler
+	b.mu.RUnlock()
+
+	if handler != nil {
+		handler(userID, data)
+	}
+}
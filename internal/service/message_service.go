@@ -5,6 +5,7 @@ import (
 	"errors"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
 	"microvibe-go/pkg/logger"
 
 	"go.uber.org/zap"
@@ -43,29 +44,38 @@ type MessageService interface {
 	MarkAllNotificationsAsRead(ctx context.Context, userID uint) error
 	// GetUnreadNotificationCount 获取未读通知数
 	GetUnreadNotificationCount(ctx context.Context, userID uint) (int64, error)
+
+	// GetUserPresence 批量查询用户在线状态
+	GetUserPresence(ctx context.Context, userIDs []uint) ([]*UserPresence, error)
 }
 
 // messageServiceImpl 消息服务层实现
 type messageServiceImpl struct {
 	messageRepo      repository.MessageRepository
+	groupRepo        repository.GroupConversationRepository
 	notificationRepo repository.NotificationRepository
 	userRepo         repository.UserRepository
 	videoRepo        repository.VideoRepository
+	blacklistRepo    repository.BlacklistRepository
 	signalingService MessageSignalingService
 }
 
 // NewMessageService 创建消息服务实例
 func NewMessageService(
 	messageRepo repository.MessageRepository,
+	groupRepo repository.GroupConversationRepository,
 	notificationRepo repository.NotificationRepository,
 	userRepo repository.UserRepository,
 	videoRepo repository.VideoRepository,
+	blacklistRepo repository.BlacklistRepository,
 ) MessageService {
 	return &messageServiceImpl{
 		messageRepo:      messageRepo,
+		groupRepo:        groupRepo,
 		notificationRepo: notificationRepo,
 		userRepo:         userRepo,
 		videoRepo:        videoRepo,
+		blacklistRepo:    blacklistRepo,
 	}
 }
 
@@ -122,15 +132,20 @@ func (s *messageServiceImpl) SendMessage(ctx context.Context, req *SendMessageRe
 		return nil, errors.New("会话不存在")
 	}
 
-	// 确定接收者 (目前为私聊逻辑)
-	var receiverID uint
-	if conversation.User1ID == req.SenderID {
-		receiverID = conversation.User2ID
-	} else if conversation.User2ID == req.SenderID {
-		receiverID = conversation.User1ID
-	} else {
+	content, err := normalizeMessageContent(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.IsGroup() {
+		return s.sendGroupMessage(ctx, conversation, req, content)
+	}
+
+	// 确定接收者
+	if !conversation.IsParticipant(req.SenderID) {
 		return nil, errors.New("无权在该会话发送消息")
 	}
+	receiverID := conversation.PeerID(req.SenderID)
 
 	// 检查接收者状态
 	receiver, err := s.userRepo.FindByID(ctx, receiverID)
@@ -141,7 +156,118 @@ func (s *messageServiceImpl) SendMessage(ctx context.Context, req *SendMessageRe
 		return nil, errors.New("接收者账号异常，无法发送消息")
 	}
 
-	// 处理媒体消息的内容占位符
+	// 被对方拉黑时无法发送
+	blocked, err := s.blacklistRepo.IsBlocked(ctx, receiverID, req.SenderID)
+	if err != nil {
+		logger.Error("查询黑名单失败", zap.Error(err), zap.Uint("receiver_id", receiverID))
+		return nil, errors.New("发送消息失败")
+	}
+	if blocked {
+		return nil, errors.New("对方已将你拉黑，无法发送消息")
+	}
+
+	// 创建消息
+	message := &model.Message{
+		SenderID:       req.SenderID,
+		ReceiverID:     &receiverID,
+		ConversationID: &req.ConversationID, // 确保 Message 模型也支持 ConversationID
+		Type:           req.Type,
+		Content:        content,
+		MediaURL:       req.MediaURL,
+		VideoID:        req.VideoID,
+	}
+
+	if err := s.messageRepo.CreateMessage(ctx, message); err != nil {
+		logger.Error("创建消息失败", zap.Error(err))
+		return nil, errors.New("发送消息失败")
+	}
+
+	s.attachMessageRelations(ctx, message)
+	message.Receiver = receiver
+
+	// 实时推送（通过 WebSocket）- 推送给接收者时，isMine 应该为 false
+	if s.signalingService != nil {
+		go func() {
+			// 从接收者视角看 isMine = false
+			messageVO := toPushMessageVO(message)
+			if err := s.signalingService.PushToUser(receiverID, "message", messageVO); err != nil {
+				logger.Error("实时推送消息失败", zap.Error(err), zap.Uint("receiver_id", receiverID))
+			}
+		}()
+	}
+
+	logger.Info("消息发送成功", zap.Uint("message_id", message.ID))
+	return message, nil
+}
+
+// sendGroupMessage 发送群消息：仅群成员可发送，推送给未拉黑发送者的其他成员
+func (s *messageServiceImpl) sendGroupMessage(ctx context.Context, conversation *model.Conversation, req *SendMessageRequest, content string) (*model.Message, error) {
+	if _, err := s.groupRepo.FindMember(ctx, conversation.ID, req.SenderID); err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("你不是该群成员")
+		}
+		logger.Error("查询群成员失败", zap.Error(err), zap.Uint("conversation_id", conversation.ID))
+		return nil, errors.New("发送消息失败")
+	}
+
+	memberIDs, err := s.groupRepo.ListMemberIDs(ctx, conversation.ID)
+	if err != nil {
+		logger.Error("查询群成员失败", zap.Error(err), zap.Uint("conversation_id", conversation.ID))
+		return nil, errors.New("发送消息失败")
+	}
+
+	// 拉黑了发送者的成员不接收该消息
+	blockers, err := s.blacklistRepo.FindBlockersOf(ctx, req.SenderID, memberIDs)
+	if err != nil {
+		logger.Error("查询黑名单失败", zap.Error(err), zap.Uint("sender_id", req.SenderID))
+		return nil, errors.New("发送消息失败")
+	}
+	excluded := make(map[uint]bool, len(blockers)+1)
+	excluded[req.SenderID] = true
+	for _, userID := range blockers {
+		excluded[userID] = true
+	}
+	recipientIDs := make([]uint, 0, len(memberIDs))
+	for _, userID := range memberIDs {
+		if !excluded[userID] {
+			recipientIDs = append(recipientIDs, userID)
+		}
+	}
+
+	message := &model.Message{
+		SenderID:       req.SenderID,
+		ConversationID: &conversation.ID,
+		Type:           req.Type,
+		Content:        content,
+		MediaURL:       req.MediaURL,
+		VideoID:        req.VideoID,
+	}
+
+	if err := s.groupRepo.CreateGroupMessage(ctx, message, recipientIDs); err != nil {
+		logger.Error("创建群消息失败", zap.Error(err), zap.Uint("conversation_id", conversation.ID))
+		return nil, errors.New("发送消息失败")
+	}
+
+	s.attachMessageRelations(ctx, message)
+
+	if s.signalingService != nil && len(recipientIDs) > 0 {
+		go func() {
+			messageVO := toPushMessageVO(message)
+			if err := s.signalingService.PushToUsers(recipientIDs, "message", messageVO); err != nil {
+				logger.Error("群消息推送失败", zap.Error(err), zap.Uint("conversation_id", conversation.ID))
+			}
+		}()
+	}
+
+	logger.Info("群消息发送成功",
+		zap.Uint("message_id", message.ID),
+		zap.Uint("conversation_id", conversation.ID),
+		zap.Int("recipients", len(recipientIDs)))
+	return message, nil
+}
+
+// normalizeMessageContent 校验消息内容并为媒体消息填充占位符
+func normalizeMessageContent(req *SendMessageRequest) (string, error) {
 	content := req.Content
 	if content == "" {
 		switch req.Type {
@@ -158,77 +284,57 @@ func (s *messageServiceImpl) SendMessage(ctx context.Context, req *SendMessageRe
 
 	// 视频分享必须带 video_id
 	if req.Type == 5 && req.VideoID == nil {
-		return nil, errors.New("分享视频缺少 video_id")
+		return "", errors.New("分享视频缺少 video_id")
 	}
 
 	// 如果是文本消息且内容为空，则报错
 	if req.Type == 1 && content == "" {
-		return nil, errors.New("消息内容不能为空")
-	}
-
-	// 创建消息
-	message := &model.Message{
-		SenderID:       req.SenderID,
-		ReceiverID:     receiverID,
-		ConversationID: &req.ConversationID, // 确保 Message 模型也支持 ConversationID
-		Type:           req.Type,
-		Content:        content,
-		MediaURL:       req.MediaURL,
-		VideoID:        req.VideoID,
+		return "", errors.New("消息内容不能为空")
 	}
 
-	if err := s.messageRepo.CreateMessage(ctx, message); err != nil {
-		logger.Error("创建消息失败", zap.Error(err))
-		return nil, errors.New("发送消息失败")
-	}
+	return content, nil
+}
 
+// attachMessageRelations 加载发送者和分享视频，并累计视频分享数
+func (s *messageServiceImpl) attachMessageRelations(ctx context.Context, message *model.Message) {
 	// 视频分享：增加视频分享数
-	if req.Type == 5 && req.VideoID != nil {
-		if err := s.videoRepo.IncrementShareCount(ctx, *req.VideoID, 1); err != nil {
-			logger.Error("增加分享数失败", zap.Error(err), zap.Uint("video_id", *req.VideoID))
+	if message.Type == 5 && message.VideoID != nil {
+		if err := s.videoRepo.IncrementShareCount(ctx, *message.VideoID, 1); err != nil {
+			logger.Error("增加分享数失败", zap.Error(err), zap.Uint("video_id", *message.VideoID))
 		}
 	}
 
-	// 加载关联的发送者和接收者信息用于 VO 转换
+	// 加载关联的发送者信息用于 VO 转换
 	sender, _ := s.userRepo.FindByID(ctx, message.SenderID)
 	message.Sender = sender
-	message.Receiver = receiver
 
 	// 视频分享：拉取关联视频用于推送展示
-	if req.Type == 5 && req.VideoID != nil {
-		if v, err := s.videoRepo.FindByID(ctx, *req.VideoID); err == nil {
+	if message.Type == 5 && message.VideoID != nil {
+		if v, err := s.videoRepo.FindByID(ctx, *message.VideoID); err == nil {
 			message.Video = v
 		}
 	}
+}
 
-	// 实时推送（通过 WebSocket）- 推送给接收者时，isMine 应该为 false
-	if s.signalingService != nil {
-		go func() {
-			// 构造 MessageVO，从接收者视角看 isMine = false
-			messageVO := &model.MessageVO{
-				ID:             message.ID,
-				SenderID:       message.SenderID,
-				ReceiverID:     message.ReceiverID,
-				ConversationID: message.ConversationID,
-				Type:           message.Type,
-				Content:        message.Content,
-				MediaURL:       message.MediaURL,
-				VideoID:        message.VideoID,
-				IsRead:         message.IsRead,
-				ReadAt:         message.ReadAt,
-				CreatedAt:      message.CreatedAt,
-				IsMine:         false, // 接收者视角
-				Sender:         sender.ToAuthorVO(),
-				Receiver:       receiver.ToAuthorVO(),
-			}
-			if err := s.signalingService.PushToUser(message.ReceiverID, "message", messageVO); err != nil {
-				logger.Error("实时推送消息失败", zap.Error(err), zap.Uint("receiver_id", message.ReceiverID))
-			}
-		}()
+// toPushMessageVO 构造推送给接收者的 MessageVO（接收者视角 isMine = false）
+func toPushMessageVO(message *model.Message) *model.MessageVO {
+	return &model.MessageVO{
+		ID:             message.ID,
+		SenderID:       message.SenderID,
+		ReceiverID:     message.ReceiverID,
+		ConversationID: message.ConversationID,
+		Type:           message.Type,
+		Content:        message.Content,
+		MediaURL:       message.MediaURL,
+		VideoID:        message.VideoID,
+		IsRead:         message.IsRead,
+		ReadAt:         message.ReadAt,
+		CreatedAt:      message.CreatedAt,
+		IsMine:         false,
+		Sender:         message.Sender.ToAuthorVO(),
+		Receiver:       message.Receiver.ToAuthorVO(),
+		Video:          message.Video,
 	}
-
-	logger.Info("消息发送成功", zap.Uint("message_id", message.ID))
-	return message, nil
 }
 
 // GetConversationMessages 获取会话消息
@@ -273,10 +379,6 @@ func (s *messageServiceImpl) GetConversationMessagesByID(ctx context.Context, us
 		return nil, 0, err
 	}
 
-	if conversation.User1ID != userID && conversation.User2ID != userID {
-		return nil, 0, errors.New("无权访问该会话")
-	}
-
 	if page < 1 {
 		page = 1
 	}
@@ -284,6 +386,18 @@ func (s *messageServiceImpl) GetConversationMessagesByID(ctx context.Context, us
 		pageSize = 20
 	}
 
+	// 群聊：成员可查看，已拉黑用户的消息不展示
+	if conversation.IsGroup() {
+		if _, err := s.groupRepo.FindMember(ctx, conversationID, userID); err != nil {
+			return nil, 0, errors.New("无权访问该会话")
+		}
+		return s.groupRepo.ListGroupMessages(ctx, conversationID, userID, page, pageSize)
+	}
+
+	if !conversation.IsParticipant(userID) {
+		return nil, 0, errors.New("无权访问该会话")
+	}
+
 	return s.messageRepo.GetConversationMessagesByID(ctx, conversationID, page, pageSize)
 }
 
@@ -297,16 +411,21 @@ func (s *messageServiceImpl) MarkConversationAsReadByID(ctx context.Context, use
 		return err
 	}
 
-	if conversation.User1ID != userID && conversation.User2ID != userID {
-		return errors.New("无权访问该会话")
+	if conversation.IsGroup() {
+		if err := s.groupRepo.MarkGroupAsRead(ctx, conversationID, userID); err != nil {
+			if pkgerrors.IsNotFound(err) {
+				return errors.New("无权访问该会话")
+			}
+			return err
+		}
+		return nil
 	}
 
-	targetUserID := conversation.User1ID
-	if targetUserID == userID {
-		targetUserID = conversation.User2ID
+	if !conversation.IsParticipant(userID) {
+		return errors.New("无权访问该会话")
 	}
 
-	return s.messageRepo.MarkConversationAsRead(ctx, userID, targetUserID)
+	return s.messageRepo.MarkConversationAsRead(ctx, userID, conversation.PeerID(userID))
 }
 
 // GetOrCreateConversation 获取或创建会话
@@ -419,3 +538,21 @@ func (s *messageServiceImpl) GetUnreadNotificationCount(ctx context.Context, use
 	logger.Info("获取未读通知数", zap.Uint("user_id", userID))
 	return s.notificationRepo.GetUnreadCount(ctx, userID)
 }
+
+// GetUserPresence 批量查询用户在线状态（未启用实时推送时均视为离线）
+func (s *messageServiceImpl) GetUserPresence(ctx context.Context, userIDs []uint) ([]*UserPresence, error) {
+	if s.signalingService == nil {
+		result := make([]*UserPresence, 0, len(userIDs))
+		for _, userID := range userIDs {
+			result = append(result, &UserPresence{UserID: userID})
+		}
+		return result, nil
+	}
+
+	presence, err := s.signalingService.GetPresence(ctx, userIDs)
+	if err != nil {
+		logger.Error("查询用户在线状态失败", zap.Error(err))
+		return nil, errors.New("查询在线状态失败")
+	}
+	return presence, nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -19,8 +20,12 @@ import (
 type MessageSignalingService interface {
 	// HandleWebSocket 处理 WebSocket 连接
 	HandleWebSocket(c *gin.Context)
-	// PushToUser 推送消息给指定用户
+	// PushToUser 推送消息给指定用户（无论用户连接在哪个实例上）
 	PushToUser(userID uint, msgType string, payload interface{}) error
+	// PushToUsers 推送同一条消息给多个用户（群聊扇出）
+	PushToUsers(userIDs []uint, msgType string, payload interface{}) error
+	// GetPresence 批量查询用户在线状态
+	GetPresence(ctx context.Context, userIDs []uint) ([]*UserPresence, error)
 }
 
 // clientInfo 客户端连接信息
@@ -34,13 +39,19 @@ type messageSignalingServiceImpl struct {
 	clients      map[uint][]*clientInfo
 	clientsMutex sync.RWMutex
 
+	// broker 消息中心代理（在线登记和跨实例路由）
+	broker MessageHubBroker
+
+	// syncService 离线消息同步服务（处理客户端的 sync 帧）
+	syncService MessageSyncService
+
 	upgrader websocket.Upgrader
 	config   *config.Config
 }
 
 // NewMessageSignalingService 创建消息信令服务
 func NewMessageSignalingService(cfg *config.Config) MessageSignalingService {
-	return &messageSignalingServiceImpl{
+	s := &messageSignalingServiceImpl{
 		clients: make(map[uint][]*clientInfo),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
@@ -51,6 +62,30 @@ func NewMessageSignalingService(cfg *config.Config) MessageSignalingService {
 		},
 		config: cfg,
 	}
+
+	// 默认使用进程内代理，多实例部署时通过 SetHubBroker 替换
+	s.SetHubBroker(NewMemoryMessageHubBroker())
+	return s
+}
+
+// SetHubBroker 设置消息中心代理（延迟注入）
+func (s *messageSignalingServiceImpl) SetHubBroker(broker MessageHubBroker) {
+	broker.Subscribe(s.deliverToLocalClients)
+	s.broker = broker
+}
+
+// SetSyncService 设置离线消息同步服务（延迟注入）
+func (s *messageSignalingServiceImpl) SetSyncService(syncService MessageSyncService) {
+	s.syncService = syncService
+}
+
+// syncFrame 客户端发起的同步请求帧
+type syncFrame struct {
+	Type    string `json:"type"`
+	Payload struct {
+		Cursor *int64 `json:"cursor"`
+		Limit  int    `json:"limit"`
+	} `json:"payload"`
 }
 
 // HandleWebSocket 处理 WebSocket 连接
@@ -70,6 +105,12 @@ func (s *messageSignalingServiceImpl) HandleWebSocket(c *gin.Context) {
 
 	userID := claims.UserID
 
+	// 浏览器 WebSocket 无法自定义请求头，设备信息同时支持查询参数
+	device := SyncDevice{
+		DeviceID: c.DefaultQuery("device_id", c.GetHeader("X-Device-ID")),
+		Platform: c.DefaultQuery("platform", "web"),
+	}
+
 	// 升级连接
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -92,25 +133,63 @@ func (s *messageSignalingServiceImpl) HandleWebSocket(c *gin.Context) {
 		logger.Info("用户已断开 WebSocket 消息中心", zap.Uint("user_id", userID))
 	}()
 
-	// 保持连接（读取心跳或关闭信号）
+	// 保持连接（读取心跳、同步请求或关闭信号）
 	for {
-		_, _, err := conn.ReadMessage()
+		_, msgBytes, err := conn.ReadMessage()
 		if err != nil {
 			break
 		}
+
+		var frame syncFrame
+		if err := json.Unmarshal(msgBytes, &frame); err != nil || frame.Type != "sync" {
+			continue
+		}
+		s.handleSync(client, userID, device, &frame)
+	}
+}
+
+// handleSync 处理同步帧，将结果回写到发起请求的连接
+func (s *messageSignalingServiceImpl) handleSync(client *clientInfo, userID uint, device SyncDevice, frame *syncFrame) {
+	reply := map[string]interface{}{"type": "sync"}
+	if s.syncService == nil {
+		reply["error"] = "sync is not available"
+	} else if result, err := s.syncService.Sync(context.Background(), userID, device, frame.Payload.Cursor, frame.Payload.Limit); err != nil {
+		reply["error"] = err.Error()
+	} else {
+		reply["payload"] = result
+	}
+
+	msgBytes, err := json.Marshal(reply)
+	if err != nil {
+		logger.Error("序列化同步结果失败", zap.Error(err), zap.Uint("user_id", userID))
+		return
+	}
+
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	if err := client.Conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+		logger.Warn("发送同步结果失败", zap.Error(err), zap.Uint("user_id", userID))
 	}
 }
 
 // PushToUser 推送消息给指定用户
 func (s *messageSignalingServiceImpl) PushToUser(userID uint, msgType string, payload interface{}) error {
-	s.clientsMutex.RLock()
-	clients, exists := s.clients[userID]
-	s.clientsMutex.RUnlock()
+	msg := map[string]interface{}{
+		"type":    msgType,
+		"payload": payload,
+	}
 
-	if !exists || len(clients) == 0 {
-		return nil // 用户不在线，不报错
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message failed: %w", err)
 	}
 
+	// 由代理路由到用户连接所在的实例，用户不在线时不报错
+	return s.broker.Deliver(context.Background(), userID, msgBytes)
+}
+
+// PushToUsers 推送同一条消息给多个用户，消息只序列化一次
+func (s *messageSignalingServiceImpl) PushToUsers(userIDs []uint, msgType string, payload interface{}) error {
 	msg := map[string]interface{}{
 		"type":    msgType,
 		"payload": payload,
@@ -121,7 +200,26 @@ func (s *messageSignalingServiceImpl) PushToUser(userID uint, msgType string, pa
 		return fmt.Errorf("marshal message failed: %w", err)
 	}
 
-	// 推送给该用户的所有活跃连接
+	ctx := context.Background()
+	for _, userID := range userIDs {
+		if err := s.broker.Deliver(ctx, userID, msgBytes); err != nil {
+			logger.Warn("推送消息失败", zap.Error(err), zap.Uint("user_id", userID))
+		}
+	}
+	return nil
+}
+
+// GetPresence 批量查询用户在线状态
+func (s *messageSignalingServiceImpl) GetPresence(ctx context.Context, userIDs []uint) ([]*UserPresence, error) {
+	return s.broker.Presence(ctx, userIDs)
+}
+
+// deliverToLocalClients 推送消息给该用户在本实例上的所有活跃连接
+func (s *messageSignalingServiceImpl) deliverToLocalClients(userID uint, msgBytes []byte) {
+	s.clientsMutex.RLock()
+	clients := append([]*clientInfo(nil), s.clients[userID]...)
+	s.clientsMutex.RUnlock()
+
 	for _, client := range clients {
 		go func(c *clientInfo) {
 			c.writeMu.Lock()
@@ -131,20 +229,19 @@ func (s *messageSignalingServiceImpl) PushToUser(userID uint, msgType string, pa
 			}
 		}(client)
 	}
-
-	return nil
 }
 
 func (s *messageSignalingServiceImpl) addClient(userID uint, client *clientInfo) {
 	s.clientsMutex.Lock()
-	defer s.clientsMutex.Unlock()
 	s.clients[userID] = append(s.clients[userID], client)
+	count := len(s.clients[userID])
+	s.clientsMutex.Unlock()
+
+	s.reportConnections(userID, count)
 }
 
 func (s *messageSignalingServiceImpl) removeClient(userID uint, client *clientInfo) {
 	s.clientsMutex.Lock()
-	defer s.clientsMutex.Unlock()
-
 	clients := s.clients[userID]
 	for i, c := range clients {
 		if c == client {
@@ -153,7 +250,18 @@ func (s *messageSignalingServiceImpl) removeClient(userID uint, client *clientIn
 		}
 	}
 
-	if len(s.clients[userID]) == 0 {
+	count := len(s.clients[userID])
+	if count == 0 {
 		delete(s.clients, userID)
 	}
+	s.clientsMutex.Unlock()
+
+	s.reportConnections(userID, count)
+}
+
+// reportConnections 向代理登记用户在本实例上的连接数
+func (s *messageSignalingServiceImpl) reportConnections(userID uint, count int) {
+	if err := s.broker.SetLocalConnections(context.Background(), userID, count); err != nil {
+		logger.Warn("登记用户在线状态失败", zap.Error(err), zap.Uint("user_id", userID))
+	}
 }
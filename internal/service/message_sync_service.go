@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultMessageSyncLimit 单次同步默认返回的事件数
+	defaultMessageSyncLimit = 100
+	// maxMessageSyncLimit 单次同步最多返回的事件数
+	maxMessageSyncLimit = 500
+)
+
+// SyncDevice 发起同步的设备
+type SyncDevice struct {
+	DeviceID string // 设备标识（客户端通过 X-Device-ID 或 device_id 提供）
+	Platform string // 平台：web, android, ios ...
+}
+
+// key 设备游标标识，未提供设备ID时按平台区分
+func (d SyncDevice) key() string {
+	if d.DeviceID != "" {
+		return d.DeviceID
+	}
+	if d.Platform != "" {
+		return "platform:" + d.Platform
+	}
+	return "platform:web"
+}
+
+// MessageSyncEventVO 同步事件视图对象
+type MessageSyncEventVO struct {
+	Seq            int64            `json:"seq"`
+	Type           string           `json:"type"` // message/read/delete
+	ConversationID *uint            `json:"conversation_id"`
+	MessageID      *uint            `json:"message_id,omitempty"`
+	ActorID        uint             `json:"actor_id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Message        *model.MessageVO `json:"message,omitempty"` // 新消息内容（消息已被删除时为空）
+}
+
+// MessageSyncResult 同步结果
+type MessageSyncResult struct {
+	Events    []*MessageSyncEventVO `json:"events"`
+	Cursor    int64                 `json:"cursor"`     // 本次返回的最后一个序号，下次同步时带上以确认
+	LatestSeq int64                 `json:"latest_seq"` // 用户当前最大序号
+	HasMore   bool                  `json:"has_more"`
+}
+
+// MessageSyncService 消息离线同步服务接口
+type MessageSyncService interface {
+	// Sync 返回序号大于游标的所有消息、已读回执和删除事件
+	// cursor 为客户端已确认收到的序号，同时会记为该设备的游标；为空时使用该设备上次确认的游标
+	Sync(ctx context.Context, userID uint, device SyncDevice, cursor *int64, limit int) (*MessageSyncResult, error)
+}
+
+type messageSyncServiceImpl struct {
+	syncRepo repository.MessageSyncRepository
+}
+
+// NewMessageSyncService 创建消息同步服务
+func NewMessageSyncService(syncRepo repository.MessageSyncRepository) MessageSyncService {
+	return &messageSyncServiceImpl{
+		syncRepo: syncRepo,
+	}
+}
+
+// Sync 增量同步
+func (s *messageSyncServiceImpl) Sync(ctx context.Context, userID uint, device SyncDevice, cursor *int64, limit int) (*MessageSyncResult, error) {
+	if limit <= 0 {
+		limit = defaultMessageSyncLimit
+	}
+	if limit > maxMessageSyncLimit {
+		limit = maxMessageSyncLimit
+	}
+
+	deviceKey := device.key()
+
+	var afterSeq int64
+	if cursor != nil {
+		if *cursor < 0 {
+			return nil, errors.New("无效的同步游标")
+		}
+		afterSeq = *cursor
+
+		// 客户端带上的游标表示此前的事件已处理，记为设备游标
+		if err := s.syncRepo.SaveCursor(ctx, &model.MessageSyncCursor{
+			UserID:   userID,
+			DeviceID: deviceKey,
+			Platform: device.Platform,
+			LastSeq:  afterSeq,
+		}); err != nil {
+			logger.Error("保存同步游标失败", zap.Error(err), zap.Uint("user_id", userID), zap.String("device", deviceKey))
+			return nil, errors.New("同步失败")
+		}
+	} else {
+		stored, err := s.syncRepo.FindCursor(ctx, userID, deviceKey)
+		if err != nil && !pkgerrors.IsNotFound(err) {
+			logger.Error("查询同步游标失败", zap.Error(err), zap.Uint("user_id", userID), zap.String("device", deviceKey))
+			return nil, errors.New("同步失败")
+		}
+		if stored != nil {
+			afterSeq = stored.LastSeq
+		}
+	}
+
+	latestSeq, err := s.syncRepo.GetLatestSeq(ctx, userID)
+	if err != nil {
+		logger.Error("查询消息序号失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, errors.New("同步失败")
+	}
+
+	events, err := s.syncRepo.ListEvents(ctx, userID, afterSeq, limit+1)
+	if err != nil {
+		logger.Error("查询同步事件失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, errors.New("同步失败")
+	}
+
+	result := &MessageSyncResult{
+		Events:    make([]*MessageSyncEventVO, 0, len(events)),
+		Cursor:    afterSeq,
+		LatestSeq: latestSeq,
+	}
+	if len(events) > limit {
+		events = events[:limit]
+		result.HasMore = true
+	}
+
+	for _, evt := range events {
+		vo := &MessageSyncEventVO{
+			Seq:            evt.Seq,
+			Type:           evt.Type,
+			ConversationID: evt.ConversationID,
+			MessageID:      evt.MessageID,
+			ActorID:        evt.ActorID,
+			CreatedAt:      evt.CreatedAt,
+		}
+		if evt.Type == model.MessageSyncEventMessage && evt.Message != nil {
+			vo.Message = toSyncMessageVO(evt.Message, userID)
+		}
+		result.Events = append(result.Events, vo)
+		result.Cursor = evt.Seq
+	}
+
+	return result, nil
+}
+
+// toSyncMessageVO 将消息转换为当前用户视角的VO
+func toSyncMessageVO(m *model.Message, viewerID uint) *model.MessageVO {
+	return &model.MessageVO{
+		ID:             m.ID,
+		SenderID:       m.SenderID,
+		ReceiverID:     m.ReceiverID,
+		ConversationID: m.ConversationID,
+		Type:           m.Type,
+		Content:        m.Content,
+		MediaURL:       m.MediaURL,
+		VideoID:        m.VideoID,
+		IsRead:         m.IsRead,
+		ReadAt:         m.ReadAt,
+		CreatedAt:      m.CreatedAt,
+		IsMine:         m.SenderID == viewerID,
+		Sender:         m.Sender.ToAuthorVO(),
+		Receiver:       m.Receiver.ToAuthorVO(),
+		Video:          m.Video,
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/internal/algorithm/recommend"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	"time"
+)
+
+// MuteService 负反馈（不感兴趣、屏蔽作者/话题/分类）服务接口
+type MuteService interface {
+	// MarkNotInterested 标记视频不感兴趣（推荐流不再出现，并对同分类、同作者的内容降权）
+	MarkNotInterested(ctx context.Context, userID, videoID uint) error
+	// Mute 屏蔽作者、话题或分类
+	Mute(ctx context.Context, userID uint, muteType int8, targetID uint) (*model.UserMute, error)
+	// Unmute 撤销屏蔽或不感兴趣
+	Unmute(ctx context.Context, userID, id uint) error
+	// ListMutes 屏蔽列表，muteType 为 0 时返回所有类型
+	ListMutes(ctx context.Context, userID uint, muteType int8, page, pageSize int) ([]*MuteVO, int64, error)
+}
+
+// MuteVO 屏蔽列表项
+type MuteVO struct {
+	ID        uint      `json:"id"`
+	Type      int8      `json:"type"`      // 类型：1-不感兴趣的视频，2-作者，3-话题，4-分类
+	TargetID  uint      `json:"target_id"` // 视频、作者、话题或分类ID
+	Name      string    `json:"name"`      // 视频标题、作者昵称、话题或分类名称
+	Image     string    `json:"image"`     // 视频封面或作者头像
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type muteServiceImpl struct {
+	muteRepo        repository.MuteRepository
+	videoRepo       repository.VideoRepository
+	userRepo        repository.UserRepository
+	hashtagRepo     repository.HashtagRepository
+	categoryRepo    repository.CategoryRepository
+	recommendEngine *recommend.Engine
+}
+
+// NewMuteService 创建负反馈服务实例
+func NewMuteService(
+	muteRepo repository.MuteRepository,
+	videoRepo repository.VideoRepository,
+	userRepo repository.UserRepository,
+	hashtagRepo repository.HashtagRepository,
+	categoryRepo repository.CategoryRepository,
+) MuteService {
+	return &muteServiceImpl{
+		muteRepo:     muteRepo,
+		videoRepo:    videoRepo,
+		userRepo:     userRepo,
+		hashtagRepo:  hashtagRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// SetRecommendEngine 设置推荐引擎（屏蔽变化后清除推荐过滤缓存）
+func (s *muteServiceImpl) SetRecommendEngine(engine *recommend.Engine) {
+	s.recommendEngine = engine
+}
+
+func (s *muteServiceImpl) MarkNotInterested(ctx context.Context, userID, videoID uint) error {
+	video, err := s.videoRepo.FindByID(ctx, videoID)
+	if err != nil {
+		return errors.New("视频不存在")
+	}
+	if video.UserID == userID {
+		return errors.New("不能对自己的视频标记不感兴趣")
+	}
+
+	mute := &model.UserMute{
+		UserID:   userID,
+		Type:     model.MuteTypeVideo,
+		TargetID: videoID,
+		AuthorID: video.UserID,
+	}
+	if video.CategoryID != nil {
+		mute.CategoryID = *video.CategoryID
+	}
+	if err := s.muteRepo.Create(ctx, mute); err != nil {
+		return err
+	}
+
+	if s.recommendEngine != nil {
+		s.recommendEngine.InvalidateMutes(ctx, userID)
+		// 推荐会话中尚未下发的同分类、同作者视频后移
+		s.recommendEngine.Feedback(ctx, userID, videoID, recommend.FeedbackSkip)
+	}
+	return nil
+}
+
+func (s *muteServiceImpl) Mute(ctx context.Context, userID uint, muteType int8, targetID uint) (*model.UserMute, error) {
+	switch muteType {
+	case model.MuteTypeAuthor:
+		if targetID == userID {
+			return nil, errors.New("不能屏蔽自己")
+		}
+		if _, err := s.userRepo.FindByID(ctx, targetID); err != nil {
+			return nil, errors.New("用户不存在")
+		}
+	case model.MuteTypeHashtag:
+		if _, err := s.hashtagRepo.GetHashtagByID(ctx, targetID); err != nil {
+			return nil, errors.New("话题不存在")
+		}
+	case model.MuteTypeCategory:
+		if _, err := s.categoryRepo.FindByID(ctx, targetID); err != nil {
+			return nil, errors.New("分类不存在")
+		}
+	default:
+		return nil, errors.New("屏蔽类型错误")
+	}
+
+	mute := &model.UserMute{
+		UserID:   userID,
+		Type:     muteType,
+		TargetID: targetID,
+	}
+	if err := s.muteRepo.Create(ctx, mute); err != nil {
+		return nil, err
+	}
+
+	if s.recommendEngine != nil {
+		s.recommendEngine.InvalidateMutes(ctx, userID)
+	}
+	return mute, nil
+}
+
+func (s *muteServiceImpl) Unmute(ctx context.Context, userID, id uint) error {
+	mute, err := s.muteRepo.FindByID(ctx, id)
+	if err != nil || mute.UserID != userID {
+		return errors.New("屏蔽记录不存在")
+	}
+	if err := s.muteRepo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+
+	if s.recommendEngine != nil {
+		s.recommendEngine.InvalidateMutes(ctx, userID)
+	}
+	return nil
+}
+
+func (s *muteServiceImpl) ListMutes(ctx context.Context, userID uint, muteType int8, page, pageSize int) ([]*MuteVO, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	mutes, total, err := s.muteRepo.FindByUserID(ctx, userID, muteType, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 按类型批量加载名称和图片
+	ids := make(map[int8][]uint)
+	for _, m := range mutes {
+		ids[m.Type] = append(ids[m.Type], m.TargetID)
+	}
+	type target struct{ name, image string }
+	targets := make(map[int8]map[uint]target)
+	for t := range ids {
+		targets[t] = make(map[uint]target)
+	}
+
+	if len(ids[model.MuteTypeVideo]) > 0 {
+		if videos, err := s.videoRepo.FindByIDs(ctx, ids[model.MuteTypeVideo]); err == nil {
+			for _, v := range videos {
+				targets[model.MuteTypeVideo][v.ID] = target{v.Title, v.CoverURL}
+			}
+		}
+	}
+	if len(ids[model.MuteTypeAuthor]) > 0 {
+		if users, err := s.userRepo.FindByIDs(ctx, ids[model.MuteTypeAuthor]); err == nil {
+			for _, u := range users {
+				targets[model.MuteTypeAuthor][u.ID] = target{u.Nickname, u.Avatar}
+			}
+		}
+	}
+	if len(ids[model.MuteTypeHashtag]) > 0 {
+		if hashtags, err := s.hashtagRepo.GetHashtagsByIDs(ctx, ids[model.MuteTypeHashtag]); err == nil {
+			for _, h := range hashtags {
+				targets[model.MuteTypeHashtag][h.ID] = target{name: h.Name}
+			}
+		}
+	}
+	if len(ids[model.MuteTypeCategory]) > 0 {
+		if categories, err := s.categoryRepo.FindAll(ctx); err == nil {
+			for _, c := range categories {
+				targets[model.MuteTypeCategory][c.ID] = target{c.Name, c.Icon}
+			}
+		}
+	}
+
+	list := make([]*MuteVO, 0, len(mutes))
+	for _, m := range mutes {
+		t := targets[m.Type][m.TargetID]
+		list = append(list, &MuteVO{
+			ID:        m.ID,
+			Type:      m.Type,
+			TargetID:  m.TargetID,
+			Name:      t.name,
+			Image:     t.image,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return list, total, nil
+}
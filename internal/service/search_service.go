@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/fulltext"
 	"microvibe-go/pkg/logger"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -14,12 +17,12 @@ import (
 type SearchService interface {
 	// Search 综合搜索
 	Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error)
-	// SearchVideos 搜索视频
-	SearchVideos(ctx context.Context, keyword string, userID uint, page, pageSize int) ([]*model.VideoVO, int64, error)
+	// SearchVideos 搜索视频（支持筛选、排序，并返回分面统计）
+	SearchVideos(ctx context.Context, req *SearchRequest) (*SearchResponse, error)
 	// SearchUsers 搜索用户
 	SearchUsers(ctx context.Context, keyword string, userID uint, page, pageSize int) ([]*model.UserVO, int64, error)
 	// SearchHashtags 搜索话题
-	SearchHashtags(ctx context.Context, keyword string, page, pageSize int) ([]*model.Hashtag, int64, error)
+	SearchHashtags(ctx context.Context, keyword string, page, pageSize int) ([]*model.HashtagVO, int64, error)
 	// GetSearchHistory 获取搜索历史
 	GetSearchHistory(ctx context.Context, userID uint, limit int) ([]*model.SearchHistory, error)
 	// ClearSearchHistory 清空搜索历史
@@ -36,6 +39,8 @@ type searchServiceImpl struct {
 	followRepo   repository.FollowRepository
 	likeRepo     repository.LikeRepository
 	favoriteRepo repository.FavoriteRepository
+
+	trendingService TrendingService // 可选，记录搜索次数用于计算热搜
 }
 
 // NewSearchService 创建搜索服务实例
@@ -53,23 +58,41 @@ func NewSearchService(
 	}
 }
 
+// searchSnippetLength 长文本高亮片段的最大字数
+const searchSnippetLength = 60
+
+// SetTrendingService 设置趋势服务
+func (s *searchServiceImpl) SetTrendingService(trendingService TrendingService) {
+	s.trendingService = trendingService
+}
+
 // SearchRequest 搜索请求
+// Keyword 支持 #话题 和 @用户 语法，例如 "舞蹈 #街舞 @小明"
 type SearchRequest struct {
 	Keyword  string `json:"keyword" binding:"required,min=1,max=100"`
 	Category string `json:"category"` // video, user, hashtag, all
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
 	UserID   *uint  `json:"user_id"` // 可选，用于记录搜索历史
+
+	// 视频筛选和排序（只作用于视频结果）
+	CategoryID    uint       `json:"category_id"`    // 视频分类ID
+	Duration      string     `json:"duration"`       // 时长区间：short, medium, long
+	PublishedFrom *time.Time `json:"published_from"` // 发布时间下限（含）
+	PublishedTo   *time.Time `json:"published_to"`   // 发布时间上限（不含）
+	AuthorID      uint       `json:"author_id"`      // 作者ID
+	Sort          string     `json:"sort"`           // relevance, newest, most_played, most_liked
 }
 
 // SearchResponse 搜索响应
 type SearchResponse struct {
-	Videos        []*model.VideoVO `json:"videos,omitempty"`
-	Hashtags      []*model.Hashtag `json:"hashtags,omitempty"`
-	BestMatchUser *UserWithVideos  `json:"best_match_user,omitempty"`
-	Total         int64            `json:"total"`
-	Page          int              `json:"page"`
-	PageSize      int              `json:"page_size"`
+	Videos        []*model.VideoVO    `json:"videos,omitempty"`
+	Hashtags      []*model.HashtagVO  `json:"hashtags,omitempty"`
+	BestMatchUser *UserWithVideos     `json:"best_match_user,omitempty"`
+	Facets        *model.SearchFacets `json:"facets,omitempty"` // 视频结果的分面统计
+	Total         int64               `json:"total"`
+	Page          int                 `json:"page"`
+	PageSize      int                 `json:"page_size"`
 }
 
 // UserWithVideos 最佳匹配用户及其视频
@@ -101,6 +124,9 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 		if err := s.searchRepo.IncrementSearchCount(context.Background(), req.Keyword); err != nil {
 			logger.Error("更新热搜失败", zap.Error(err))
 		}
+		if s.trendingService != nil {
+			s.trendingService.RecordSearch(context.Background(), req.Keyword)
+		}
 	}()
 
 	resp := &SearchResponse{
@@ -108,24 +134,37 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 		PageSize: req.PageSize,
 	}
 
+	query := fulltext.ParseQuery(req.Keyword)
+	videoQuery, err := buildVideoSearchQuery(query, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 指定了 @用户 时以该用户作为最佳匹配用户的候选
+	userKeyword := req.Keyword
+	if len(query.Users) > 0 {
+		userKeyword = query.Users[0]
+	}
+
 	// 根据分类搜索
 	switch req.Category {
 	case "video":
-		videos, total, err := s.searchRepo.SearchVideos(ctx, req.Keyword, req.Page, req.PageSize)
+		videos, total, err := s.searchVideos(ctx, videoQuery, req.Page, req.PageSize)
 		if err != nil {
 			logger.Error("搜索视频失败", zap.Error(err))
 			return nil, err
 		}
+		resp.Facets = s.searchFacets(ctx, videoQuery)
 		var currentUserID uint
 		if req.UserID != nil {
 			currentUserID = *req.UserID
 		}
-		resp.Videos = s.toVideoVOList(ctx, videos, currentUserID)
+		resp.Videos = s.highlightVideos(s.toVideoVOList(ctx, videos, currentUserID), query.Terms)
 		resp.Total = total
 
 	case "user":
 		// category=user 时，将最佳匹配用户附带视频返回
-		bestUser, err := s.searchRepo.SearchBestMatchUser(ctx, req.Keyword)
+		bestUser, err := s.searchRepo.SearchBestMatchUser(ctx, userKeyword)
 		if err != nil {
 			logger.Error("搜索最佳匹配用户失败", zap.Error(err))
 			return nil, err
@@ -150,7 +189,7 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 		}
 
 	case "hashtag":
-		hashtags, total, err := s.searchRepo.SearchHashtags(ctx, req.Keyword, req.Page, req.PageSize)
+		hashtags, total, err := s.searchHashtags(ctx, query, req.Page, req.PageSize)
 		if err != nil {
 			logger.Error("搜索话题失败", zap.Error(err))
 			return nil, err
@@ -159,7 +198,7 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 		resp.Total = total
 
 	default: // "all" 或空
-		videos, _, err := s.searchRepo.SearchVideos(ctx, req.Keyword, 1, 10)
+		videos, _, err := s.searchVideos(ctx, videoQuery, 1, 10)
 		if err != nil {
 			logger.Error("搜索视频失败", zap.Error(err))
 		} else {
@@ -167,10 +206,10 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 			if req.UserID != nil {
 				currentUserID = *req.UserID
 			}
-			resp.Videos = s.toVideoVOList(ctx, videos, currentUserID)
+			resp.Videos = s.highlightVideos(s.toVideoVOList(ctx, videos, currentUserID), query.Terms)
 		}
 
-		hashtags, _, err := s.searchRepo.SearchHashtags(ctx, req.Keyword, 1, 5)
+		hashtags, _, err := s.searchHashtags(ctx, query, 1, 5)
 		if err != nil {
 			logger.Error("搜索话题失败", zap.Error(err))
 		} else {
@@ -178,7 +217,7 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 		}
 
 		// 最佳匹配用户附带其视频
-		bestUser, err := s.searchRepo.SearchBestMatchUser(ctx, req.Keyword)
+		bestUser, err := s.searchRepo.SearchBestMatchUser(ctx, userKeyword)
 		if err != nil {
 			logger.Error("搜索最佳匹配用户失败", zap.Error(err))
 		} else if bestUser != nil {
@@ -206,13 +245,142 @@ func (s *searchServiceImpl) Search(ctx context.Context, req *SearchRequest) (*Se
 }
 
 // SearchVideos 搜索视频
-func (s *searchServiceImpl) SearchVideos(ctx context.Context, keyword string, userID uint, page, pageSize int) ([]*model.VideoVO, int64, error) {
-	logger.Info("搜索视频", zap.String("keyword", keyword))
-	videos, total, err := s.searchRepo.SearchVideos(ctx, keyword, page, pageSize)
+func (s *searchServiceImpl) SearchVideos(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	logger.Info("搜索视频", zap.String("keyword", req.Keyword), zap.String("sort", req.Sort))
+
+	query := fulltext.ParseQuery(req.Keyword)
+	videoQuery, err := buildVideoSearchQuery(query, req)
+	if err != nil {
+		return nil, err
+	}
+
+	videos, total, err := s.searchVideos(ctx, videoQuery, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentUserID uint
+	if req.UserID != nil {
+		currentUserID = *req.UserID
+	}
+
+	return &SearchResponse{
+		Videos:   s.highlightVideos(s.toVideoVOList(ctx, videos, currentUserID), query.Terms),
+		Facets:   s.searchFacets(ctx, videoQuery),
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// buildVideoSearchQuery 根据搜索语句和筛选条件构造视频查询
+func buildVideoSearchQuery(query *fulltext.Query, req *SearchRequest) (*repository.VideoSearchQuery, error) {
+	switch req.Sort {
+	case "", model.SearchSortRelevance, model.SearchSortNewest, model.SearchSortMostPlayed, model.SearchSortMostLiked:
+	default:
+		return nil, errors.New("不支持的排序方式")
+	}
+	if req.Duration != "" {
+		if _, ok := model.FindDurationBucket(req.Duration); !ok {
+			return nil, errors.New("不支持的时长筛选")
+		}
+	}
+	if req.PublishedFrom != nil && req.PublishedTo != nil && !req.PublishedFrom.Before(*req.PublishedTo) {
+		return nil, errors.New("发布时间范围无效")
+	}
+
+	return &repository.VideoSearchQuery{
+		TSQuery:       query.TSQuery(),
+		Hashtags:      query.Hashtags,
+		Authors:       query.Users,
+		CategoryID:    req.CategoryID,
+		AuthorID:      req.AuthorID,
+		Duration:      req.Duration,
+		PublishedFrom: req.PublishedFrom,
+		PublishedTo:   req.PublishedTo,
+		Sort:          req.Sort,
+	}, nil
+}
+
+// hasVideoSearchTerms 是否有关键词、话题或用户条件（只有筛选条件时不做搜索）
+func hasVideoSearchTerms(q *repository.VideoSearchQuery) bool {
+	return q.TSQuery != "" || len(q.Hashtags) > 0 || len(q.Authors) > 0
+}
+
+// searchVideos 搜索视频，没有有效搜索条件时返回空结果
+func (s *searchServiceImpl) searchVideos(ctx context.Context, q *repository.VideoSearchQuery, page, pageSize int) ([]*model.Video, int64, error) {
+	if !hasVideoSearchTerms(q) {
+		return []*model.Video{}, 0, nil
+	}
+	return s.searchRepo.SearchVideos(ctx, q, page, pageSize)
+}
+
+// searchFacets 统计视频搜索结果的分面，失败时只记录日志
+func (s *searchServiceImpl) searchFacets(ctx context.Context, q *repository.VideoSearchQuery) *model.SearchFacets {
+	if !hasVideoSearchTerms(q) {
+		return &model.SearchFacets{Categories: []*model.CategoryFacet{}, Durations: []*model.DurationFacet{}}
+	}
+	facets, err := s.searchRepo.SearchVideoFacets(ctx, q)
+	if err != nil {
+		logger.Error("统计搜索分面失败", zap.Error(err))
+		return nil
+	}
+	return facets
+}
+
+// searchHashtags 按解析后的搜索语句搜索话题（#话题 也作为关键词）
+func (s *searchServiceImpl) searchHashtags(ctx context.Context, query *fulltext.Query, page, pageSize int) ([]*model.HashtagVO, int64, error) {
+	terms := append(append([]string{}, query.Terms...), query.Hashtags...)
+	tsQuery := fulltext.BuildTSQuery(terms)
+	if tsQuery == "" {
+		return []*model.HashtagVO{}, 0, nil
+	}
+
+	hashtags, total, err := s.searchRepo.SearchHashtags(ctx, tsQuery, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
-	return s.toVideoVOList(ctx, videos, userID), total, nil
+
+	vos := make([]*model.HashtagVO, 0, len(hashtags))
+	for _, h := range hashtags {
+		vo := &model.HashtagVO{Hashtag: h}
+		if name := fulltext.Highlight(h.Name, terms, 0); name != "" {
+			vo.Highlights = map[string]string{"name": name}
+		}
+		vos = append(vos, vo)
+	}
+	return vos, total, nil
+}
+
+// highlightVideos 为视频的标题、标签和描述生成高亮片段
+func (s *searchServiceImpl) highlightVideos(vos []*model.VideoVO, terms []string) []*model.VideoVO {
+	if len(terms) == 0 {
+		return vos
+	}
+	for _, vo := range vos {
+		vo.Highlights = buildHighlights(terms, map[string]string{
+			"title":       vo.Title,
+			"tags":        vo.Tags,
+			"description": vo.Description,
+		})
+	}
+	return vos
+}
+
+// buildHighlights 为各字段生成高亮片段，只返回有命中的字段
+func buildHighlights(terms []string, fields map[string]string) map[string]string {
+	var highlights map[string]string
+	for name, text := range fields {
+		snippet := fulltext.Highlight(text, terms, searchSnippetLength)
+		if snippet == "" {
+			continue
+		}
+		if highlights == nil {
+			highlights = make(map[string]string, len(fields))
+		}
+		highlights[name] = snippet
+	}
+	return highlights
 }
 
 // toVideoVO 将 Video 转换为 VideoVO
@@ -267,7 +435,25 @@ func (s *searchServiceImpl) SearchUsers(ctx context.Context, keyword string, use
 		users, total, err = s.searchRepo.RecommendUsers(ctx, userID, page, pageSize)
 	} else {
 		logger.Info("搜索用户", zap.String("keyword", keyword))
-		users, total, err = s.searchRepo.SearchUsers(ctx, keyword, page, pageSize)
+		terms := fulltext.ParseQuery(keyword).AllTerms()
+		tsQuery := fulltext.BuildTSQuery(terms)
+		if tsQuery == "" {
+			return []*model.UserVO{}, 0, nil
+		}
+		users, total, err = s.searchRepo.SearchUsers(ctx, tsQuery, page, pageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		vos := s.toUserVOList(ctx, users, userID)
+		for _, vo := range vos {
+			vo.Highlights = buildHighlights(terms, map[string]string{
+				"username": vo.Username,
+				"nickname": vo.Nickname,
+				"bio":      vo.Bio,
+			})
+		}
+		return vos, total, nil
 	}
 
 	if err != nil {
@@ -302,9 +488,9 @@ func (s *searchServiceImpl) toUserVOList(ctx context.Context, users []*model.Use
 }
 
 // SearchHashtags 搜索话题
-func (s *searchServiceImpl) SearchHashtags(ctx context.Context, keyword string, page, pageSize int) ([]*model.Hashtag, int64, error) {
+func (s *searchServiceImpl) SearchHashtags(ctx context.Context, keyword string, page, pageSize int) ([]*model.HashtagVO, int64, error) {
 	logger.Info("搜索话题", zap.String("keyword", keyword))
-	return s.searchRepo.SearchHashtags(ctx, keyword, page, pageSize)
+	return s.searchHashtags(ctx, fulltext.ParseQuery(keyword), page, pageSize)
 }
 
 // GetSearchHistory 获取搜索历史
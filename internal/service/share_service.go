@@ -6,6 +6,7 @@ import (
 	"microvibe-go/internal/algorithm/recommend"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
 )
 
 type ShareService interface {
@@ -69,6 +70,11 @@ func (s *shareServiceImpl) ShareVideo(ctx context.Context, userID, videoID uint,
 		}()
 	}
 
+	// 发布分享事件（推荐引擎据此实时更新用户兴趣）
+	go func() {
+		_ = event.PublishAsync(context.Background(), event.NewVideoSharedEvent(videoID, userID, platform))
+	}()
+
 	return nil
 }
 
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"math"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// 分桶计数（ZSET：成员 -> 次数），后缀为分桶序号
+	// 键名中的 {} 保证同一类计数落在同一个集群槽位，便于 ZUNIONSTORE 聚合
+	trendingSearchKeyPrefix  = "trending:{search}:"
+	trendingHashtagKeyPrefix = "trending:{hashtag}:"
+	// trendingLockKey 计算锁，保证多实例部署时每个周期只有一个实例执行计算
+	trendingLockKey = "trending:lock"
+
+	// trendingCandidateLimit 每次计算读取的候选数量上限
+	trendingCandidateLimit = 1000
+
+	// 话题热度的计数权重：投稿比浏览更能说明话题的热度
+	trendingHashtagViewWeight  = 1
+	trendingHashtagVideoWeight = 5
+)
+
+// TrendingService 热搜和热门话题趋势服务
+// 搜索和话题浏览按时间分桶计入 Redis，定时任务对滑动窗口内的分桶按半衰期指数衰减加权求和得到热度，
+// 并对比近期与更早时段的速率标记新上榜和上升中的热搜，然后重新计算热搜排名和热门话题
+type TrendingService interface {
+	// RecordSearch 记录一次搜索
+	RecordSearch(ctx context.Context, keyword string)
+
+	// RecordHashtagView 记录一次话题浏览
+	RecordHashtagView(ctx context.Context, hashtagID uint)
+
+	// RecordHashtagVideo 记录一次话题投稿
+	RecordHashtagVideo(ctx context.Context, hashtagID uint)
+
+	// Refresh 立即重新计算热度和排名（其他实例正在计算时跳过）
+	Refresh(ctx context.Context) error
+
+	// Start 启动定时计算，ctx 取消后停止
+	Start(ctx context.Context)
+}
+
+type trendingServiceImpl struct {
+	client      *redis.Client
+	searchRepo  repository.SearchRepository
+	hashtagRepo repository.HashtagRepository
+	config      config.TrendingConfig
+	instanceID  string
+}
+
+// trendScore 单个成员的趋势计算结果
+type trendScore struct {
+	Member string
+	Score  float64 // 衰减加权后的热度
+	Recent float64 // 近期窗口内的次数
+	Before float64 // 窗口内更早时段的次数
+}
+
+// NewTrendingService 创建趋势服务（redisClient 为空时不记录也不计算）
+func NewTrendingService(
+	redisClient *redis.Client,
+	searchRepo repository.SearchRepository,
+	hashtagRepo repository.HashtagRepository,
+	cfg *config.Config,
+) TrendingService {
+	return &trendingServiceImpl{
+		client:      redisClient,
+		searchRepo:  searchRepo,
+		hashtagRepo: hashtagRepo,
+		config:      cfg.Trending,
+		instanceID:  generateInstanceID(),
+	}
+}
+
+// RecordSearch 记录一次搜索
+func (s *trendingServiceImpl) RecordSearch(ctx context.Context, keyword string) {
+	s.record(ctx, trendingSearchKeyPrefix, keyword, 1)
+}
+
+// RecordHashtagView 记录一次话题浏览
+func (s *trendingServiceImpl) RecordHashtagView(ctx context.Context, hashtagID uint) {
+	s.record(ctx, trendingHashtagKeyPrefix, strconv.FormatUint(uint64(hashtagID), 10), trendingHashtagViewWeight)
+}
+
+// RecordHashtagVideo 记录一次话题投稿
+func (s *trendingServiceImpl) RecordHashtagVideo(ctx context.Context, hashtagID uint) {
+	s.record(ctx, trendingHashtagKeyPrefix, strconv.FormatUint(uint64(hashtagID), 10), trendingHashtagVideoWeight)
+}
+
+// record 计入当前分桶，分桶在滑出窗口后自动过期
+func (s *trendingServiceImpl) record(ctx context.Context, prefix, member string, weight float64) {
+	if s.client == nil || member == "" {
+		return
+	}
+
+	bucketSize := s.bucketSize()
+	key := prefix + strconv.FormatInt(time.Now().Unix()/int64(bucketSize/time.Second), 10)
+
+	pipe := s.client.Pipeline()
+	pipe.ZIncrBy(ctx, key, weight, member)
+	pipe.Expire(ctx, key, s.window()+bucketSize)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("记录趋势计数失败", zap.Error(err), zap.String("key", key))
+	}
+}
+
+// Start 启动定时计算
+func (s *trendingServiceImpl) Start(ctx context.Context) {
+	if s.client == nil || s.config.Interval <= 0 {
+		return
+	}
+
+	interval := time.Duration(s.config.Interval) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Refresh(ctx); err != nil {
+				logger.Error("趋势计算失败", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Info("趋势计算任务已启动", zap.Duration("interval", interval))
+}
+
+// Refresh 重新计算热度和排名
+func (s *trendingServiceImpl) Refresh(ctx context.Context) error {
+	if s.client == nil {
+		return nil
+	}
+
+	// 锁在一个周期后自动过期，不主动释放，保证每个周期只计算一次
+	lockTTL := time.Duration(s.config.Interval) * time.Second
+	if lockTTL <= 0 {
+		lockTTL = time.Minute
+	}
+	acquired, err := s.client.SetNX(ctx, trendingLockKey, s.instanceID, lockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+
+	if err := s.refreshHotSearches(ctx); err != nil {
+		return err
+	}
+	return s.refreshHashtags(ctx)
+}
+
+// refreshHotSearches 重新计算热搜热度、趋势标记和排名
+func (s *trendingServiceImpl) refreshHotSearches(ctx context.Context) error {
+	scores, recentBuckets, beforeBuckets, err := s.computeTrends(ctx, trendingSearchKeyPrefix)
+	if err != nil {
+		return err
+	}
+
+	trends := make([]*repository.HotSearchTrend, 0, len(scores))
+	for _, sc := range scores {
+		trends = append(trends, &repository.HotSearchTrend{
+			Keyword: sc.Member,
+			Score:   sc.Score,
+			Label:   s.trendLabel(sc, recentBuckets, beforeBuckets),
+		})
+	}
+
+	if err := s.searchRepo.ApplyHotSearchTrends(ctx, trends, s.topN()); err != nil {
+		return err
+	}
+	logger.Info("热搜趋势已更新", zap.Int("keywords", len(trends)))
+	return nil
+}
+
+// refreshHashtags 重新计算话题热度和热门标记
+func (s *trendingServiceImpl) refreshHashtags(ctx context.Context) error {
+	scores, _, _, err := s.computeTrends(ctx, trendingHashtagKeyPrefix)
+	if err != nil {
+		return err
+	}
+
+	trends := make([]*repository.HashtagTrend, 0, len(scores))
+	for _, sc := range scores {
+		id, err := strconv.ParseUint(sc.Member, 10, 64)
+		if err != nil {
+			continue
+		}
+		trends = append(trends, &repository.HashtagTrend{ID: uint(id), Score: sc.Score})
+	}
+
+	if err := s.hashtagRepo.ApplyHashtagTrends(ctx, trends, s.topN()); err != nil {
+		return err
+	}
+	logger.Info("话题趋势已更新", zap.Int("hashtags", len(trends)))
+	return nil
+}
+
+// computeTrends 聚合滑动窗口内的分桶
+// 热度 = Σ 分桶计数 × 0.5^(分桶距今时长/半衰期)，同时返回近期窗口和更早时段的原始次数
+func (s *trendingServiceImpl) computeTrends(ctx context.Context, prefix string) ([]*trendScore, int, int, error) {
+	bucketSeconds := int64(s.bucketSize() / time.Second)
+	buckets := int(int64(s.window()/time.Second) / bucketSeconds)
+	if buckets < 1 {
+		buckets = 1
+	}
+	recentBuckets := int(int64(s.config.RecentWindow) / bucketSeconds)
+	if recentBuckets < 1 {
+		recentBuckets = 1
+	}
+	if recentBuckets > buckets {
+		recentBuckets = buckets
+	}
+	halfLife := float64(s.config.HalfLife)
+	if halfLife <= 0 {
+		halfLife = float64(int64(s.window() / time.Second))
+	}
+
+	current := time.Now().Unix() / bucketSeconds
+	var decayed, recent, before redis.ZStore
+	for i := 0; i < buckets; i++ {
+		key := prefix + strconv.FormatInt(current-int64(i), 10)
+		decayed.Keys = append(decayed.Keys, key)
+		decayed.Weights = append(decayed.Weights, math.Pow(0.5, float64(int64(i)*bucketSeconds)/halfLife))
+		if i < recentBuckets {
+			recent.Keys = append(recent.Keys, key)
+		} else {
+			before.Keys = append(before.Keys, key)
+		}
+	}
+
+	decayedKey := prefix + "agg:decayed:" + s.instanceID
+	recentKey := prefix + "agg:recent:" + s.instanceID
+	beforeKey := prefix + "agg:before:" + s.instanceID
+	defer s.client.Del(context.Background(), decayedKey, recentKey, beforeKey)
+
+	pipe := s.client.Pipeline()
+	pipe.ZUnionStore(ctx, decayedKey, &decayed)
+	pipe.ZUnionStore(ctx, recentKey, &recent)
+	if len(before.Keys) > 0 {
+		pipe.ZUnionStore(ctx, beforeKey, &before)
+	}
+	top := pipe.ZRevRangeWithScores(ctx, decayedKey, 0, trendingCandidateLimit-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, 0, err
+	}
+
+	members := make([]string, 0, len(top.Val()))
+	scores := make([]*trendScore, 0, len(top.Val()))
+	for _, z := range top.Val() {
+		member, _ := z.Member.(string)
+		members = append(members, member)
+		scores = append(scores, &trendScore{Member: member, Score: z.Score})
+	}
+	if len(members) == 0 {
+		return scores, recentBuckets, buckets - recentBuckets, nil
+	}
+
+	pipe = s.client.Pipeline()
+	recentCounts := pipe.ZMScore(ctx, recentKey, members...)
+	beforeCounts := pipe.ZMScore(ctx, beforeKey, members...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, 0, err
+	}
+	for i, sc := range scores {
+		if v := recentCounts.Val(); i < len(v) {
+			sc.Recent = v[i]
+		}
+		if v := beforeCounts.Val(); i < len(v) {
+			sc.Before = v[i]
+		}
+	}
+
+	return scores, recentBuckets, buckets - recentBuckets, nil
+}
+
+// trendLabel 根据近期速率判断趋势：窗口内此前没有出现过为新上榜，速率明显加快为上升中
+func (s *trendingServiceImpl) trendLabel(sc *trendScore, recentBuckets, beforeBuckets int) string {
+	if sc.Recent < float64(s.config.MinCount) || sc.Recent == 0 {
+		return ""
+	}
+	if sc.Before == 0 {
+		return model.HotSearchLabelNew
+	}
+	if beforeBuckets == 0 {
+		return ""
+	}
+
+	recentRate := sc.Recent / float64(recentBuckets)
+	beforeRate := sc.Before / float64(beforeBuckets)
+	ratio := s.config.RisingRatio
+	if ratio <= 1 {
+		ratio = 3
+	}
+	if recentRate >= beforeRate*ratio {
+		return model.HotSearchLabelRising
+	}
+	return ""
+}
+
+func (s *trendingServiceImpl) bucketSize() time.Duration {
+	if s.config.BucketSize <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(s.config.BucketSize) * time.Second
+}
+
+func (s *trendingServiceImpl) window() time.Duration {
+	if s.config.Window <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(s.config.Window) * time.Second
+}
+
+func (s *trendingServiceImpl) topN() int {
+	if s.config.TopN <= 0 {
+		return 50
+	}
+	return s.config.TopN
+}
@@ -2,34 +2,102 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"microvibe-go/internal/algorithm/recommend"
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+const (
+	// viewSignalTTL 一次观看的去重窗口，窗口内的进度上报合并为一次观看
+	viewSignalTTL = 10 * time.Minute
+	// viewIdleTimeout 超过该时长没有新的进度上报视为离开视频（客户端不上报结束时据此判断划走）
+	viewIdleTimeout = 30 * time.Second
+	// viewSweepInterval 扫描已离开视频的观看的间隔
+	viewSweepInterval = 10 * time.Second
+	// viewSweepBatch 每次扫描处理的观看数量
+	viewSweepBatch = 500
+
+	// viewSignalKeyPrefix 观看记录（Hash：max 最大进度，signaled 是否已发出观看信号）
+	viewSignalKeyPrefix = "video:view:signal:"
+	// viewPendingKey 未结束的观看（ZSet：user_id:video_id -> 判定离开的时间戳）
+	viewPendingKey = "video:view:pending"
+)
+
+// viewSignalScript 合并一次观看的进度上报
+// 记录窗口内的最大播放进度；观看结束（完播或离开）时返回最大进度，每个窗口只返回一次，否则返回 -1。
+// 未结束的观看记入待结束集合，超过空闲时长没有新的上报时由 viewSweepScript 结束
+// KEYS[1] 观看记录；KEYS[2] 待结束集合；ARGV[1] 本次进度；ARGV[2] 窗口时长（秒）；
+// ARGV[3] 是否结束（1/0）；ARGV[4] 判定离开的时间戳；ARGV[5] 待结束集合成员
+var viewSignalScript = redis.NewScript(`
+local pos = tonumber(ARGV[1])
+local max = tonumber(redis.call('HGET', KEYS[1], 'max') or '0')
+if pos > max then
+	max = pos
+	redis.call('HSET', KEYS[1], 'max', max)
+end
+redis.call('EXPIRE', KEYS[1], tonumber(ARGV[2]))
+if redis.call('HEXISTS', KEYS[1], 'signaled') == 1 then
+	return -1
+end
+if ARGV[3] == '1' then
+	redis.call('HSET', KEYS[1], 'signaled', 1)
+	redis.call('ZREM', KEYS[2], ARGV[5])
+	return max
+end
+redis.call('ZADD', KEYS[2], ARGV[4], ARGV[5])
+return -1
+`)
+
+// viewSweepScript 取出已超过空闲时长的观看并标记为已发出信号，返回 [成员, 最大进度, ...]
+// 取出和标记在同一脚本内完成，多实例同时扫描时每次观看只会被一个实例取出
+// KEYS[1] 待结束集合；ARGV[1] 当前时间戳；ARGV[2] 批量大小；ARGV[3] 观看记录键前缀
+var viewSweepScript = redis.NewScript(`
+local members = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+local result = {}
+for _, member in ipairs(members) do
+	redis.call('ZREM', KEYS[1], member)
+	local key = ARGV[3] .. member
+	if redis.call('EXISTS', key) == 1 and redis.call('HSETNX', key, 'signaled', 1) == 1 then
+		table.insert(result, member)
+		table.insert(result, redis.call('HGET', key, 'max') or '0')
+	end
+end
+return result
+`)
+
 // VideoHistoryService 视频播放历史服务层接口
 type VideoHistoryService interface {
-	// ReportProgress 上报播放进度，返回是否已处理为”已看完”
-	ReportProgress(ctx context.Context, userID, videoID uint, position, duration int, finished bool) (bool, error)
+	// ReportProgress 上报播放进度（final 表示离开视频时的最后一次上报，可选；未上报时按空闲超时判断离开），返回是否已处理为”已看完”
+	ReportProgress(ctx context.Context, userID, videoID uint, position, duration int, finished, final bool) (bool, error)
 	// GetHistory 获取用户播放历史
 	GetHistory(ctx context.Context, userID uint, page, pageSize int, finished *bool) ([]*model.VideoHistoryVO, int64, error)
 	// DeleteHistory 删除单条历史
 	DeleteHistory(ctx context.Context, userID, historyID uint) error
 	// ClearHistory 清空历史
 	ClearHistory(ctx context.Context, userID uint) error
+	// Start 启动观看结束扫描（超过空闲时长没有进度上报的观看按最大进度发出观看信号），ctx 取消后停止
+	Start(ctx context.Context)
 }
 
 type videoHistoryServiceImpl struct {
-	historyRepo     repository.VideoHistoryRepository
-	behaviorRepo    repository.BehaviorRepository
-	likeRepo        repository.LikeRepository
-	favoriteRepo    repository.FavoriteRepository
-	followRepo      repository.FollowRepository
-	statsService    VideoStatsService
-	recommendEngine *recommend.Engine
+	historyRepo       repository.VideoHistoryRepository
+	behaviorRepo      repository.BehaviorRepository
+	likeRepo          repository.LikeRepository
+	favoriteRepo      repository.FavoriteRepository
+	followRepo        repository.FollowRepository
+	redisClient       *redis.Client
+	statsService      VideoStatsService
+	recommendEngine   *recommend.Engine
+	experimentService ExperimentService
 }
 
 // NewVideoHistoryService 创建视频播放历史服务实例
@@ -39,6 +107,7 @@ func NewVideoHistoryService(
 	likeRepo repository.LikeRepository,
 	favoriteRepo repository.FavoriteRepository,
 	followRepo repository.FollowRepository,
+	redisClient *redis.Client,
 ) VideoHistoryService {
 	return &videoHistoryServiceImpl{
 		historyRepo:  historyRepo,
@@ -46,6 +115,7 @@ func NewVideoHistoryService(
 		likeRepo:     likeRepo,
 		favoriteRepo: favoriteRepo,
 		followRepo:   followRepo,
+		redisClient:  redisClient,
 	}
 }
 
@@ -59,8 +129,13 @@ func (s *videoHistoryServiceImpl) SetRecommendEngine(engine *recommend.Engine) {
 	s.recommendEngine = engine
 }
 
+// SetExperimentService 设置 A/B 实验服务（为行为记录打实验标记）
+func (s *videoHistoryServiceImpl) SetExperimentService(experimentService ExperimentService) {
+	s.experimentService = experimentService
+}
+
 // ReportProgress 上报播放进度
-func (s *videoHistoryServiceImpl) ReportProgress(ctx context.Context, userID, videoID uint, position, duration int, finished bool) (bool, error) {
+func (s *videoHistoryServiceImpl) ReportProgress(ctx context.Context, userID, videoID uint, position, duration int, finished, final bool) (bool, error) {
 	logger.Debug("上报播放进度", zap.Uint("user_id", userID), zap.Uint("video_id", videoID), zap.Int("position", position))
 
 	// 1. 如果 position 不足但已经达到总时长的 90%，也自动认为完成
@@ -102,7 +177,9 @@ func (s *videoHistoryServiceImpl) ReportProgress(ctx context.Context, userID, vi
 	// 异步记录行为日志，不阻塞进度返回
 	go func() {
 		bgCtx := context.Background()
-		_ = s.behaviorRepo.Create(bgCtx, behavior)
+		if err := s.behaviorRepo.Create(bgCtx, behavior); err == nil && s.experimentService != nil {
+			s.experimentService.TagBehavior(bgCtx, behavior)
+		}
 
 		// 更新用户画像 (兴趣分数)
 		if s.recommendEngine != nil {
@@ -114,11 +191,116 @@ func (s *videoHistoryServiceImpl) ReportProgress(ctx context.Context, userID, vi
 		if s.statsService != nil {
 			_ = s.statsService.RecordPlay(bgCtx, videoID, duration, position, finished)
 		}
+
+		// 一次观看只发出一次观看信号（按整次观看的最大进度判断划走、观看或完播）
+		// 完播或客户端上报离开时立即发出，否则由 Start 启动的扫描在空闲超时后发出
+		if watched, ok := s.viewSignal(bgCtx, userID, videoID, position, finished || final); ok {
+			s.publishView(bgCtx, userID, videoID, watched, finished)
+		}
 	}()
 
 	return finished, nil
 }
 
+// Start 启动观看结束扫描
+func (s *videoHistoryServiceImpl) Start(ctx context.Context) {
+	if s.redisClient == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(viewSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := s.sweepIdleViews(ctx); err != nil {
+				logger.Error("扫描结束的观看失败", zap.Error(err))
+			}
+		}
+	}()
+
+	logger.Info("观看结束扫描已启动", zap.Duration("idle_timeout", viewIdleTimeout))
+}
+
+// viewSignal 合并心跳式的进度上报，观看结束时返回本次观看的最大进度
+// 每个去重窗口内只返回一次；没有 Redis 时直接以本次上报为准
+func (s *videoHistoryServiceImpl) viewSignal(ctx context.Context, userID, videoID uint, position int, ended bool) (int, bool) {
+	if s.redisClient == nil {
+		return position, ended
+	}
+
+	member := fmt.Sprintf("%d:%d", userID, videoID)
+	deadline := time.Now().Add(viewIdleTimeout).Unix()
+	watched, err := viewSignalScript.Run(ctx, s.redisClient, []string{viewSignalKeyPrefix + member, viewPendingKey},
+		position, int(viewSignalTTL.Seconds()), boolFlag(ended), deadline, member).Int()
+	if err != nil {
+		logger.Warn("合并观看进度失败", zap.Error(err), zap.Uint("user_id", userID), zap.Uint("video_id", videoID))
+		return position, ended
+	}
+	if watched < 0 {
+		return 0, false
+	}
+	return watched, true
+}
+
+// sweepIdleViews 结束超过空闲时长没有进度上报的观看，按整次观看的最大进度发出观看信号
+// 快速划走的视频通常只有一两次上报，由此得到负向兴趣信号而不依赖客户端上报离开
+func (s *videoHistoryServiceImpl) sweepIdleViews(ctx context.Context) error {
+	for {
+		result, err := viewSweepScript.Run(ctx, s.redisClient, []string{viewPendingKey},
+			time.Now().Unix(), viewSweepBatch, viewSignalKeyPrefix).StringSlice()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i+1 < len(result); i += 2 {
+			userID, videoID, ok := parseViewMember(result[i])
+			if !ok {
+				continue
+			}
+			watched, _ := strconv.Atoi(result[i+1])
+			s.publishView(ctx, userID, videoID, watched, false)
+		}
+
+		if len(result) < viewSweepBatch*2 {
+			return nil
+		}
+	}
+}
+
+// publishView 发出一次观看信号
+func (s *videoHistoryServiceImpl) publishView(ctx context.Context, userID, videoID uint, watched int, finished bool) {
+	if finished && s.recommendEngine != nil {
+		s.recommendEngine.Feedback(ctx, userID, videoID, recommend.FeedbackFinish)
+	}
+
+	// 发布观看事件（推荐引擎据此实时更新用户兴趣，快速划走记为负向兴趣）
+	_ = event.PublishAsync(ctx, event.NewVideoViewedEvent(videoID, userID, watched, ""))
+}
+
+// parseViewMember 解析待结束集合成员 user_id:video_id
+func parseViewMember(member string) (uint, uint, bool) {
+	userPart, videoPart, found := strings.Cut(member, ":")
+	if !found {
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseUint(userPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	videoID, err := strconv.ParseUint(videoPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint(userID), uint(videoID), true
+}
+
 // GetHistory 获取用户播放历史
 func (s *videoHistoryServiceImpl) GetHistory(ctx context.Context, userID uint, page, pageSize int, finished *bool) ([]*model.VideoHistoryVO, int64, error) {
 	histories, total, err := s.historyRepo.FindByUserID(ctx, userID, page, pageSize, finished)
@@ -189,3 +371,11 @@ func (s *videoHistoryServiceImpl) DeleteHistory(ctx context.Context, userID, his
 func (s *videoHistoryServiceImpl) ClearHistory(ctx context.Context, userID uint) error {
 	return s.historyRepo.ClearAll(ctx, userID)
 }
+
+// boolFlag 布尔值转为脚本参数
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
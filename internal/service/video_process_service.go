@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/event"
+	"microvibe-go/pkg/logger"
+	"microvibe-go/pkg/media"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultVideoProcessAttempts     = 3
+	defaultVideoProcessPollInterval = 2 * time.Second
+	defaultVideoProcessLeaseTimeout = 2 * time.Minute
+
+	// videoProcessBaseBackoff 首次重试的等待时间，之后每次翻倍
+	videoProcessBaseBackoff = 10 * time.Second
+	// videoProcessMaxBackoff 重试等待时间上限
+	videoProcessMaxBackoff = 10 * time.Minute
+)
+
+// VideoProcessStageVO 处理阶段状态
+type VideoProcessStageVO struct {
+	Stage  string `json:"stage"`
+	Status string `json:"status"` // pending, running, retrying, succeeded, failed
+}
+
+// VideoProcessJobVO 处理任务状态
+type VideoProcessJobVO struct {
+	*model.VideoProcessJob
+	Stages []*VideoProcessStageVO `json:"stages"`
+}
+
+// VideoProcessService 视频处理任务服务接口
+// 上传的视频依次经过探测、封面、转码和发布阶段，任务持久化在数据库中，
+// 失败后按指数退避重试，worker 崩溃后由其他 worker 在租约超时后接管
+type VideoProcessService interface {
+	// Enqueue 提交处理任务
+	Enqueue(ctx context.Context, job *model.VideoProcessJob) error
+
+	// GetJobStatus 查询视频最近一次处理任务的状态（仅上传者可查看）
+	GetJobStatus(ctx context.Context, userID, videoID uint) (*VideoProcessJobVO, error)
+
+	// Start 启动 worker，ctx 取消后停止领取新任务
+	Start(ctx context.Context)
+}
+
+type videoProcessServiceImpl struct {
+	jobRepo   repository.VideoProcessJobRepository
+	videoRepo repository.VideoRepository
+	config    *config.Config
+
+	workerPrefix string
+}
+
+// NewVideoProcessService 创建视频处理任务服务
+func NewVideoProcessService(
+	jobRepo repository.VideoProcessJobRepository,
+	videoRepo repository.VideoRepository,
+	cfg *config.Config,
+) VideoProcessService {
+	return &videoProcessServiceImpl{
+		jobRepo:      jobRepo,
+		videoRepo:    videoRepo,
+		config:       cfg,
+		workerPrefix: generateInstanceID(),
+	}
+}
+
+// Enqueue 提交处理任务
+func (s *videoProcessServiceImpl) Enqueue(ctx context.Context, job *model.VideoProcessJob) error {
+	job.Status = model.VideoProcessJobPending
+	job.Stage = model.VideoProcessStageProbe
+	job.Attempts = 0
+	job.MaxAttempts = s.maxAttempts()
+	job.NextRunAt = time.Now()
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		logger.Error("创建视频处理任务失败", zap.Error(err), zap.Uint("video_id", job.VideoID))
+		return errors.New("提交视频处理任务失败")
+	}
+
+	logger.Info("视频处理任务已提交", zap.Uint("job_id", job.ID), zap.Uint("video_id", job.VideoID))
+	return nil
+}
+
+// GetJobStatus 查询处理任务状态
+func (s *videoProcessServiceImpl) GetJobStatus(ctx context.Context, userID, videoID uint) (*VideoProcessJobVO, error) {
+	job, err := s.jobRepo.FindLatestByVideoID(ctx, videoID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, errors.New("处理任务不存在")
+		}
+		logger.Error("查询视频处理任务失败", zap.Error(err), zap.Uint("video_id", videoID))
+		return nil, errors.New("查询处理任务失败")
+	}
+	if job.UserID != userID {
+		return nil, errors.New("无权查看该任务")
+	}
+
+	return &VideoProcessJobVO{
+		VideoProcessJob: job,
+		Stages:          buildStageStatus(job),
+	}, nil
+}
+
+// Start 启动 worker
+func (s *videoProcessServiceImpl) Start(ctx context.Context) {
+	workers := s.config.VideoProcessing.Workers
+	for i := 0; i < workers; i++ {
+		go s.workerLoop(ctx, fmt.Sprintf("%s-%d", s.workerPrefix, i))
+	}
+	logger.Info("视频处理 worker 已启动", zap.Int("workers", workers))
+}
+
+// workerLoop 循环领取并执行任务
+func (s *videoProcessServiceImpl) workerLoop(ctx context.Context, workerID string) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := s.jobRepo.Claim(ctx, workerID, s.leaseTimeout())
+		if err != nil {
+			if !pkgerrors.IsNotFound(err) {
+				logger.Error("领取视频处理任务失败", zap.Error(err), zap.String("worker_id", workerID))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.pollInterval()):
+			}
+			continue
+		}
+
+		s.runJob(ctx, workerID, job)
+	}
+}
+
+// runJob 从任务的当前阶段开始依次执行剩余阶段
+func (s *videoProcessServiceImpl) runJob(ctx context.Context, workerID string, job *model.VideoProcessJob) {
+	logger.Info("开始处理视频",
+		zap.Uint("job_id", job.ID),
+		zap.Uint("video_id", job.VideoID),
+		zap.String("stage", job.Stage),
+		zap.Int("attempt", job.Attempts))
+
+	// 执行期间定期续约，避免长时间转码被其他 worker 误判为崩溃
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go s.heartbeat(heartbeatCtx, workerID, job.ID)
+
+	for {
+		if err := s.runStage(ctx, job); err != nil {
+			s.handleStageFailure(ctx, workerID, job, err)
+			return
+		}
+
+		next := nextVideoProcessStage(job.Stage)
+		if next == "" {
+			now := time.Now()
+			if err := s.jobRepo.Update(ctx, job.ID, workerID, map[string]interface{}{
+				"status":      model.VideoProcessJobSucceeded,
+				"last_error":  "",
+				"locked_by":   "",
+				"finished_at": now,
+			}); err != nil {
+				logger.Error("更新视频处理任务失败", zap.Error(err), zap.Uint("job_id", job.ID))
+				return
+			}
+			logger.Info("视频处理完成", zap.Uint("job_id", job.ID), zap.Uint("video_id", job.VideoID))
+			return
+		}
+
+		// 保存阶段产出并进入下一阶段（本次领取即为下一阶段的第一次尝试）
+		job.Stage = next
+		job.Attempts = 1
+		if err := s.jobRepo.Update(ctx, job.ID, workerID, map[string]interface{}{
+			"stage":      job.Stage,
+			"attempts":   job.Attempts,
+			"last_error": "",
+			"duration":   job.Duration,
+			"width":      job.Width,
+			"height":     job.Height,
+			"renditions": job.Renditions,
+		}); err != nil {
+			// 租约已被其他 worker 接管，放弃本次执行
+			logger.Warn("视频处理任务已失去租约", zap.Error(err), zap.Uint("job_id", job.ID))
+			return
+		}
+	}
+}
+
+// runStage 执行任务的当前阶段
+func (s *videoProcessServiceImpl) runStage(ctx context.Context, job *model.VideoProcessJob) error {
+	switch job.Stage {
+	case model.VideoProcessStageProbe:
+		duration, width, height, err := media.GetVideoMetadata(job.SourcePath)
+		if err != nil {
+			return err
+		}
+		job.Duration, job.Width, job.Height = duration, width, height
+		return nil
+
+	case model.VideoProcessStageCover:
+		// 上传了自定义封面时无需抽帧
+		if job.CustomCover {
+			if _, err := os.Stat(job.CoverPath); err == nil {
+				return nil
+			}
+		}
+		return media.ExtractCover(job.SourcePath, job.CoverPath)
+
+	case model.VideoProcessStageTranscode:
+		renditions, err := media.TranscodeToABRHLS(job.SourcePath, job.HLSDir, job.Width, job.Height, s.hlsOptions())
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(renditions))
+		for _, r := range renditions {
+			names = append(names, r.Name)
+		}
+		job.Renditions = strings.Join(names, ",")
+		return nil
+
+	case model.VideoProcessStagePublish:
+		now := time.Now()
+		if err := s.videoRepo.UpdateFields(ctx, job.VideoID, map[string]interface{}{
+			"status":       model.VideoStatusPublished,
+			"video_url":    job.VideoURL,
+			"cover_url":    job.CoverURL,
+			"duration":     job.Duration,
+			"width":        job.Width,
+			"height":       job.Height,
+			"renditions":   job.Renditions,
+			"published_at": now,
+		}); err != nil {
+			return err
+		}
+
+		// 发布后删除上传的原始视频文件以节省空间（不属于本服务的文件保留）
+		if !job.KeepSource {
+			if err := os.Remove(job.SourcePath); err != nil && !os.IsNotExist(err) {
+				logger.Warn("删除原始视频文件失败", zap.Error(err), zap.String("path", job.SourcePath))
+			}
+		}
+
+		// 发布视频发布事件（直播回放据此回填回放地址并通知粉丝）
+		if video, err := s.videoRepo.FindByID(ctx, job.VideoID); err == nil {
+			var categoryID uint
+			if video.CategoryID != nil {
+				categoryID = *video.CategoryID
+			}
+			_ = event.PublishAsync(context.Background(), event.NewVideoPublishedEvent(video.ID, video.UserID, video.Title, categoryID))
+		}
+		return nil
+	}
+
+	return fmt.Errorf("未知的处理阶段: %s", job.Stage)
+}
+
+// handleStageFailure 阶段失败：未超过最大尝试次数时退避重试，否则标记任务和视频为失败
+func (s *videoProcessServiceImpl) handleStageFailure(ctx context.Context, workerID string, job *model.VideoProcessJob, stageErr error) {
+	if job.Attempts < job.MaxAttempts {
+		delay := videoProcessBackoff(job.Attempts)
+		logger.Warn("视频处理阶段失败，稍后重试",
+			zap.Error(stageErr),
+			zap.Uint("job_id", job.ID),
+			zap.String("stage", job.Stage),
+			zap.Int("attempt", job.Attempts),
+			zap.Duration("retry_in", delay))
+
+		if err := s.jobRepo.Update(ctx, job.ID, workerID, map[string]interface{}{
+			"status":      model.VideoProcessJobPending,
+			"last_error":  stageErr.Error(),
+			"next_run_at": time.Now().Add(delay),
+			"locked_by":   "",
+		}); err != nil {
+			logger.Error("更新视频处理任务失败", zap.Error(err), zap.Uint("job_id", job.ID))
+		}
+		return
+	}
+
+	logger.Error("视频处理失败，已达最大尝试次数",
+		zap.Error(stageErr),
+		zap.Uint("job_id", job.ID),
+		zap.Uint("video_id", job.VideoID),
+		zap.String("stage", job.Stage))
+
+	if err := s.jobRepo.Update(ctx, job.ID, workerID, map[string]interface{}{
+		"status":      model.VideoProcessJobFailed,
+		"last_error":  stageErr.Error(),
+		"locked_by":   "",
+		"finished_at": time.Now(),
+	}); err != nil {
+		logger.Error("更新视频处理任务失败", zap.Error(err), zap.Uint("job_id", job.ID))
+		return
+	}
+
+	if err := s.videoRepo.UpdateFields(ctx, job.VideoID, map[string]interface{}{
+		"status": model.VideoStatusProcessFailed,
+	}); err != nil {
+		logger.Error("更新视频状态失败", zap.Error(err), zap.Uint("video_id", job.VideoID))
+	}
+}
+
+// heartbeat 定期续约任务
+func (s *videoProcessServiceImpl) heartbeat(ctx context.Context, workerID string, jobID uint) {
+	ticker := time.NewTicker(s.leaseTimeout() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.jobRepo.Renew(ctx, jobID, workerID); err != nil && ctx.Err() == nil {
+				logger.Warn("视频处理任务续约失败", zap.Error(err), zap.Uint("job_id", jobID))
+			}
+		}
+	}
+}
+
+// hlsOptions 根据配置构造转码参数（未配置码率阶梯时使用默认阶梯）
+func (s *videoProcessServiceImpl) hlsOptions() media.HLSOptions {
+	cfg := s.config.VideoProcessing
+	opts := media.HLSOptions{
+		SegmentDuration: cfg.SegmentDuration,
+		FMP4:            cfg.FMP4,
+	}
+	for _, r := range cfg.Renditions {
+		if r.Name == "" || r.Height <= 0 || r.VideoBitrate <= 0 {
+			continue
+		}
+		audio := r.AudioBitrate
+		if audio <= 0 {
+			audio = 128
+		}
+		opts.Renditions = append(opts.Renditions, media.Rendition{
+			Name:         r.Name,
+			Height:       r.Height,
+			VideoBitrate: r.VideoBitrate,
+			AudioBitrate: audio,
+		})
+	}
+	return opts
+}
+
+func (s *videoProcessServiceImpl) maxAttempts() int {
+	if n := s.config.VideoProcessing.MaxAttempts; n > 0 {
+		return n
+	}
+	return defaultVideoProcessAttempts
+}
+
+func (s *videoProcessServiceImpl) pollInterval() time.Duration {
+	if n := s.config.VideoProcessing.PollInterval; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return defaultVideoProcessPollInterval
+}
+
+func (s *videoProcessServiceImpl) leaseTimeout() time.Duration {
+	if n := s.config.VideoProcessing.LeaseTimeout; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return defaultVideoProcessLeaseTimeout
+}
+
+// videoProcessBackoff 第 attempt 次失败后的重试等待时间
+func videoProcessBackoff(attempt int) time.Duration {
+	delay := videoProcessBaseBackoff
+	for i := 1; i < attempt && delay < videoProcessMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > videoProcessMaxBackoff {
+		delay = videoProcessMaxBackoff
+	}
+	return delay
+}
+
+// nextVideoProcessStage 返回下一阶段，已是最后阶段时返回空
+func nextVideoProcessStage(stage string) string {
+	for i, s := range model.VideoProcessStages {
+		if s == stage && i+1 < len(model.VideoProcessStages) {
+			return model.VideoProcessStages[i+1]
+		}
+	}
+	return ""
+}
+
+// buildStageStatus 根据任务当前阶段推导各阶段状态
+func buildStageStatus(job *model.VideoProcessJob) []*VideoProcessStageVO {
+	current := -1
+	for i, stage := range model.VideoProcessStages {
+		if stage == job.Stage {
+			current = i
+			break
+		}
+	}
+
+	stages := make([]*VideoProcessStageVO, 0, len(model.VideoProcessStages))
+	for i, stage := range model.VideoProcessStages {
+		status := model.VideoProcessJobPending
+		switch {
+		case job.Status == model.VideoProcessJobSucceeded || i < current:
+			status = model.VideoProcessJobSucceeded
+		case i == current && job.Status == model.VideoProcessJobPending && job.Attempts > 0:
+			status = "retrying"
+		case i == current:
+			status = job.Status
+		}
+		stages = append(stages, &VideoProcessStageVO{Stage: stage, Status: status})
+	}
+	return stages
+}
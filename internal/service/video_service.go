@@ -9,6 +9,7 @@ import (
 	"microvibe-go/internal/model"
 	"microvibe-go/internal/repository"
 	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/event"
 	"microvibe-go/pkg/logger"
 	"strings"
 	"time"
@@ -44,8 +45,8 @@ type VideoService interface {
 	LikeVideo(ctx context.Context, userID, videoID uint) error
 	// UnlikeVideo 取消点赞
 	UnlikeVideo(ctx context.Context, userID, videoID uint) error
-	// FavoriteVideo 收藏视频
-	FavoriteVideo(ctx context.Context, userID, videoID uint) error
+	// FavoriteVideo 收藏视频（folderID 为 nil 时收藏到默认收藏夹）
+	FavoriteVideo(ctx context.Context, userID, videoID uint, folderID *uint) error
 	// UnfavoriteVideo 取消收藏
 	UnfavoriteVideo(ctx context.Context, userID, videoID uint) error
 	// UpdateVideoStatus 更新视频状态 (审核)
@@ -69,6 +70,7 @@ type videoServiceImpl struct {
 	favoriteRepo    repository.FavoriteRepository
 	followRepo      repository.FollowRepository
 	userRepo        repository.UserRepository
+	folderRepo      repository.FavoriteFolderRepository
 	cfg             *config.Config
 	hashtagService  HashtagService
 	messageService  MessageService
@@ -98,6 +100,11 @@ func (s *videoServiceImpl) SetUserRepo(userRepo repository.UserRepository) {
 	s.userRepo = userRepo
 }
 
+// SetFavoriteFolderRepo 设置收藏夹 Repository（延迟注入，用于收藏到指定收藏夹）
+func (s *videoServiceImpl) SetFavoriteFolderRepo(folderRepo repository.FavoriteFolderRepository) {
+	s.folderRepo = folderRepo
+}
+
 // SetHashtagService 设置话题服务（用于延迟注入避免循环依赖）
 func (s *videoServiceImpl) SetHashtagService(hashtagService HashtagService) {
 	s.hashtagService = hashtagService
@@ -133,6 +140,9 @@ type CreateVideoRequest struct {
 	Tags         []string `json:"tags"`
 	IsPublic     *bool    `json:"is_public"`
 	AllowComment *bool    `json:"allow_comment"`
+
+	// Processing 上传的视频尚待处理（转码完成后由处理任务发布）
+	Processing bool `json:"-"`
 }
 
 // CreateVideo 创建视频
@@ -164,6 +174,10 @@ func (s *videoServiceImpl) CreateVideo(ctx context.Context, req *CreateVideoRequ
 	if req.AllowComment != nil {
 		video.AllowComment = *req.AllowComment
 	}
+	if req.Processing {
+		video.Status = model.VideoStatusProcessing
+		video.PublishedAt = nil
+	}
 
 	// 处理标签 - 将标签数组转换为逗号分隔的字符串
 	if len(req.Tags) > 0 {
@@ -330,7 +344,30 @@ func (s *videoServiceImpl) GetUserFavoriteVideosWithPrivacy(ctx context.Context,
 		return nil, 0, pkgerrors.NewAppError(pkgerrors.CodeForbidden, "该用户的收藏列表未公开")
 	}
 
-	return s.GetUserFavoriteVideos(ctx, targetUserID, page, pageSize)
+	// 他人只能看到默认收藏夹和公开收藏夹中的视频
+	limit := pageSize
+	offset := (page - 1) * pageSize
+
+	favorites, err := s.favoriteRepo.FindPublicByUserID(ctx, targetUserID, limit, offset)
+	if err != nil {
+		logger.Error("获取用户公开收藏列表失败", zap.Error(err), zap.Uint("user_id", targetUserID))
+		return nil, 0, pkgerrors.ConvertDBError(err)
+	}
+
+	total, err := s.favoriteRepo.CountPublicByUserID(ctx, targetUserID)
+	if err != nil {
+		logger.Error("获取用户公开收藏总数失败", zap.Error(err), zap.Uint("user_id", targetUserID))
+		return nil, 0, pkgerrors.ConvertDBError(err)
+	}
+
+	videos := make([]*model.Video, 0, len(favorites))
+	for _, f := range favorites {
+		if f.Video != nil {
+			videos = append(videos, f.Video)
+		}
+	}
+
+	return videos, total, nil
 }
 
 // GetUserLikedVideos 获取用户点赞的视频列表
@@ -532,9 +569,13 @@ func (s *videoServiceImpl) LikeVideo(ctx context.Context, userID, videoID uint)
 				VideoID: videoID,
 				Action:  2, // 2-点赞
 			})
+			s.recommendEngine.Feedback(context.Background(), userID, videoID, recommend.FeedbackLike)
 		}()
 	}
 
+	// 发布点赞事件（推荐引擎据此实时更新用户兴趣）
+	go func() { _ = event.PublishAsync(context.Background(), event.NewVideoLikedEvent(videoID, userID)) }()
+
 	// 发送通知（异步）
 	if s.messageService != nil {
 		go func() {
@@ -583,7 +624,7 @@ func (s *videoServiceImpl) UnlikeVideo(ctx context.Context, userID, videoID uint
 }
 
 // FavoriteVideo 收藏视频
-func (s *videoServiceImpl) FavoriteVideo(ctx context.Context, userID, videoID uint) error {
+func (s *videoServiceImpl) FavoriteVideo(ctx context.Context, userID, videoID uint, folderID *uint) error {
 	logger.Info("收藏视频", zap.Uint("user_id", userID), zap.Uint("video_id", videoID))
 
 	// 检查是否已收藏
@@ -607,10 +648,26 @@ func (s *videoServiceImpl) FavoriteVideo(ctx context.Context, userID, videoID ui
 		return errors.New("操作失败")
 	}
 
+	// 检查目标收藏夹归属
+	if folderID != nil && s.folderRepo != nil {
+		folder, err := s.folderRepo.FindByID(ctx, *folderID)
+		if err != nil {
+			if pkgerrors.IsNotFound(err) {
+				return errors.New("收藏夹不存在")
+			}
+			logger.Error("获取收藏夹失败", zap.Error(err), zap.Uint("folder_id", *folderID))
+			return errors.New("操作失败")
+		}
+		if folder.UserID != userID {
+			return errors.New("收藏夹不存在")
+		}
+	}
+
 	// 创建收藏记录
 	favorite := &model.Favorite{
-		UserID:  userID,
-		VideoID: videoID,
+		UserID:   userID,
+		VideoID:  videoID,
+		FolderID: folderID,
 	}
 
 	if err := s.favoriteRepo.Create(ctx, favorite); err != nil {
@@ -739,6 +796,12 @@ func (s *videoServiceImpl) enrichVideo(video *model.Video, followMap map[uint]bo
 	vo.VideoURL = s.fullURL(video.VideoURL)
 	vo.CoverURL = s.fullURL(video.CoverURL)
 
+	// 多码率视频的 VideoURL 即主播放列表
+	if video.Renditions != "" {
+		vo.MasterURL = vo.VideoURL
+		vo.Renditions = strings.Split(video.Renditions, ",")
+	}
+
 	// 处理作者信息
 	if video.User != nil {
 		isFollowed := false
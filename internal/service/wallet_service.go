@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"microvibe-go/internal/config"
+	"microvibe-go/internal/model"
+	"microvibe-go/internal/repository"
+	pkgerrors "microvibe-go/pkg/errors"
+	"microvibe-go/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultPlatformRate 默认平台抽成比例
+const defaultPlatformRate = 0.3
+
+// RechargeRequest 充值请求
+type RechargeRequest struct {
+	UserID    uint   `json:"user_id" binding:"required"`           // 充值用户ID
+	Amount    int64  `json:"amount" binding:"required,min=1"`      // 充值金额（虚拟币）
+	RequestID string `json:"request_id" binding:"required,max=64"` // 客户端请求ID（幂等键）
+	Remark    string `json:"remark" binding:"omitempty,max=200"`   // 备注
+}
+
+// TopUpRequest 用户充值请求
+type TopUpRequest struct {
+	Amount    int64  `json:"amount" binding:"required,min=1,max=1000000"` // 充值金额（虚拟币）
+	RequestID string `json:"request_id" binding:"required,max=64"`        // 客户端请求ID（幂等键，重试时保持不变）
+}
+
+// SpendRequest 消费请求
+type SpendRequest struct {
+	Amount    int64  `json:"amount" binding:"required,min=1"`      // 消费金额（虚拟币）
+	RequestID string `json:"request_id" binding:"required,max=64"` // 客户端请求ID（幂等键，重试时保持不变）
+	BizType   string `json:"biz_type" binding:"required,max=32"`   // 业务类型
+	BizID     uint   `json:"biz_id"`                               // 业务ID
+	Remark    string `json:"remark" binding:"omitempty,max=200"`   // 备注
+}
+
+// WalletService 钱包服务接口
+type WalletService interface {
+	// GetWallet 获取用户钱包（未开通时返回零余额钱包）
+	GetWallet(ctx context.Context, userID uint) (*model.Wallet, error)
+
+	// Recharge 充值（按 user_id + request_id 幂等）
+	Recharge(ctx context.Context, req *RechargeRequest) (*model.WalletTransaction, error)
+
+	// TopUp 用户为自己充值（按 user_id + request_id 幂等）
+	TopUp(ctx context.Context, userID uint, req *TopUpRequest) (*model.WalletTransaction, error)
+
+	// Spend 消费扣费（按 user_id + request_id 幂等）
+	Spend(ctx context.Context, userID uint, req *SpendRequest) (*model.WalletTransaction, error)
+
+	// ListTransactions 获取交易记录
+	ListTransactions(ctx context.Context, userID uint, page, pageSize int) ([]*model.WalletTransaction, int64, error)
+
+	// PayGift 送礼扣费并创建送礼记录（按 user_id + request_id 幂等）
+	// 返回的 bool 表示是否为重复请求（重复请求返回首次创建的送礼记录，不再扣费）
+	PayGift(ctx context.Context, requestID string, record *model.LiveGiftRecord) (*model.LiveGiftRecord, bool, error)
+}
+
+type walletServiceImpl struct {
+	walletRepo repository.WalletRepository
+	giftRepo   repository.LiveGiftRepository
+	cfg        *config.Config
+}
+
+// NewWalletService 创建钱包服务
+func NewWalletService(
+	walletRepo repository.WalletRepository,
+	giftRepo repository.LiveGiftRepository,
+	cfg *config.Config,
+) WalletService {
+	return &walletServiceImpl{
+		walletRepo: walletRepo,
+		giftRepo:   giftRepo,
+		cfg:        cfg,
+	}
+}
+
+// GetWallet 获取用户钱包
+func (s *walletServiceImpl) GetWallet(ctx context.Context, userID uint) (*model.Wallet, error) {
+	wallet, err := s.walletRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return &model.Wallet{UserID: userID}, nil
+		}
+		logger.Error("查询钱包失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, errors.New("查询钱包失败")
+	}
+	return wallet, nil
+}
+
+// Recharge 充值
+func (s *walletServiceImpl) Recharge(ctx context.Context, req *RechargeRequest) (*model.WalletTransaction, error) {
+	// 1. 幂等：相同请求ID直接返回首次结果
+	if txn, err := s.findReplay(ctx, req.UserID, req.RequestID, model.WalletTxnTypeRecharge); txn != nil || err != nil {
+		return txn, err
+	}
+
+	// 2. 写交易与分录
+	txn := &model.WalletTransaction{
+		UserID:    req.UserID,
+		RequestID: req.RequestID,
+		Type:      model.WalletTxnTypeRecharge,
+		Amount:    req.Amount,
+		Remark:    req.Remark,
+	}
+	if err := s.walletRepo.Recharge(ctx, txn); err != nil {
+		// 并发的相同请求被唯一索引拦截，返回先完成的那一笔
+		if pkgerrors.IsDuplicateKey(err) {
+			return s.findReplay(ctx, req.UserID, req.RequestID, model.WalletTxnTypeRecharge)
+		}
+		logger.Error("充值失败", zap.Error(err), zap.Uint("user_id", req.UserID), zap.String("request_id", req.RequestID))
+		return nil, errors.New("充值失败")
+	}
+
+	logger.Info("充值成功",
+		zap.Uint("user_id", req.UserID),
+		zap.Int64("amount", req.Amount),
+		zap.String("request_id", req.RequestID))
+
+	return txn, nil
+}
+
+// TopUp 用户充值
+func (s *walletServiceImpl) TopUp(ctx context.Context, userID uint, req *TopUpRequest) (*model.WalletTransaction, error) {
+	return s.Recharge(ctx, &RechargeRequest{
+		UserID:    userID,
+		Amount:    req.Amount,
+		RequestID: req.RequestID,
+		Remark:    "用户充值",
+	})
+}
+
+// Spend 消费扣费
+func (s *walletServiceImpl) Spend(ctx context.Context, userID uint, req *SpendRequest) (*model.WalletTransaction, error) {
+	// 1. 幂等：相同请求ID直接返回首次结果，不重复扣费
+	if txn, err := s.findReplay(ctx, userID, req.RequestID, model.WalletTxnTypeSpend); txn != nil || err != nil {
+		return txn, err
+	}
+
+	// 2. 扣费并写交易与分录
+	txn := &model.WalletTransaction{
+		UserID:    userID,
+		RequestID: req.RequestID,
+		Type:      model.WalletTxnTypeSpend,
+		Amount:    req.Amount,
+		BizType:   req.BizType,
+		BizID:     req.BizID,
+		Remark:    req.Remark,
+	}
+	if err := s.walletRepo.Spend(ctx, txn); err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, errors.New("余额不足")
+		}
+		// 并发的相同请求被唯一索引拦截，返回先完成的那一笔
+		if pkgerrors.IsDuplicateKey(err) {
+			return s.findReplay(ctx, userID, req.RequestID, model.WalletTxnTypeSpend)
+		}
+		logger.Error("消费扣费失败", zap.Error(err), zap.Uint("user_id", userID), zap.String("request_id", req.RequestID))
+		return nil, errors.New("扣费失败")
+	}
+
+	logger.Info("消费扣费成功",
+		zap.Uint("user_id", userID),
+		zap.Int64("amount", req.Amount),
+		zap.String("biz_type", req.BizType),
+		zap.String("request_id", req.RequestID))
+
+	return txn, nil
+}
+
+// ListTransactions 获取交易记录
+func (s *walletServiceImpl) ListTransactions(ctx context.Context, userID uint, page, pageSize int) ([]*model.WalletTransaction, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	txns, total, err := s.walletRepo.ListTransactions(ctx, userID, page, pageSize)
+	if err != nil {
+		logger.Error("查询交易记录失败", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, 0, errors.New("查询交易记录失败")
+	}
+
+	return txns, total, nil
+}
+
+// PayGift 送礼扣费
+func (s *walletServiceImpl) PayGift(ctx context.Context, requestID string, record *model.LiveGiftRecord) (*model.LiveGiftRecord, bool, error) {
+	if requestID == "" {
+		requestID = generateRequestID()
+	}
+
+	// 1. 幂等：相同请求ID直接返回首次创建的送礼记录
+	if existing, err := s.findGiftReplay(ctx, record.UserID, requestID); existing != nil || err != nil {
+		return existing, existing != nil, err
+	}
+
+	// 2. 按平台抽成比例计算主播收益
+	txn := &model.WalletTransaction{
+		UserID:    record.UserID,
+		RequestID: requestID,
+		Type:      model.WalletTxnTypeGift,
+		Amount:    record.TotalValue,
+		BizType:   "live_gift",
+		Remark:    fmt.Sprintf("直播间%d送出%s x%d", record.LiveID, record.GiftName, record.Quantity),
+	}
+	streamerShare := record.TotalValue - int64(float64(record.TotalValue)*s.platformRate())
+
+	// 3. 扣费、分账、写送礼记录和直播间统计在同一事务内完成
+	if err := s.walletRepo.SpendForGift(ctx, txn, record, streamerShare); err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			return nil, false, errors.New("余额不足")
+		}
+		if pkgerrors.IsDuplicateKey(err) {
+			existing, err := s.findGiftReplay(ctx, record.UserID, requestID)
+			return existing, existing != nil, err
+		}
+		logger.Error("送礼扣费失败", zap.Error(err), zap.Uint("user_id", record.UserID), zap.String("request_id", requestID))
+		return nil, false, errors.New("送礼失败")
+	}
+
+	return record, false, nil
+}
+
+// findReplay 查询已存在的同请求ID交易，类型不一致视为请求ID冲突
+func (s *walletServiceImpl) findReplay(ctx context.Context, userID uint, requestID, txnType string) (*model.WalletTransaction, error) {
+	txn, err := s.walletRepo.FindTransactionByRequestID(ctx, userID, requestID)
+	if err != nil {
+		if pkgerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		logger.Error("查询交易失败", zap.Error(err), zap.Uint("user_id", userID), zap.String("request_id", requestID))
+		return nil, errors.New("查询交易失败")
+	}
+	if txn.Type != txnType {
+		return nil, errors.New("请求ID已被使用")
+	}
+	return txn, nil
+}
+
+// findGiftReplay 查询已存在的同请求ID送礼记录
+func (s *walletServiceImpl) findGiftReplay(ctx context.Context, userID uint, requestID string) (*model.LiveGiftRecord, error) {
+	txn, err := s.findReplay(ctx, userID, requestID, model.WalletTxnTypeGift)
+	if txn == nil || err != nil {
+		return nil, err
+	}
+
+	record, err := s.giftRepo.FindGiftRecordByID(ctx, txn.BizID)
+	if err != nil {
+		logger.Error("查询送礼记录失败", zap.Error(err), zap.Uint("record_id", txn.BizID))
+		return nil, errors.New("查询送礼记录失败")
+	}
+	return record, nil
+}
+
+// platformRate 平台抽成比例（配置非法时使用默认值）
+func (s *walletServiceImpl) platformRate() float64 {
+	if s.cfg == nil {
+		return defaultPlatformRate
+	}
+	rate := s.cfg.Wallet.PlatformRate
+	if rate < 0 || rate > 1 {
+		return defaultPlatformRate
+	}
+	return rate
+}
+
+// generateRequestID 生成服务端请求ID（客户端未提供幂等键时使用）
+func generateRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// 如果随机数生成失败，使用时间戳作为备选方案
+		return fmt.Sprintf("srv_%d", time.Now().UnixNano())
+	}
+	return "srv_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,141 @@
+// Package abtest 实验分流
+//
+// 采用分层分流模型：每个层把用户哈希到 100 个流量桶，同一层内的实验占用互不重叠的
+// 流量区间（互斥），不同层使用不同的哈希种子（正交）。命中实验的用户再按实验自身的哈希种子
+// 根据变体权重分配到某个变体。分流只依赖用户 ID 和实验定义，不需要存储分配结果。
+//
+// 实验的流量区间在启动时通过 Allocate 分配并固定下来，同层其他实验的启停不会改变已运行
+// 实验的用户分组。
+package abtest
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// TrafficBuckets 每层的流量桶数量（实验流量以百分比表示）
+const TrafficBuckets = 100
+
+// Experiment 参与分流的实验
+type Experiment struct {
+	ID       uint
+	Layer    string
+	Offset   int // 在层内占用的起始流量桶
+	Traffic  int // 在层内占用的流量百分比（1-100）
+	Variants []Variant
+}
+
+// Variant 实验变体
+type Variant struct {
+	ID     uint
+	Weight int // 实验内的流量权重
+}
+
+// Assignment 用户命中的实验变体
+type Assignment struct {
+	ExperimentID uint
+	VariantID    uint
+}
+
+// Bucket 将用户按哈希种子映射到 [0, buckets) 中的一个桶
+func Bucket(seed string, userID uint, buckets int) int {
+	if buckets <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	h.Write([]byte{':'})
+	h.Write([]byte(strconv.FormatUint(uint64(userID), 10)))
+	return int(h.Sum64() % uint64(buckets))
+}
+
+// Allocate 在层内为实验分配一段连续的空闲流量区间，返回起始桶
+// occupied 为同层运行中实验占用的区间，没有足够的连续空闲流量时返回 false
+func Allocate(occupied []Experiment, traffic int) (int, bool) {
+	if traffic <= 0 || traffic > TrafficBuckets {
+		return 0, false
+	}
+
+	used := make([]bool, TrafficBuckets)
+	for _, exp := range occupied {
+		for b := exp.Offset; b < exp.Offset+exp.Traffic && b < TrafficBuckets; b++ {
+			if b >= 0 {
+				used[b] = true
+			}
+		}
+	}
+
+	free := 0
+	for b := 0; b < TrafficBuckets; b++ {
+		if used[b] {
+			free = 0
+			continue
+		}
+		free++
+		if free == traffic {
+			return b - traffic + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Assign 计算用户命中的实验变体，每层至多命中一个实验
+// 同层实验的流量区间重叠时，ID 较小的实验优先
+// 结果按层名排序，便于调用方按固定顺序合并实验参数
+func Assign(userID uint, experiments []Experiment) []Assignment {
+	layers := make(map[string][]Experiment)
+	for _, exp := range experiments {
+		layers[exp.Layer] = append(layers[exp.Layer], exp)
+	}
+
+	names := make([]string, 0, len(layers))
+	for name := range layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	assignments := make([]Assignment, 0, len(names))
+	for _, name := range names {
+		exps := layers[name]
+		sort.Slice(exps, func(i, j int) bool { return exps[i].ID < exps[j].ID })
+
+		bucket := Bucket("layer:"+name, userID, TrafficBuckets)
+		for _, exp := range exps {
+			if bucket < exp.Offset || bucket >= exp.Offset+exp.Traffic {
+				continue
+			}
+			if variantID, ok := pickVariant(userID, exp); ok {
+				assignments = append(assignments, Assignment{ExperimentID: exp.ID, VariantID: variantID})
+			}
+			break
+		}
+	}
+
+	return assignments
+}
+
+// pickVariant 按权重为命中实验的用户选择变体
+func pickVariant(userID uint, exp Experiment) (uint, bool) {
+	total := 0
+	for _, v := range exp.Variants {
+		if v.Weight > 0 {
+			total += v.Weight
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	bucket := Bucket("experiment:"+strconv.FormatUint(uint64(exp.ID), 10), userID, total)
+	for _, v := range exp.Variants {
+		if v.Weight <= 0 {
+			continue
+		}
+		if bucket < v.Weight {
+			return v.ID, true
+		}
+		bucket -= v.Weight
+	}
+	return 0, false
+}
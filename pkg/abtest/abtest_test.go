@@ -0,0 +1,129 @@
+package abtest_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"microvibe-go/pkg/abtest"
+)
+
+func TestBucket_Deterministic(t *testing.T) {
+	for userID := uint(1); userID <= 100; userID++ {
+		a := abtest.Bucket("layer:feed", userID, abtest.TrafficBuckets)
+		b := abtest.Bucket("layer:feed", userID, abtest.TrafficBuckets)
+		if a != b {
+			t.Fatalf("用户 %d 两次分桶结果不同: %d, %d", userID, a, b)
+		}
+		if a < 0 || a >= abtest.TrafficBuckets {
+			t.Fatalf("用户 %d 分桶越界: %d", userID, a)
+		}
+	}
+}
+
+func TestAssign_Deterministic(t *testing.T) {
+	experiments := []abtest.Experiment{
+		{ID: 1, Layer: "recall", Traffic: 50, Variants: []abtest.Variant{{ID: 11, Weight: 1}, {ID: 12, Weight: 1}}},
+		{ID: 2, Layer: "rank", Traffic: 100, Variants: []abtest.Variant{{ID: 21, Weight: 1}, {ID: 22, Weight: 1}}},
+	}
+
+	for userID := uint(1); userID <= 100; userID++ {
+		if a, b := abtest.Assign(userID, experiments), abtest.Assign(userID, experiments); !reflect.DeepEqual(a, b) {
+			t.Fatalf("用户 %d 两次分流结果不同: %v, %v", userID, a, b)
+		}
+	}
+}
+
+func TestAssign_LayerExclusive(t *testing.T) {
+	experiments := []abtest.Experiment{
+		{ID: 1, Layer: "feed", Offset: 0, Traffic: 30, Variants: []abtest.Variant{{ID: 11, Weight: 1}}},
+		{ID: 2, Layer: "feed", Offset: 30, Traffic: 30, Variants: []abtest.Variant{{ID: 21, Weight: 1}}},
+	}
+
+	counts := make(map[uint]int)
+	const users = 20000
+	for userID := uint(1); userID <= users; userID++ {
+		assignments := abtest.Assign(userID, experiments)
+		if len(assignments) > 1 {
+			t.Fatalf("用户 %d 在同一层命中了多个实验: %v", userID, assignments)
+		}
+		for _, a := range assignments {
+			counts[a.ExperimentID]++
+		}
+	}
+
+	for _, id := range []uint{1, 2} {
+		if ratio := float64(counts[id]) / users; math.Abs(ratio-0.3) > 0.02 {
+			t.Errorf("实验 %d 期望流量约 30%%, 得到 %.2f%%", id, ratio*100)
+		}
+	}
+}
+
+func TestAssign_VariantWeights(t *testing.T) {
+	experiments := []abtest.Experiment{
+		{ID: 1, Layer: "rank", Traffic: 100, Variants: []abtest.Variant{{ID: 11, Weight: 1}, {ID: 12, Weight: 3}}},
+	}
+
+	counts := make(map[uint]int)
+	const users = 20000
+	for userID := uint(1); userID <= users; userID++ {
+		for _, a := range abtest.Assign(userID, experiments) {
+			counts[a.VariantID]++
+		}
+	}
+
+	if counts[11]+counts[12] != users {
+		t.Fatalf("全量实验应覆盖所有用户, 得到 %d", counts[11]+counts[12])
+	}
+	if ratio := float64(counts[12]) / users; math.Abs(ratio-0.75) > 0.02 {
+		t.Errorf("变体 12 期望流量约 75%%, 得到 %.2f%%", ratio*100)
+	}
+}
+
+func TestAssign_StableWhenNeighbourStops(t *testing.T) {
+	first := abtest.Experiment{ID: 1, Layer: "feed", Offset: 0, Traffic: 40, Variants: []abtest.Variant{{ID: 11, Weight: 1}}}
+	second := abtest.Experiment{ID: 2, Layer: "feed", Offset: 40, Traffic: 40, Variants: []abtest.Variant{{ID: 21, Weight: 1}, {ID: 22, Weight: 1}}}
+
+	for userID := uint(1); userID <= 1000; userID++ {
+		before := abtest.Assign(userID, []abtest.Experiment{first, second})
+		after := abtest.Assign(userID, []abtest.Experiment{second})
+
+		var want []abtest.Assignment
+		for _, a := range before {
+			if a.ExperimentID == 2 {
+				want = append(want, a)
+			}
+		}
+		if len(want) != len(after) || (len(want) == 1 && want[0] != after[0]) {
+			t.Fatalf("同层实验停止后用户 %d 的分组发生变化: %v -> %v", userID, before, after)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	occupied := []abtest.Experiment{
+		{ID: 1, Offset: 0, Traffic: 30},
+		{ID: 2, Offset: 50, Traffic: 20},
+	}
+
+	tests := []struct {
+		name       string
+		traffic    int
+		wantOffset int
+		wantOK     bool
+	}{
+		{name: "使用第一段空闲区间", traffic: 20, wantOffset: 30, wantOK: true},
+		{name: "第一段不足时使用后面的区间", traffic: 30, wantOffset: 70, wantOK: true},
+		{name: "没有足够的连续空闲流量", traffic: 40, wantOK: false},
+		{name: "流量超出范围", traffic: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, ok := abtest.Allocate(occupied, tt.traffic)
+			if ok != tt.wantOK || (ok && offset != tt.wantOffset) {
+				t.Errorf("期望 (%d, %v), 得到 (%d, %v)", tt.wantOffset, tt.wantOK, offset, ok)
+			}
+		})
+	}
+}
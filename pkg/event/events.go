@@ -455,8 +455,9 @@ type LiveGiftReceivedEvent struct {
 	UserID   uint   `json:"user_id"`
 	GiftID   uint   `json:"gift_id"`
 	GiftName string `json:"gift_name"`
-	Amount   int    `json:"amount"` // 礼物数量
-	Value    int64  `json:"value"`  // 礼物价值
+	Amount   int    `json:"amount"`              // 礼物数量
+	Value    int64  `json:"value"`               // 礼物价值
+	RecordID uint   `json:"record_id,omitempty"` // 送礼记录ID（服务端扣费送礼时填充，直播间礼物统计已在扣费事务内更新）
 }
 
 // NewLiveGiftReceivedEvent 创建收到礼物事件
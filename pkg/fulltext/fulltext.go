@@ -0,0 +1,214 @@
+// Package fulltext 全文检索辅助工具
+//
+// PostgreSQL 内置的分词器无法切分中文，这里采用单字切分的方式：建立索引时把每个汉字作为
+// 独立的词元（见数据库函数 mv_search_segment），查询时把连续的汉字组合成相邻短语（<->），
+// 这样 "音乐" 只会匹配相邻出现的 "音"、"乐"，不依赖 zhparser 等扩展。
+package fulltext
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Config 建立索引和查询时使用的文本检索配置
+	Config = "simple"
+
+	// HighlightPreTag 高亮开始标记
+	HighlightPreTag = "<em>"
+	// HighlightPostTag 高亮结束标记
+	HighlightPostTag = "</em>"
+)
+
+// Query 解析后的搜索语句
+// 支持的语法：
+//   - 普通词语：全文匹配，多个词语之间为 AND
+//   - #话题：只返回带该话题的视频
+//   - @用户：只返回该用户（用户名或昵称）发布的视频
+type Query struct {
+	Terms    []string
+	Hashtags []string
+	Users    []string
+}
+
+// ParseQuery 解析搜索语句
+func ParseQuery(raw string) *Query {
+	q := &Query{}
+	for _, field := range strings.Fields(raw) {
+		switch {
+		case strings.HasPrefix(field, "#") || strings.HasPrefix(field, "＃"):
+			if name := trimPrefixRune(field); name != "" {
+				q.Hashtags = append(q.Hashtags, name)
+			}
+		case strings.HasPrefix(field, "@") || strings.HasPrefix(field, "＠"):
+			if name := trimPrefixRune(field); name != "" {
+				q.Users = append(q.Users, name)
+			}
+		default:
+			q.Terms = append(q.Terms, field)
+		}
+	}
+	return q
+}
+
+// IsEmpty 是否没有任何检索条件
+func (q *Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Hashtags) == 0 && len(q.Users) == 0
+}
+
+// TSQuery 普通词语对应的 tsquery 表达式
+func (q *Query) TSQuery() string {
+	return BuildTSQuery(q.Terms)
+}
+
+// AllTerms 普通词语加上话题和用户名（用于话题、用户搜索，以及高亮）
+func (q *Query) AllTerms() []string {
+	terms := make([]string, 0, len(q.Terms)+len(q.Hashtags)+len(q.Users))
+	terms = append(terms, q.Terms...)
+	terms = append(terms, q.Hashtags...)
+	terms = append(terms, q.Users...)
+	return terms
+}
+
+// BuildTSQuery 将词语转换为 to_tsquery 表达式
+// 每个词语切分为词元后用 <-> 连接成短语，词语之间用 & 连接；
+// 词元只保留字母和数字，因此结果可以安全地作为 to_tsquery 的参数
+// 没有有效词元时返回空字符串
+func BuildTSQuery(terms []string) string {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		tokens := Tokenize(term)
+		switch len(tokens) {
+		case 0:
+			continue
+		case 1:
+			phrases = append(phrases, tokens[0])
+		default:
+			phrases = append(phrases, "("+strings.Join(tokens, " <-> ")+")")
+		}
+	}
+	return strings.Join(phrases, " & ")
+}
+
+// Tokenize 按索引规则切分词元：汉字单字成词，其余连续的字母数字成词，统一转为小写
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Highlight 用高亮标记包裹 text 中出现的词语（不区分大小写），其余文本做 HTML 转义
+// maxRunes > 0 时截取第一个命中位置附近的片段，截断处补省略号
+// 没有命中任何词语时返回空字符串
+func Highlight(text string, terms []string, maxRunes int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	matched := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		needle := []rune(strings.TrimSpace(term))
+		if len(needle) == 0 {
+			continue
+		}
+		for i, r := range needle {
+			needle[i] = unicode.ToLower(r)
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if !hasPrefixRunes(lower[i:], needle) {
+				continue
+			}
+			for j := i; j < i+len(needle); j++ {
+				matched[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		// 命中位置前保留约四分之一的上下文
+		start = first - maxRunes/4
+		if start < 0 {
+			start = 0
+		}
+		end = start + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxRunes
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && matched[j] == matched[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if matched[i] {
+			b.WriteString(HighlightPreTag)
+			b.WriteString(segment)
+			b.WriteString(HighlightPostTag)
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// isCJK 是否为需要单字切分的汉字（与 mv_search_segment 的字符范围保持一致）
+func isCJK(r rune) bool {
+	return (r >= 0x3400 && r <= 0x9FFF) || (r >= 0xF900 && r <= 0xFAFF)
+}
+
+func hasPrefixRunes(s, prefix []rune) bool {
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func trimPrefixRune(field string) string {
+	_, size := utf8.DecodeRuneInString(field)
+	return field[size:]
+}
@@ -0,0 +1,101 @@
+package fulltext_test
+
+import (
+	"reflect"
+	"testing"
+
+	"microvibe-go/pkg/fulltext"
+)
+
+func TestParseQuery(t *testing.T) {
+	q := fulltext.ParseQuery("  搞笑 猫咪 #萌宠 @小明 ＃日常  ")
+
+	if want := []string{"搞笑", "猫咪"}; !reflect.DeepEqual(q.Terms, want) {
+		t.Errorf("期望词语 %v, 得到 %v", want, q.Terms)
+	}
+	if want := []string{"萌宠", "日常"}; !reflect.DeepEqual(q.Hashtags, want) {
+		t.Errorf("期望话题 %v, 得到 %v", want, q.Hashtags)
+	}
+	if want := []string{"小明"}; !reflect.DeepEqual(q.Users, want) {
+		t.Errorf("期望用户 %v, 得到 %v", want, q.Users)
+	}
+}
+
+func TestParseQuery_Empty(t *testing.T) {
+	if q := fulltext.ParseQuery(" # @ "); !q.IsEmpty() {
+		t.Errorf("只有前缀符号时应为空查询, 得到 %+v", q)
+	}
+}
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		want  string
+	}{
+		{name: "中文按单字组成短语", terms: []string{"音乐"}, want: "(音 <-> 乐)"},
+		{name: "英文转小写", terms: []string{"Music"}, want: "music"},
+		{name: "中英混合", terms: []string{"MV音乐"}, want: "(mv <-> 音 <-> 乐)"},
+		{name: "多个词语取交集", terms: []string{"猫", "dog"}, want: "猫 & dog"},
+		{name: "过滤特殊字符", terms: []string{"a'b|c", "!!!"}, want: "(a <-> b <-> c)"},
+		{name: "没有有效词元", terms: []string{"&|!"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fulltext.BuildTSQuery(tt.terms); got != tt.want {
+				t.Errorf("期望 %q, 得到 %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		terms    []string
+		maxRunes int
+		want     string
+	}{
+		{
+			name:  "高亮多个词语",
+			text:  "今天的音乐节真热闹",
+			terms: []string{"音乐", "热闹"},
+			want:  "今天的<em>音乐</em>节真<em>热闹</em>",
+		},
+		{
+			name:  "不区分大小写并保留原文",
+			text:  "Hello World",
+			terms: []string{"world"},
+			want:  "Hello <em>World</em>",
+		},
+		{
+			name:  "转义HTML",
+			text:  "<b>猫</b>",
+			terms: []string{"猫"},
+			want:  "&lt;b&gt;<em>猫</em>&lt;/b&gt;",
+		},
+		{
+			name:     "截取命中位置附近的片段",
+			text:     "一二三四五六七八九十猫一二三四五六七八九十",
+			terms:    []string{"猫"},
+			maxRunes: 8,
+			want:     "...九十<em>猫</em>一二三四五...",
+		},
+		{
+			name:  "没有命中",
+			text:  "今天天气不错",
+			terms: []string{"音乐"},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fulltext.Highlight(tt.text, tt.terms, tt.maxRunes); got != tt.want {
+				t.Errorf("期望 %q, 得到 %q", tt.want, got)
+			}
+		})
+	}
+}
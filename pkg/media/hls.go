@@ -0,0 +1,205 @@
+package media
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MasterPlaylistName 多码率主播放列表文件名
+const MasterPlaylistName = "master.m3u8"
+
+const defaultSegmentDuration = 4
+
+// Rendition HLS 码率档位
+// Height 指短边像素数：横屏视频即高度，竖屏视频（短视频常见）则为宽度，
+// 这样竖屏 1080x1920 的 720p 档位输出为 720x1280 而不是被压缩成 405x720
+type Rendition struct {
+	Name         string // 档位名称，同时作为输出子目录，如 720p
+	Height       int    // 短边像素数
+	VideoBitrate int    // 视频码率（kbps）
+	AudioBitrate int    // 音频码率（kbps）
+
+	// 以下由 SelectRenditions 根据源视频计算
+	OutputWidth  int
+	OutputHeight int
+}
+
+// Bandwidth 主播放列表中声明的峰值带宽（bps），按视频最大码率加音频码率计算
+func (r Rendition) Bandwidth() int {
+	return (videoMaxRate(r.VideoBitrate) + r.AudioBitrate) * 1000
+}
+
+// AverageBandwidth 主播放列表中声明的平均带宽（bps）
+func (r Rendition) AverageBandwidth() int {
+	return (r.VideoBitrate + r.AudioBitrate) * 1000
+}
+
+// HLSOptions 多码率 HLS 转码参数
+type HLSOptions struct {
+	Renditions      []Rendition // 码率阶梯
+	SegmentDuration int         // 切片时长（秒）
+	FMP4            bool        // 使用 fMP4 切片（默认 MPEG-TS）
+}
+
+// DefaultRenditions 默认码率阶梯
+var DefaultRenditions = []Rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "540p", Height: 540, VideoBitrate: 1400, AudioBitrate: 128},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+}
+
+// SelectRenditions 从码率阶梯中选出不超过源视频短边的档位，并计算各档位的输出分辨率
+// 源视频低于最低档位时，以源分辨率输出最低档位的码率；源分辨率未知时只输出最低档位
+func SelectRenditions(ladder []Rendition, sourceWidth, sourceHeight int) []Rendition {
+	if len(ladder) == 0 {
+		ladder = DefaultRenditions
+	}
+
+	sorted := append([]Rendition(nil), ladder...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Height < sorted[j].Height })
+
+	if sourceWidth <= 0 || sourceHeight <= 0 {
+		lowest := sorted[0]
+		lowest.OutputWidth, lowest.OutputHeight = -2, lowest.Height
+		return []Rendition{lowest}
+	}
+
+	portrait := sourceHeight > sourceWidth
+	shortSide, longSide := sourceHeight, sourceWidth
+	if portrait {
+		shortSide, longSide = sourceWidth, sourceHeight
+	}
+
+	var selected []Rendition
+	for _, r := range sorted {
+		if r.Height > shortSide {
+			break
+		}
+		selected = append(selected, r)
+	}
+	if len(selected) == 0 {
+		lowest := sorted[0]
+		lowest.Name = strconv.Itoa(evenFloor(shortSide)) + "p"
+		lowest.Height = shortSide
+		selected = []Rendition{lowest}
+	}
+
+	for i := range selected {
+		short := evenFloor(selected[i].Height)
+		long := evenRound(float64(short) * float64(longSide) / float64(shortSide))
+		if portrait {
+			selected[i].OutputWidth, selected[i].OutputHeight = short, long
+		} else {
+			selected[i].OutputWidth, selected[i].OutputHeight = long, short
+		}
+	}
+	return selected
+}
+
+// TranscodeToABRHLS 按码率阶梯转码为多码率 HLS
+// 每个档位输出到 outputDir/<档位名称>/index.m3u8，并在 outputDir 下生成主播放列表 master.m3u8
+// 返回实际输出的档位
+func TranscodeToABRHLS(videoPath, outputDir string, sourceWidth, sourceHeight int, opts HLSOptions) ([]Rendition, error) {
+	renditions := SelectRenditions(opts.Renditions, sourceWidth, sourceHeight)
+
+	segment := opts.SegmentDuration
+	if segment <= 0 {
+		segment = defaultSegmentDuration
+	}
+
+	for _, r := range renditions {
+		if err := transcodeRendition(videoPath, filepath.Join(outputDir, r.Name), r, segment, opts.FMP4); err != nil {
+			return nil, fmt.Errorf("rendition %s: %w", r.Name, err)
+		}
+	}
+
+	master := BuildMasterPlaylist(renditions, opts.FMP4)
+	if err := os.WriteFile(filepath.Join(outputDir, MasterPlaylistName), []byte(master), 0644); err != nil {
+		return nil, fmt.Errorf("write master playlist error: %w", err)
+	}
+	return renditions, nil
+}
+
+// BuildMasterPlaylist 生成主播放列表（档位按带宽升序）
+func BuildMasterPlaylist(renditions []Rendition, fmp4 bool) string {
+	sorted := append([]Rendition(nil), renditions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Bandwidth() < sorted[j].Bandwidth() })
+
+	version := 3
+	if fmp4 {
+		version = 7
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#EXT-X-VERSION:%d\n", version)
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, r := range sorted {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d", r.Bandwidth(), r.AverageBandwidth())
+		if r.OutputWidth > 0 && r.OutputHeight > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", r.OutputWidth, r.OutputHeight)
+		}
+		fmt.Fprintf(&b, ",NAME=\"%s\"\n", r.Name)
+		fmt.Fprintf(&b, "%s/index.m3u8\n", r.Name)
+	}
+	return b.String()
+}
+
+// transcodeRendition 转码单个档位
+// 各档位按相同间隔强制关键帧，保证切片边界对齐，播放器切换档位时不会跳帧
+func transcodeRendition(videoPath, outputDir string, r Rendition, segment int, fmp4 bool) error {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	args := []string{"-y", "-i", videoPath,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", fmt.Sprintf("scale=%d:%d", r.OutputWidth, r.OutputHeight),
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+		"-b:v", fmt.Sprintf("%dk", r.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", videoMaxRate(r.VideoBitrate)),
+		"-bufsize", fmt.Sprintf("%dk", r.VideoBitrate*3/2),
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segment),
+		"-sc_threshold", "0",
+		"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", r.AudioBitrate), "-ac", "2",
+		"-hls_time", strconv.Itoa(segment),
+		"-hls_playlist_type", "vod",
+	}
+
+	if fmp4 {
+		args = append(args,
+			"-hls_segment_type", "fmp4",
+			"-hls_fmp4_init_filename", "init.mp4",
+			"-hls_segment_filename", filepath.Join(outputDir, "segment_%03d.m4s"))
+	} else {
+		args = append(args,
+			"-hls_segment_filename", filepath.Join(outputDir, "segment_%03d.ts"))
+	}
+	args = append(args, filepath.Join(outputDir, "index.m3u8"))
+
+	cmd := exec.Command("ffmpeg", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg hls error: %w, output: %s", err, string(out))
+	}
+	return nil
+}
+
+// videoMaxRate 视频最大码率（kbps），允许 VBR 在平均码率基础上浮动 7%
+func videoMaxRate(bitrate int) int {
+	return bitrate * 107 / 100
+}
+
+func evenFloor(n int) int {
+	return n &^ 1
+}
+
+func evenRound(f float64) int {
+	return int(math.Round(f/2)) * 2
+}
@@ -0,0 +1,122 @@
+package media_test
+
+import (
+	"strings"
+	"testing"
+
+	"microvibe-go/pkg/media"
+)
+
+func renditionNames(renditions []media.Rendition) []string {
+	names := make([]string, 0, len(renditions))
+	for _, r := range renditions {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestSelectRenditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		wantNames  string
+		wantWidth  int // 最高档位的输出宽度
+		wantHeight int // 最高档位的输出高度
+	}{
+		{
+			name:       "横屏 1080p 输出全部档位",
+			width:      1920,
+			height:     1080,
+			wantNames:  "360p,540p,720p,1080p",
+			wantWidth:  1920,
+			wantHeight: 1080,
+		},
+		{
+			name:       "横屏 720p 不放大到 1080p",
+			width:      1280,
+			height:     720,
+			wantNames:  "360p,540p,720p",
+			wantWidth:  1280,
+			wantHeight: 720,
+		},
+		{
+			name:       "竖屏按短边计算",
+			width:      720,
+			height:     1280,
+			wantNames:  "360p,540p,720p",
+			wantWidth:  720,
+			wantHeight: 1280,
+		},
+		{
+			name:       "低于最低档位时使用源分辨率",
+			width:      320,
+			height:     240,
+			wantNames:  "240p",
+			wantWidth:  320,
+			wantHeight: 240,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := media.SelectRenditions(media.DefaultRenditions, tt.width, tt.height)
+
+			if names := strings.Join(renditionNames(got), ","); names != tt.wantNames {
+				t.Fatalf("期望档位 %s, 得到 %s", tt.wantNames, names)
+			}
+
+			top := got[len(got)-1]
+			if top.OutputWidth != tt.wantWidth || top.OutputHeight != tt.wantHeight {
+				t.Errorf("期望最高档位分辨率 %dx%d, 得到 %dx%d", tt.wantWidth, tt.wantHeight, top.OutputWidth, top.OutputHeight)
+			}
+
+			for _, r := range got {
+				if r.OutputWidth%2 != 0 || r.OutputHeight%2 != 0 {
+					t.Errorf("档位 %s 分辨率 %dx%d 不是偶数", r.Name, r.OutputWidth, r.OutputHeight)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectRenditions_UnknownSource(t *testing.T) {
+	got := media.SelectRenditions(media.DefaultRenditions, 0, 0)
+	if len(got) != 1 || got[0].Name != "360p" {
+		t.Fatalf("源分辨率未知时应只输出最低档位, 得到 %v", renditionNames(got))
+	}
+}
+
+func TestBuildMasterPlaylist(t *testing.T) {
+	renditions := media.SelectRenditions(media.DefaultRenditions, 1280, 720)
+	playlist := media.BuildMasterPlaylist(renditions, false)
+
+	if !strings.HasPrefix(playlist, "#EXTM3U\n#EXT-X-VERSION:3\n") {
+		t.Errorf("主播放列表头部错误:\n%s", playlist)
+	}
+	if got := strings.Count(playlist, "#EXT-X-STREAM-INF:"); got != len(renditions) {
+		t.Errorf("期望 %d 个档位, 得到 %d", len(renditions), got)
+	}
+
+	for _, want := range []string{
+		"RESOLUTION=640x360",
+		"RESOLUTION=1280x720",
+		"720p/index.m3u8",
+	} {
+		if !strings.Contains(playlist, want) {
+			t.Errorf("主播放列表缺少 %q:\n%s", want, playlist)
+		}
+	}
+
+	// 档位按带宽升序排列
+	if strings.Index(playlist, "360p/index.m3u8") > strings.Index(playlist, "720p/index.m3u8") {
+		t.Errorf("档位未按带宽升序排列:\n%s", playlist)
+	}
+}
+
+func TestBuildMasterPlaylist_FMP4(t *testing.T) {
+	playlist := media.BuildMasterPlaylist(media.SelectRenditions(nil, 1920, 1080), true)
+	if !strings.Contains(playlist, "#EXT-X-VERSION:7") {
+		t.Errorf("fMP4 主播放列表版本应为 7:\n%s", playlist)
+	}
+}
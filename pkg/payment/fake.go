@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 模拟支付单状态
+const (
+	FakeStatusOpen     = "open"
+	FakeStatusClosed   = "closed"
+	FakeStatusRefunded = "refunded"
+)
+
+// FakeProvider 模拟支付渠道，不对接真实渠道
+// 支付单保存在进程内存中；回调以表单提交，使用密钥对字段做 HMAC-SHA256 签名，
+// 可以用 SignNotification 构造回调来模拟用户完成支付
+type FakeProvider struct {
+	secret string
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	orderNo string
+	amount  int64
+	status  string
+}
+
+// NewFakeProvider 创建模拟支付渠道（secret 为空时拒绝所有回调）
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret:   secret,
+		payments: make(map[string]*fakePayment),
+	}
+}
+
+// Name 渠道名称
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// CreatePayment 创建支付单
+func (p *FakeProvider) CreatePayment(ctx context.Context, req *Request) (*Payment, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("支付金额必须大于 0")
+	}
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	paymentID := "fake_" + hex.EncodeToString(b)
+
+	p.mu.Lock()
+	p.payments[paymentID] = &fakePayment{orderNo: req.OrderNo, amount: req.Amount, status: FakeStatusOpen}
+	p.mu.Unlock()
+
+	return &Payment{
+		PaymentID: paymentID,
+		PayURL:    "fake://pay/" + paymentID,
+	}, nil
+}
+
+// ClosePayment 关闭支付单
+func (p *FakeProvider) ClosePayment(ctx context.Context, paymentID string) error {
+	return p.setStatus(paymentID, FakeStatusClosed)
+}
+
+// Refund 退款
+func (p *FakeProvider) Refund(ctx context.Context, paymentID string, amount int64) error {
+	return p.setStatus(paymentID, FakeStatusRefunded)
+}
+
+// Status 支付单状态（不存在时返回空字符串）
+func (p *FakeProvider) Status(paymentID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if payment, ok := p.payments[paymentID]; ok {
+		return payment.status
+	}
+	return ""
+}
+
+func (p *FakeProvider) setStatus(paymentID, status string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return ErrPaymentNotFound
+	}
+	payment.status = status
+	return nil
+}
+
+// ParseNotification 校验回调签名并解析表单
+func (p *FakeProvider) ParseNotification(r *http.Request) (*Notification, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, ErrInvalidNotification
+	}
+	form := r.PostForm
+
+	amount, err := strconv.ParseInt(form.Get("amount"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidNotification
+	}
+	n := &Notification{
+		PaymentID: form.Get("payment_id"),
+		OrderNo:   form.Get("order_no"),
+		Amount:    amount,
+		Paid:      form.Get("status") == "paid",
+	}
+
+	sign := form.Get("sign")
+	if p.secret == "" || n.PaymentID == "" || n.OrderNo == "" ||
+		!hmac.Equal([]byte(sign), []byte(p.sign(form.Get("status"), n))) {
+		return nil, ErrInvalidNotification
+	}
+	return n, nil
+}
+
+// SignNotification 构造带签名的回调表单
+func (p *FakeProvider) SignNotification(n *Notification) url.Values {
+	status := "failed"
+	if n.Paid {
+		status = "paid"
+	}
+
+	return url.Values{
+		"payment_id": {n.PaymentID},
+		"order_no":   {n.OrderNo},
+		"amount":     {strconv.FormatInt(n.Amount, 10)},
+		"status":     {status},
+		"sign":       {p.sign(status, n)},
+	}
+}
+
+// sign 对回调字段按固定顺序拼接后签名
+func (p *FakeProvider) sign(status string, n *Notification) string {
+	message := strings.Join([]string{n.PaymentID, n.OrderNo, strconv.FormatInt(n.Amount, 10), status}, "&")
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,108 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"microvibe-go/pkg/payment"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func notifyRequest(form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestFakeProvider_NotificationRoundTrip(t *testing.T) {
+	p := payment.NewFakeProvider("secret")
+	pay, err := p.CreatePayment(context.Background(), &payment.Request{OrderNo: "LO1", Amount: 990})
+	if err != nil {
+		t.Fatalf("CreatePayment failed: %v", err)
+	}
+
+	form := p.SignNotification(&payment.Notification{PaymentID: pay.PaymentID, OrderNo: "LO1", Amount: 990, Paid: true})
+	n, err := p.ParseNotification(notifyRequest(form))
+	if err != nil {
+		t.Fatalf("ParseNotification failed: %v", err)
+	}
+	if n.PaymentID != pay.PaymentID || n.OrderNo != "LO1" || n.Amount != 990 || !n.Paid {
+		t.Fatalf("unexpected notification: %+v", n)
+	}
+}
+
+func TestFakeProvider_RejectsTamperedNotification(t *testing.T) {
+	p := payment.NewFakeProvider("secret")
+	form := p.SignNotification(&payment.Notification{PaymentID: "fake_1", OrderNo: "LO1", Amount: 990, Paid: false})
+
+	cases := map[string]func(url.Values){
+		"amount":  func(v url.Values) { v.Set("amount", "1") },
+		"order":   func(v url.Values) { v.Set("order_no", "LO2") },
+		"status":  func(v url.Values) { v.Set("status", "paid") },
+		"no sign": func(v url.Values) { v.Del("sign") },
+	}
+	for name, tamper := range cases {
+		v := url.Values{}
+		for k, vals := range form {
+			v[k] = append([]string(nil), vals...)
+		}
+		tamper(v)
+		if _, err := p.ParseNotification(notifyRequest(v)); !errors.Is(err, payment.ErrInvalidNotification) {
+			t.Errorf("%s: expected ErrInvalidNotification, got %v", name, err)
+		}
+	}
+}
+
+func TestFakeProvider_EmptySecretRejectsNotifications(t *testing.T) {
+	p := payment.NewFakeProvider("")
+	form := p.SignNotification(&payment.Notification{PaymentID: "fake_1", OrderNo: "LO1", Amount: 990, Paid: true})
+
+	if _, err := p.ParseNotification(notifyRequest(form)); !errors.Is(err, payment.ErrInvalidNotification) {
+		t.Fatalf("expected ErrInvalidNotification, got %v", err)
+	}
+}
+
+func TestFakeProvider_CloseAndRefund(t *testing.T) {
+	p := payment.NewFakeProvider("secret")
+	ctx := context.Background()
+
+	closed, _ := p.CreatePayment(ctx, &payment.Request{OrderNo: "LO1", Amount: 100})
+	if err := p.ClosePayment(ctx, closed.PaymentID); err != nil {
+		t.Fatalf("ClosePayment failed: %v", err)
+	}
+	if got := p.Status(closed.PaymentID); got != payment.FakeStatusClosed {
+		t.Fatalf("expected closed, got %q", got)
+	}
+
+	refunded, _ := p.CreatePayment(ctx, &payment.Request{OrderNo: "LO2", Amount: 100})
+	if err := p.Refund(ctx, refunded.PaymentID, 100); err != nil {
+		t.Fatalf("Refund failed: %v", err)
+	}
+	if got := p.Status(refunded.PaymentID); got != payment.FakeStatusRefunded {
+		t.Fatalf("expected refunded, got %q", got)
+	}
+
+	if err := p.ClosePayment(ctx, "fake_missing"); !errors.Is(err, payment.ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound, got %v", err)
+	}
+}
+
+func TestFakeProvider_RejectsNonPositiveAmount(t *testing.T) {
+	p := payment.NewFakeProvider("secret")
+	if _, err := p.CreatePayment(context.Background(), &payment.Request{OrderNo: "LO1", Amount: 0}); err == nil {
+		t.Fatal("expected error for zero amount")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	p, err := payment.NewProvider("fake", "secret")
+	if err != nil || p.Name() != "fake" {
+		t.Fatalf("expected fake provider, got %v, %v", p, err)
+	}
+	if _, err := payment.NewProvider("unknown", "secret"); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
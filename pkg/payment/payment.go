@@ -0,0 +1,70 @@
+// Package payment 支付渠道
+//
+// 订单服务通过 Provider 接口创建支付单、关闭支付单、退款，并校验支付渠道的异步回调。
+// 接入新的支付渠道时实现 Provider 即可；FakeProvider 不对接真实渠道，用于开发和测试。
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInvalidNotification 支付回调校验失败
+	ErrInvalidNotification = errors.New("支付回调校验失败")
+	// ErrPaymentNotFound 支付单不存在
+	ErrPaymentNotFound = errors.New("支付单不存在")
+)
+
+// Provider 支付渠道
+type Provider interface {
+	// Name 渠道名称（记录在订单上）
+	Name() string
+
+	// CreatePayment 创建支付单，返回客户端拉起支付所需的信息
+	CreatePayment(ctx context.Context, req *Request) (*Payment, error)
+
+	// ClosePayment 关闭未支付的支付单（订单取消或超时）
+	ClosePayment(ctx context.Context, paymentID string) error
+
+	// Refund 退款（订单关闭后才到达的支付，或主播对已支付订单退款）
+	Refund(ctx context.Context, paymentID string, amount int64) error
+
+	// ParseNotification 校验并解析支付结果回调
+	ParseNotification(r *http.Request) (*Notification, error)
+}
+
+// Request 创建支付单请求
+type Request struct {
+	OrderNo   string    // 商户订单号
+	Amount    int64     // 支付金额（分）
+	Subject   string    // 商品描述
+	ExpiresAt time.Time // 支付单过期时间
+}
+
+// Payment 支付单
+type Payment struct {
+	PaymentID string // 渠道支付单号
+	PayURL    string // 客户端拉起支付的地址
+}
+
+// Notification 支付结果回调
+type Notification struct {
+	PaymentID string // 渠道支付单号
+	OrderNo   string // 商户订单号
+	Amount    int64  // 实付金额（分）
+	Paid      bool   // 是否支付成功
+}
+
+// NewProvider 按名称创建支付渠道
+func NewProvider(name, secret string) (Provider, error) {
+	switch name {
+	case "", "fake":
+		return NewFakeProvider(secret), nil
+	default:
+		return nil, fmt.Errorf("不支持的支付渠道: %s", name)
+	}
+}
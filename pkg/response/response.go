@@ -21,6 +21,16 @@ type PageData struct {
 	PageSize int         `json:"page_size"` // 每页数量
 }
 
+// CursorData 游标分页数据结构
+type CursorData struct {
+	List       interface{} `json:"list"`        // 数据列表
+	Total      int64       `json:"total"`       // 总数
+	Page       int         `json:"page"`        // 当前页码（兼容按页码翻页的客户端）
+	PageSize   int         `json:"page_size"`   // 每页数量
+	NextCursor string      `json:"next_cursor"` // 下一页游标
+	HasMore    bool        `json:"has_more"`    // 是否还有下一页
+}
+
 // 业务状态码
 const (
 	CodeSuccess      = 0   // 成功
@@ -118,3 +128,15 @@ func PageSuccess(c *gin.Context, list interface{}, total int64, page, pageSize i
 		PageSize: pageSize,
 	})
 }
+
+// CursorSuccess 游标分页成功响应
+func CursorSuccess(c *gin.Context, list interface{}, total int64, page, pageSize int, nextCursor string, hasMore bool) {
+	Success(c, CursorData{
+		List:       list,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	})
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrStreamSignInvalid 推流签名无效
+	ErrStreamSignInvalid = errors.New("推流签名无效")
+	// ErrStreamSignExpired 推流签名已过期
+	ErrStreamSignExpired = errors.New("推流签名已过期")
+	// ErrWebhookSignInvalid 回调签名无效
+	ErrWebhookSignInvalid = errors.New("回调签名无效")
+	// ErrWebhookSignExpired 回调时间戳超出允许范围
+	ErrWebhookSignExpired = errors.New("回调签名已过期")
+)
+
+// SignStreamKey 生成推流密钥签名：HMAC-SHA256(secret, "streamKey:expires") 的十六进制串
+func SignStreamKey(streamKey string, expires int64, secret string) string {
+	return hmacHex(secret, streamKey+":"+strconv.FormatInt(expires, 10))
+}
+
+// VerifyStreamKey 校验推流密钥签名和有效期（expires 为 Unix 秒）
+func VerifyStreamKey(streamKey string, expires int64, sign, secret string) error {
+	if sign == "" || !hmac.Equal([]byte(sign), []byte(SignStreamKey(streamKey, expires, secret))) {
+		return ErrStreamSignInvalid
+	}
+	if time.Now().Unix() > expires {
+		return ErrStreamSignExpired
+	}
+	return nil
+}
+
+// SignWebhook 生成流媒体回调签名：HMAC-SHA256(secret, "timestamp.body") 的十六进制串
+// 签名覆盖请求体和时间戳（Unix 秒），每次回调的签名都不同
+func SignWebhook(timestamp int64, body []byte, secret string) string {
+	return hmacHex(secret, strconv.FormatInt(timestamp, 10)+"."+string(body))
+}
+
+// VerifyWebhook 校验回调签名，时间戳与当前时间相差超过 maxAge 时视为过期，防止截获的回调被重放
+func VerifyWebhook(timestamp int64, body []byte, sign, secret string, maxAge time.Duration) error {
+	if sign == "" || !hmac.Equal([]byte(sign), []byte(SignWebhook(timestamp, body, secret))) {
+		return ErrWebhookSignInvalid
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > maxAge || age < -maxAge {
+		return ErrWebhookSignExpired
+	}
+	return nil
+}
+
+func hmacHex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package utils_test
+
+import (
+	"errors"
+	"microvibe-go/pkg/utils"
+	"testing"
+	"time"
+)
+
+func TestVerifyStreamKey_Valid(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Unix()
+	sign := utils.SignStreamKey("abc123", expires, "secret")
+
+	if err := utils.VerifyStreamKey("abc123", expires, sign, "secret"); err != nil {
+		t.Fatalf("VerifyStreamKey failed: %v", err)
+	}
+}
+
+func TestVerifyStreamKey_Expired(t *testing.T) {
+	expires := time.Now().Add(-time.Minute).Unix()
+	sign := utils.SignStreamKey("abc123", expires, "secret")
+
+	if err := utils.VerifyStreamKey("abc123", expires, sign, "secret"); !errors.Is(err, utils.ErrStreamSignExpired) {
+		t.Fatalf("expected ErrStreamSignExpired, got %v", err)
+	}
+}
+
+func TestVerifyStreamKey_Tampered(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Unix()
+	sign := utils.SignStreamKey("abc123", expires, "secret")
+
+	cases := []struct {
+		name      string
+		streamKey string
+		expires   int64
+		sign      string
+		secret    string
+	}{
+		{"other key", "abc124", expires, sign, "secret"},
+		{"extended expiry", "abc123", expires + 3600, sign, "secret"},
+		{"wrong secret", "abc123", expires, sign, "other"},
+		{"empty sign", "abc123", expires, "", "secret"},
+	}
+	for _, tc := range cases {
+		if err := utils.VerifyStreamKey(tc.streamKey, tc.expires, tc.sign, tc.secret); !errors.Is(err, utils.ErrStreamSignInvalid) {
+			t.Errorf("%s: expected ErrStreamSignInvalid, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestVerifyWebhook_Valid(t *testing.T) {
+	ts := time.Now().Unix()
+	body := []byte("call=publish&name=abc123")
+	sign := utils.SignWebhook(ts, body, "secret")
+
+	if err := utils.VerifyWebhook(ts, body, sign, "secret", 5*time.Minute); err != nil {
+		t.Fatalf("VerifyWebhook failed: %v", err)
+	}
+	if sign == utils.SignWebhook(ts+1, body, "secret") {
+		t.Fatal("signatures for different timestamps should differ")
+	}
+}
+
+func TestVerifyWebhook_Stale(t *testing.T) {
+	body := []byte("call=publish&name=abc123")
+
+	for _, ts := range []int64{time.Now().Add(-10 * time.Minute).Unix(), time.Now().Add(10 * time.Minute).Unix()} {
+		sign := utils.SignWebhook(ts, body, "secret")
+		if err := utils.VerifyWebhook(ts, body, sign, "secret", 5*time.Minute); !errors.Is(err, utils.ErrWebhookSignExpired) {
+			t.Errorf("ts %d: expected ErrWebhookSignExpired, got %v", ts, err)
+		}
+	}
+}
+
+func TestVerifyWebhook_Tampered(t *testing.T) {
+	ts := time.Now().Unix()
+	body := []byte("call=publish&name=abc123")
+	sign := utils.SignWebhook(ts, body, "secret")
+
+	cases := []struct {
+		name   string
+		ts     int64
+		body   []byte
+		sign   string
+		secret string
+	}{
+		{"other body", ts, []byte("call=publish&name=abc124"), sign, "secret"},
+		{"other timestamp", ts + 60, body, sign, "secret"},
+		{"wrong secret", ts, body, sign, "other"},
+		{"empty sign", ts, body, "", "secret"},
+	}
+	for _, tc := range cases {
+		if err := utils.VerifyWebhook(tc.ts, tc.body, tc.sign, tc.secret, 5*time.Minute); !errors.Is(err, utils.ErrWebhookSignInvalid) {
+			t.Errorf("%s: expected ErrWebhookSignInvalid, got %v", tc.name, err)
+		}
+	}
+}